@@ -92,9 +92,19 @@ func (db *DB) runMigrations() error {
 			return fmt.Errorf("failed to read migration %s: %w", file.Name(), err)
 		}
 
-		if _, err := db.Exec(string(content)); err != nil {
+		// Each migration is all or nothing, so a table rebuild that fails
+		// part way leaves the original table in place
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", file.Name(), err)
+		}
+		if _, err := tx.Exec(string(content)); err != nil {
+			tx.Rollback()
 			return fmt.Errorf("failed to execute migration %s: %w", file.Name(), err)
 		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", file.Name(), err)
+		}
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package database
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -47,6 +48,16 @@ func TestMigrationSystem(t *testing.T) {
 			"treasuries",
 			"settings",
 			"metrics",
+			"campaigns",
+			"strategies",
+			"accounts",
+			"cash_transactions",
+			"option_implied_volatility",
+			"scenarios",
+			"scenario_moves",
+			"alert_rules",
+			"alerts",
+			"notification_deliveries",
 		}
 
 		for _, table := range expectedTables {
@@ -76,6 +87,27 @@ func TestMigrationSystem(t *testing.T) {
 			"idx_metrics_type",
 			"idx_options_unique",
 			"idx_dividends_unique",
+			"idx_campaigns_symbol",
+			"idx_options_campaign",
+			"idx_long_positions_campaign",
+			"idx_dividends_campaign",
+			"idx_options_parent",
+			"idx_strategies_symbol",
+			"idx_options_strategy",
+			"idx_options_account",
+			"idx_long_positions_account",
+			"idx_dividends_account",
+			"idx_treasuries_account",
+			"idx_metrics_account",
+			"idx_cash_transactions_date",
+			"idx_cash_transactions_account",
+			"idx_option_implied_volatility_unique",
+			"idx_option_implied_volatility_date",
+			"idx_scenario_moves_unique",
+			"idx_alerts_rule_subject",
+			"idx_alerts_fired_at",
+			"idx_notification_deliveries_created_at",
+			"idx_options_split_from",
 		}
 
 		for _, index := range expectedIndexes {
@@ -101,6 +133,76 @@ func TestMigrationSystem(t *testing.T) {
 		}
 	})
 
+	t.Run("options and treasuries have closing columns", func(t *testing.T) {
+		for table, column := range map[string]string{"options": "closing_commission", "treasuries": "redeemed"} {
+			var count int
+			err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?", table, column).Scan(&count)
+			if err != nil {
+				t.Fatalf("Failed to check for %s column: %v", column, err)
+			}
+			if count != 1 {
+				t.Errorf("Expected %s.%s column to exist", table, column)
+			}
+		}
+	})
+
+	t.Run("symbols table has volatility column", func(t *testing.T) {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('symbols') WHERE name='volatility'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check for volatility column: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected symbols.volatility column to exist")
+		}
+	})
+
+	t.Run("symbols table has beta column", func(t *testing.T) {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('symbols') WHERE name='beta'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check for beta column: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected symbols.beta column to exist")
+		}
+	})
+
+	t.Run("metrics table has no type CHECK", func(t *testing.T) {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='metrics' AND sql LIKE '%CHECK%'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check metrics table: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("Expected metric types to be validated by MetricService, not a CHECK")
+		}
+	})
+
+	t.Run("lot method setting is seeded", func(t *testing.T) {
+		var value string
+		err := db.QueryRow("SELECT value FROM settings WHERE name = 'LOT_METHOD'").Scan(&value)
+		if err != nil {
+			t.Fatalf("Failed to get LOT_METHOD setting: %v", err)
+		}
+		if value != "fifo" {
+			t.Errorf("Expected LOT_METHOD to default to fifo, got %s", value)
+		}
+	})
+
+	t.Run("unique indexes include the account", func(t *testing.T) {
+		for _, index := range []string{"idx_options_unique", "idx_dividends_unique"} {
+			var count int
+			err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND name=? AND sql LIKE '%account_id%'", index).Scan(&count)
+			if err != nil {
+				t.Fatalf("Failed to check index %s: %v", index, err)
+			}
+			if count != 1 {
+				t.Errorf("Expected %s to include account_id", index)
+			}
+		}
+	})
+
 	t.Run("migrations are idempotent", func(t *testing.T) {
 		// Run migrations again - should not fail
 		err := db.runMigrations()
@@ -108,14 +210,25 @@ func TestMigrationSystem(t *testing.T) {
 			t.Errorf("Migrations should be idempotent, but failed on second run: %v", err)
 		}
 
-		// Check migration count didn't increase
+		// Check migration count didn't increase beyond one record per migration file
+		migrationFiles, err := migrationsFS.ReadDir("migrations")
+		if err != nil {
+			t.Fatalf("Failed to read migrations directory: %v", err)
+		}
+		expected := 0
+		for _, file := range migrationFiles {
+			if strings.HasSuffix(file.Name(), ".sql") {
+				expected++
+			}
+		}
+
 		var count int
 		err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
 		if err != nil {
 			t.Fatalf("Failed to query schema_migrations: %v", err)
 		}
-		if count != 1 {
-			t.Errorf("Expected only 1 migration record after re-running migrations, got %d", count)
+		if count != expected {
+			t.Errorf("Expected %d migration records after re-running migrations, got %d", expected, count)
 		}
 	})
 }
@@ -0,0 +1,32 @@
+-- ============================================================================
+-- Wheel Campaigns
+-- ============================================================================
+-- A campaign groups the options, long positions and dividends that make up a
+-- single wheel cycle (cash-secured put -> assignment -> covered calls -> called
+-- away). Records link to a campaign through a nullable campaign_id column so
+-- existing rows are unaffected.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol TEXT NOT NULL,
+    name TEXT NOT NULL,
+    started DATE NOT NULL,
+    ended DATE,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (symbol) REFERENCES symbols(symbol)
+);
+
+ALTER TABLE options ADD COLUMN campaign_id INTEGER REFERENCES campaigns(id);
+ALTER TABLE long_positions ADD COLUMN campaign_id INTEGER REFERENCES campaigns(id);
+ALTER TABLE dividends ADD COLUMN campaign_id INTEGER REFERENCES campaigns(id);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_symbol ON campaigns(symbol);
+CREATE INDEX IF NOT EXISTS idx_options_campaign ON options(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_long_positions_campaign ON long_positions(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_dividends_campaign ON dividends(campaign_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261016090000_add_wheel_campaigns');
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- Option Close Reason
+-- ============================================================================
+-- Records why an option was closed when it was closed by a workflow such as
+-- put assignment ('assigned'). NULL means a manual close (buyback or expiry).
+-- ============================================================================
+
+ALTER TABLE options ADD COLUMN close_reason TEXT;
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261016100000_add_option_close_reason');
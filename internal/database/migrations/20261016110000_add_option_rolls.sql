@@ -0,0 +1,14 @@
+-- ============================================================================
+-- Option Rolls
+-- ============================================================================
+-- Links an option opened by rolling to the option it replaced. Following
+-- parent_option_id back to the first option gives the whole roll chain.
+-- Rolled options are closed with close_reason 'rolled'.
+-- ============================================================================
+
+ALTER TABLE options ADD COLUMN parent_option_id INTEGER REFERENCES options(id);
+
+CREATE INDEX IF NOT EXISTS idx_options_parent ON options(parent_option_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261016110000_add_option_rolls');
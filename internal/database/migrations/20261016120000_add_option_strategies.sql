@@ -0,0 +1,29 @@
+-- ============================================================================
+-- Multi-Leg Option Strategies
+-- ============================================================================
+-- A strategy groups the legs of a multi-leg position (put/call credit spreads,
+-- strangles, iron condors, jade lizards). Legs are ordinary options rows that
+-- link to their strategy through strategy_id. Each option now records its
+-- direction: 'short' legs are sold for a credit (every existing row) and
+-- 'long' legs are bought for a debit.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS strategies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('put_credit_spread', 'call_credit_spread', 'strangle', 'iron_condor', 'jade_lizard')),
+    opened DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (symbol) REFERENCES symbols(symbol)
+);
+
+ALTER TABLE options ADD COLUMN direction TEXT NOT NULL DEFAULT 'short' CHECK (direction IN ('short', 'long'));
+ALTER TABLE options ADD COLUMN strategy_id INTEGER REFERENCES strategies(id);
+
+CREATE INDEX IF NOT EXISTS idx_strategies_symbol ON strategies(symbol);
+CREATE INDEX IF NOT EXISTS idx_options_strategy ON options(strategy_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261016120000_add_option_strategies');
@@ -0,0 +1,15 @@
+-- ============================================================================
+-- Option Direction in Unique Key
+-- ============================================================================
+-- A bought option can share every other field with a sold one (e.g. closing
+-- one broker lot while opening another), so direction joins the duplicate
+-- check used by imports.
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_options_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_options_unique
+ON options(symbol, type, opened, strike, expiration, premium, contracts, direction);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017090000_add_direction_to_options_unique');
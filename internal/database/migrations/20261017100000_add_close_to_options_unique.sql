@@ -0,0 +1,15 @@
+-- ============================================================================
+-- Partial Closes
+-- ============================================================================
+-- Closing part of a position splits it into a closed option and an open one
+-- with otherwise identical terms, so the close date and exit price join the
+-- duplicate check. Open options still compare equal (no close, no exit).
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_options_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_options_unique
+ON options(symbol, type, opened, strike, expiration, premium, contracts, direction, COALESCE(closed, ''), COALESCE(exit_price, -1));
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017100000_add_close_to_options_unique');
@@ -0,0 +1,21 @@
+-- ============================================================================
+-- Instrument Classification
+-- ============================================================================
+-- Classifies each symbol as an equity, an ETF or a broad-based index. Index
+-- options are cash settled (never assigned into shares) and are Section 1256
+-- contracts taxed 60% long-term / 40% short-term. contract_multiplier is the
+-- number of shares (or index units) one option contract covers.
+-- ============================================================================
+
+ALTER TABLE symbols ADD COLUMN instrument_class TEXT NOT NULL DEFAULT 'equity'
+    CHECK (instrument_class IN ('equity', 'etf', 'index'));
+
+ALTER TABLE symbols ADD COLUMN contract_multiplier INTEGER NOT NULL DEFAULT 100
+    CHECK (contract_multiplier > 0);
+
+-- Common broad-based indexes already tracked
+UPDATE symbols SET instrument_class = 'index'
+WHERE symbol IN ('SPX', 'SPXW', 'XSP', 'NDX', 'NDXP', 'XND', 'RUT', 'MRUT', 'DJX', 'OEX', 'XEO', 'VIX');
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017110000_add_symbol_instrument_class');
@@ -0,0 +1,33 @@
+-- ============================================================================
+-- Brokerage Accounts
+-- ============================================================================
+-- Positions can belong to one of several brokerage accounts held in the same
+-- database, so household totals and per-account views come from one place.
+-- Records link to an account through a nullable account_id column; existing
+-- rows stay unassigned. Metrics with an account_id are that account's
+-- snapshot, those without are household totals.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    broker TEXT,
+    type TEXT NOT NULL DEFAULT 'taxable' CHECK (type IN ('taxable', 'ira', 'roth')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE options ADD COLUMN account_id INTEGER REFERENCES accounts(id);
+ALTER TABLE long_positions ADD COLUMN account_id INTEGER REFERENCES accounts(id);
+ALTER TABLE dividends ADD COLUMN account_id INTEGER REFERENCES accounts(id);
+ALTER TABLE treasuries ADD COLUMN account_id INTEGER REFERENCES accounts(id);
+ALTER TABLE metrics ADD COLUMN account_id INTEGER REFERENCES accounts(id);
+
+CREATE INDEX IF NOT EXISTS idx_options_account ON options(account_id);
+CREATE INDEX IF NOT EXISTS idx_long_positions_account ON long_positions(account_id);
+CREATE INDEX IF NOT EXISTS idx_dividends_account ON dividends(account_id);
+CREATE INDEX IF NOT EXISTS idx_treasuries_account ON treasuries(account_id);
+CREATE INDEX IF NOT EXISTS idx_metrics_account ON metrics(account_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017120000_add_accounts');
@@ -0,0 +1,50 @@
+-- ============================================================================
+-- Cash Ledger
+-- ============================================================================
+-- Cash moves whenever a trade does: premium collected or paid, buybacks,
+-- commissions, stock bought and sold, dividends and treasury purchases and
+-- redemptions. Those flows are derived from the trade tables; this table only
+-- holds the entries that have no trade behind them: deposits, withdrawals and
+-- interest on idle cash. Amounts are positive; withdrawals reduce the balance.
+--
+-- The new 'cash' metric is the running balance on each snapshot date. The
+-- metrics table is rebuilt once without the CHECK on its type, which SQLite
+-- cannot drop in place; MetricService validates metric types instead, so new
+-- ones need no further rebuilds. The runner applies each migration in a
+-- transaction, so a failed rebuild leaves the original table in place.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS cash_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date DATE NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('deposit', 'withdrawal', 'interest')),
+    amount REAL NOT NULL CHECK (amount > 0),
+    notes TEXT,
+    account_id INTEGER REFERENCES accounts(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_transactions_date ON cash_transactions(date);
+CREATE INDEX IF NOT EXISTS idx_cash_transactions_account ON cash_transactions(account_id);
+
+CREATE TABLE IF NOT EXISTS metrics_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created DATETIME DEFAULT CURRENT_TIMESTAMP,
+    type TEXT NOT NULL,
+    value REAL NOT NULL,
+    account_id INTEGER REFERENCES accounts(id)
+);
+
+INSERT INTO metrics_new (id, created, type, value, account_id)
+SELECT id, created, type, value, account_id FROM metrics;
+
+DROP TABLE metrics;
+ALTER TABLE metrics_new RENAME TO metrics;
+
+CREATE INDEX IF NOT EXISTS idx_metrics_created ON metrics(created);
+CREATE INDEX IF NOT EXISTS idx_metrics_type ON metrics(type);
+CREATE INDEX IF NOT EXISTS idx_metrics_account ON metrics(account_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017130000_add_cash_ledger');
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- Pricing Inputs
+-- ============================================================================
+-- Options are valued locally with Black-Scholes (index options) or a binomial
+-- tree (American equity and ETF options). The underlying price and dividend
+-- come from symbols; volatility is the annualized volatility of the symbol in
+-- percent, or DEFAULT_VOLATILITY when it is not set. RISK_FREE_RATE is the
+-- annual rate in percent.
+-- ============================================================================
+
+ALTER TABLE symbols ADD COLUMN volatility REAL CHECK (volatility IS NULL OR volatility > 0);
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('RISK_FREE_RATE', '4.5', 'Annual risk-free rate in percent used to price options');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('DEFAULT_VOLATILITY', '30', 'Annualized volatility in percent used to price options on symbols without their own');
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017140000_add_pricing_inputs');
@@ -0,0 +1,31 @@
+-- ============================================================================
+-- Option Implied Volatility
+-- ============================================================================
+-- One implied volatility per option per day, backed out of the option's mark
+-- (options.current_price) with the underlying price, time to expiration and a
+-- risk-free rate from our own treasury yields. reference_volatility is the
+-- volatility we price the option with (the symbol's own or the
+-- DEFAULT_VOLATILITY setting), so an implied volatility above it means the
+-- premium was rich and below it cheap. Volatilities and the rate are in
+-- percent. Recording an option again on the same day replaces that day's row.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS option_implied_volatility (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    option_id INTEGER NOT NULL REFERENCES options(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    mark REAL NOT NULL CHECK (mark > 0),
+    underlying_price REAL NOT NULL CHECK (underlying_price > 0),
+    rate REAL NOT NULL,
+    implied_volatility REAL NOT NULL CHECK (implied_volatility > 0),
+    reference_volatility REAL NOT NULL,
+    model TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_option_implied_volatility_unique ON option_implied_volatility(option_id, date);
+CREATE INDEX IF NOT EXISTS idx_option_implied_volatility_date ON option_implied_volatility(date);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017150000_add_option_implied_volatility');
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- Portfolio Greeks
+-- ============================================================================
+-- Option and share deltas are added up per symbol and for the portfolio, and
+-- beta-weighted against the BETA_BENCHMARK symbol: a symbol's delta times its
+-- beta and price, in shares of the benchmark. beta is the symbol's beta to the
+-- benchmark; NULL counts as 1.
+--
+-- The new 'net_delta' and 'daily_theta' metrics are snapshotted with the
+-- others. The metrics table has no type CHECK since 20261017130000, so they
+-- need no schema change.
+-- ============================================================================
+
+ALTER TABLE symbols ADD COLUMN beta REAL;
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('BETA_BENCHMARK', 'SPY', 'Symbol that portfolio delta is beta-weighted against');
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017160000_add_portfolio_greeks');
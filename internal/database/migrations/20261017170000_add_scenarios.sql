@@ -0,0 +1,33 @@
+-- ============================================================================
+-- Scenarios
+-- ============================================================================
+-- A scenario is a what-if shock to the portfolio: every underlying moves by
+-- price_move percent and its volatility by volatility_change points, valued
+-- days_forward days from today. scenario_moves overrides the portfolio-wide
+-- shock for individual symbols; a NULL column keeps the scenario's value.
+-- Scenarios are run against the open positions when they are viewed, so only
+-- their inputs are saved.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS scenarios (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    price_move REAL NOT NULL DEFAULT 0 CHECK (price_move > -100),
+    volatility_change REAL NOT NULL DEFAULT 0,
+    days_forward INTEGER NOT NULL DEFAULT 0 CHECK (days_forward >= 0),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS scenario_moves (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scenario_id INTEGER NOT NULL REFERENCES scenarios(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    price_move REAL CHECK (price_move > -100),
+    volatility_change REAL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scenario_moves_unique ON scenario_moves(scenario_id, symbol);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017170000_add_scenarios');
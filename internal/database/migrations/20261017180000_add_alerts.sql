@@ -0,0 +1,61 @@
+-- ============================================================================
+-- Alerts
+-- ============================================================================
+-- alert_rules are evaluated against the open positions every
+-- ALERT_INTERVAL_MINUTES. What threshold means depends on the rule type:
+--   profit_target      option has captured at least threshold percent of its
+--                      max profit at its current price
+--   dte                option expires within threshold days
+--   put_itm            put is in the money by more than threshold percent
+--   treasury_maturity  treasury matures within threshold days
+--   ex_dividend_call   ex-dividend date falls before a short call's
+--                      expiration (threshold unused)
+-- A rule fires once per subject (e.g. 'option:12'), so alerts are kept after
+-- they are dismissed to keep them from firing again.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK (type IN ('profit_target', 'dte', 'put_itm', 'treasury_maturity', 'ex_dividend_call')),
+    threshold REAL NOT NULL DEFAULT 0 CHECK (threshold >= 0),
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    last_evaluated_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    symbol TEXT,
+    message TEXT NOT NULL,
+    fired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    read_at DATETIME,
+    dismissed_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_rule_subject ON alerts(rule_id, subject);
+CREATE INDEX IF NOT EXISTS idx_alerts_fired_at ON alerts(fired_at);
+
+INSERT OR IGNORE INTO alert_rules (name, type, threshold)
+VALUES ('50% of max profit', 'profit_target', 50);
+
+INSERT OR IGNORE INTO alert_rules (name, type, threshold)
+VALUES ('21 days to expiration', 'dte', 21);
+
+INSERT OR IGNORE INTO alert_rules (name, type, threshold)
+VALUES ('Put 5% in the money', 'put_itm', 5);
+
+INSERT OR IGNORE INTO alert_rules (name, type, threshold)
+VALUES ('Treasury matures within 7 days', 'treasury_maturity', 7);
+
+INSERT OR IGNORE INTO alert_rules (name, type, threshold)
+VALUES ('Ex-dividend before short call expires', 'ex_dividend_call', 0);
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('ALERT_INTERVAL_MINUTES', '15', 'Minutes between evaluations of the alert rules');
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017180000_add_alerts');
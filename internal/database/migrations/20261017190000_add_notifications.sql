@@ -0,0 +1,55 @@
+-- ============================================================================
+-- Notifications
+-- ============================================================================
+-- Fired alerts, completed imports and failed price refreshes are sent to the
+-- configured channels: a JSON webhook when WEBHOOK_URL is set and email when
+-- SMTP_HOST and SMTP_TO are set. NOTIFY_EVENTS lists the event types that are
+-- sent. Each delivery is retried up to NOTIFY_MAX_ATTEMPTS times and its
+-- outcome is kept in notification_deliveries.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel TEXT NOT NULL CHECK (channel IN ('webhook', 'smtp')),
+    event_type TEXT NOT NULL,
+    title TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    attempts INTEGER NOT NULL CHECK (attempts > 0),
+    error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_created_at ON notification_deliveries(created_at);
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('NOTIFY_EVENTS', 'alert,import,price_refresh', 'Comma-separated event types sent to the notification channels');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('NOTIFY_MAX_ATTEMPTS', '3', 'Attempts to deliver a notification before it is logged as failed');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('WEBHOOK_URL', NULL, 'URL that notifications are POSTed to as JSON');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('WEBHOOK_SECRET', NULL, 'Secret that signs webhook bodies in the X-Wheeler-Signature header');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('SMTP_HOST', NULL, 'SMTP server that notification emails are sent through');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('SMTP_PORT', '587', 'SMTP server port; 465 connects with TLS, other ports upgrade with STARTTLS when offered');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('SMTP_USERNAME', NULL, 'SMTP username, blank to send without authenticating');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('SMTP_PASSWORD', NULL, 'SMTP password');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('SMTP_FROM', NULL, 'Sender address of notification emails');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('SMTP_TO', NULL, 'Comma-separated recipients of notification emails');
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017190000_add_notifications');
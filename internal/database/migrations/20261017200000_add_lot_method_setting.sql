@@ -0,0 +1,13 @@
+-- ============================================================================
+-- Lot Method Setting
+-- ============================================================================
+-- LOT_METHOD is the default order in which selling shares consumes open tax
+-- lots when the sale does not name one: 'fifo', 'lifo' or 'highest_cost'.
+-- Specific lots are always chosen per sale.
+-- ============================================================================
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('LOT_METHOD', 'fifo', 'Default order in which share sales consume open tax lots: fifo, lifo or highest_cost');
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017200000_add_lot_method_setting');
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- Account-Scoped Duplicate Checks
+-- ============================================================================
+-- The same trade or dividend can be recorded in two brokerage accounts, so the
+-- account joins the duplicate checks of options and dividends. Unassigned
+-- records (no account) still compare equal to each other.
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_options_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_options_unique
+ON options(symbol, type, opened, strike, expiration, premium, contracts, direction, COALESCE(closed, ''), COALESCE(exit_price, -1), COALESCE(account_id, 0));
+
+DROP INDEX IF EXISTS idx_dividends_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dividends_unique
+ON dividends(symbol, received, amount, COALESCE(account_id, 0));
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017210000_add_account_to_unique_indexes');
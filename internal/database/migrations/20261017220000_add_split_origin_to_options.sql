@@ -0,0 +1,14 @@
+-- ============================================================================
+-- Partial Close Origin
+-- ============================================================================
+-- Links the closed part of a partially closed option to the option it was
+-- split from, so a later partial close on the same date and exit price joins
+-- that part rather than an identical option in another account.
+-- ============================================================================
+
+ALTER TABLE options ADD COLUMN split_from_option_id INTEGER REFERENCES options(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_options_split_from ON options(split_from_option_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017220000_add_split_origin_to_options');
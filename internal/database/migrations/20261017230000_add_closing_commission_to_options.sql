@@ -0,0 +1,13 @@
+-- ============================================================================
+-- Closing Commissions
+-- ============================================================================
+-- An option's commission covers both its opening and its closing trade. The
+-- closing part is recorded separately so the cash ledger can book it on the
+-- day the option closed. Options closed before this column existed, and
+-- imported closed trades, keep their whole commission on the opened date.
+-- ============================================================================
+
+ALTER TABLE options ADD COLUMN closing_commission REAL NOT NULL DEFAULT 0;
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017230000_add_closing_commission_to_options');
@@ -0,0 +1,16 @@
+-- ============================================================================
+-- Treasury Redemption Date
+-- ============================================================================
+-- Records the day a treasury was redeemed or sold, set when its exit price is
+-- recorded. Existing redeemed treasuries take the earlier of their maturity
+-- and their last update, which is what the cash ledger used before.
+-- ============================================================================
+
+ALTER TABLE treasuries ADD COLUMN redeemed DATE;
+
+UPDATE treasuries
+SET redeemed = MIN(maturity, updated_at)
+WHERE exit_price IS NOT NULL;
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261017233000_add_redeemed_to_treasuries');
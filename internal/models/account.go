@@ -0,0 +1,500 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Account types: a taxable brokerage account or a tax-advantaged retirement account
+const (
+	AccountTaxable = "taxable"
+	AccountIRA     = "ira"
+	AccountRoth    = "roth"
+)
+
+// Account is a brokerage account that options, long positions, dividends and
+// treasuries can belong to. Records without an account only show up in
+// household totals.
+type Account struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Broker    *string   `json:"broker"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AccountItems identifies records to move into or out of an account
+type AccountItems struct {
+	OptionIDs       []int    `json:"option_ids"`
+	LongPositionIDs []int    `json:"long_position_ids"`
+	DividendIDs     []int    `json:"dividend_ids"`
+	TreasuryCUSPIDs []string `json:"treasury_cuspids"`
+}
+
+// IsValidAccountType reports whether accountType is a known account type
+func IsValidAccountType(accountType string) bool {
+	switch accountType {
+	case AccountTaxable, AccountIRA, AccountRoth:
+		return true
+	}
+	return false
+}
+
+// IsTaxable returns true if gains in the account are taxed as they are realized
+func (a *Account) IsTaxable() bool {
+	return a.Type == AccountTaxable
+}
+
+type AccountService struct {
+	db *sql.DB
+}
+
+func NewAccountService(db *sql.DB) *AccountService {
+	return &AccountService{db: db}
+}
+
+func (s *AccountService) Create(name string, broker *string, accountType string) (*Account, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("account name is required")
+	}
+	if !IsValidAccountType(accountType) {
+		return nil, fmt.Errorf("account type must be '%s', '%s' or '%s'", AccountTaxable, AccountIRA, AccountRoth)
+	}
+
+	query := `INSERT INTO accounts (name, broker, type) VALUES (?, ?, ?)
+			  RETURNING id, name, broker, type, created_at, updated_at`
+
+	var account Account
+	err := s.db.QueryRow(query, name, broker, accountType).Scan(
+		&account.ID, &account.Name, &account.Broker, &account.Type, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	return &account, nil
+}
+
+func (s *AccountService) GetByID(id int) (*Account, error) {
+	query := `SELECT id, name, broker, type, created_at, updated_at FROM accounts WHERE id = ?`
+
+	var account Account
+	err := s.db.QueryRow(query, id).Scan(
+		&account.ID, &account.Name, &account.Broker, &account.Type, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account not found")
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetOrCreateByName returns the account with the given name, creating it as a
+// taxable account if there is none
+func (s *AccountService) GetOrCreateByName(name string) (*Account, error) {
+	name = strings.TrimSpace(name)
+
+	var id int
+	err := s.db.QueryRow(`SELECT id FROM accounts WHERE name = ? COLLATE NOCASE`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return s.Create(name, nil, AccountTaxable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account %s: %w", name, err)
+	}
+
+	return s.GetByID(id)
+}
+
+func (s *AccountService) GetAll() ([]*Account, error) {
+	query := `SELECT id, name, broker, type, created_at, updated_at FROM accounts ORDER BY name`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*Account
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.ID, &account.Name, &account.Broker, &account.Type, &account.CreatedAt, &account.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, &account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+func (s *AccountService) Update(id int, name string, broker *string, accountType string) (*Account, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("account name is required")
+	}
+	if !IsValidAccountType(accountType) {
+		return nil, fmt.Errorf("account type must be '%s', '%s' or '%s'", AccountTaxable, AccountIRA, AccountRoth)
+	}
+
+	query := `UPDATE accounts SET name = ?, broker = ?, type = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ?
+			  RETURNING id, name, broker, type, created_at, updated_at`
+
+	var account Account
+	err := s.db.QueryRow(query, name, broker, accountType, id).Scan(
+		&account.ID, &account.Name, &account.Broker, &account.Type, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account not found")
+		}
+		return nil, fmt.Errorf("failed to update account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// Delete removes an account and its metrics, leaving its records and cash entries in place but unassigned
+func (s *AccountService) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"options", "long_positions", "dividends", "treasuries", "cash_transactions"} {
+		if _, err := tx.Exec(`UPDATE `+table+` SET account_id = NULL WHERE account_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to unassign %s from account: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM metrics WHERE account_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete account metrics: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM accounts WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account not found")
+	}
+
+	return tx.Commit()
+}
+
+// AssignItems moves options, long positions, dividends and treasuries into an
+// account, or out of any account if accountID is nil
+func (s *AccountService) AssignItems(accountID *int, items AccountItems) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if accountID != nil {
+		var exists int
+		if err := tx.QueryRow(`SELECT 1 FROM accounts WHERE id = ?`, *accountID).Scan(&exists); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("account not found")
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+	}
+
+	updates := []struct {
+		table string
+		key   string
+		label string
+		ids   []interface{}
+	}{
+		{"options", "id", "option", intArgs(items.OptionIDs)},
+		{"long_positions", "id", "long position", intArgs(items.LongPositionIDs)},
+		{"dividends", "id", "dividend", intArgs(items.DividendIDs)},
+		{"treasuries", "cuspid", "treasury", stringArgs(items.TreasuryCUSPIDs)},
+	}
+
+	for _, update := range updates {
+		for _, id := range update.ids {
+			result, err := tx.Exec(`UPDATE `+update.table+` SET account_id = ? WHERE `+update.key+` = ?`, accountID, id)
+			if err != nil {
+				return fmt.Errorf("failed to update %s %v: %w", update.label, id, err)
+			}
+			if rowsAffected, err := result.RowsAffected(); err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			} else if rowsAffected == 0 {
+				return fmt.Errorf("%s %v not found", update.label, id)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AssignUnassigned moves every record that has no account into an account,
+// for a database that predates accounts
+func (s *AccountService) AssignUnassigned(accountID int) error {
+	if _, err := s.GetByID(accountID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"options", "long_positions", "dividends", "treasuries", "cash_transactions"} {
+		if _, err := tx.Exec(`UPDATE `+table+` SET account_id = ? WHERE account_id IS NULL`, accountID); err != nil {
+			return fmt.Errorf("failed to assign %s to account: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+func stringArgs(ids []string) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// AccountFilter narrows records to those in one account. A nil filter keeps
+// everything, for household totals.
+type AccountFilter struct {
+	AccountID     int
+	options       map[int]bool
+	longPositions map[int]bool
+	dividends     map[int]bool
+	treasuries    map[string]bool
+}
+
+// Filter loads the records in an account, or returns a nil filter if
+// accountID is nil
+func (s *AccountService) Filter(accountID *int) (*AccountFilter, error) {
+	if accountID == nil {
+		return nil, nil
+	}
+
+	filter := &AccountFilter{
+		AccountID:     *accountID,
+		options:       make(map[int]bool),
+		longPositions: make(map[int]bool),
+		dividends:     make(map[int]bool),
+		treasuries:    make(map[string]bool),
+	}
+
+	for table, ids := range map[string]map[int]bool{
+		"options":        filter.options,
+		"long_positions": filter.longPositions,
+		"dividends":      filter.dividends,
+	} {
+		rows, err := s.db.Query(`SELECT id FROM `+table+` WHERE account_id = ?`, *accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account %s: %w", table, err)
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan account %s: %w", table, err)
+			}
+			ids[id] = true
+		}
+		rows.Close()
+	}
+
+	rows, err := s.db.Query(`SELECT cuspid FROM treasuries WHERE account_id = ?`, *accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account treasuries: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cuspid string
+		if err := rows.Scan(&cuspid); err != nil {
+			return nil, fmt.Errorf("failed to scan account treasuries: %w", err)
+		}
+		filter.treasuries[cuspid] = true
+	}
+
+	return filter, rows.Err()
+}
+
+// TaxableFilter loads a filter that drops records in IRA and Roth accounts,
+// whose trades are not reported on a tax return. Unassigned records are kept.
+func (s *AccountService) TaxableFilter() (*TaxableFilter, error) {
+	filter := &TaxableFilter{options: make(map[int]bool), longPositions: make(map[int]bool)}
+
+	for table, ids := range map[string]map[int]bool{
+		"options":        filter.options,
+		"long_positions": filter.longPositions,
+	} {
+		rows, err := s.db.Query(`SELECT t.id FROM `+table+` t JOIN accounts a ON a.id = t.account_id WHERE a.type != ?`, AccountTaxable)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tax-advantaged %s: %w", table, err)
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan tax-advantaged %s: %w", table, err)
+			}
+			ids[id] = true
+		}
+		rows.Close()
+	}
+
+	return filter, nil
+}
+
+func (f *AccountFilter) Options(options []*Option) []*Option {
+	if f == nil {
+		return options
+	}
+	filtered := []*Option{}
+	for _, option := range options {
+		if f.options[option.ID] {
+			filtered = append(filtered, option)
+		}
+	}
+	return filtered
+}
+
+func (f *AccountFilter) LongPositions(positions []*LongPosition) []*LongPosition {
+	if f == nil {
+		return positions
+	}
+	filtered := []*LongPosition{}
+	for _, position := range positions {
+		if f.longPositions[position.ID] {
+			filtered = append(filtered, position)
+		}
+	}
+	return filtered
+}
+
+func (f *AccountFilter) Dividends(dividends []*Dividend) []*Dividend {
+	if f == nil {
+		return dividends
+	}
+	filtered := []*Dividend{}
+	for _, dividend := range dividends {
+		if f.dividends[dividend.ID] {
+			filtered = append(filtered, dividend)
+		}
+	}
+	return filtered
+}
+
+func (f *AccountFilter) Treasuries(treasuries []*Treasury) []*Treasury {
+	if f == nil {
+		return treasuries
+	}
+	filtered := []*Treasury{}
+	for _, treasury := range treasuries {
+		if f.treasuries[treasury.CUSPID] {
+			filtered = append(filtered, treasury)
+		}
+	}
+	return filtered
+}
+
+// CashEntries keeps the deposits, withdrawals and interest recorded in the filtered account
+func (f *AccountFilter) CashEntries(entries []*CashEntry) []*CashEntry {
+	if f == nil {
+		return entries
+	}
+	filtered := []*CashEntry{}
+	for _, entry := range entries {
+		if entry.AccountID != nil && *entry.AccountID == f.AccountID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// HasOption returns true if the option is in the filtered account
+func (f *AccountFilter) HasOption(id int) bool {
+	return f == nil || f.options[id]
+}
+
+// HasLongPosition returns true if the long position is in the filtered account
+func (f *AccountFilter) HasLongPosition(id int) bool {
+	return f == nil || f.longPositions[id]
+}
+
+// HasRealized returns true if the stock lot or option behind a realized gain
+// is in the filtered account
+func (f *AccountFilter) HasRealized(kind string, id int) bool {
+	if kind == RealizedKindStock {
+		return f.HasLongPosition(id)
+	}
+	return f.HasOption(id)
+}
+
+// TaxableFilter drops options and long positions held in tax-advantaged
+// accounts. A nil filter keeps everything.
+type TaxableFilter struct {
+	options       map[int]bool
+	longPositions map[int]bool
+}
+
+// IsTaxable returns true if the stock lot or option behind a realized gain is
+// not held in a tax-advantaged account
+func (f *TaxableFilter) IsTaxable(kind string, id int) bool {
+	if f == nil {
+		return true
+	}
+	if kind == RealizedKindStock {
+		return !f.longPositions[id]
+	}
+	return !f.options[id]
+}
+
+func (f *TaxableFilter) Options(options []*Option) []*Option {
+	if f == nil {
+		return options
+	}
+	filtered := []*Option{}
+	for _, option := range options {
+		if !f.options[option.ID] {
+			filtered = append(filtered, option)
+		}
+	}
+	return filtered
+}
+
+func (f *TaxableFilter) LongPositions(positions []*LongPosition) []*LongPosition {
+	if f == nil {
+		return positions
+	}
+	filtered := []*LongPosition{}
+	for _, position := range positions {
+		if !f.longPositions[position.ID] {
+			filtered = append(filtered, position)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountService_FiltersAndAssignment(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	accountService := NewAccountService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	positionService := NewLongPositionService(testDB.DB)
+	treasuryService := NewTreasuryService(testDB.DB)
+	metricService := NewMetricService(testDB.DB)
+
+	taxable, err := accountService.Create("Brokerage", nil, AccountTaxable)
+	if err != nil {
+		t.Fatalf("Failed to create taxable account: %v", err)
+	}
+	ira, err := accountService.GetOrCreateByName("Rollover IRA")
+	if err != nil {
+		t.Fatalf("Failed to create IRA account: %v", err)
+	}
+	if ira, err = accountService.Update(ira.ID, ira.Name, nil, AccountIRA); err != nil {
+		t.Fatalf("Failed to make account an IRA: %v", err)
+	}
+	if _, err := accountService.Create("Bad", nil, "401k"); err == nil {
+		t.Errorf("Expected an unknown account type to be rejected")
+	}
+
+	opened := time.Now().AddDate(0, 0, -10)
+	expiration := time.Now().AddDate(0, 0, 20)
+
+	put, err := optionService.Create("AAPL", "Put", opened, 170, expiration, 2.50, 1)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	iraLot, err := positionService.Create("AAPL", opened, 100, 160)
+	if err != nil {
+		t.Fatalf("Failed to create IRA lot: %v", err)
+	}
+	if _, err := positionService.Create("AAPL", opened, 100, 150); err != nil {
+		t.Fatalf("Failed to create unassigned lot: %v", err)
+	}
+	if _, err := treasuryService.Create("912797AA1", opened, expiration, 1000, 4.5, 990); err != nil {
+		t.Fatalf("Failed to create treasury: %v", err)
+	}
+
+	if err := accountService.AssignItems(&taxable.ID, AccountItems{OptionIDs: []int{put.ID}, TreasuryCUSPIDs: []string{"912797AA1"}}); err != nil {
+		t.Fatalf("Failed to assign to taxable account: %v", err)
+	}
+	if err := accountService.AssignItems(&ira.ID, AccountItems{LongPositionIDs: []int{iraLot.ID}}); err != nil {
+		t.Fatalf("Failed to assign to IRA: %v", err)
+	}
+	if err := accountService.AssignItems(&ira.ID, AccountItems{OptionIDs: []int{9999}}); err == nil {
+		t.Errorf("Expected assigning a missing option to fail")
+	}
+
+	// Shares put to us land in the put's account
+	_, assignedLot, err := optionService.Assign(put.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to assign put: %v", err)
+	}
+
+	positions, err := positionService.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get positions: %v", err)
+	}
+	options, err := optionService.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get options: %v", err)
+	}
+	treasuries, err := treasuryService.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get treasuries: %v", err)
+	}
+
+	filter, err := accountService.Filter(&taxable.ID)
+	if err != nil {
+		t.Fatalf("Failed to load filter: %v", err)
+	}
+	if got := filter.LongPositions(positions); len(got) != 1 || got[0].ID != assignedLot.ID {
+		t.Errorf("Expected only the assigned lot in the taxable account, got %d lots", len(got))
+	}
+	if got := filter.Options(options); len(got) != 1 || got[0].ID != put.ID {
+		t.Errorf("Expected only the put in the taxable account, got %d options", len(got))
+	}
+	if got := filter.Treasuries(treasuries); len(got) != 1 {
+		t.Errorf("Expected the treasury in the taxable account, got %d", len(got))
+	}
+
+	var household *AccountFilter
+	if household, err = accountService.Filter(nil); err != nil || household != nil {
+		t.Fatalf("Expected a nil filter for all accounts, got %v, %v", household, err)
+	}
+	if got := household.LongPositions(positions); len(got) != 3 {
+		t.Errorf("Expected a nil filter to keep all 3 lots, got %d", len(got))
+	}
+
+	// Tax reports leave out the IRA lot but keep unassigned records
+	taxableOnly, err := accountService.TaxableFilter()
+	if err != nil {
+		t.Fatalf("Failed to load taxable filter: %v", err)
+	}
+	for _, position := range taxableOnly.LongPositions(positions) {
+		if position.ID == iraLot.ID {
+			t.Errorf("Expected the IRA lot to be left out of taxable positions")
+		}
+	}
+	if got := taxableOnly.LongPositions(positions); len(got) != 2 {
+		t.Errorf("Expected 2 taxable lots, got %d", len(got))
+	}
+
+	// Metrics are snapshotted for the household and for each account
+	if err := metricService.ComprehensiveSnapshot(1); err != nil {
+		t.Fatalf("Failed to snapshot metrics: %v", err)
+	}
+	values := func(accountID *int) map[MetricType]float64 {
+		metrics, err := metricService.GetAllForAccount(accountID)
+		if err != nil {
+			t.Fatalf("Failed to get metrics: %v", err)
+		}
+		byType := make(map[MetricType]float64)
+		for _, metric := range metrics {
+			byType[metric.Type] = metric.Value
+		}
+		return byType
+	}
+	if got := values(nil)[LongValue]; got != 100*160+100*150+100*170 {
+		t.Errorf("Expected household long value 47000, got %.2f", got)
+	}
+	if got := values(&taxable.ID)[LongValue]; got != 100*170 {
+		t.Errorf("Expected taxable long value 17000, got %.2f", got)
+	}
+	if got := values(&ira.ID)[LongValue]; got != 100*160 {
+		t.Errorf("Expected IRA long value 16000, got %.2f", got)
+	}
+	if got := values(&taxable.ID)[TreasuryValue]; got != 1000 {
+		t.Errorf("Expected taxable treasury value 1000, got %.2f", got)
+	}
+
+	// Deleting an account keeps its records, unassigned
+	if err := accountService.Delete(ira.ID); err != nil {
+		t.Fatalf("Failed to delete IRA: %v", err)
+	}
+	if metrics, _ := metricService.GetAllForAccount(&ira.ID); len(metrics) != 0 {
+		t.Errorf("Expected the IRA's metrics to be deleted, got %d", len(metrics))
+	}
+	if err := accountService.AssignUnassigned(taxable.ID); err != nil {
+		t.Fatalf("Failed to claim unassigned records: %v", err)
+	}
+	if filter, _ = accountService.Filter(&taxable.ID); len(filter.LongPositions(positions)) != 3 {
+		t.Errorf("Expected all 3 lots in the taxable account after claiming unassigned records")
+	}
+}
+
+func TestAccountService_SameRecordInTwoAccounts(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	accountService := NewAccountService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	dividendService := NewDividendService(testDB.DB)
+
+	first, err := accountService.GetOrCreateByName("Brokerage")
+	if err != nil {
+		t.Fatalf("Failed to create first account: %v", err)
+	}
+	second, err := accountService.GetOrCreateByName("Rollover IRA")
+	if err != nil {
+		t.Fatalf("Failed to create second account: %v", err)
+	}
+
+	opened := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	received := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, accountID := range []*int{&first.ID, &second.ID} {
+		if _, err := optionService.CreateInAccount(accountID, "AAPL", "Put", DirectionShort, opened, 170, expiration, 2.50, 1, 0.65, nil, nil); err != nil {
+			t.Fatalf("Expected the same option in account %d to be created, got %v", *accountID, err)
+		}
+		if _, err := dividendService.CreateInAccount(accountID, "AAPL", received, 24); err != nil {
+			t.Fatalf("Expected the same dividend in account %d to be created, got %v", *accountID, err)
+		}
+	}
+
+	if _, err := optionService.CreateInAccount(&first.ID, "AAPL", "Put", DirectionShort, opened, 170, expiration, 2.50, 1, 0.65, nil, nil); err == nil {
+		t.Errorf("Expected a duplicate option in the same account to be rejected")
+	}
+	if _, err := dividendService.CreateInAccount(&first.ID, "AAPL", received, 24); err == nil {
+		t.Errorf("Expected a duplicate dividend in the same account to be rejected")
+	}
+
+	dividends, err := dividendService.GetBySymbolInAccount("AAPL", &second.ID)
+	if err != nil {
+		t.Fatalf("Failed to get dividends: %v", err)
+	}
+	if len(dividends) != 1 {
+		t.Errorf("Expected 1 dividend in the second account, got %d", len(dividends))
+	}
+}
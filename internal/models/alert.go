@@ -0,0 +1,451 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert rule types
+const (
+	AlertProfitTarget     = "profit_target"
+	AlertDTE              = "dte"
+	AlertPutITM           = "put_itm"
+	AlertTreasuryMaturity = "treasury_maturity"
+	AlertExDividendCall   = "ex_dividend_call"
+)
+
+const (
+	// AlertIntervalSetting is how many minutes pass between evaluations of the alert rules
+	AlertIntervalSetting = "ALERT_INTERVAL_MINUTES"
+	// DefaultAlertInterval is the evaluation interval in minutes when the setting is missing or invalid
+	DefaultAlertInterval = 15
+)
+
+// AlertRuleTypes lists every rule type
+var AlertRuleTypes = []string{AlertProfitTarget, AlertDTE, AlertPutITM, AlertTreasuryMaturity, AlertExDividendCall}
+
+// IsValidAlertRuleType reports whether ruleType is a known alert rule type
+func IsValidAlertRuleType(ruleType string) bool {
+	for _, known := range AlertRuleTypes {
+		if ruleType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// AlertRule is a user-defined condition on the open positions. Threshold is a
+// percent of max profit, a number of days or a percent in the money depending
+// on Type; ex_dividend_call rules don't use it.
+type AlertRule struct {
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Type            string     `json:"type"`
+	Threshold       float64    `json:"threshold"`
+	Enabled         bool       `json:"enabled"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Validate normalizes the rule and checks that it can be evaluated
+func (r *AlertRule) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if !IsValidAlertRuleType(r.Type) {
+		return fmt.Errorf("invalid rule type: %s", r.Type)
+	}
+	if r.Threshold < 0 {
+		return fmt.Errorf("threshold cannot be negative")
+	}
+	return nil
+}
+
+// Alert is a rule that fired for one subject, such as 'option:12' or
+// 'treasury:912797AA1'. A rule fires at most once per subject.
+type Alert struct {
+	ID          int        `json:"id"`
+	RuleID      int        `json:"rule_id"`
+	RuleName    string     `json:"rule_name"`
+	RuleType    string     `json:"rule_type"`
+	Subject     string     `json:"subject"`
+	Symbol      *string    `json:"symbol"`
+	Message     string     `json:"message"`
+	FiredAt     time.Time  `json:"fired_at"`
+	ReadAt      *time.Time `json:"read_at"`
+	DismissedAt *time.Time `json:"dismissed_at"`
+}
+
+// daysUntil counts the days from now to date, rounding up like CalculateDaysRemaining
+func daysUntil(date, now time.Time) int {
+	return int(math.Ceil(date.Sub(now).Hours() / 24))
+}
+
+// describeOption names an option leg, e.g. "Short 2 AAPL 190.00 Put 2026-11-20"
+func describeOption(option *Option) string {
+	direction := "Short"
+	if option.IsLong() {
+		direction = "Long"
+	}
+	return fmt.Sprintf("%s %d %s %.2f %s %s", direction, option.Contracts, option.Symbol, option.Strike, option.Type, option.Expiration.Format("2006-01-02"))
+}
+
+// EvaluateRules returns an alert for every subject that meets one of the
+// enabled rules as of now. Closed options and sold treasuries are skipped.
+// The alerts are not saved and have no ID.
+func EvaluateRules(rules []*AlertRule, options []*Option, symbols []*Symbol, treasuries []*Treasury, now time.Time) []*Alert {
+	prices := make(map[string]*Symbol)
+	for _, symbol := range symbols {
+		prices[symbol.Symbol] = symbol
+	}
+
+	alerts := []*Alert{}
+	fire := func(rule *AlertRule, subject string, symbol *string, message string) {
+		alerts = append(alerts, &Alert{
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			RuleType: rule.Type,
+			Subject:  subject,
+			Symbol:   symbol,
+			Message:  message,
+			FiredAt:  now,
+		})
+	}
+
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		if rule.Type == AlertTreasuryMaturity {
+			for _, treasury := range treasuries {
+				if treasury.ExitPrice != nil {
+					continue
+				}
+				days := daysUntil(treasury.Maturity, now)
+				if days < 0 || float64(days) > rule.Threshold {
+					continue
+				}
+				fire(rule, "treasury:"+treasury.CUSPID, nil,
+					fmt.Sprintf("Treasury %s ($%.2f) matures in %d days on %s", treasury.CUSPID, treasury.Amount, days, treasury.Maturity.Format("2006-01-02")))
+			}
+			continue
+		}
+
+		for _, option := range options {
+			if option.Closed != nil {
+				continue
+			}
+			subject := "option:" + strconv.Itoa(option.ID)
+			symbol := option.Symbol
+			underlying := prices[option.Symbol]
+
+			switch rule.Type {
+			case AlertProfitTarget:
+				if option.CurrentPrice == nil {
+					continue
+				}
+				marked := *option
+				marked.ExitPrice = option.CurrentPrice
+				percent := marked.CalculatePercentOfProfit()
+				if percent < rule.Threshold {
+					continue
+				}
+				fire(rule, subject, &symbol, fmt.Sprintf("%s has captured %.0f%% of its max profit", describeOption(option), percent))
+			case AlertDTE:
+				days := daysUntil(option.Expiration, now)
+				if float64(days) > rule.Threshold {
+					continue
+				}
+				message := fmt.Sprintf("%s expires in %d days", describeOption(option), days)
+				if days < 0 {
+					message = fmt.Sprintf("%s expired %d days ago and is still open", describeOption(option), -days)
+				}
+				fire(rule, subject, &symbol, message)
+			case AlertPutITM:
+				if option.Type != "Put" || underlying == nil || underlying.Price <= 0 || underlying.Price >= option.Strike {
+					continue
+				}
+				percent := (option.Strike - underlying.Price) / underlying.Price * 100
+				if percent <= rule.Threshold {
+					continue
+				}
+				fire(rule, subject, &symbol, fmt.Sprintf("%s is %.1f%% in the money with %s at $%.2f", describeOption(option), percent, option.Symbol, underlying.Price))
+			case AlertExDividendCall:
+				if option.Type != "Call" || option.IsLong() || underlying == nil || underlying.ExDividendDate == nil {
+					continue
+				}
+				exDividend := *underlying.ExDividendDate
+				if daysUntil(exDividend, now) < 0 || !exDividend.Before(option.Expiration) {
+					continue
+				}
+				fire(rule, subject+":"+exDividend.Format("2006-01-02"), &symbol,
+					fmt.Sprintf("%s goes ex-dividend on %s before %s expires", option.Symbol, exDividend.Format("2006-01-02"), describeOption(option)))
+			}
+		}
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].RuleID != alerts[j].RuleID {
+			return alerts[i].RuleID < alerts[j].RuleID
+		}
+		return alerts[i].Subject < alerts[j].Subject
+	})
+
+	return alerts
+}
+
+type AlertService struct {
+	db *sql.DB
+}
+
+func NewAlertService(db *sql.DB) *AlertService {
+	return &AlertService{db: db}
+}
+
+const alertRuleColumns = `id, name, type, threshold, enabled, last_evaluated_at, created_at, updated_at`
+
+func scanAlertRule(scanner interface{ Scan(...interface{}) error }) (*AlertRule, error) {
+	var rule AlertRule
+	err := scanner.Scan(&rule.ID, &rule.Name, &rule.Type, &rule.Threshold, &rule.Enabled, &rule.LastEvaluatedAt, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRule saves a new alert rule
+func (s *AlertService) CreateRule(rule *AlertRule) (*AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	var id int
+	err := s.db.QueryRow(`INSERT INTO alert_rules (name, type, threshold, enabled) VALUES (?, ?, ?, ?) RETURNING id`,
+		rule.Name, rule.Type, rule.Threshold, rule.Enabled).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	return s.GetRule(id)
+}
+
+// UpdateRule replaces a rule's name, type, threshold and whether it is enabled
+func (s *AlertService) UpdateRule(id int, rule *AlertRule) (*AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Exec(`UPDATE alert_rules SET name = ?, type = ?, threshold = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		rule.Name, rule.Type, rule.Threshold, rule.Enabled, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("alert rule not found")
+	}
+
+	return s.GetRule(id)
+}
+
+// GetRules returns every alert rule by name
+func (s *AlertService) GetRules() ([]*AlertRule, error) {
+	rows, err := s.db.Query(`SELECT ` + alertRuleColumns + ` FROM alert_rules ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*AlertRule{}
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (s *AlertService) GetRule(id int) (*AlertRule, error) {
+	rule, err := scanAlertRule(s.db.QueryRow(`SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("alert rule not found")
+		}
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a rule and the alerts it fired
+func (s *AlertService) DeleteRule(id int) error {
+	result, err := s.db.Exec(`DELETE FROM alert_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+
+	return nil
+}
+
+// Evaluate checks the enabled rules against every open position and saves
+// the alerts that haven't fired before. It returns only the new alerts.
+func (s *AlertService) Evaluate(now time.Time) ([]*Alert, error) {
+	rules, err := s.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := NewSymbolService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	treasuries, err := NewTreasuryService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	fired := []*Alert{}
+	for _, alert := range EvaluateRules(rules, options, symbols, treasuries, now) {
+		result, err := tx.Exec(`INSERT OR IGNORE INTO alerts (rule_id, subject, symbol, message, fired_at) VALUES (?, ?, ?, ?, ?)`,
+			alert.RuleID, alert.Subject, alert.Symbol, alert.Message, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save alert: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			continue
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get alert ID: %w", err)
+		}
+		alert.ID = int(id)
+		fired = append(fired, alert)
+	}
+
+	if _, err := tx.Exec(`UPDATE alert_rules SET last_evaluated_at = ? WHERE enabled = 1`, now); err != nil {
+		return nil, fmt.Errorf("failed to update last evaluation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit alerts: %w", err)
+	}
+
+	return fired, nil
+}
+
+// GetAlerts returns the alerts newest first, with dismissed ones only if asked for
+func (s *AlertService) GetAlerts(includeDismissed bool) ([]*Alert, error) {
+	query := `SELECT a.id, a.rule_id, r.name, r.type, a.subject, a.symbol, a.message, a.fired_at, a.read_at, a.dismissed_at
+		FROM alerts a JOIN alert_rules r ON r.id = a.rule_id`
+	if !includeDismissed {
+		query += ` WHERE a.dismissed_at IS NULL`
+	}
+	query += ` ORDER BY a.fired_at DESC, a.id DESC`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*Alert{}
+	for rows.Next() {
+		var alert Alert
+		if err := rows.Scan(&alert.ID, &alert.RuleID, &alert.RuleName, &alert.RuleType, &alert.Subject, &alert.Symbol, &alert.Message,
+			&alert.FiredAt, &alert.ReadAt, &alert.DismissedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, &alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// UnreadCount returns how many alerts in the inbox haven't been read
+func (s *AlertService) UnreadCount() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM alerts WHERE read_at IS NULL AND dismissed_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread alerts: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one alert as read
+func (s *AlertService) MarkRead(id int) error {
+	return s.stamp(`UPDATE alerts SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = ?`, id)
+}
+
+// MarkAllRead marks every alert in the inbox as read
+func (s *AlertService) MarkAllRead() error {
+	if _, err := s.db.Exec(`UPDATE alerts SET read_at = CURRENT_TIMESTAMP WHERE read_at IS NULL`); err != nil {
+		return fmt.Errorf("failed to mark alerts read: %w", err)
+	}
+	return nil
+}
+
+// Dismiss removes an alert from the inbox. It is kept so the rule doesn't fire
+// again for the same subject.
+func (s *AlertService) Dismiss(id int) error {
+	return s.stamp(`UPDATE alerts SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP), dismissed_at = COALESCE(dismissed_at, CURRENT_TIMESTAMP) WHERE id = ?`, id)
+}
+
+func (s *AlertService) stamp(query string, id int) error {
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("alert not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	now := date(time.October, 17)
+	mark := 0.80
+	exDividend := date(time.November, 7)
+	exitPrice := 10000.0
+
+	rules := []*AlertRule{
+		{ID: 1, Name: "Half profit", Type: AlertProfitTarget, Threshold: 50, Enabled: true},
+		{ID: 2, Name: "21 DTE", Type: AlertDTE, Threshold: 21, Enabled: true},
+		{ID: 3, Name: "Put ITM", Type: AlertPutITM, Threshold: 5, Enabled: true},
+		{ID: 4, Name: "Maturity", Type: AlertTreasuryMaturity, Threshold: 7, Enabled: true},
+		{ID: 5, Name: "Ex-dividend", Type: AlertExDividendCall, Enabled: true},
+		{ID: 6, Name: "Disabled", Type: AlertDTE, Threshold: 365, Enabled: false},
+	}
+	options := []*Option{
+		// Captured 60% of its premium, expires in 35 days
+		{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 160, Premium: 2, Contracts: 1, Expiration: date(time.November, 21), CurrentPrice: &mark},
+		// 10% in the money, expires in 14 days
+		{ID: 2, Symbol: "KO", Type: "Put", Direction: DirectionShort, Strike: 66, Premium: 1, Contracts: 1, Expiration: date(time.October, 31)},
+		// Short call across AAPL's ex-dividend date
+		{ID: 3, Symbol: "AAPL", Type: "Call", Direction: DirectionShort, Strike: 220, Premium: 3, Contracts: 1, Expiration: date(time.December, 19)},
+		// Long calls aren't assigned
+		{ID: 4, Symbol: "AAPL", Type: "Call", Direction: DirectionLong, Strike: 230, Premium: 1, Contracts: 1, Expiration: date(time.December, 19)},
+	}
+	symbols := []*Symbol{
+		{Symbol: "AAPL", Price: 200, ExDividendDate: &exDividend},
+		{Symbol: "KO", Price: 60},
+	}
+	treasuries := []*Treasury{
+		{CUSPID: "912797AA1", Maturity: date(time.October, 22), Amount: 10000},
+		{CUSPID: "912797BB2", Maturity: date(time.November, 20), Amount: 10000},
+		{CUSPID: "912797CC3", Maturity: date(time.October, 20), Amount: 10000, ExitPrice: &exitPrice},
+	}
+
+	alerts := EvaluateRules(rules, options, symbols, treasuries, now)
+
+	expected := []string{"1 option:1", "2 option:2", "3 option:2", "4 treasury:912797AA1", "5 option:3:2026-11-07"}
+	if len(alerts) != len(expected) {
+		for _, alert := range alerts {
+			t.Logf("%d %s: %s", alert.RuleID, alert.Subject, alert.Message)
+		}
+		t.Fatalf("Expected %d alerts, got %d", len(expected), len(alerts))
+	}
+	for i, alert := range alerts {
+		if got := fmt.Sprintf("%d %s", alert.RuleID, alert.Subject); got != expected[i] {
+			t.Errorf("Alert %d: expected %s, got %s", i, expected[i], got)
+		}
+	}
+	if alerts[0].Message != "Short 1 AAPL 160.00 Put 2026-11-21 has captured 60% of its max profit" {
+		t.Errorf("Unexpected profit target message: %s", alerts[0].Message)
+	}
+	if alerts[2].Message != "Short 1 KO 66.00 Put 2026-10-31 is 10.0% in the money with KO at $60.00" {
+		t.Errorf("Unexpected put ITM message: %s", alerts[2].Message)
+	}
+	if alerts[3].Symbol != nil {
+		t.Errorf("Expected treasury alerts to have no symbol")
+	}
+}
+
+func TestAlertService_Evaluate(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	service := NewAlertService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	option, err := optionService.Create("AAPL", "Put", now.AddDate(0, 0, -20), 150, now.AddDate(0, 0, 10), 2.00, 1)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+
+	// The migration seeds one rule per type
+	rules, err := service.GetRules()
+	if err != nil {
+		t.Fatalf("Failed to get rules: %v", err)
+	}
+	if len(rules) != len(AlertRuleTypes) {
+		t.Fatalf("Expected %d seeded rules, got %d", len(AlertRuleTypes), len(rules))
+	}
+
+	fired, err := service.Evaluate(now)
+	if err != nil {
+		t.Fatalf("Failed to evaluate alerts: %v", err)
+	}
+	if len(fired) != 1 || fired[0].RuleType != AlertDTE || fired[0].Subject != "option:1" || fired[0].ID == 0 {
+		t.Fatalf("Expected the 21 DTE rule to fire for the put, got %+v", fired)
+	}
+
+	// A rule fires once per subject, so the next pass only sees the new profit
+	mark := 0.50
+	if _, err := optionService.SetMark(option.ID, &mark); err != nil {
+		t.Fatalf("Failed to set mark: %v", err)
+	}
+	fired, err = service.Evaluate(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to evaluate alerts: %v", err)
+	}
+	if len(fired) != 1 || fired[0].RuleType != AlertProfitTarget {
+		t.Fatalf("Expected only the profit target to fire, got %+v", fired)
+	}
+
+	rule, err := service.GetRule(fired[0].RuleID)
+	if err != nil {
+		t.Fatalf("Failed to get rule: %v", err)
+	}
+	if rule.LastEvaluatedAt == nil || !rule.LastEvaluatedAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected the last evaluation to be recorded, got %v", rule.LastEvaluatedAt)
+	}
+
+	if count, _ := service.UnreadCount(); count != 2 {
+		t.Errorf("Expected 2 unread alerts, got %d", count)
+	}
+	if err := service.Dismiss(fired[0].ID); err != nil {
+		t.Fatalf("Failed to dismiss alert: %v", err)
+	}
+	inbox, err := service.GetAlerts(false)
+	if err != nil {
+		t.Fatalf("Failed to get alerts: %v", err)
+	}
+	if len(inbox) != 1 || inbox[0].RuleType != AlertDTE {
+		t.Errorf("Expected only the DTE alert left in the inbox, got %d alerts", len(inbox))
+	}
+	if err := service.MarkAllRead(); err != nil {
+		t.Fatalf("Failed to mark alerts read: %v", err)
+	}
+	if count, _ := service.UnreadCount(); count != 0 {
+		t.Errorf("Expected no unread alerts, got %d", count)
+	}
+	if err := service.Dismiss(999); err == nil {
+		t.Errorf("Expected dismissing a missing alert to fail")
+	}
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Calendar event types
+const (
+	CalendarExpiration = "expiration"
+	CalendarExDividend = "ex_dividend"
+	CalendarMaturity   = "maturity"
+)
+
+// DefaultCalendarAlarmDays is how many days before an expiration or maturity
+// its alarm goes off when the feed doesn't ask for another lead time
+const DefaultCalendarAlarmDays = 2
+
+// CalendarEventTypes lists every event type in the order they are built
+var CalendarEventTypes = []string{CalendarExpiration, CalendarExDividend, CalendarMaturity}
+
+// IsValidCalendarEventType reports whether eventType is a known calendar event type
+func IsValidCalendarEventType(eventType string) bool {
+	for _, known := range CalendarEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CalendarEvent is an all-day event on Date. AlarmDays is how many days
+// before it a reminder goes off, or zero for none.
+type CalendarEvent struct {
+	UID         string    `json:"uid"`
+	Type        string    `json:"type"`
+	Date        time.Time `json:"date"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description"`
+	AlarmDays   int       `json:"alarm_days"`
+}
+
+// Calendar is a named list of events, oldest first
+type Calendar struct {
+	Name   string           `json:"name"`
+	Events []*CalendarEvent `json:"events"`
+}
+
+// BuildCalendar collects the events of the given types: one per symbol per
+// expiration of the open options, the ex-dividend date of each symbol with an
+// open option or long position, and the maturity of each open treasury.
+// Expirations and maturities get an alarm alarmDays before them.
+func BuildCalendar(name string, options []*Option, longPositions []*LongPosition, symbols []*Symbol, treasuries []*Treasury, types []string, alarmDays int) *Calendar {
+	calendar := &Calendar{Name: name, Events: []*CalendarEvent{}}
+	include := make(map[string]bool)
+	for _, eventType := range types {
+		include[eventType] = true
+	}
+
+	held := make(map[string]bool)
+	expirations := make(map[string][]*Option)
+	for _, option := range options {
+		if option.Closed != nil {
+			continue
+		}
+		held[option.Symbol] = true
+		key := option.Symbol + "-" + option.Expiration.Format("20060102")
+		expirations[key] = append(expirations[key], option)
+	}
+	for _, position := range longPositions {
+		if position.Closed == nil {
+			held[position.Symbol] = true
+		}
+	}
+
+	if include[CalendarExpiration] {
+		for key, legs := range expirations {
+			sort.Slice(legs, func(i, j int) bool {
+				if legs[i].Type != legs[j].Type {
+					return legs[i].Type > legs[j].Type
+				}
+				return legs[i].Strike < legs[j].Strike
+			})
+			var lines []string
+			for _, leg := range legs {
+				direction := "Short"
+				if leg.IsLong() {
+					direction = "Long"
+				}
+				lines = append(lines, fmt.Sprintf("%s %d %s %.2f %s", direction, leg.Contracts, leg.Symbol, leg.Strike, leg.Type))
+			}
+			calendar.Events = append(calendar.Events, &CalendarEvent{
+				UID:         CalendarExpiration + "-" + key,
+				Type:        CalendarExpiration,
+				Date:        legs[0].Expiration,
+				Summary:     fmt.Sprintf("%s options expire", legs[0].Symbol),
+				Description: strings.Join(lines, "\n"),
+				AlarmDays:   alarmDays,
+			})
+		}
+	}
+
+	if include[CalendarExDividend] {
+		for _, symbol := range symbols {
+			if symbol.ExDividendDate == nil || !held[symbol.Symbol] {
+				continue
+			}
+			calendar.Events = append(calendar.Events, &CalendarEvent{
+				UID:         CalendarExDividend + "-" + symbol.Symbol + "-" + symbol.ExDividendDate.Format("20060102"),
+				Type:        CalendarExDividend,
+				Date:        *symbol.ExDividendDate,
+				Summary:     fmt.Sprintf("%s ex-dividend", symbol.Symbol),
+				Description: fmt.Sprintf("Dividend of $%.4f per share. Short calls in the money may be assigned early the day before.", symbol.Dividend),
+			})
+		}
+	}
+
+	if include[CalendarMaturity] {
+		for _, treasury := range treasuries {
+			if treasury.ExitPrice != nil {
+				continue
+			}
+			calendar.Events = append(calendar.Events, &CalendarEvent{
+				UID:         CalendarMaturity + "-" + treasury.CUSPID,
+				Type:        CalendarMaturity,
+				Date:        treasury.Maturity,
+				Summary:     fmt.Sprintf("Treasury %s matures", treasury.CUSPID),
+				Description: fmt.Sprintf("$%.2f face value at %.2f%% yield, bought %s", treasury.Amount, treasury.Yield, treasury.Purchased.Format("2006-01-02")),
+				AlarmDays:   alarmDays,
+			})
+		}
+	}
+
+	sort.Slice(calendar.Events, func(i, j int) bool {
+		if !calendar.Events[i].Date.Equal(calendar.Events[j].Date) {
+			return calendar.Events[i].Date.Before(calendar.Events[j].Date)
+		}
+		return calendar.Events[i].UID < calendar.Events[j].UID
+	})
+
+	return calendar
+}
+
+// icsEscape escapes text for an iCalendar TEXT value
+func icsEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// icsFold splits a content line into lines of at most 75 octets, continuing
+// each with a space, without breaking a UTF-8 character
+func icsFold(line string) string {
+	var folded strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		folded.WriteString(line[:cut])
+		folded.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	folded.WriteString(line)
+	folded.WriteString("\r\n")
+	return folded.String()
+}
+
+// ICS renders the calendar as an iCalendar (RFC 5545) feed. now stamps the events.
+func (c *Calendar) ICS(now time.Time) string {
+	var ics strings.Builder
+	write := func(line string) {
+		ics.WriteString(icsFold(line))
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:-//Wheeler//Wheeler Calendar//EN")
+	write("CALSCALE:GREGORIAN")
+	write("METHOD:PUBLISH")
+	write("X-WR-CALNAME:" + icsEscape(c.Name))
+	write("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	write("X-PUBLISHED-TTL:PT1H")
+	for _, event := range c.Events {
+		write("BEGIN:VEVENT")
+		write("UID:" + event.UID + "@wheeler")
+		write("DTSTAMP:" + stamp)
+		write("DTSTART;VALUE=DATE:" + event.Date.Format("20060102"))
+		write("DTEND;VALUE=DATE:" + event.Date.AddDate(0, 0, 1).Format("20060102"))
+		write("SUMMARY:" + icsEscape(event.Summary))
+		write("DESCRIPTION:" + icsEscape(event.Description))
+		write("CATEGORIES:" + strings.ToUpper(event.Type))
+		write("TRANSP:TRANSPARENT")
+		if event.AlarmDays > 0 {
+			write("BEGIN:VALARM")
+			write("ACTION:DISPLAY")
+			write("DESCRIPTION:" + icsEscape(event.Summary))
+			write(fmt.Sprintf("TRIGGER:-P%dD", event.AlarmDays))
+			write("END:VALARM")
+		}
+		write("END:VEVENT")
+	}
+	write("END:VCALENDAR")
+
+	return ics.String()
+}
+
+type CalendarService struct {
+	db *sql.DB
+}
+
+func NewCalendarService(db *sql.DB) *CalendarService {
+	return &CalendarService{db: db}
+}
+
+// Calendar builds the calendar of one account, named after it, or of the
+// household if accountID is nil
+func (s *CalendarService) Calendar(accountID *int, types []string, alarmDays int) (*Calendar, error) {
+	name := "Wheeler"
+	if accountID != nil {
+		account, err := NewAccountService(s.db).GetByID(*accountID)
+		if err != nil {
+			return nil, err
+		}
+		name += " - " + account.Name
+	}
+
+	filter, err := NewAccountService(s.db).Filter(accountID)
+	if err != nil {
+		return nil, err
+	}
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+	longPositions, err := NewLongPositionService(s.db).GetOpenPositions()
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := NewSymbolService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	treasuries, err := NewTreasuryService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildCalendar(name, filter.Options(options), filter.LongPositions(longPositions), symbols, filter.Treasuries(treasuries), types, alarmDays), nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildCalendar_EventsAndFilters(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closed := date(time.October, 1)
+	exitPrice := 10000.0
+	exDividend := date(time.November, 7)
+
+	options := []*Option{
+		{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 190, Contracts: 1, Expiration: date(time.November, 20)},
+		{ID: 2, Symbol: "AAPL", Type: "Call", Direction: DirectionShort, Strike: 220, Contracts: 1, Expiration: date(time.November, 20)},
+		{ID: 3, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 180, Contracts: 1, Expiration: date(time.October, 17), Closed: &closed},
+	}
+	symbols := []*Symbol{
+		{Symbol: "AAPL", Dividend: 0.26, ExDividendDate: &exDividend},
+		{Symbol: "KO", Dividend: 0.51, ExDividendDate: &exDividend},
+	}
+	treasuries := []*Treasury{
+		{CUSPID: "912797AA1", Maturity: date(time.December, 4), Amount: 10000, Yield: 4.2, Purchased: date(time.September, 4)},
+		{CUSPID: "912797BB2", Maturity: date(time.November, 6), Amount: 10000, ExitPrice: &exitPrice},
+	}
+
+	calendar := BuildCalendar("Wheeler", options, nil, symbols, treasuries, CalendarEventTypes, 3)
+
+	// One AAPL expiration, AAPL's ex-dividend date (KO isn't held) and the open treasury
+	if len(calendar.Events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(calendar.Events))
+	}
+	exDiv, expiration, maturity := calendar.Events[0], calendar.Events[1], calendar.Events[2]
+	if exDiv.Type != CalendarExDividend || exDiv.Summary != "AAPL ex-dividend" || exDiv.AlarmDays != 0 {
+		t.Errorf("Expected AAPL's ex-dividend date first without an alarm, got %+v", exDiv)
+	}
+	if expiration.Type != CalendarExpiration || expiration.Description != "Short 1 AAPL 190.00 Put\nShort 1 AAPL 220.00 Call" || expiration.AlarmDays != 3 {
+		t.Errorf("Expected both AAPL legs in one expiration with an alarm, got %+v", expiration)
+	}
+	if maturity.Type != CalendarMaturity || maturity.UID != "maturity-912797AA1" {
+		t.Errorf("Expected the open treasury's maturity last, got %+v", maturity)
+	}
+
+	onlyMaturities := BuildCalendar("Wheeler", options, nil, symbols, treasuries, []string{CalendarMaturity}, 3)
+	if len(onlyMaturities.Events) != 1 || onlyMaturities.Events[0].Type != CalendarMaturity {
+		t.Errorf("Expected only the maturity, got %d events", len(onlyMaturities.Events))
+	}
+}
+
+func TestCalendar_ICS(t *testing.T) {
+	calendar := &Calendar{Name: "Wheeler - Schwab, IRA", Events: []*CalendarEvent{{
+		UID:         "expiration-AAPL-20261120",
+		Type:        CalendarExpiration,
+		Date:        time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC),
+		Summary:     "AAPL options expire",
+		Description: strings.Repeat("Short 1 AAPL 190.00 Put; ", 4) + "\nLong 1 AAPL 180.00 Put",
+		AlarmDays:   2,
+	}}}
+
+	ics := calendar.ICS(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Wheeler - Schwab\\, IRA\r\n",
+		"UID:expiration-AAPL-20261120@wheeler\r\n",
+		"DTSTAMP:20261017T120000Z\r\n",
+		"DTSTART;VALUE=DATE:20261120\r\n",
+		"DTEND;VALUE=DATE:20261121\r\n",
+		"TRIGGER:-P2D\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, line) {
+			t.Errorf("Expected the feed to contain %q", line)
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines folded at 75 octets, got %d: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	if !strings.Contains(unfolded, `DESCRIPTION:Short 1 AAPL 190.00 Put\; Short`) || !strings.Contains(unfolded, `\nLong 1 AAPL 180.00 Put`) {
+		t.Errorf("Expected the description escaped and unfolded intact, got %q", unfolded)
+	}
+}
@@ -0,0 +1,495 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Campaign groups the options, long positions and dividends of one wheel cycle
+type Campaign struct {
+	ID            int             `json:"id"`
+	Symbol        string          `json:"symbol"`
+	Name          string          `json:"name"`
+	Started       time.Time       `json:"started"`
+	Ended         *time.Time      `json:"ended"`
+	Notes         *string         `json:"notes"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Options       []*Option       `json:"options"`
+	LongPositions []*LongPosition `json:"long_positions"`
+	Dividends     []*Dividend     `json:"dividends"`
+}
+
+// CampaignSummary holds the calculated results of a campaign
+type CampaignSummary struct {
+	TotalPremium     float64 `json:"total_premium"`
+	CapitalGain      float64 `json:"capital_gain"`
+	Dividends        float64 `json:"dividends"`
+	TotalReturn      float64 `json:"total_return"`
+	CapitalAtRisk    float64 `json:"capital_at_risk"`
+	DaysAtRisk       int     `json:"days_at_risk"`
+	AnnualizedReturn float64 `json:"annualized_return"`
+}
+
+// CampaignItems identifies records to link to or unlink from a campaign
+type CampaignItems struct {
+	OptionIDs       []int `json:"option_ids"`
+	LongPositionIDs []int `json:"long_position_ids"`
+	DividendIDs     []int `json:"dividend_ids"`
+}
+
+func (c *Campaign) IsOpen() bool {
+	return c.Ended == nil
+}
+
+// CalculateTotalPremium sums the net premium of every option in the campaign
+func (c *Campaign) CalculateTotalPremium() float64 {
+	var total float64
+	for _, option := range c.Options {
+		total += option.CalculateTotalProfit()
+	}
+	return total
+}
+
+// CalculateCapitalGain sums stock profit/loss, valuing open lots at currentPrice
+func (c *Campaign) CalculateCapitalGain(currentPrice float64) float64 {
+	var total float64
+	for _, position := range c.LongPositions {
+		if position.Closed == nil && currentPrice <= 0 {
+			continue
+		}
+		total += position.CalculateProfitLoss(currentPrice)
+	}
+	return total
+}
+
+func (c *Campaign) CalculateDividends() float64 {
+	var total float64
+	for _, dividend := range c.Dividends {
+		total += dividend.Amount
+	}
+	return total
+}
+
+// CalculateCapitalAtRisk returns the largest amount of capital committed at
+// any one time: the collateral of open short puts (strike * contracts *
+// multiplier) plus the cost basis of open stock. A put closed on the day its
+// shares are assigned no longer counts, so assignment is not counted twice.
+func (c *Campaign) CalculateCapitalAtRisk() float64 {
+	type commitment struct {
+		opened time.Time
+		closed *time.Time
+		amount float64
+	}
+	var commitments []commitment
+	for _, option := range c.Options {
+		if option.Type != "Put" || option.IsLong() {
+			continue
+		}
+		commitments = append(commitments, commitment{option.Opened, option.Closed, option.Strike * float64(option.Contracts*option.Multiplier())})
+	}
+	for _, position := range c.LongPositions {
+		commitments = append(commitments, commitment{position.Opened, position.Closed, position.CalculateTotalInvested()})
+	}
+
+	// Capital only grows when something opens, so the peak is at an opening
+	var capital float64
+	for i, at := range commitments {
+		var open float64
+		for j, other := range commitments {
+			if j == i || (!other.opened.After(at.opened) && (other.closed == nil || other.closed.After(at.opened))) {
+				open += other.amount
+			}
+		}
+		if open > capital {
+			capital = open
+		}
+	}
+
+	return capital
+}
+
+// CalculateDaysAtRisk returns the days between the campaign start and its end,
+// using today for campaigns that are still open
+func (c *Campaign) CalculateDaysAtRisk() int {
+	start := c.Started
+	for _, option := range c.Options {
+		if option.Opened.Before(start) {
+			start = option.Opened
+		}
+	}
+	for _, position := range c.LongPositions {
+		if position.Opened.Before(start) {
+			start = position.Opened
+		}
+	}
+
+	end := time.Now()
+	if c.Ended != nil {
+		end = *c.Ended
+	}
+
+	days := int(end.Sub(start).Hours() / 24)
+	if days < 1 {
+		days = 1
+	}
+	return days
+}
+
+// Summary calculates the campaign results using currentPrice for open stock
+func (c *Campaign) Summary(currentPrice float64) CampaignSummary {
+	summary := CampaignSummary{
+		TotalPremium:  c.CalculateTotalPremium(),
+		CapitalGain:   c.CalculateCapitalGain(currentPrice),
+		Dividends:     c.CalculateDividends(),
+		CapitalAtRisk: c.CalculateCapitalAtRisk(),
+		DaysAtRisk:    c.CalculateDaysAtRisk(),
+	}
+	summary.TotalReturn = summary.TotalPremium + summary.CapitalGain + summary.Dividends
+
+	if summary.CapitalAtRisk > 0 {
+		periodReturn := (summary.TotalReturn / summary.CapitalAtRisk) * 100
+		summary.AnnualizedReturn = periodReturn * (365.25 / float64(summary.DaysAtRisk))
+	}
+
+	return summary
+}
+
+type CampaignService struct {
+	db *sql.DB
+}
+
+func NewCampaignService(db *sql.DB) *CampaignService {
+	return &CampaignService{db: db}
+}
+
+func (s *CampaignService) Create(symbol, name string, started time.Time, notes *string) (*Campaign, error) {
+	if name == "" {
+		return nil, fmt.Errorf("campaign name is required")
+	}
+
+	query := `INSERT INTO campaigns (symbol, name, started, notes)
+			  VALUES (?, ?, ?, ?)
+			  RETURNING id, symbol, name, started, ended, notes, created_at, updated_at`
+
+	var campaign Campaign
+	err := s.db.QueryRow(query, symbol, name, started, notes).Scan(
+		&campaign.ID, &campaign.Symbol, &campaign.Name, &campaign.Started, &campaign.Ended,
+		&campaign.Notes, &campaign.CreatedAt, &campaign.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	return &campaign, nil
+}
+
+// GetByID retrieves a campaign and its linked records
+func (s *CampaignService) GetByID(id int) (*Campaign, error) {
+	query := `SELECT id, symbol, name, started, ended, notes, created_at, updated_at
+			  FROM campaigns WHERE id = ?`
+
+	var campaign Campaign
+	err := s.db.QueryRow(query, id).Scan(
+		&campaign.ID, &campaign.Symbol, &campaign.Name, &campaign.Started, &campaign.Ended,
+		&campaign.Notes, &campaign.CreatedAt, &campaign.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("campaign not found")
+		}
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	if err := s.loadItems(&campaign); err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+// GetBySymbol retrieves all campaigns for a symbol with their linked records
+func (s *CampaignService) GetBySymbol(symbol string) ([]*Campaign, error) {
+	query := `SELECT id, symbol, name, started, ended, notes, created_at, updated_at
+			  FROM campaigns WHERE symbol = ? ORDER BY started DESC, id DESC`
+
+	return s.query(query, symbol)
+}
+
+// GetAll retrieves all campaigns with their linked records
+func (s *CampaignService) GetAll() ([]*Campaign, error) {
+	query := `SELECT id, symbol, name, started, ended, notes, created_at, updated_at
+			  FROM campaigns ORDER BY started DESC, id DESC`
+
+	return s.query(query)
+}
+
+func (s *CampaignService) query(query string, args ...interface{}) ([]*Campaign, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []*Campaign
+	for rows.Next() {
+		var campaign Campaign
+		if err := rows.Scan(&campaign.ID, &campaign.Symbol, &campaign.Name, &campaign.Started, &campaign.Ended,
+			&campaign.Notes, &campaign.CreatedAt, &campaign.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign: %w", err)
+		}
+		campaigns = append(campaigns, &campaign)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaigns: %w", err)
+	}
+
+	for _, campaign := range campaigns {
+		if err := s.loadItems(campaign); err != nil {
+			return nil, err
+		}
+	}
+
+	return campaigns, nil
+}
+
+// Update changes a campaign's name, dates and notes
+func (s *CampaignService) Update(id int, name string, started time.Time, ended *time.Time, notes *string) (*Campaign, error) {
+	if name == "" {
+		return nil, fmt.Errorf("campaign name is required")
+	}
+	if ended != nil && ended.Before(started) {
+		return nil, fmt.Errorf("campaign cannot end before it started")
+	}
+
+	query := `UPDATE campaigns
+			  SET name = ?, started = ?, ended = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ?
+			  RETURNING id, symbol, name, started, ended, notes, created_at, updated_at`
+
+	var campaign Campaign
+	err := s.db.QueryRow(query, name, started, ended, notes, id).Scan(
+		&campaign.ID, &campaign.Symbol, &campaign.Name, &campaign.Started, &campaign.Ended,
+		&campaign.Notes, &campaign.CreatedAt, &campaign.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("campaign not found")
+		}
+		return nil, fmt.Errorf("failed to update campaign: %w", err)
+	}
+
+	if err := s.loadItems(&campaign); err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+// Delete removes a campaign, leaving its records in place but unlinked
+func (s *CampaignService) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"options", "long_positions", "dividends"} {
+		if _, err := tx.Exec(`UPDATE `+table+` SET campaign_id = NULL WHERE campaign_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to unlink %s from campaign: %w", table, err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM campaigns WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete campaign: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("campaign not found")
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBySymbol removes all campaigns for a symbol
+func (s *CampaignService) DeleteBySymbol(symbol string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"options", "long_positions", "dividends"} {
+		if _, err := tx.Exec(`UPDATE `+table+` SET campaign_id = NULL WHERE campaign_id IN (SELECT id FROM campaigns WHERE symbol = ?)`, symbol); err != nil {
+			return fmt.Errorf("failed to unlink %s from campaigns: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM campaigns WHERE symbol = ?`, symbol); err != nil {
+		return fmt.Errorf("failed to delete campaigns: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// LinkItems attaches options, long positions and dividends to a campaign.
+// Every record must belong to the campaign's symbol.
+func (s *CampaignService) LinkItems(campaignID int, items CampaignItems) error {
+	var symbol string
+	if err := s.db.QueryRow(`SELECT symbol FROM campaigns WHERE id = ?`, campaignID).Scan(&symbol); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("campaign not found")
+		}
+		return fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	return s.setCampaignID(&campaignID, symbol, items)
+}
+
+// UnlinkItems detaches options, long positions and dividends from whatever campaign they belong to
+func (s *CampaignService) UnlinkItems(items CampaignItems) error {
+	return s.setCampaignID(nil, "", items)
+}
+
+func (s *CampaignService) setCampaignID(campaignID *int, symbol string, items CampaignItems) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updates := []struct {
+		table string
+		label string
+		ids   []int
+	}{
+		{"options", "option", items.OptionIDs},
+		{"long_positions", "long position", items.LongPositionIDs},
+		{"dividends", "dividend", items.DividendIDs},
+	}
+
+	for _, update := range updates {
+		for _, id := range update.ids {
+			var itemSymbol string
+			if err := tx.QueryRow(`SELECT symbol FROM `+update.table+` WHERE id = ?`, id).Scan(&itemSymbol); err != nil {
+				if err == sql.ErrNoRows {
+					return fmt.Errorf("%s %d not found", update.label, id)
+				}
+				return fmt.Errorf("failed to get %s %d: %w", update.label, id, err)
+			}
+			if campaignID != nil && itemSymbol != symbol {
+				return fmt.Errorf("%s %d is for %s, campaign is for %s", update.label, id, itemSymbol, symbol)
+			}
+
+			if _, err := tx.Exec(`UPDATE `+update.table+` SET campaign_id = ? WHERE id = ?`, campaignID, id); err != nil {
+				return fmt.Errorf("failed to update %s %d: %w", update.label, id, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// loadItems populates the options, long positions and dividends linked to a campaign
+func (s *CampaignService) loadItems(campaign *Campaign) error {
+	var err error
+	if campaign.Options, err = s.getOptions(campaign.ID); err != nil {
+		return err
+	}
+	if campaign.LongPositions, err = s.getLongPositions(campaign.ID); err != nil {
+		return err
+	}
+	if campaign.Dividends, err = s.getDividends(campaign.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *CampaignService) getOptions(campaignID int) ([]*Option, error) {
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)
+			  FROM options WHERE campaign_id = ? ORDER BY opened ASC, id ASC`
+
+	rows, err := s.db.Query(query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []*Option{}
+	for rows.Next() {
+		var option Option
+		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
+			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign option: %w", err)
+		}
+		options = append(options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign options: %w", err)
+	}
+
+	return options, nil
+}
+
+func (s *CampaignService) getLongPositions(campaignID int) ([]*LongPosition, error) {
+	query := `SELECT id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at
+			  FROM long_positions WHERE campaign_id = ? ORDER BY opened ASC, id ASC`
+
+	rows, err := s.db.Query(query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign long positions: %w", err)
+	}
+	defer rows.Close()
+
+	positions := []*LongPosition{}
+	for rows.Next() {
+		var position LongPosition
+		if err := rows.Scan(&position.ID, &position.Symbol, &position.Opened, &position.Closed, &position.Shares,
+			&position.BuyPrice, &position.ExitPrice, &position.CreatedAt, &position.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign long position: %w", err)
+		}
+		positions = append(positions, &position)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign long positions: %w", err)
+	}
+
+	return positions, nil
+}
+
+func (s *CampaignService) getDividends(campaignID int) ([]*Dividend, error) {
+	query := `SELECT id, symbol, received, amount, created_at
+			  FROM dividends WHERE campaign_id = ? ORDER BY received ASC, id ASC`
+
+	rows, err := s.db.Query(query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign dividends: %w", err)
+	}
+	defer rows.Close()
+
+	dividends := []*Dividend{}
+	for rows.Next() {
+		var dividend Dividend
+		if err := rows.Scan(&dividend.ID, &dividend.Symbol, &dividend.Received, &dividend.Amount, &dividend.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan campaign dividend: %w", err)
+		}
+		dividends = append(dividends, &dividend)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign dividends: %w", err)
+	}
+
+	return dividends, nil
+}
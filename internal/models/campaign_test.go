@@ -0,0 +1,180 @@
+package models
+
+import (
+	"math"
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCampaignService_FullWheelCycle(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	symbolService := NewSymbolService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	positionService := NewLongPositionService(testDB.DB)
+	dividendService := NewDividendService(testDB.DB)
+	campaignService := NewCampaignService(testDB.DB)
+
+	if _, err := symbolService.Create("KO"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	assigned := start.AddDate(0, 0, 30)
+	calledAway := start.AddDate(0, 0, 100)
+
+	campaign, err := campaignService.Create("KO", "KO cycle 1", start, nil)
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+
+	// Cash-secured put: 1 contract at $60 for $1.50, held to assignment
+	put, err := optionService.CreateWithCommission("KO", "Put", start, 60, assigned, 1.50, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	zero := 0.0
+	if _, err := optionService.UpdateByID(put.ID, put.Symbol, put.Type, put.Direction, put.Opened, put.Strike, put.Expiration, put.Premium, put.Contracts, 0, &assigned, &zero); err != nil {
+		t.Fatalf("Failed to close put: %v", err)
+	}
+
+	// Assigned shares, later called away at $62
+	position, err := positionService.Create("KO", assigned, 100, 60)
+	if err != nil {
+		t.Fatalf("Failed to create long position: %v", err)
+	}
+	exit := 62.0
+	if _, err := positionService.UpdateByID(position.ID, "KO", assigned, 100, 60, &calledAway, &exit); err != nil {
+		t.Fatalf("Failed to close long position: %v", err)
+	}
+
+	// Covered call: 1 contract at $62 for $1.00
+	call, err := optionService.CreateWithCommission("KO", "Call", assigned, 62, calledAway, 1.00, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to create call: %v", err)
+	}
+
+	dividend, err := dividendService.Create("KO", start.AddDate(0, 0, 60), 46)
+	if err != nil {
+		t.Fatalf("Failed to create dividend: %v", err)
+	}
+
+	// An unrelated option on the same symbol stays out of the campaign
+	if _, err := optionService.CreateWithCommission("KO", "Put", start, 55, assigned, 0.50, 1, 0); err != nil {
+		t.Fatalf("Failed to create unrelated put: %v", err)
+	}
+
+	err = campaignService.LinkItems(campaign.ID, CampaignItems{
+		OptionIDs:       []int{put.ID, call.ID},
+		LongPositionIDs: []int{position.ID},
+		DividendIDs:     []int{dividend.ID},
+	})
+	if err != nil {
+		t.Fatalf("Failed to link campaign items: %v", err)
+	}
+
+	if _, err := campaignService.Update(campaign.ID, campaign.Name, start, &calledAway, nil); err != nil {
+		t.Fatalf("Failed to end campaign: %v", err)
+	}
+
+	loaded, err := campaignService.GetByID(campaign.ID)
+	if err != nil {
+		t.Fatalf("Failed to get campaign: %v", err)
+	}
+
+	if len(loaded.Options) != 2 || len(loaded.LongPositions) != 1 || len(loaded.Dividends) != 1 {
+		t.Fatalf("Expected 2 options, 1 position, 1 dividend; got %d, %d, %d",
+			len(loaded.Options), len(loaded.LongPositions), len(loaded.Dividends))
+	}
+
+	summary := loaded.Summary(0)
+
+	if summary.TotalPremium != 250 {
+		t.Errorf("Expected total premium 250, got %.2f", summary.TotalPremium)
+	}
+	if summary.CapitalGain != 200 {
+		t.Errorf("Expected capital gain 200, got %.2f", summary.CapitalGain)
+	}
+	if summary.Dividends != 46 {
+		t.Errorf("Expected dividends 46, got %.2f", summary.Dividends)
+	}
+	if summary.TotalReturn != 496 {
+		t.Errorf("Expected total return 496, got %.2f", summary.TotalReturn)
+	}
+	if summary.CapitalAtRisk != 6000 {
+		t.Errorf("Expected capital at risk 6000, got %.2f", summary.CapitalAtRisk)
+	}
+	if summary.DaysAtRisk != 100 {
+		t.Errorf("Expected 100 days at risk, got %d", summary.DaysAtRisk)
+	}
+
+	expectedAROI := (496.0 / 6000.0) * 100 * (365.25 / 100)
+	if math.Abs(summary.AnnualizedReturn-expectedAROI) > 0.01 {
+		t.Errorf("Expected annualized return %.2f, got %.2f", expectedAROI, summary.AnnualizedReturn)
+	}
+
+	t.Run("link rejects other symbols", func(t *testing.T) {
+		if _, err := symbolService.Create("PEP"); err != nil {
+			t.Fatalf("Failed to create symbol: %v", err)
+		}
+		other, err := optionService.CreateWithCommission("PEP", "Put", start, 150, assigned, 2.00, 1, 0)
+		if err != nil {
+			t.Fatalf("Failed to create PEP put: %v", err)
+		}
+		if err := campaignService.LinkItems(campaign.ID, CampaignItems{OptionIDs: []int{other.ID}}); err == nil {
+			t.Error("Expected error linking PEP option to KO campaign")
+		}
+	})
+
+	t.Run("delete unlinks records", func(t *testing.T) {
+		if err := campaignService.Delete(campaign.ID); err != nil {
+			t.Fatalf("Failed to delete campaign: %v", err)
+		}
+		if _, err := optionService.GetByID(put.ID); err != nil {
+			t.Errorf("Expected put to survive campaign deletion: %v", err)
+		}
+		campaigns, err := campaignService.GetBySymbol("KO")
+		if err != nil {
+			t.Fatalf("Failed to get campaigns: %v", err)
+		}
+		if len(campaigns) != 0 {
+			t.Errorf("Expected no campaigns after delete, got %d", len(campaigns))
+		}
+	})
+}
+
+func TestCampaign_CalculateCapitalAtRisk(t *testing.T) {
+	day := func(offset int) time.Time {
+		return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).AddDate(0, 0, offset)
+	}
+	closedOn := func(offset int) *time.Time {
+		closed := day(offset)
+		return &closed
+	}
+
+	// Put assigned on day 30, shares sold on day 60, rebought on day 90 and still
+	// held, with a second put sold on day 100 while the shares are open
+	campaign := &Campaign{
+		Options: []*Option{
+			{Type: "Put", Direction: DirectionShort, Opened: day(0), Closed: closedOn(30), Strike: 60, Contracts: 1, ContractMultiplier: 100},
+			{Type: "Put", Direction: DirectionShort, Opened: day(100), Strike: 55, Contracts: 1, ContractMultiplier: 100},
+			{Type: "Put", Direction: DirectionLong, Opened: day(100), Strike: 50, Contracts: 1, ContractMultiplier: 100},
+		},
+		LongPositions: []*LongPosition{
+			{Opened: day(30), Closed: closedOn(60), Shares: 100, BuyPrice: 60},
+			{Opened: day(90), Shares: 100, BuyPrice: 58},
+		},
+	}
+
+	// The rebought shares plus the second put's collateral
+	if capital := campaign.CalculateCapitalAtRisk(); capital != 5800+5500 {
+		t.Errorf("Expected capital at risk 11300, got %.2f", capital)
+	}
+}
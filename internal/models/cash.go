@@ -0,0 +1,333 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Cash entry types recorded by hand. Every other cash flow is derived from trades.
+const (
+	CashDeposit    = "deposit"
+	CashWithdrawal = "withdrawal"
+	CashInterest   = "interest"
+)
+
+// Cash flow kinds derived from trades
+const (
+	CashFlowPremium        = "premium"
+	CashFlowOptionClose    = "option_close"
+	CashFlowCommission     = "commission"
+	CashFlowStockBuy       = "stock_buy"
+	CashFlowStockSale      = "stock_sale"
+	CashFlowDividend       = "dividend"
+	CashFlowTreasuryBuy    = "treasury_buy"
+	CashFlowTreasuryRedeem = "treasury_redeem"
+)
+
+// CashEntry is a deposit, withdrawal or interest payment with no trade behind it
+type CashEntry struct {
+	ID        int       `json:"id"`
+	Date      time.Time `json:"date"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Notes     *string   `json:"notes"`
+	AccountID *int      `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsValidCashEntryType reports whether entryType is a known cash entry type
+func IsValidCashEntryType(entryType string) bool {
+	switch entryType {
+	case CashDeposit, CashWithdrawal, CashInterest:
+		return true
+	}
+	return false
+}
+
+// SignedAmount returns the entry's effect on the balance; withdrawals are negative
+func (e *CashEntry) SignedAmount() float64 {
+	if e.Type == CashWithdrawal {
+		return -e.Amount
+	}
+	return e.Amount
+}
+
+// CashFlow is one movement of cash, in (positive) or out (negative), with the
+// balance after it. EntryID is set for deposits, withdrawals and interest.
+type CashFlow struct {
+	Date        time.Time `json:"date"`
+	Kind        string    `json:"kind"`
+	Symbol      string    `json:"symbol"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	Balance     float64   `json:"balance"`
+	EntryID     *int      `json:"entry_id"`
+}
+
+// CashLedger is every cash flow, oldest first, with the running balance
+type CashLedger struct {
+	Flows   []*CashFlow        `json:"flows"`
+	Balance float64            `json:"balance"`
+	ByKind  map[string]float64 `json:"by_kind"`
+}
+
+// Total returns the net cash flow of the given kinds
+func (l *CashLedger) Total(kinds ...string) float64 {
+	var total float64
+	for _, kind := range kinds {
+		total += l.ByKind[kind]
+	}
+	return total
+}
+
+// BalanceOn returns the balance at the end of date
+func (l *CashLedger) BalanceOn(date time.Time) float64 {
+	day := date.Format("2006-01-02")
+	var balance float64
+	for _, flow := range l.Flows {
+		if flow.Date.Format("2006-01-02") > day {
+			break
+		}
+		balance = flow.Balance
+	}
+	return balance
+}
+
+// BuildCashLedger derives the cash flows of trades and adds the manual entries.
+// Sold options bring in their premium and cost their buyback; bought options
+// the reverse. Commissions are paid when the option is opened, except the
+// closing commission, keyed by option ID, which is paid when it closes. Stock
+// costs buy_price per share when a lot opens, which for an assigned put is the
+// strike on the assignment date, and brings in exit_price per share when it
+// closes. Treasuries cost their buy price when purchased and return their exit
+// price on their redemption date, or at maturity if none is recorded.
+func BuildCashLedger(entries []*CashEntry, options []*Option, closingCommissions map[int]float64, positions []*LongPosition, dividends []*Dividend, treasuries []*Treasury, instruments Instruments) *CashLedger {
+	ledger := &CashLedger{Flows: []*CashFlow{}, ByKind: make(map[string]float64)}
+	add := func(flow *CashFlow) {
+		if flow.Amount != 0 {
+			ledger.Flows = append(ledger.Flows, flow)
+		}
+	}
+
+	// Entries go first so that a deposit is counted before a purchase on the same day
+	for _, entry := range entries {
+		id := entry.ID
+		description := entry.Type
+		if entry.Notes != nil && *entry.Notes != "" {
+			description = *entry.Notes
+		}
+		add(&CashFlow{Date: entry.Date, Kind: entry.Type, Description: description, Amount: entry.SignedAmount(), EntryID: &id})
+	}
+
+	for _, option := range options {
+		multiplier := float64(instruments.Multiplier(option.Symbol) * option.Contracts)
+		description := fmt.Sprintf("%s %s %.2f %s", option.Symbol, option.Expiration.Format("01/02/2006"), option.Strike, option.Type)
+		sign := 1.0
+		if option.IsLong() {
+			sign = -1.0
+		}
+
+		add(&CashFlow{Date: option.Opened, Kind: CashFlowPremium, Symbol: option.Symbol, Description: description, Amount: sign * option.Premium * multiplier})
+		// The closing commission is paid when the option closes
+		closingCommission := 0.0
+		if option.Closed != nil {
+			closingCommission = math.Max(0, math.Min(closingCommissions[option.ID], option.Commission))
+		}
+		add(&CashFlow{Date: option.Opened, Kind: CashFlowCommission, Symbol: option.Symbol, Description: description, Amount: -(option.Commission - closingCommission)})
+		if option.Closed != nil {
+			add(&CashFlow{Date: *option.Closed, Kind: CashFlowOptionClose, Symbol: option.Symbol, Description: description, Amount: -sign * option.GetExitPriceValue() * multiplier})
+			add(&CashFlow{Date: *option.Closed, Kind: CashFlowCommission, Symbol: option.Symbol, Description: description, Amount: -closingCommission})
+		}
+	}
+
+	for _, lot := range positions {
+		description := fmt.Sprintf("%d sh %s", lot.Shares, lot.Symbol)
+		add(&CashFlow{Date: lot.Opened, Kind: CashFlowStockBuy, Symbol: lot.Symbol, Description: description, Amount: -lot.BuyPrice * float64(lot.Shares)})
+		if lot.Closed != nil {
+			add(&CashFlow{Date: *lot.Closed, Kind: CashFlowStockSale, Symbol: lot.Symbol, Description: description, Amount: lot.GetExitPriceValue() * float64(lot.Shares)})
+		}
+	}
+
+	for _, dividend := range dividends {
+		add(&CashFlow{Date: dividend.Received, Kind: CashFlowDividend, Symbol: dividend.Symbol, Description: dividend.Symbol + " dividend", Amount: dividend.Amount})
+	}
+
+	for _, treasury := range treasuries {
+		add(&CashFlow{Date: treasury.Purchased, Kind: CashFlowTreasuryBuy, Description: treasury.CUSPID, Amount: -treasury.BuyPrice})
+		if treasury.ExitPrice != nil {
+			redeemed := treasury.Maturity
+			if treasury.Redeemed != nil {
+				redeemed = *treasury.Redeemed
+			}
+			add(&CashFlow{Date: redeemed, Kind: CashFlowTreasuryRedeem, Description: treasury.CUSPID, Amount: *treasury.ExitPrice})
+		}
+	}
+
+	sort.SliceStable(ledger.Flows, func(i, j int) bool {
+		return ledger.Flows[i].Date.Format("2006-01-02") < ledger.Flows[j].Date.Format("2006-01-02")
+	})
+
+	for _, flow := range ledger.Flows {
+		ledger.Balance += flow.Amount
+		flow.Balance = ledger.Balance
+		ledger.ByKind[flow.Kind] += flow.Amount
+	}
+
+	return ledger
+}
+
+type CashService struct {
+	db *sql.DB
+}
+
+func NewCashService(db *sql.DB) *CashService {
+	return &CashService{db: db}
+}
+
+func (s *CashService) Create(date time.Time, entryType string, amount float64, notes *string, accountID *int) (*CashEntry, error) {
+	if !IsValidCashEntryType(entryType) {
+		return nil, fmt.Errorf("cash entry type must be '%s', '%s' or '%s'", CashDeposit, CashWithdrawal, CashInterest)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	query := `INSERT INTO cash_transactions (date, type, amount, notes, account_id) VALUES (?, ?, ?, ?, ?)
+			  RETURNING id, date, type, amount, notes, account_id, created_at, updated_at`
+
+	var entry CashEntry
+	err := s.db.QueryRow(query, date, entryType, amount, notes, accountID).Scan(
+		&entry.ID, &entry.Date, &entry.Type, &entry.Amount, &entry.Notes, &entry.AccountID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cash entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (s *CashService) GetByID(id int) (*CashEntry, error) {
+	query := `SELECT id, date, type, amount, notes, account_id, created_at, updated_at FROM cash_transactions WHERE id = ?`
+
+	var entry CashEntry
+	err := s.db.QueryRow(query, id).Scan(
+		&entry.ID, &entry.Date, &entry.Type, &entry.Amount, &entry.Notes, &entry.AccountID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("cash entry not found")
+		}
+		return nil, fmt.Errorf("failed to get cash entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (s *CashService) GetAll() ([]*CashEntry, error) {
+	query := `SELECT id, date, type, amount, notes, account_id, created_at, updated_at FROM cash_transactions ORDER BY date, id`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*CashEntry
+	for rows.Next() {
+		var entry CashEntry
+		if err := rows.Scan(&entry.ID, &entry.Date, &entry.Type, &entry.Amount, &entry.Notes, &entry.AccountID, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cash entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cash entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (s *CashService) Update(id int, date time.Time, entryType string, amount float64, notes *string) (*CashEntry, error) {
+	if !IsValidCashEntryType(entryType) {
+		return nil, fmt.Errorf("cash entry type must be '%s', '%s' or '%s'", CashDeposit, CashWithdrawal, CashInterest)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	query := `UPDATE cash_transactions SET date = ?, type = ?, amount = ?, notes = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+			  RETURNING id, date, type, amount, notes, account_id, created_at, updated_at`
+
+	var entry CashEntry
+	err := s.db.QueryRow(query, date, entryType, amount, notes, id).Scan(
+		&entry.ID, &entry.Date, &entry.Type, &entry.Amount, &entry.Notes, &entry.AccountID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("cash entry not found")
+		}
+		return nil, fmt.Errorf("failed to update cash entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (s *CashService) Delete(id int) error {
+	result, err := s.db.Exec(`DELETE FROM cash_transactions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete cash entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("cash entry not found")
+	}
+
+	return nil
+}
+
+// Ledger builds the cash ledger of one account, or of the household if accountID is nil
+func (s *CashService) Ledger(accountID *int) (*CashLedger, error) {
+	entries, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	optionService := NewOptionService(s.db)
+	options, err := optionService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	closingCommissions, err := optionService.GetClosingCommissions()
+	if err != nil {
+		return nil, err
+	}
+	positions, err := NewLongPositionService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	dividends, err := NewDividendService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	treasuries, err := NewTreasuryService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := NewAccountService(s.db).Filter(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildCashLedger(filter.CashEntries(entries), filter.Options(options), closingCommissions, filter.LongPositions(positions),
+		filter.Dividends(dividends), filter.Treasuries(treasuries), instruments), nil
+}
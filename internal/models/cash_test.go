@@ -0,0 +1,200 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCashLedger_TradesAndEntries(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	cashService := NewCashService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	dividendService := NewDividendService(testDB.DB)
+	treasuryService := NewTreasuryService(testDB.DB)
+	metricService := NewMetricService(testDB.DB)
+
+	day := func(offset int) time.Time {
+		return time.Now().AddDate(0, 0, offset).Truncate(24 * time.Hour)
+	}
+
+	if _, err := cashService.Create(day(-40), CashDeposit, 50000, nil, nil); err != nil {
+		t.Fatalf("Failed to record deposit: %v", err)
+	}
+	if _, err := cashService.Create(day(-40), "transfer", 100, nil, nil); err == nil {
+		t.Errorf("Expected an unknown entry type to be rejected")
+	}
+	if _, err := cashService.Create(day(-40), CashWithdrawal, -5, nil, nil); err == nil {
+		t.Errorf("Expected a non-positive amount to be rejected")
+	}
+
+	// Sell a put for 2.00 (+200, -0.65 commission) and get assigned 100 shares at 150 (-15000)
+	put, err := optionService.Create("AAPL", "Put", day(-30), 150, day(-20), 2.00, 1)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	if _, _, err := optionService.Assign(put.ID, day(-20)); err != nil {
+		t.Fatalf("Failed to assign put: %v", err)
+	}
+
+	// Sell a call for 1.50 (+150, -0.65) that is called away at 155 (+15500)
+	call, err := optionService.Create("AAPL", "Call", day(-15), 155, day(-5), 1.50, 1)
+	if err != nil {
+		t.Fatalf("Failed to create call: %v", err)
+	}
+	if _, _, err := optionService.CallAway(call.ID, day(-5)); err != nil {
+		t.Fatalf("Failed to call away: %v", err)
+	}
+
+	// Sell a put for 1.00 and buy it back for 0.25 (+100, -0.65, -25)
+	if _, err := optionService.CreateClosed("AAPL", "Put", DirectionShort, day(-10), 140, day(10), 1.00, 1, 0.65, day(-3), 0.25); err != nil {
+		t.Fatalf("Failed to create closed put: %v", err)
+	}
+
+	if _, err := dividendService.Create("AAPL", day(-8), 24); err != nil {
+		t.Fatalf("Failed to create dividend: %v", err)
+	}
+	if _, err := treasuryService.Create("912797AA1", day(-2), day(90), 10000, 4.5, 9900); err != nil {
+		t.Fatalf("Failed to create treasury: %v", err)
+	}
+	interest := "Sweep interest"
+	if _, err := cashService.Create(day(-1), CashInterest, 12.50, &interest, nil); err != nil {
+		t.Fatalf("Failed to record interest: %v", err)
+	}
+
+	ledger, err := cashService.Ledger(nil)
+	if err != nil {
+		t.Fatalf("Failed to build ledger: %v", err)
+	}
+
+	expected := 50000 + (200 - 0.65) - 15000 + (150 - 0.65) + 15500 + (100 - 0.65 - 25) + 24 - 9900 + 12.50
+	if math.Abs(ledger.Balance-expected) > 0.001 {
+		t.Errorf("Expected balance %.2f, got %.2f", expected, ledger.Balance)
+	}
+	if got := ledger.Total(CashFlowStockBuy, CashFlowStockSale); math.Abs(got-500) > 0.001 {
+		t.Errorf("Expected net stock flow 500, got %.2f", got)
+	}
+	if got := ledger.Total(CashFlowPremium); math.Abs(got-450) > 0.001 {
+		t.Errorf("Expected premium 450, got %.2f", got)
+	}
+
+	for i := 1; i < len(ledger.Flows); i++ {
+		if ledger.Flows[i].Date.Before(ledger.Flows[i-1].Date) {
+			t.Fatalf("Expected flows oldest first, flow %d is out of order", i)
+		}
+	}
+	if last := ledger.Flows[len(ledger.Flows)-1]; last.Kind != CashInterest || last.EntryID == nil || last.Description != interest {
+		t.Errorf("Expected the interest entry last, got %+v", last)
+	}
+
+	// The balance right after assignment is the deposit and put premium less the shares
+	if got, want := ledger.BalanceOn(day(-20)), 50000+200-0.65-15000; math.Abs(got-want) > 0.001 {
+		t.Errorf("Expected balance %.2f after assignment, got %.2f", want, got)
+	}
+	if got := ledger.BalanceOn(day(-60)); got != 0 {
+		t.Errorf("Expected no balance before the first deposit, got %.2f", got)
+	}
+
+	// Snapshots record the balance as the cash metric
+	if err := metricService.ComprehensiveSnapshot(1); err != nil {
+		t.Fatalf("Failed to snapshot metrics: %v", err)
+	}
+	cashMetrics, err := metricService.GetByType(Cash)
+	if err != nil {
+		t.Fatalf("Failed to get cash metrics: %v", err)
+	}
+	if len(cashMetrics) != 1 || math.Abs(cashMetrics[0].Value-expected) > 0.001 {
+		t.Errorf("Expected one cash metric of %.2f, got %+v", expected, cashMetrics)
+	}
+
+	// An account only sees its own entries and trades
+	account, err := NewAccountService(testDB.DB).Create("Brokerage", nil, AccountTaxable)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if _, err := cashService.Create(day(-1), CashDeposit, 1000, nil, &account.ID); err != nil {
+		t.Fatalf("Failed to record account deposit: %v", err)
+	}
+	accountLedger, err := cashService.Ledger(&account.ID)
+	if err != nil {
+		t.Fatalf("Failed to build account ledger: %v", err)
+	}
+	if len(accountLedger.Flows) != 1 || accountLedger.Balance != 1000 {
+		t.Errorf("Expected only the account's deposit, got %d flows and balance %.2f", len(accountLedger.Flows), accountLedger.Balance)
+	}
+}
+
+func TestCashLedger_ClosingCommissionAndRedemption(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	cashService := NewCashService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	treasuryService := NewTreasuryService(testDB.DB)
+
+	day := func(offset int) time.Time {
+		return time.Now().AddDate(0, 0, offset).Truncate(24 * time.Hour)
+	}
+
+	// Sell 2 puts (-1.30 opening commission) and buy them back 10 days later (-1.30 closing)
+	put, err := optionService.Create("AAPL", "Put", day(-20), 150, day(10), 2.00, 2)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	if err := optionService.CloseByID(put.ID, day(-10), 0.50); err != nil {
+		t.Fatalf("Failed to close put: %v", err)
+	}
+
+	// A bill sold before maturity is redeemed when its exit price is recorded,
+	// and later edits keep that date
+	if _, err := treasuryService.Create("912797AA1", day(-30), day(60), 10000, 4.5, 9900); err != nil {
+		t.Fatalf("Failed to create treasury: %v", err)
+	}
+	exitPrice := 9950.0
+	sold, err := treasuryService.Update("912797AA1", nil, &exitPrice)
+	if err != nil {
+		t.Fatalf("Failed to record exit price: %v", err)
+	}
+	if sold.Redeemed == nil || sold.Redeemed.After(time.Now()) {
+		t.Fatalf("Expected a redemption date of today, got %v", sold.Redeemed)
+	}
+	redeemed := *sold.Redeemed
+	currentValue := 9950.0
+	edited, err := treasuryService.Update("912797AA1", &currentValue, &exitPrice)
+	if err != nil {
+		t.Fatalf("Failed to edit treasury: %v", err)
+	}
+	if edited.Redeemed == nil || !edited.Redeemed.Equal(redeemed) {
+		t.Errorf("Expected the redemption date to stay %v, got %v", redeemed, edited.Redeemed)
+	}
+
+	// A matured bill is redeemed at maturity
+	matured, err := treasuryService.CreateFull("912797BB2", day(-120), day(-30), 5000, 4.5, 4950, nil, &exitPrice)
+	if err != nil {
+		t.Fatalf("Failed to create matured treasury: %v", err)
+	}
+	if matured.Redeemed == nil || !matured.Redeemed.Equal(day(-30)) {
+		t.Errorf("Expected the matured bill redeemed at maturity, got %v", matured.Redeemed)
+	}
+
+	ledger, err := cashService.Ledger(nil)
+	if err != nil {
+		t.Fatalf("Failed to build ledger: %v", err)
+	}
+
+	// Both bills bought and the matured one redeemed; before the buyback only the opening commission is paid
+	if got, want := ledger.BalanceOn(day(-11)), -9900-4950+9950+400-1.30; math.Abs(got-want) > 0.001 {
+		t.Errorf("Expected balance %.2f before the buyback, got %.2f", want, got)
+	}
+	if got, want := ledger.BalanceOn(day(-10)), -9900-4950+9950+400-1.30-100-1.30; math.Abs(got-want) > 0.001 {
+		t.Errorf("Expected balance %.2f after the buyback, got %.2f", want, got)
+	}
+	if got := ledger.Total(CashFlowCommission); math.Abs(got+2.60) > 0.001 {
+		t.Errorf("Expected commissions of 2.60, got %.2f", got)
+	}
+	// The sold bill comes back today
+	if got, want := ledger.BalanceOn(day(-1)), -9900-4950+9950+300-2.60; math.Abs(got-want) > 0.001 {
+		t.Errorf("Expected balance %.2f before the sale, got %.2f", want, got)
+	}
+	if math.Abs(ledger.Balance-(-9900-4950+9950+300-2.60+9950)) > 0.001 {
+		t.Errorf("Expected both redemptions in the balance, got %.2f", ledger.Balance)
+	}
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// PutObligation is the cash a short put, or the put legs of one strategy,
+// would take if assigned at expiration, net of the premium collected for it
+type PutObligation struct {
+	Symbol     string    `json:"symbol"`
+	OptionIDs  []int     `json:"option_ids"`
+	Expiration time.Time `json:"expiration"`
+	Strike     float64   `json:"strike"`
+	Contracts  int       `json:"contracts"`
+	Assignment float64   `json:"assignment"`
+	Premium    float64   `json:"premium"`
+	Net        float64   `json:"net"`
+}
+
+// CollateralWeek is the put obligations expiring in one week, starting on
+// Monday, checked against the collateral available by the week's last expiration.
+// Cumulative includes every obligation expiring this week or earlier, because
+// an assigned put keeps its cash tied up in stock.
+type CollateralWeek struct {
+	WeekOf     time.Time        `json:"week_of"`
+	Puts       []*PutObligation `json:"puts"`
+	Obligation float64          `json:"obligation"`
+	Cumulative float64          `json:"cumulative"`
+	Treasuries float64          `json:"treasuries"`
+	Collateral float64          `json:"collateral"`
+	Shortfall  float64          `json:"shortfall"`
+}
+
+// CollateralReport checks whether the open short puts are secured by cash and
+// by treasuries that mature before the puts expire. The premium of the open
+// puts is netted from their obligations, so it is taken out of Cash, which
+// the ledger balance already credits with it.
+type CollateralReport struct {
+	Cash       float64           `json:"cash"`
+	Premium    float64           `json:"premium"`
+	Treasuries float64           `json:"treasuries"`
+	Obligation float64           `json:"obligation"`
+	Shortfall  float64           `json:"shortfall"`
+	Weeks      []*CollateralWeek `json:"weeks"`
+}
+
+// IsCovered returns true if every put can be assigned without running out of collateral
+func (r *CollateralReport) IsCovered() bool {
+	return r.Shortfall <= 0
+}
+
+// FirstShortfall returns the earliest week that is under-collateralized, or nil
+func (r *CollateralReport) FirstShortfall() *CollateralWeek {
+	for _, week := range r.Weeks {
+		if week.Shortfall > 0 {
+			return week
+		}
+	}
+	return nil
+}
+
+// weekOf returns the Monday of the week containing date
+func weekOf(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// putObligation nets the put legs of one position. Long puts in the same
+// strategy reduce the assignment and cost their premium; calls are ignored.
+// It returns nil if the legs secure nothing.
+func putObligation(legs []*Option, instruments Instruments) *PutObligation {
+	obligation := &PutObligation{}
+	for _, leg := range legs {
+		if leg.Type != "Put" {
+			continue
+		}
+		multiplier := float64(instruments.Multiplier(leg.Symbol) * leg.Contracts)
+		sign := 1.0
+		if leg.IsLong() {
+			sign = -1.0
+		} else if obligation.Strike == 0 || leg.Strike > obligation.Strike {
+			obligation.Strike = leg.Strike
+			obligation.Contracts = leg.Contracts
+		}
+		obligation.Symbol = leg.Symbol
+		obligation.OptionIDs = append(obligation.OptionIDs, leg.ID)
+		obligation.Assignment += sign * leg.Strike * multiplier
+		obligation.Premium += sign * leg.Premium * multiplier
+		if obligation.Expiration.IsZero() || leg.Expiration.Before(obligation.Expiration) {
+			obligation.Expiration = leg.Expiration
+		}
+	}
+	if obligation.Assignment <= 0 {
+		return nil
+	}
+	obligation.Net = obligation.Assignment - obligation.Premium
+	return obligation
+}
+
+// BuildCollateralReport compares the open put obligations against cash plus the
+// face value of open treasuries maturing on or before each put's expiration.
+// balance is the cash ledger balance. Puts in the same strategy are netted and
+// expire with their earliest leg. The check assumes every put is assigned, so
+// obligations accumulate week by week.
+func BuildCollateralReport(options []*Option, treasuries []*Treasury, balance float64, instruments Instruments) *CollateralReport {
+	report := &CollateralReport{Weeks: []*CollateralWeek{}}
+
+	var obligations []*PutObligation
+	strategyLegs := make(map[int][]*Option)
+	for _, option := range options {
+		if option.Closed != nil || option.Type != "Put" {
+			continue
+		}
+		if option.StrategyID != nil {
+			strategyLegs[*option.StrategyID] = append(strategyLegs[*option.StrategyID], option)
+			continue
+		}
+		if option.IsLong() {
+			continue
+		}
+		if obligation := putObligation([]*Option{option}, instruments); obligation != nil {
+			obligations = append(obligations, obligation)
+		}
+	}
+	for _, legs := range strategyLegs {
+		if obligation := putObligation(legs, instruments); obligation != nil {
+			obligations = append(obligations, obligation)
+		}
+	}
+	sort.Slice(obligations, func(i, j int) bool {
+		if !obligations[i].Expiration.Equal(obligations[j].Expiration) {
+			return obligations[i].Expiration.Before(obligations[j].Expiration)
+		}
+		return obligations[i].Symbol < obligations[j].Symbol
+	})
+	for _, obligation := range obligations {
+		report.Premium += obligation.Premium
+	}
+	report.Cash = balance - report.Premium
+
+	var open []*Treasury
+	for _, treasury := range treasuries {
+		if treasury.ExitPrice == nil {
+			open = append(open, treasury)
+			report.Treasuries += treasury.Amount
+		}
+	}
+	maturingBy := func(date time.Time) float64 {
+		var total float64
+		for _, treasury := range open {
+			if !treasury.Maturity.After(date) {
+				total += treasury.Amount
+			}
+		}
+		return total
+	}
+
+	var week *CollateralWeek
+	for _, obligation := range obligations {
+		monday := weekOf(obligation.Expiration)
+		if week == nil || !week.WeekOf.Equal(monday) {
+			week = &CollateralWeek{WeekOf: monday, Puts: []*PutObligation{}, Cumulative: report.Obligation}
+			report.Weeks = append(report.Weeks, week)
+		}
+		report.Obligation += obligation.Net
+		week.Puts = append(week.Puts, obligation)
+		week.Obligation += obligation.Net
+		week.Cumulative = report.Obligation
+		week.Treasuries = maturingBy(obligation.Expiration)
+		week.Collateral = report.Cash + week.Treasuries
+		week.Shortfall = math.Max(week.Shortfall, week.Cumulative-week.Collateral)
+		report.Shortfall = math.Max(report.Shortfall, week.Shortfall)
+	}
+
+	return report
+}
+
+type CollateralService struct {
+	db *sql.DB
+}
+
+func NewCollateralService(db *sql.DB) *CollateralService {
+	return &CollateralService{db: db}
+}
+
+// Report builds the collateral report of one account, or of the household if accountID is nil
+func (s *CollateralService) Report(accountID *int) (*CollateralReport, error) {
+	return s.report(accountID, 0)
+}
+
+// PutImpact builds the collateral report of the account a new short put is in,
+// or of the household if it is unassigned, with the put and as it would be
+// without it: without its obligation and without the premium it brought in.
+func (s *CollateralService) PutImpact(optionID int) (with, without *CollateralReport, err error) {
+	var accountID *int
+	if err := s.db.QueryRow(`SELECT account_id FROM options WHERE id = ?`, optionID).Scan(&accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("option not found")
+		}
+		return nil, nil, fmt.Errorf("failed to get option account: %w", err)
+	}
+
+	if with, err = s.report(accountID, 0); err != nil {
+		return nil, nil, err
+	}
+	if without, err = s.report(accountID, optionID); err != nil {
+		return nil, nil, err
+	}
+	return with, without, nil
+}
+
+// report builds the collateral report of one account, leaving out the open
+// option excluded, if any, and the premium it added to the cash balance
+func (s *CollateralService) report(accountID *int, excluded int) (*CollateralReport, error) {
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+	treasuries, err := NewTreasuryService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	ledger, err := NewCashService(s.db).Ledger(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cash ledger: %w", err)
+	}
+
+	filter, err := NewAccountService(s.db).Filter(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := ledger.Balance
+	kept := []*Option{}
+	for _, option := range filter.Options(options) {
+		if option.ID != excluded {
+			kept = append(kept, option)
+			continue
+		}
+		premium := option.Premium * float64(instruments.Multiplier(option.Symbol)*option.Contracts)
+		if option.IsLong() {
+			premium = -premium
+		}
+		balance -= premium
+	}
+
+	return BuildCollateralReport(kept, filter.Treasuries(treasuries), balance, instruments), nil
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBuildCollateralReport_ShortfallByWeek(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closed := date(time.October, 20)
+	exitPrice := 9900.0
+	strategyID := 7
+
+	options := []*Option{
+		// 100 strike put for 2.00: 10000 assignment less 200 premium
+		{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 100, Premium: 2.00, Contracts: 1, Expiration: date(time.November, 4)},
+		// 50/45 put spread for a 0.60 credit: only the 1000 width is at risk, less 120 premium
+		{ID: 2, Symbol: "KO", Type: "Put", Direction: DirectionShort, Strike: 50, Premium: 1.00, Contracts: 2, Expiration: date(time.November, 6), StrategyID: &strategyID},
+		{ID: 3, Symbol: "KO", Type: "Put", Direction: DirectionLong, Strike: 45, Premium: 0.40, Contracts: 2, Expiration: date(time.November, 6), StrategyID: &strategyID},
+		// 300 strike put for 5.00: 30000 assignment less 500 premium
+		{ID: 4, Symbol: "MSFT", Type: "Put", Direction: DirectionShort, Strike: 300, Premium: 5.00, Contracts: 1, Expiration: date(time.November, 20)},
+		// Protective puts, closed puts and calls need no collateral
+		{ID: 5, Symbol: "AAPL", Type: "Put", Direction: DirectionLong, Strike: 90, Premium: 1.00, Contracts: 1, Expiration: date(time.November, 20)},
+		{ID: 6, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 95, Premium: 1.00, Contracts: 1, Expiration: date(time.November, 20), Closed: &closed},
+		{ID: 7, Symbol: "AAPL", Type: "Call", Direction: DirectionShort, Strike: 120, Premium: 1.00, Contracts: 1, Expiration: date(time.November, 20)},
+	}
+	treasuries := []*Treasury{
+		{CUSPID: "912797AA1", Maturity: date(time.November, 15), Amount: 10000},
+		{CUSPID: "912797BB2", Maturity: date(time.November, 1), Amount: 10000, ExitPrice: &exitPrice},
+	}
+
+	report := BuildCollateralReport(options, treasuries, 20000, Instruments{})
+
+	// The ledger balance already holds the 820 of premium netted from the obligations
+	if math.Abs(report.Cash-19180) > 0.001 || math.Abs(report.Premium-820) > 0.001 {
+		t.Errorf("Expected cash 19180 after 820 of premium, got cash %.2f and premium %.2f", report.Cash, report.Premium)
+	}
+	if report.Treasuries != 10000 {
+		t.Errorf("Expected only the unsold treasury to count, got %.2f", report.Treasuries)
+	}
+	if math.Abs(report.Obligation-40180) > 0.001 {
+		t.Errorf("Expected obligations of 40180, got %.2f", report.Obligation)
+	}
+	if len(report.Weeks) != 2 {
+		t.Fatalf("Expected 2 expiration weeks, got %d", len(report.Weeks))
+	}
+
+	first := report.Weeks[0]
+	if !first.WeekOf.Equal(date(time.November, 2)) || len(first.Puts) != 2 {
+		t.Errorf("Expected the AAPL put and KO spread in the week of Nov 2, got %d puts in the week of %s", len(first.Puts), first.WeekOf.Format("2006-01-02"))
+	}
+	if math.Abs(first.Obligation-10680) > 0.001 || first.Shortfall != 0 {
+		t.Errorf("Expected 10680 covered in the first week, got %.2f with shortfall %.2f", first.Obligation, first.Shortfall)
+	}
+
+	// By Nov 20 the treasury has matured but every put together needs more than there is
+	second := report.Weeks[1]
+	if second.Treasuries != 10000 || math.Abs(second.Collateral-29180) > 0.001 {
+		t.Errorf("Expected 29180 of collateral including the matured treasury, got %.2f", second.Collateral)
+	}
+	if math.Abs(second.Shortfall-11000) > 0.001 || math.Abs(report.Shortfall-11000) > 0.001 {
+		t.Errorf("Expected a shortfall of 11000, got %.2f (report %.2f)", second.Shortfall, report.Shortfall)
+	}
+	if report.IsCovered() || report.FirstShortfall() != second {
+		t.Errorf("Expected the report to flag the week of Nov 16")
+	}
+
+	if covered := BuildCollateralReport(options, treasuries, 60000, Instruments{}); !covered.IsCovered() {
+		t.Errorf("Expected enough cash to cover every put, got shortfall %.2f", covered.Shortfall)
+	}
+}
+
+func TestCollateralService_PutImpact(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	accountService := NewAccountService(testDB.DB)
+	cashService := NewCashService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	collateralService := NewCollateralService(testDB.DB)
+
+	brokerage, err := accountService.Create("Brokerage", nil, AccountTaxable)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	margin, err := accountService.Create("Margin", nil, AccountTaxable)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if _, err := cashService.Create(time.Now().AddDate(0, 0, -30), CashDeposit, 15000, nil, &brokerage.ID); err != nil {
+		t.Fatalf("Failed to deposit cash: %v", err)
+	}
+
+	opened := time.Now().AddDate(0, 0, -1)
+	expiration := time.Now().AddDate(0, 0, 14)
+	sellPut := func(accountID int, strike, premium float64) *Option {
+		put, err := optionService.Create("AAPL", "Put", opened, strike, expiration, premium, 1)
+		if err != nil {
+			t.Fatalf("Failed to create put: %v", err)
+		}
+		if err := accountService.AssignItems(&accountID, AccountItems{OptionIDs: []int{put.ID}}); err != nil {
+			t.Fatalf("Failed to assign put: %v", err)
+		}
+		return put
+	}
+
+	// The margin account is already short of collateral
+	sellPut(margin.ID, 50, 1.00)
+
+	// A covered put in the brokerage account is checked against that account only
+	covered := sellPut(brokerage.ID, 100, 1.00)
+	with, without, err := collateralService.PutImpact(covered.ID)
+	if err != nil {
+		t.Fatalf("Failed to check put impact: %v", err)
+	}
+	if with.Shortfall != 0 || without.Shortfall != 0 || len(without.Weeks) != 0 {
+		t.Errorf("Expected the brokerage put covered, got shortfall %.2f (%.2f without)", with.Shortfall, without.Shortfall)
+	}
+	if math.Abs(without.Cash-(15000-OptionCommissionPerContract)) > 0.001 {
+		t.Errorf("Expected the put's premium left out of cash without it, got %.2f", without.Cash)
+	}
+
+	// A second put needs 9700 more than the 5100 or so left
+	uncovered := sellPut(brokerage.ID, 99, 2.00)
+	with, without, err = collateralService.PutImpact(uncovered.ID)
+	if err != nil {
+		t.Fatalf("Failed to check put impact: %v", err)
+	}
+	cash := 15000 - 2*OptionCommissionPerContract
+	if math.Abs(with.Shortfall-(9900+9700-cash)) > 0.001 || without.Shortfall != 0 {
+		t.Errorf("Expected the second put to create a %.2f shortfall, got %.2f (%.2f without)", 9900+9700-cash, with.Shortfall, without.Shortfall)
+	}
+
+	if _, _, err := collateralService.PutImpact(9999); err == nil {
+		t.Error("Expected error for a missing option")
+	}
+}
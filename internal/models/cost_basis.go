@@ -0,0 +1,152 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// CostBasis is the premium-adjusted cost basis of a symbol's open stock
+type CostBasis struct {
+	Symbol                       string          `json:"symbol"`
+	Shares                       int             `json:"shares"`
+	HoldingStart                 *time.Time      `json:"holding_start"`
+	StockCost                    float64         `json:"stock_cost"`
+	CostPerShare                 float64         `json:"cost_per_share"`
+	PutPremium                   float64         `json:"put_premium"`
+	CallPremium                  float64         `json:"call_premium"`
+	Dividends                    float64         `json:"dividends"`
+	AdjustedCost                 float64         `json:"adjusted_cost"`
+	AdjustedCostPerShare         float64         `json:"adjusted_cost_per_share"`
+	DividendAdjustedCost         float64         `json:"dividend_adjusted_cost"`
+	DividendAdjustedCostPerShare float64         `json:"dividend_adjusted_cost_per_share"`
+	Lots                         []CostBasisLot  `json:"lots"`
+	Options                      []CostBasisItem `json:"options"`
+	DividendItems                []CostBasisItem `json:"dividend_items"`
+}
+
+// CostBasisLot shows one open lot with the premiums spread evenly per share
+type CostBasisLot struct {
+	ID                    int       `json:"id"`
+	Opened                time.Time `json:"opened"`
+	Shares                int       `json:"shares"`
+	BuyPrice              float64   `json:"buy_price"`
+	AdjustedPrice         float64   `json:"adjusted_price"`
+	DividendAdjustedPrice float64   `json:"dividend_adjusted_price"`
+}
+
+// CostBasisItem is an option or dividend that contributed to the adjustment
+type CostBasisItem struct {
+	ID     int       `json:"id"`
+	Type   string    `json:"type"`
+	Date   time.Time `json:"date"`
+	Amount float64   `json:"amount"`
+}
+
+// HasHoldings reports whether there is open stock to adjust
+func (c *CostBasis) HasHoldings() bool {
+	return c.Shares > 0
+}
+
+// CalculateCostBasis computes the premium-adjusted cost basis of the open lots.
+// The holding starts on the earliest open lot's opened date; net premium from
+// options still open or closed on or after that date (which includes the put
+// assigned into the shares and the calls written against them) and dividends
+// received on or after it reduce the stock cost. Long calls such as LEAPS are a
+// separate position rather than an adjustment to the shares, so they are left out.
+func CalculateCostBasis(symbol string, positions []*LongPosition, options []*Option, dividends []*Dividend) *CostBasis {
+	basis := &CostBasis{
+		Symbol:        symbol,
+		Lots:          []CostBasisLot{},
+		Options:       []CostBasisItem{},
+		DividendItems: []CostBasisItem{},
+	}
+
+	var openLots []*LongPosition
+	for _, position := range positions {
+		if position.Closed != nil || position.Symbol != symbol {
+			continue
+		}
+		openLots = append(openLots, position)
+		basis.Shares += position.Shares
+		basis.StockCost += position.CalculateTotalInvested()
+		if basis.HoldingStart == nil || position.Opened.Before(*basis.HoldingStart) {
+			opened := position.Opened
+			basis.HoldingStart = &opened
+		}
+	}
+
+	if basis.Shares == 0 {
+		return basis
+	}
+
+	start := *basis.HoldingStart
+	for _, option := range options {
+		if option.Symbol != symbol {
+			continue
+		}
+		if option.Closed != nil && option.Closed.Before(start) {
+			continue
+		}
+		if option.Type == "Call" && option.IsLong() {
+			continue
+		}
+		premium := option.CalculateTotalProfit()
+		if option.Type == "Put" {
+			basis.PutPremium += premium
+		} else {
+			basis.CallPremium += premium
+		}
+		basis.Options = append(basis.Options, CostBasisItem{
+			ID:     option.ID,
+			Type:   option.Type,
+			Date:   option.Opened,
+			Amount: premium,
+		})
+	}
+
+	for _, dividend := range dividends {
+		if dividend.Symbol != symbol || dividend.Received.Before(start) {
+			continue
+		}
+		basis.Dividends += dividend.Amount
+		basis.DividendItems = append(basis.DividendItems, CostBasisItem{
+			ID:     dividend.ID,
+			Type:   "Dividend",
+			Date:   dividend.Received,
+			Amount: dividend.Amount,
+		})
+	}
+
+	sort.Slice(basis.Options, func(i, j int) bool {
+		return basis.Options[i].Date.Before(basis.Options[j].Date)
+	})
+	sort.Slice(basis.DividendItems, func(i, j int) bool {
+		return basis.DividendItems[i].Date.Before(basis.DividendItems[j].Date)
+	})
+
+	shares := float64(basis.Shares)
+	premiumPerShare := (basis.PutPremium + basis.CallPremium) / shares
+	dividendPerShare := basis.Dividends / shares
+
+	basis.CostPerShare = basis.StockCost / shares
+	basis.AdjustedCost = basis.StockCost - basis.PutPremium - basis.CallPremium
+	basis.AdjustedCostPerShare = basis.AdjustedCost / shares
+	basis.DividendAdjustedCost = basis.AdjustedCost - basis.Dividends
+	basis.DividendAdjustedCostPerShare = basis.DividendAdjustedCost / shares
+
+	sort.Slice(openLots, func(i, j int) bool {
+		return openLots[i].Opened.Before(openLots[j].Opened)
+	})
+	for _, lot := range openLots {
+		basis.Lots = append(basis.Lots, CostBasisLot{
+			ID:                    lot.ID,
+			Opened:                lot.Opened,
+			Shares:                lot.Shares,
+			BuyPrice:              lot.BuyPrice,
+			AdjustedPrice:         lot.BuyPrice - premiumPerShare,
+			DividendAdjustedPrice: lot.BuyPrice - premiumPerShare - dividendPerShare,
+		})
+	}
+
+	return basis
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateCostBasis(t *testing.T) {
+	day := func(month, d int) time.Time {
+		return time.Date(2024, time.Month(month), d, 0, 0, 0, 0, time.UTC)
+	}
+	closedOn := func(month, d int) *time.Time {
+		closed := day(month, d)
+		return &closed
+	}
+	zero := 0.0
+	soldAt := 130.0
+
+	positions := []*LongPosition{
+		// Sold before the current holding started and must not count
+		{ID: 1, Symbol: "AAPL", Opened: day(1, 2), Closed: closedOn(1, 31), Shares: 100, BuyPrice: 120, ExitPrice: &soldAt},
+		{ID: 2, Symbol: "AAPL", Opened: day(3, 15), Shares: 100, BuyPrice: 150},
+		{ID: 3, Symbol: "AAPL", Opened: day(4, 19), Shares: 100, BuyPrice: 140},
+	}
+	options := []*Option{
+		// Expired before the holding started
+		{ID: 1, Symbol: "AAPL", Type: "Put", Opened: day(1, 2), Closed: closedOn(1, 19), Strike: 115, Premium: 1.00, Contracts: 1, ExitPrice: &zero},
+		// Assigned into lot 2 and lot 3
+		{ID: 2, Symbol: "AAPL", Type: "Put", Opened: day(3, 1), Closed: closedOn(3, 15), Strike: 150, Premium: 3.00, Contracts: 1, ExitPrice: &zero},
+		{ID: 3, Symbol: "AAPL", Type: "Put", Opened: day(4, 1), Closed: closedOn(4, 19), Strike: 140, Premium: 2.00, Contracts: 1, ExitPrice: &zero},
+		// Covered call still open
+		{ID: 4, Symbol: "AAPL", Type: "Call", Opened: day(4, 22), Strike: 150, Premium: 1.50, Contracts: 2, Commission: 2},
+	}
+	dividends := []*Dividend{
+		{ID: 1, Symbol: "AAPL", Received: day(2, 15), Amount: 24},
+		{ID: 2, Symbol: "AAPL", Received: day(5, 16), Amount: 50},
+	}
+
+	basis := CalculateCostBasis("AAPL", positions, options, dividends)
+
+	if !basis.HasHoldings() || basis.Shares != 200 {
+		t.Fatalf("Expected 200 open shares, got %d", basis.Shares)
+	}
+	if basis.HoldingStart == nil || !basis.HoldingStart.Equal(day(3, 15)) {
+		t.Errorf("Expected holding start 03/15, got %v", basis.HoldingStart)
+	}
+
+	checks := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"stock cost", basis.StockCost, 29000},
+		{"cost per share", basis.CostPerShare, 145},
+		{"put premium", basis.PutPremium, 500},
+		{"call premium", basis.CallPremium, 298},
+		{"dividends", basis.Dividends, 50},
+		{"adjusted cost", basis.AdjustedCost, 28202},
+		{"adjusted cost per share", basis.AdjustedCostPerShare, 141.01},
+		{"dividend adjusted cost per share", basis.DividendAdjustedCostPerShare, 140.76},
+		{"lot 2 adjusted price", basis.Lots[0].AdjustedPrice, 146.01},
+		{"lot 3 dividend adjusted price", basis.Lots[1].DividendAdjustedPrice, 135.76},
+	}
+	for _, check := range checks {
+		if math.Abs(check.got-check.expected) > 0.001 {
+			t.Errorf("Expected %s %.2f, got %.2f", check.name, check.expected, check.got)
+		}
+	}
+
+	if len(basis.Options) != 3 || len(basis.DividendItems) != 1 {
+		t.Errorf("Expected 3 options and 1 dividend in breakdown, got %d and %d", len(basis.Options), len(basis.DividendItems))
+	}
+
+	t.Run("no open shares", func(t *testing.T) {
+		basis := CalculateCostBasis("AAPL", positions[:1], options, dividends)
+		if basis.HasHoldings() || basis.AdjustedCostPerShare != 0 || len(basis.Options) != 0 {
+			t.Errorf("Expected empty cost basis without open shares, got %+v", basis)
+		}
+	})
+}
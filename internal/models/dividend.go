@@ -16,16 +16,22 @@ func NewDividendService(db *sql.DB) *DividendService {
 }
 
 func (s *DividendService) Create(symbol string, received time.Time, amount float64) (*Dividend, error) {
+	return s.CreateInAccount(nil, symbol, received, amount)
+}
+
+// CreateInAccount records a dividend directly in an account. A nil account
+// leaves it unassigned.
+func (s *DividendService) CreateInAccount(accountID *int, symbol string, received time.Time, amount float64) (*Dividend, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("dividend amount must be positive")
 	}
 
-	query := `INSERT INTO dividends (symbol, received, amount) 
-			  VALUES (?, ?, ?) 
+	query := `INSERT INTO dividends (symbol, received, amount, account_id) 
+			  VALUES (?, ?, ?, ?) 
 			  RETURNING id, symbol, received, amount, created_at`
 
 	var dividend Dividend
-	err := s.db.QueryRow(query, symbol, received, amount).Scan(
+	err := s.db.QueryRow(query, symbol, received, amount, accountID).Scan(
 		&dividend.ID, &dividend.Symbol, &dividend.Received, &dividend.Amount, &dividend.CreatedAt,
 	)
 	if err != nil {
@@ -61,6 +67,34 @@ func (s *DividendService) GetBySymbol(symbol string) ([]*Dividend, error) {
 	return dividends, nil
 }
 
+// GetBySymbolInAccount returns the dividends of a symbol recorded in an
+// account. A nil account returns the unassigned dividends.
+func (s *DividendService) GetBySymbolInAccount(symbol string, accountID *int) ([]*Dividend, error) {
+	query := `SELECT id, symbol, received, amount, created_at 
+			  FROM dividends WHERE symbol = ? AND account_id IS ? ORDER BY received DESC`
+
+	rows, err := s.db.Query(query, symbol, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dividends: %w", err)
+	}
+	defer rows.Close()
+
+	var dividends []*Dividend
+	for rows.Next() {
+		var dividend Dividend
+		if err := rows.Scan(&dividend.ID, &dividend.Symbol, &dividend.Received, &dividend.Amount, &dividend.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dividend: %w", err)
+		}
+		dividends = append(dividends, &dividend)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dividends: %w", err)
+	}
+
+	return dividends, nil
+}
+
 func (s *DividendService) GetAll() ([]*Dividend, error) {
 	query := `SELECT id, symbol, received, amount, created_at 
 			  FROM dividends ORDER BY received DESC`
@@ -0,0 +1,179 @@
+package models
+
+import "time"
+
+// Form 8949 boxes: short-term (A, B) and long-term (D, E) sales whose basis was
+// or was not reported to the IRS on Form 1099-B
+const (
+	Form8949BoxA = "A"
+	Form8949BoxB = "B"
+	Form8949BoxD = "D"
+	Form8949BoxE = "E"
+)
+
+// Form 8949 adjustment code for a nondeductible wash sale loss
+const Form8949CodeWashSale = "W"
+
+// Brokers report basis to the IRS for stock acquired from 2011 and for options
+// acquired from 2014 (covered securities)
+var (
+	coveredStockSince  = time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC)
+	coveredOptionSince = time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+var form8949BoxTitles = map[string]string{
+	Form8949BoxA: "Box A: Short-term, basis reported to the IRS",
+	Form8949BoxB: "Box B: Short-term, basis not reported to the IRS",
+	Form8949BoxD: "Box D: Long-term, basis reported to the IRS",
+	Form8949BoxE: "Box E: Long-term, basis not reported to the IRS",
+}
+
+// Form8949Line is one row of Form 8949: columns (a) through (h)
+type Form8949Line struct {
+	Kind        string    `json:"kind"`
+	Symbol      string    `json:"symbol"`
+	Description string    `json:"description"`
+	Acquired    time.Time `json:"acquired"`
+	Sold        time.Time `json:"sold"`
+	Proceeds    float64   `json:"proceeds"`
+	CostBasis   float64   `json:"cost_basis"`
+	Code        string    `json:"code"`
+	Adjustment  float64   `json:"adjustment"`
+	Gain        float64   `json:"gain"`
+}
+
+// Form8949Box is the lines and totals of one Form 8949 box
+type Form8949Box struct {
+	Box        string          `json:"box"`
+	Title      string          `json:"title"`
+	Lines      []*Form8949Line `json:"lines"`
+	Proceeds   float64         `json:"proceeds"`
+	CostBasis  float64         `json:"cost_basis"`
+	Adjustment float64         `json:"adjustment"`
+	Gain       float64         `json:"gain"`
+}
+
+func (b *Form8949Box) add(line *Form8949Line) {
+	b.Lines = append(b.Lines, line)
+	b.Proceeds += line.Proceeds
+	b.CostBasis += line.CostBasis
+	b.Adjustment += line.Adjustment
+	b.Gain += line.Gain
+}
+
+// ScheduleDLine is a Schedule D line carried from Form 8949 or Form 6781
+type ScheduleDLine struct {
+	Line        string  `json:"line"`
+	Description string  `json:"description"`
+	Proceeds    float64 `json:"proceeds"`
+	CostBasis   float64 `json:"cost_basis"`
+	Adjustment  float64 `json:"adjustment"`
+	Gain        float64 `json:"gain"`
+}
+
+// Form8949 is a tax year's Form 8949 boxes and the Schedule D lines they feed.
+// Section 1256 contracts go on Form 6781 and only reach Schedule D as their
+// 40% short-term (line 4) and 60% long-term (line 11) shares.
+type Form8949 struct {
+	Year        int              `json:"year"`
+	Boxes       []*Form8949Box   `json:"boxes"`
+	Section1256 float64          `json:"section_1256"`
+	ScheduleD   []*ScheduleDLine `json:"schedule_d"`
+	ShortTerm   float64          `json:"short_term"`
+	LongTerm    float64          `json:"long_term"`
+	Total       float64          `json:"total"`
+
+	// MissingMarks counts the Section 1256 gains left out for a missing year-end mark
+	MissingMarks int `json:"missing_marks"`
+}
+
+// Lines returns every Form 8949 line, box by box
+func (f *Form8949) Lines() []*Form8949Line {
+	var lines []*Form8949Line
+	for _, box := range f.Boxes {
+		lines = append(lines, box.Lines...)
+	}
+	return lines
+}
+
+// IsCoveredSecurity reports whether a broker reports the basis of a stock lot
+// or option acquired on the given date to the IRS
+func IsCoveredSecurity(kind string, acquired time.Time) bool {
+	if kind == RealizedKindOption {
+		return !acquired.Before(coveredOptionSince)
+	}
+	return !acquired.Before(coveredStockSince)
+}
+
+// Form8949BoxFor returns the box a realized gain is reported in
+func Form8949BoxFor(gain *RealizedGain) string {
+	covered := IsCoveredSecurity(gain.Kind, gain.Acquired)
+	switch {
+	case gain.LongTerm && covered:
+		return Form8949BoxD
+	case gain.LongTerm:
+		return Form8949BoxE
+	case covered:
+		return Form8949BoxA
+	default:
+		return Form8949BoxB
+	}
+}
+
+// BuildForm8949 lays out a realized gains report as Form 8949 and Schedule D.
+// Proceeds and basis follow the broker's 1099-B: assigned put premium is in
+// the basis and called away call premium in the proceeds, while a wash sale
+// loss is added back in column (g) with code W.
+func BuildForm8949(report *RealizedGainsReport) *Form8949 {
+	boxes := make(map[string]*Form8949Box)
+	order := []string{Form8949BoxA, Form8949BoxB, Form8949BoxD, Form8949BoxE}
+	for _, box := range order {
+		boxes[box] = &Form8949Box{Box: box, Title: form8949BoxTitles[box], Lines: []*Form8949Line{}}
+	}
+
+	for _, gain := range append(append([]*RealizedGain{}, report.ShortTerm.Gains...), report.LongTerm.Gains...) {
+		line := &Form8949Line{
+			Kind:        gain.Kind,
+			Symbol:      gain.Symbol,
+			Description: gain.Description,
+			Acquired:    gain.Acquired,
+			Sold:        gain.Sold,
+			Proceeds:    gain.Proceeds,
+			CostBasis:   gain.CostBasis,
+			Adjustment:  gain.WashSaleDisallowed,
+			Gain:        gain.Gain,
+		}
+		if gain.WashSaleDisallowed > 0 {
+			line.Code = Form8949CodeWashSale
+		}
+		boxes[Form8949BoxFor(gain)].add(line)
+	}
+
+	form := &Form8949{Year: report.Year, Section1256: report.Section1256.Gain, MissingMarks: len(report.MissingMarks)}
+	for _, box := range order {
+		form.Boxes = append(form.Boxes, boxes[box])
+	}
+
+	fromBox := func(line, description string, box *Form8949Box) *ScheduleDLine {
+		return &ScheduleDLine{Line: line, Description: description, Proceeds: box.Proceeds, CostBasis: box.CostBasis, Adjustment: box.Adjustment, Gain: box.Gain}
+	}
+	shortTerm1256 := report.Section1256.Gain * (1 - Section1256LongTermShare)
+	longTerm1256 := report.Section1256.Gain * Section1256LongTermShare
+
+	form.ShortTerm = boxes[Form8949BoxA].Gain + boxes[Form8949BoxB].Gain + shortTerm1256
+	form.LongTerm = boxes[Form8949BoxD].Gain + boxes[Form8949BoxE].Gain + longTerm1256
+	form.Total = form.ShortTerm + form.LongTerm
+	form.ScheduleD = []*ScheduleDLine{
+		fromBox("1b", "Short-term from Form 8949 Box A", boxes[Form8949BoxA]),
+		fromBox("2", "Short-term from Form 8949 Box B", boxes[Form8949BoxB]),
+		{Line: "4", Description: "Short-term from Form 6781 (40% of Section 1256)", Gain: shortTerm1256},
+		{Line: "7", Description: "Net short-term capital gain or (loss)", Gain: form.ShortTerm},
+		fromBox("8b", "Long-term from Form 8949 Box D", boxes[Form8949BoxD]),
+		fromBox("9", "Long-term from Form 8949 Box E", boxes[Form8949BoxE]),
+		{Line: "11", Description: "Long-term from Form 6781 (60% of Section 1256)", Gain: longTerm1256},
+		{Line: "15", Description: "Net long-term capital gain or (loss)", Gain: form.LongTerm},
+		{Line: "16", Description: "Combined net gain or (loss)", Gain: form.Total},
+	}
+
+	return form
+}
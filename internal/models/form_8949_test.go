@@ -0,0 +1,70 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBuildForm8949(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closedOn := func(year int, month time.Month, day int) *time.Time {
+		closed := date(year, month, day)
+		return &closed
+	}
+	price := func(value float64) *float64 { return &value }
+
+	positions := []*LongPosition{
+		// Bought before 2011: long-term, basis not reported (box E)
+		{ID: 1, Symbol: "KO", Opened: date(2009, 5, 1), Closed: closedOn(2024, 2, 1), Shares: 100, BuyPrice: 25, ExitPrice: price(60)},
+		// Long-term, basis reported (box D)
+		{ID: 2, Symbol: "KO", Opened: date(2020, 5, 1), Closed: closedOn(2024, 2, 1), Shares: 100, BuyPrice: 50, ExitPrice: price(60)},
+		// Short-term loss of 1000, 400 of it washed by lot 4 (box A, code W)
+		{ID: 3, Symbol: "AAPL", Opened: date(2024, 1, 2), Closed: closedOn(2024, 3, 1), Shares: 100, BuyPrice: 50, ExitPrice: price(40)},
+		{ID: 4, Symbol: "AAPL", Opened: date(2024, 3, 15), Shares: 40, BuyPrice: 41},
+	}
+	options := []*Option{
+		// Section 1256: on Form 6781, not Form 8949
+		{ID: 10, Symbol: "XSP", Type: "Put", Direction: DirectionShort, Opened: date(2024, 3, 1), Closed: closedOn(2024, 4, 1),
+			Strike: 500, Expiration: date(2024, 4, 19), Premium: 3.00, Contracts: 1, ExitPrice: price(1.00)},
+	}
+	instruments := NewInstruments([]*Symbol{{Symbol: "XSP", InstrumentClass: InstrumentIndex}})
+
+	form := BuildForm8949(BuildRealizedGainsReport(2024, positions, options, instruments, nil, nil, nil))
+
+	boxes := make(map[string]*Form8949Box)
+	for _, box := range form.Boxes {
+		boxes[box.Box] = box
+	}
+	if len(boxes[Form8949BoxA].Lines) != 1 || len(boxes[Form8949BoxB].Lines) != 0 ||
+		len(boxes[Form8949BoxD].Lines) != 1 || len(boxes[Form8949BoxE].Lines) != 1 {
+		t.Fatalf("Expected one line each in boxes A, D and E, got A=%d B=%d D=%d E=%d",
+			len(boxes[Form8949BoxA].Lines), len(boxes[Form8949BoxB].Lines), len(boxes[Form8949BoxD].Lines), len(boxes[Form8949BoxE].Lines))
+	}
+
+	wash := boxes[Form8949BoxA].Lines[0]
+	if wash.Code != Form8949CodeWashSale || math.Abs(wash.Adjustment-400) > 0.001 || math.Abs(wash.Gain-(-600)) > 0.001 {
+		t.Errorf("Expected code W with a 400 adjustment and a 600 loss, got %q %.2f %.2f", wash.Code, wash.Adjustment, wash.Gain)
+	}
+	if math.Abs(wash.Proceeds-wash.CostBasis+wash.Adjustment-wash.Gain) > 0.001 {
+		t.Errorf("Expected column (h) = (d) - (e) + (g), got %.2f - %.2f + %.2f != %.2f", wash.Proceeds, wash.CostBasis, wash.Adjustment, wash.Gain)
+	}
+
+	if math.Abs(form.Section1256-200) > 0.001 {
+		t.Errorf("Expected 200 of Section 1256 gain, got %.2f", form.Section1256)
+	}
+	// Box A -600 plus 40% of 200; boxes D and E 1000 + 3500 plus 60% of 200
+	if math.Abs(form.ShortTerm-(-520)) > 0.001 || math.Abs(form.LongTerm-4620) > 0.001 {
+		t.Errorf("Expected short-term -520 and long-term 4620, got %.2f and %.2f", form.ShortTerm, form.LongTerm)
+	}
+
+	lines := make(map[string]*ScheduleDLine)
+	for _, line := range form.ScheduleD {
+		lines[line.Line] = line
+	}
+	if math.Abs(lines["16"].Gain-4100) > 0.001 || math.Abs(lines["1b"].Adjustment-400) > 0.001 {
+		t.Errorf("Expected line 16 of 4100 and a 400 adjustment on line 1b, got %.2f and %.2f", lines["16"].Gain, lines["1b"].Adjustment)
+	}
+}
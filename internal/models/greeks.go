@@ -0,0 +1,160 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"stonks/internal/pricing"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings holding the pricing inputs, in percent
+const (
+	RiskFreeRateSetting      = "RISK_FREE_RATE"
+	DefaultVolatilitySetting = "DEFAULT_VOLATILITY"
+)
+
+// Pricing inputs used when the settings are missing, in percent
+const (
+	DefaultRiskFreeRate = 4.5
+	DefaultVolatility   = 30.0
+)
+
+// OptionGreeks is the theoretical value and Greeks of an option per share of
+// the underlying, and for the whole position: scaled by contracts and the
+// contract multiplier, and negated for a short position
+type OptionGreeks struct {
+	OptionID int               `json:"option_id"`
+	Symbol   string            `json:"symbol"`
+	Model    string            `json:"model"`
+	Inputs   pricing.Inputs    `json:"inputs"`
+	PerShare pricing.Valuation `json:"per_share"`
+	Position pricing.Valuation `json:"position"`
+}
+
+// PricingModel returns the model for options on the symbol. Index options are
+// European; equity and ETF options can be exercised early.
+func PricingModel(underlying *Symbol) string {
+	if underlying.IsCashSettled() {
+		return pricing.ModelBlackScholes
+	}
+	return pricing.ModelBinomial
+}
+
+// YearsToExpiration returns the time from now to the end of the expiration day in years
+func YearsToExpiration(expiration, now time.Time) float64 {
+	end := time.Date(expiration.Year(), expiration.Month(), expiration.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	return math.Max(0, end.Sub(now).Hours()/24/365)
+}
+
+// PriceOption values an option from its underlying's price, dividend and
+// volatility. rate and volatility are the risk-free rate and the fallback
+// volatility in percent. It fails if the underlying has no price.
+func PriceOption(option *Option, underlying *Symbol, rate, volatility float64, now time.Time) (*OptionGreeks, error) {
+	if underlying.Price <= 0 {
+		return nil, fmt.Errorf("%s has no price to value options against", underlying.Symbol)
+	}
+	if underlying.Volatility != nil {
+		volatility = *underlying.Volatility
+	}
+
+	greeks := &OptionGreeks{
+		OptionID: option.ID,
+		Symbol:   option.Symbol,
+		Model:    PricingModel(underlying),
+		Inputs: pricing.Inputs{
+			Spot:          underlying.Price,
+			Strike:        option.Strike,
+			Years:         YearsToExpiration(option.Expiration, now),
+			Rate:          rate / 100,
+			DividendYield: underlying.CalculateYield() / 100,
+			Volatility:    volatility / 100,
+			Call:          option.Type == "Call",
+		},
+	}
+
+	valuation, err := pricing.Price(greeks.Model, greeks.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	greeks.PerShare = valuation
+
+	quantity := float64(underlying.Multiplier() * option.Contracts)
+	if !option.IsLong() {
+		quantity = -quantity
+	}
+	greeks.Position = valuation.Scale(quantity)
+
+	return greeks, nil
+}
+
+type PricingService struct {
+	db *sql.DB
+}
+
+func NewPricingService(db *sql.DB) *PricingService {
+	return &PricingService{db: db}
+}
+
+// settingPercent reads a setting in percent, or returns fallback if it is missing or invalid
+func settingPercent(settings *SettingService, name string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(settings.GetValue(name)), "%"), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// Rates returns the risk-free rate and default volatility settings in percent
+func (s *PricingService) Rates() (float64, float64) {
+	settings := NewSettingService(s.db)
+	return settingPercent(settings, RiskFreeRateSetting, DefaultRiskFreeRate),
+		settingPercent(settings, DefaultVolatilitySetting, DefaultVolatility)
+}
+
+// Greeks values each open option whose underlying has a price, keyed by option ID
+func (s *PricingService) Greeks(options []*Option, now time.Time) (map[int]*OptionGreeks, error) {
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	rate, volatility := s.Rates()
+
+	greeks := make(map[int]*OptionGreeks)
+	for _, option := range options {
+		underlying, ok := instruments[option.Symbol]
+		if !ok || option.Closed != nil || underlying.Price <= 0 {
+			continue
+		}
+		valued, err := PriceOption(option, underlying, rate, volatility, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price option %d: %w", option.ID, err)
+		}
+		greeks[option.ID] = valued
+	}
+
+	return greeks, nil
+}
+
+// SetVolatility sets the annualized volatility of a symbol in percent, or
+// clears it so the DEFAULT_VOLATILITY setting applies if volatility is nil
+func (s *SymbolService) SetVolatility(symbol string, volatility *float64) (*Symbol, error) {
+	symbol = strings.TrimSpace(strings.ToUpper(symbol))
+	if volatility != nil && *volatility <= 0 {
+		return nil, fmt.Errorf("volatility must be positive")
+	}
+
+	query := `UPDATE symbols SET volatility = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ? RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at`
+	var sym Symbol
+	err := s.db.QueryRow(query, volatility, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.InstrumentClass, &sym.ContractMultiplier, &sym.Volatility, &sym.Beta, &sym.CreatedAt, &sym.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("symbol not found")
+		}
+		return nil, fmt.Errorf("failed to set volatility: %w", err)
+	}
+
+	return &sym, nil
+}
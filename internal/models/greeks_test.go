@@ -0,0 +1,84 @@
+package models
+
+import (
+	"math"
+	"stonks/internal/pricing"
+	"testing"
+	"time"
+)
+
+func TestPricingService_GreeksFromSymbolsAndSettings(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	symbolService := NewSymbolService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	pricingService := NewPricingService(testDB.DB)
+	now := time.Now()
+
+	put, err := optionService.Create("AAPL", "Put", now, 145, now.AddDate(0, 0, 30), 2.50, 2)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+
+	// No price yet, so nothing can be valued
+	greeks, err := pricingService.Greeks([]*Option{put}, now)
+	if err != nil {
+		t.Fatalf("Failed to price options: %v", err)
+	}
+	if len(greeks) != 0 {
+		t.Errorf("Expected an option on an unpriced symbol to be skipped, got %d", len(greeks))
+	}
+
+	if _, err := symbolService.Update("AAPL", 150, 0.25, nil, nil); err != nil {
+		t.Fatalf("Failed to price symbol: %v", err)
+	}
+	if err := NewSettingService(testDB.DB).SetValue(RiskFreeRateSetting, "5", ""); err != nil {
+		t.Fatalf("Failed to set rate: %v", err)
+	}
+
+	greeks, err = pricingService.Greeks([]*Option{put}, now)
+	if err != nil {
+		t.Fatalf("Failed to price options: %v", err)
+	}
+	valued := greeks[put.ID]
+	if valued == nil {
+		t.Fatalf("Expected the put to be valued")
+	}
+	if valued.Model != pricing.ModelBinomial || valued.Inputs.Rate != 0.05 || valued.Inputs.Volatility != DefaultVolatility/100 {
+		t.Errorf("Expected a binomial model at 5%% and the default volatility, got %s at %.4f and %.4f", valued.Model, valued.Inputs.Rate, valued.Inputs.Volatility)
+	}
+	if math.Abs(valued.Inputs.DividendYield-0.25*4/150) > 1e-9 {
+		t.Errorf("Expected the dividend yield from the symbol, got %.5f", valued.Inputs.DividendYield)
+	}
+
+	// Two short contracts: 200 shares against the holder's Greeks
+	if math.Abs(valued.Position.Delta+200*valued.PerShare.Delta) > 1e-9 || valued.Position.Delta <= 0 {
+		t.Errorf("Expected a positive position delta of -200 x %.4f, got %.4f", valued.PerShare.Delta, valued.Position.Delta)
+	}
+	if valued.Position.Theta <= 0 || valued.Position.Vega >= 0 {
+		t.Errorf("Expected a short put to earn theta and lose on vega, got %+v", valued.Position)
+	}
+
+	// A symbol's own volatility overrides the default and raises the put's value
+	volatility := 45.0
+	if _, err := symbolService.SetVolatility("AAPL", &volatility); err != nil {
+		t.Fatalf("Failed to set volatility: %v", err)
+	}
+	greeks, err = pricingService.Greeks([]*Option{put}, now)
+	if err != nil {
+		t.Fatalf("Failed to price options: %v", err)
+	}
+	if greeks[put.ID].Inputs.Volatility != 0.45 || greeks[put.ID].PerShare.Value <= valued.PerShare.Value {
+		t.Errorf("Expected the symbol's 45%% volatility to raise the value above %.4f, got %+v", valued.PerShare.Value, greeks[put.ID])
+	}
+
+	// Index options are European
+	spx := &Symbol{Symbol: "SPX", Price: 5000, InstrumentClass: InstrumentIndex, ContractMultiplier: 100}
+	call := &Option{Symbol: "SPX", Type: "Call", Direction: DirectionLong, Strike: 5100, Contracts: 1, Expiration: now.AddDate(0, 0, 30)}
+	indexGreeks, err := PriceOption(call, spx, 4.5, 15, now)
+	if err != nil {
+		t.Fatalf("Failed to price index option: %v", err)
+	}
+	if indexGreeks.Model != pricing.ModelBlackScholes || indexGreeks.Position.Delta <= 0 {
+		t.Errorf("Expected a Black-Scholes long call with positive delta, got %s %+v", indexGreeks.Model, indexGreeks.Position)
+	}
+}
@@ -0,0 +1,275 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"stonks/internal/pricing"
+	"time"
+)
+
+// ImpliedVolatility is the volatility backed out of an option's mark on one
+// day. Rate and the volatilities are in percent; ReferenceVolatility is what
+// the option is priced with otherwise, the symbol's volatility or the
+// DEFAULT_VOLATILITY setting.
+type ImpliedVolatility struct {
+	ID                  int       `json:"id"`
+	OptionID            int       `json:"option_id"`
+	Date                time.Time `json:"date"`
+	Mark                float64   `json:"mark"`
+	UnderlyingPrice     float64   `json:"underlying_price"`
+	Rate                float64   `json:"rate"`
+	Volatility          float64   `json:"implied_volatility"`
+	ReferenceVolatility float64   `json:"reference_volatility"`
+	Model               string    `json:"model"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Spread returns implied minus reference volatility in points
+func (iv *ImpliedVolatility) Spread() float64 {
+	return iv.Volatility - iv.ReferenceVolatility
+}
+
+// IsRich reports whether the market priced the option above our volatility,
+// so premium sold at the mark was rich rather than cheap
+func (iv *ImpliedVolatility) IsRich() bool {
+	return iv.Spread() > 0
+}
+
+// TreasuryRate returns the yield, in percent, of the open treasury maturing
+// closest to expiration, or false if no treasury is open on now
+func TreasuryRate(treasuries []*Treasury, expiration, now time.Time) (float64, bool) {
+	var closest *Treasury
+	for _, treasury := range treasuries {
+		if treasury.ExitPrice != nil || !treasury.Maturity.After(now) || treasury.Yield <= 0 {
+			continue
+		}
+		if closest == nil {
+			closest = treasury
+			continue
+		}
+		distance := math.Abs(treasury.Maturity.Sub(expiration).Hours())
+		closestDistance := math.Abs(closest.Maturity.Sub(expiration).Hours())
+		if distance < closestDistance || (distance == closestDistance && treasury.Purchased.After(closest.Purchased)) {
+			closest = treasury
+		}
+	}
+	if closest == nil {
+		return 0, false
+	}
+	return closest.Yield, true
+}
+
+// SolveImpliedVolatility backs the implied volatility of an option out of
+// mark, the price of one unit of the underlying's option. rate and
+// volatility are the risk-free rate and the fallback reference volatility in
+// percent.
+func SolveImpliedVolatility(option *Option, underlying *Symbol, mark, rate, volatility float64, now time.Time) (*ImpliedVolatility, error) {
+	if mark <= 0 {
+		return nil, fmt.Errorf("option %d has no mark", option.ID)
+	}
+	if underlying.Price <= 0 {
+		return nil, fmt.Errorf("%s has no price to value options against", underlying.Symbol)
+	}
+	if underlying.Volatility != nil {
+		volatility = *underlying.Volatility
+	}
+
+	model := PricingModel(underlying)
+	in := pricing.Inputs{
+		Spot:          underlying.Price,
+		Strike:        option.Strike,
+		Years:         YearsToExpiration(option.Expiration, now),
+		Rate:          rate / 100,
+		DividendYield: underlying.CalculateYield() / 100,
+		Call:          option.Type == "Call",
+	}
+	implied, err := pricing.ImpliedVolatility(model, in, mark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve implied volatility of option %d: %w", option.ID, err)
+	}
+
+	return &ImpliedVolatility{
+		OptionID:            option.ID,
+		Date:                time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Mark:                mark,
+		UnderlyingPrice:     underlying.Price,
+		Rate:                rate,
+		Volatility:          implied * 100,
+		ReferenceVolatility: volatility,
+		Model:               model,
+	}, nil
+}
+
+type ImpliedVolatilityService struct {
+	db *sql.DB
+}
+
+func NewImpliedVolatilityService(db *sql.DB) *ImpliedVolatilityService {
+	return &ImpliedVolatilityService{db: db}
+}
+
+// Rate returns the risk-free rate in percent for an option expiring on
+// expiration: the yield of our treasury maturing closest to it, or the
+// RISK_FREE_RATE setting if no treasury is open
+func (s *ImpliedVolatilityService) Rate(expiration, now time.Time) (float64, error) {
+	treasuries, err := NewTreasuryService(s.db).GetAll()
+	if err != nil {
+		return 0, err
+	}
+	if rate, ok := TreasuryRate(treasuries, expiration, now); ok {
+		return rate, nil
+	}
+	rate, _ := NewPricingService(s.db).Rates()
+	return rate, nil
+}
+
+// Record solves the implied volatility of an open option from its
+// current_price and saves it as today's value, replacing any earlier one
+func (s *ImpliedVolatilityService) Record(option *Option, now time.Time) (*ImpliedVolatility, error) {
+	if option.Closed != nil {
+		return nil, fmt.Errorf("option %d is closed", option.ID)
+	}
+	if option.CurrentPrice == nil {
+		return nil, fmt.Errorf("option %d has no mark", option.ID)
+	}
+
+	underlying, err := NewSymbolService(s.db).GetBySymbol(option.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := s.Rate(option.Expiration, now)
+	if err != nil {
+		return nil, err
+	}
+	_, volatility := NewPricingService(s.db).Rates()
+
+	iv, err := SolveImpliedVolatility(option, underlying, *option.CurrentPrice, rate, volatility, now)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO option_implied_volatility (option_id, date, mark, underlying_price, rate, implied_volatility, reference_volatility, model)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(option_id, date) DO UPDATE SET mark = excluded.mark, underlying_price = excluded.underlying_price, rate = excluded.rate,
+			  implied_volatility = excluded.implied_volatility, reference_volatility = excluded.reference_volatility, model = excluded.model, updated_at = CURRENT_TIMESTAMP
+			  RETURNING id, created_at, updated_at`
+	err = s.db.QueryRow(query, iv.OptionID, iv.Date, iv.Mark, iv.UnderlyingPrice, iv.Rate, iv.Volatility, iv.ReferenceVolatility, iv.Model).Scan(&iv.ID, &iv.CreatedAt, &iv.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save implied volatility: %w", err)
+	}
+
+	return iv, nil
+}
+
+// RecordOpen records today's implied volatility of every open option with a
+// mark. Options that can't be solved, such as a mark below intrinsic value,
+// are logged and skipped.
+func (s *ImpliedVolatilityService) RecordOpen(now time.Time) ([]*ImpliedVolatility, error) {
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := []*ImpliedVolatility{}
+	for _, option := range options {
+		if option.CurrentPrice == nil {
+			continue
+		}
+		iv, err := s.Record(option, now)
+		if err != nil {
+			log.Printf("[IMPLIED VOLATILITY] Skipping option %d: %v", option.ID, err)
+			continue
+		}
+		recorded = append(recorded, iv)
+	}
+
+	return recorded, nil
+}
+
+const impliedVolatilityColumns = `id, option_id, date, mark, underlying_price, rate, implied_volatility, reference_volatility, model, created_at, updated_at`
+
+func (s *ImpliedVolatilityService) query(query string, args ...interface{}) ([]*ImpliedVolatility, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get implied volatility: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*ImpliedVolatility{}
+	for rows.Next() {
+		var iv ImpliedVolatility
+		if err := rows.Scan(&iv.ID, &iv.OptionID, &iv.Date, &iv.Mark, &iv.UnderlyingPrice, &iv.Rate, &iv.Volatility,
+			&iv.ReferenceVolatility, &iv.Model, &iv.CreatedAt, &iv.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan implied volatility: %w", err)
+		}
+		history = append(history, &iv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating implied volatility: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetByOption returns the daily implied volatility of an option, oldest first
+func (s *ImpliedVolatilityService) GetByOption(optionID int) ([]*ImpliedVolatility, error) {
+	return s.query(`SELECT `+impliedVolatilityColumns+` FROM option_implied_volatility WHERE option_id = ? ORDER BY date`, optionID)
+}
+
+// Latest returns the most recent implied volatility of each option, keyed by option ID
+func (s *ImpliedVolatilityService) Latest() (map[int]*ImpliedVolatility, error) {
+	history, err := s.query(`SELECT ` + impliedVolatilityColumns + ` FROM option_implied_volatility iv
+		WHERE date = (SELECT MAX(date) FROM option_implied_volatility WHERE option_id = iv.option_id)`)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int]*ImpliedVolatility, len(history))
+	for _, iv := range history {
+		latest[iv.OptionID] = iv
+	}
+	return latest, nil
+}
+
+// YearEndMarks returns the last mark recorded for each option in each year
+func (s *ImpliedVolatilityService) YearEndMarks() (YearEndMarks, error) {
+	history, err := s.query(`SELECT ` + impliedVolatilityColumns + ` FROM option_implied_volatility ORDER BY date`)
+	if err != nil {
+		return nil, err
+	}
+
+	marks := make(YearEndMarks)
+	for _, iv := range history {
+		if marks[iv.OptionID] == nil {
+			marks[iv.OptionID] = make(map[int]float64)
+		}
+		marks[iv.OptionID][iv.Date.Year()] = iv.Mark
+	}
+	return marks, nil
+}
+
+// SetMark sets the current price of one unit of an option's underlying, or
+// clears it if mark is nil
+func (s *OptionService) SetMark(id int, mark *float64) (*Option, error) {
+	if mark != nil && *mark < 0 {
+		return nil, fmt.Errorf("mark cannot be negative")
+	}
+
+	result, err := s.db.Exec(`UPDATE options SET current_price = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, mark, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set mark: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("option not found")
+	}
+
+	return s.GetByID(id)
+}
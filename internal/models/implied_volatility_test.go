@@ -0,0 +1,99 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTreasuryRate_ClosestOpenMaturity(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	sold := 10000.0
+	treasuries := []*Treasury{
+		{CUSPID: "SOLD", Maturity: now.AddDate(0, 1, 0), Yield: 9, ExitPrice: &sold},
+		{CUSPID: "MATURED", Maturity: now.AddDate(0, 0, -1), Yield: 8},
+		{CUSPID: "3M", Maturity: now.AddDate(0, 3, 0), Yield: 4.2},
+		{CUSPID: "1Y", Maturity: now.AddDate(1, 0, 0), Yield: 3.9},
+	}
+
+	if rate, ok := TreasuryRate(treasuries, now.AddDate(0, 0, 30), now); !ok || rate != 4.2 {
+		t.Errorf("Expected the 3-month bill's 4.2%% for a 30-day option, got %.2f (%v)", rate, ok)
+	}
+	if rate, ok := TreasuryRate(treasuries, now.AddDate(0, 10, 0), now); !ok || rate != 3.9 {
+		t.Errorf("Expected the 1-year note's 3.9%% for a 10-month option, got %.2f (%v)", rate, ok)
+	}
+	if _, ok := TreasuryRate(treasuries[:2], now.AddDate(0, 0, 30), now); ok {
+		t.Errorf("Expected no rate without an open treasury")
+	}
+}
+
+func TestImpliedVolatilityService_RecordsOneValuePerDay(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+	ivService := NewImpliedVolatilityService(testDB.DB)
+	now := time.Now()
+
+	if _, err := NewSymbolService(testDB.DB).Update("AAPL", 150, 0, nil, nil); err != nil {
+		t.Fatalf("Failed to price symbol: %v", err)
+	}
+	if _, err := NewTreasuryService(testDB.DB).Create("912797XX1", now.AddDate(0, -1, 0), now.AddDate(0, 2, 0), 10000, 5.1, 9900); err != nil {
+		t.Fatalf("Failed to create treasury: %v", err)
+	}
+
+	put, err := optionService.Create("AAPL", "Put", now, 145, now.AddDate(0, 0, 30), 2.50, 1)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	if _, err := ivService.Record(put, now); err == nil {
+		t.Errorf("Expected an option without a mark to have no implied volatility")
+	}
+
+	// Mark the put at its value at 40% volatility and back the 40% out again
+	rate, err := ivService.Rate(put.Expiration, now)
+	if err != nil || rate != 5.1 {
+		t.Fatalf("Expected the treasury's 5.1%% rate, got %.2f: %v", rate, err)
+	}
+	symbol, _ := NewSymbolService(testDB.DB).GetBySymbol("AAPL")
+	priced, err := PriceOption(put, symbol, rate, 40, now)
+	if err != nil {
+		t.Fatalf("Failed to price put: %v", err)
+	}
+	mark := priced.PerShare.Value
+	if put, err = optionService.SetMark(put.ID, &mark); err != nil {
+		t.Fatalf("Failed to mark put: %v", err)
+	}
+
+	first, err := ivService.Record(put, now)
+	if err != nil {
+		t.Fatalf("Failed to record implied volatility: %v", err)
+	}
+	if math.Abs(first.Volatility-40) > 0.05 || first.Rate != 5.1 || first.ReferenceVolatility != DefaultVolatility || !first.IsRich() {
+		t.Errorf("Expected 40%% implied against the 30%% default, so rich, got %+v", first)
+	}
+
+	// A new mark the same day replaces the day's value
+	mark = mark / 2
+	if put, err = optionService.SetMark(put.ID, &mark); err != nil {
+		t.Fatalf("Failed to mark put: %v", err)
+	}
+	recorded, err := ivService.RecordOpen(now)
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("Expected to record the marked put, got %d: %v", len(recorded), err)
+	}
+
+	history, err := ivService.GetByOption(put.ID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 1 || history[0].ID != first.ID || history[0].Volatility >= first.Volatility {
+		t.Errorf("Expected one lower value for the day, got %d rows", len(history))
+	}
+
+	latest, err := ivService.Latest()
+	if err != nil {
+		t.Fatalf("Failed to get latest: %v", err)
+	}
+	if latest[put.ID] == nil || latest[put.ID].Mark != mark || latest[put.ID].IsRich() {
+		t.Errorf("Expected the latest value to be the cheaper mark, got %+v", latest[put.ID])
+	}
+}
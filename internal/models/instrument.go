@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Instrument classes of a symbol
+const (
+	InstrumentEquity = "equity"
+	InstrumentETF    = "etf"
+	InstrumentIndex  = "index"
+)
+
+// DefaultContractMultiplier is the shares one option contract covers unless the
+// symbol says otherwise
+const DefaultContractMultiplier = 100
+
+// Section1256LongTermShare is the part of a Section 1256 contract's gain taxed
+// as long-term, whatever the holding period; the rest is short-term
+const Section1256LongTermShare = 0.60
+
+// IsValidInstrumentClass reports whether class is a known instrument class
+func IsValidInstrumentClass(class string) bool {
+	switch class {
+	case InstrumentEquity, InstrumentETF, InstrumentIndex:
+		return true
+	}
+	return false
+}
+
+// Multiplier returns the shares or index units one option contract covers
+func (s *Symbol) Multiplier() int {
+	if s.ContractMultiplier <= 0 {
+		return DefaultContractMultiplier
+	}
+	return s.ContractMultiplier
+}
+
+// IsCashSettled returns true if options on the symbol settle in cash rather than
+// by delivering shares, as broad-based index options do
+func (s *Symbol) IsCashSettled() bool {
+	return s.InstrumentClass == InstrumentIndex
+}
+
+// IsSection1256 returns true if options on the symbol are Section 1256
+// contracts, taxed 60/40 long/short-term
+func (s *Symbol) IsSection1256() bool {
+	return s.InstrumentClass == InstrumentIndex
+}
+
+// Instruments looks up the instrument classification of symbols by name.
+// Symbols it does not know are treated as equities.
+type Instruments map[string]*Symbol
+
+// NewInstruments indexes symbols by name
+func NewInstruments(symbols []*Symbol) Instruments {
+	instruments := make(Instruments, len(symbols))
+	for _, symbol := range symbols {
+		instruments[symbol.Symbol] = symbol
+	}
+	return instruments
+}
+
+// Multiplier returns the contract multiplier of symbol
+func (i Instruments) Multiplier(symbol string) int {
+	if s, ok := i[symbol]; ok {
+		return s.Multiplier()
+	}
+	return DefaultContractMultiplier
+}
+
+// IsCashSettled returns true if options on symbol settle in cash
+func (i Instruments) IsCashSettled(symbol string) bool {
+	s, ok := i[symbol]
+	return ok && s.IsCashSettled()
+}
+
+// IsSection1256 returns true if options on symbol are Section 1256 contracts
+func (i Instruments) IsSection1256(symbol string) bool {
+	s, ok := i[symbol]
+	return ok && s.IsSection1256()
+}
+
+// SetInstrument classifies a symbol and sets its option contract multiplier
+func (s *SymbolService) SetInstrument(symbol, class string, multiplier int) (*Symbol, error) {
+	symbol = strings.TrimSpace(strings.ToUpper(symbol))
+	if !IsValidInstrumentClass(class) {
+		return nil, fmt.Errorf("instrument class must be '%s', '%s' or '%s'", InstrumentEquity, InstrumentETF, InstrumentIndex)
+	}
+	if multiplier <= 0 {
+		return nil, fmt.Errorf("contract multiplier must be positive")
+	}
+
+	query := `UPDATE symbols SET instrument_class = ?, contract_multiplier = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ? RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at`
+	var sym Symbol
+	err := s.db.QueryRow(query, class, multiplier, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.InstrumentClass, &sym.ContractMultiplier, &sym.Volatility, &sym.Beta, &sym.CreatedAt, &sym.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("symbol not found")
+		}
+		return nil, fmt.Errorf("failed to set instrument: %w", err)
+	}
+
+	return &sym, nil
+}
+
+// GetInstruments returns the instrument classification of every symbol
+func (s *SymbolService) GetInstruments() (Instruments, error) {
+	symbols, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return NewInstruments(symbols), nil
+}
+
+// getInstrument returns the classification of one symbol, an equity with the
+// default multiplier if the symbol is not tracked
+func getInstrument(db *sql.DB, symbol string) (*Symbol, error) {
+	instrument := &Symbol{Symbol: symbol, InstrumentClass: InstrumentEquity, ContractMultiplier: DefaultContractMultiplier}
+	err := db.QueryRow(`SELECT instrument_class, contract_multiplier FROM symbols WHERE symbol = ?`, symbol).Scan(
+		&instrument.InstrumentClass, &instrument.ContractMultiplier)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get instrument for %s: %w", symbol, err)
+	}
+	return instrument, nil
+}
@@ -15,12 +15,18 @@ func NewLongPositionService(db *sql.DB) *LongPositionService {
 }
 
 func (s *LongPositionService) Create(symbol string, opened time.Time, shares int, buyPrice float64) (*LongPosition, error) {
-	query := `INSERT INTO long_positions (symbol, opened, shares, buy_price) 
-			  VALUES (?, ?, ?, ?) 
+	return s.CreateInAccount(nil, symbol, opened, shares, buyPrice)
+}
+
+// CreateInAccount opens a long position directly in an account. A nil account
+// leaves it unassigned.
+func (s *LongPositionService) CreateInAccount(accountID *int, symbol string, opened time.Time, shares int, buyPrice float64) (*LongPosition, error) {
+	query := `INSERT INTO long_positions (symbol, opened, shares, buy_price, account_id) 
+			  VALUES (?, ?, ?, ?, ?) 
 			  RETURNING id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at`
 	
 	var position LongPosition
-	err := s.db.QueryRow(query, symbol, opened, shares, buyPrice).Scan(
+	err := s.db.QueryRow(query, symbol, opened, shares, buyPrice, accountID).Scan(
 		&position.ID, &position.Symbol, &position.Opened, &position.Closed, &position.Shares,
 		&position.BuyPrice, &position.ExitPrice, &position.CreatedAt, &position.UpdatedAt,
 	)
@@ -252,4 +258,54 @@ func (s *LongPositionService) GetOpenPositions() ([]*LongPosition, error) {
 	}
 
 	return positions, nil
-}
\ No newline at end of file
+}
+
+// closeLotTx closes shares of an open lot inside tx. When fewer than all of the
+// lot's shares are closed, the lot is split: the existing row becomes the closed
+// portion and a new open row keeps the remaining shares, opened date, buy price,
+// campaign and account. It returns the closed row.
+func closeLotTx(tx *sql.Tx, lotID int, shares int, closed time.Time, exitPrice float64) (*LongPosition, error) {
+	var lot LongPosition
+	var campaignID, accountID *int
+	err := tx.QueryRow(`SELECT id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at, campaign_id, account_id 
+			  FROM long_positions WHERE id = ?`, lotID).Scan(
+		&lot.ID, &lot.Symbol, &lot.Opened, &lot.Closed, &lot.Shares,
+		&lot.BuyPrice, &lot.ExitPrice, &lot.CreatedAt, &lot.UpdatedAt, &campaignID, &accountID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("long position not found")
+		}
+		return nil, fmt.Errorf("failed to get long position: %w", err)
+	}
+	if lot.Closed != nil {
+		return nil, fmt.Errorf("long position %d is already closed", lotID)
+	}
+	if shares <= 0 || shares > lot.Shares {
+		return nil, fmt.Errorf("cannot close %d shares of long position %d with %d shares", shares, lotID, lot.Shares)
+	}
+
+	if remaining := lot.Shares - shares; remaining > 0 {
+		_, err := tx.Exec(`INSERT INTO long_positions (symbol, opened, shares, buy_price, campaign_id, account_id) VALUES (?, ?, ?, ?, ?, ?)`,
+			lot.Symbol, lot.Opened, remaining, lot.BuyPrice, campaignID, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split long position %d: %w", lotID, err)
+		}
+	}
+
+	query := `UPDATE long_positions 
+			  SET shares = ?, closed = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
+			  WHERE id = ? 
+			  RETURNING id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at`
+
+	var position LongPosition
+	err = tx.QueryRow(query, shares, closed, exitPrice, lotID).Scan(
+		&position.ID, &position.Symbol, &position.Opened, &position.Closed, &position.Shares,
+		&position.BuyPrice, &position.ExitPrice, &position.CreatedAt, &position.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close long position %d: %w", lotID, err)
+	}
+
+	return &position, nil
+}
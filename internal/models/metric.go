@@ -37,13 +37,36 @@ const (
 
 	// OpenCallCount is the count of open Call options
 	OpenCallCount MetricType = "open_call_count"
+
+	// Cash is the cash ledger balance
+	Cash MetricType = "cash"
+
+	// NetDelta is the delta of open options and shares, in shares summed across symbols
+	NetDelta MetricType = "net_delta"
+
+	// DailyTheta is the theta of open options in dollars per day
+	DailyTheta MetricType = "daily_theta"
 )
 
+// IsValidMetricType reports whether metricType is a known metric type. The
+// metrics table has no CHECK on the type, so it is validated here.
+func IsValidMetricType(metricType MetricType) bool {
+	switch metricType {
+	case TreasuryValue, TotalValue, LongValue, LongCount, PutExposure, OpenPutPremium, OpenPutCount,
+		OpenCallPremium, OpenCallCount, Cash, NetDelta, DailyTheta:
+		return true
+	}
+	return false
+}
+
+// Metric is a dated value of one metric type, for one account if AccountID is
+// set or for the whole household if it is nil
 type Metric struct {
-	ID      int        `json:"id"`
-	Created time.Time  `json:"created"`
-	Type    MetricType `json:"type"`
-	Value   float64    `json:"value"`
+	ID        int        `json:"id"`
+	Created   time.Time  `json:"created"`
+	Type      MetricType `json:"type"`
+	Value     float64    `json:"value"`
+	AccountID *int       `json:"account_id"`
 }
 
 type MetricService struct {
@@ -58,10 +81,13 @@ func (ms *MetricService) Create(metricType MetricType, value float64) (*Metric,
 	if metricType == "" {
 		return nil, fmt.Errorf("metric type cannot be empty")
 	}
+	if !IsValidMetricType(metricType) {
+		return nil, fmt.Errorf("invalid metric type: %s", metricType)
+	}
 
-	query := `INSERT INTO metrics (type, value) VALUES (?, ?) RETURNING id, created, type, value`
+	query := `INSERT INTO metrics (type, value) VALUES (?, ?) RETURNING id, created, type, value, account_id`
 	var metric Metric
-	err := ms.db.QueryRow(query, string(metricType), value).Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value)
+	err := ms.db.QueryRow(query, string(metricType), value).Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value, &metric.AccountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metric: %w", err)
 	}
@@ -70,9 +96,9 @@ func (ms *MetricService) Create(metricType MetricType, value float64) (*Metric,
 }
 
 func (ms *MetricService) GetByID(id int) (*Metric, error) {
-	query := `SELECT id, created, type, value FROM metrics WHERE id = ?`
+	query := `SELECT id, created, type, value, account_id FROM metrics WHERE id = ?`
 	var metric Metric
-	err := ms.db.QueryRow(query, id).Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value)
+	err := ms.db.QueryRow(query, id).Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value, &metric.AccountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("metric not found")
@@ -83,9 +109,16 @@ func (ms *MetricService) GetByID(id int) (*Metric, error) {
 	return &metric, nil
 }
 
+// GetAll returns the household metrics
 func (ms *MetricService) GetAll() ([]*Metric, error) {
-	query := `SELECT id, created, type, value FROM metrics ORDER BY created DESC`
-	rows, err := ms.db.Query(query)
+	return ms.GetAllForAccount(nil)
+}
+
+// GetAllForAccount returns the metrics of one account, or the household
+// metrics if accountID is nil
+func (ms *MetricService) GetAllForAccount(accountID *int) ([]*Metric, error) {
+	query := `SELECT id, created, type, value, account_id FROM metrics WHERE account_id IS ? ORDER BY created DESC`
+	rows, err := ms.db.Query(query, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metrics: %w", err)
 	}
@@ -94,7 +127,7 @@ func (ms *MetricService) GetAll() ([]*Metric, error) {
 	var metrics []*Metric
 	for rows.Next() {
 		var metric Metric
-		if err := rows.Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value); err != nil {
+		if err := rows.Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value, &metric.AccountID); err != nil {
 			return nil, fmt.Errorf("failed to scan metric: %w", err)
 		}
 		metrics = append(metrics, &metric)
@@ -108,7 +141,7 @@ func (ms *MetricService) GetAll() ([]*Metric, error) {
 }
 
 func (ms *MetricService) GetByType(metricType MetricType) ([]*Metric, error) {
-	query := `SELECT id, created, type, value FROM metrics WHERE type = ? ORDER BY created DESC`
+	query := `SELECT id, created, type, value, account_id FROM metrics WHERE type = ? AND account_id IS NULL ORDER BY created DESC`
 	rows, err := ms.db.Query(query, string(metricType))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metrics by type: %w", err)
@@ -118,7 +151,7 @@ func (ms *MetricService) GetByType(metricType MetricType) ([]*Metric, error) {
 	var metrics []*Metric
 	for rows.Next() {
 		var metric Metric
-		if err := rows.Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value); err != nil {
+		if err := rows.Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value, &metric.AccountID); err != nil {
 			return nil, fmt.Errorf("failed to scan metric: %w", err)
 		}
 		metrics = append(metrics, &metric)
@@ -132,9 +165,9 @@ func (ms *MetricService) GetByType(metricType MetricType) ([]*Metric, error) {
 }
 
 func (ms *MetricService) Update(id int, value float64) (*Metric, error) {
-	query := `UPDATE metrics SET value = ? WHERE id = ? RETURNING id, created, type, value`
+	query := `UPDATE metrics SET value = ? WHERE id = ? RETURNING id, created, type, value, account_id`
 	var metric Metric
-	err := ms.db.QueryRow(query, value, id).Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value)
+	err := ms.db.QueryRow(query, value, id).Scan(&metric.ID, &metric.Created, &metric.Type, &metric.Value, &metric.AccountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("metric not found")
@@ -179,103 +212,157 @@ func (ms *MetricService) CreateSnapshot(metrics map[MetricType]float64) ([]*Metr
 	return createdMetrics, nil
 }
 
-// ComprehensiveSnapshot creates historical snapshots for each day going back the specified number of days
+// ComprehensiveSnapshot creates historical snapshots for each day going back the specified number of days,
+// for the household and for each account
 func (ms *MetricService) ComprehensiveSnapshot(days int) error {
 	if days <= 0 {
 		return fmt.Errorf("days must be positive")
 	}
 
+	rows, err := ms.db.Query(`SELECT id FROM accounts ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to get accounts: %w", err)
+	}
+	accountIDs := []*int{nil}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan account: %w", err)
+		}
+		accountIDs = append(accountIDs, &id)
+	}
+	rows.Close()
+
+	for _, accountID := range accountIDs {
+		if err := ms.snapshot(days, accountID); err != nil {
+			if accountID != nil {
+				return fmt.Errorf("account %d: %w", *accountID, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// snapshot upserts every metric of one account, or of the household if accountID is nil,
+// for each of the last days days
+func (ms *MetricService) snapshot(days int, accountID *int) error {
 	// Get today's date and calculate the start date
 	today := time.Now()
 
+	// The cash balance on each day comes from one pass over the ledger
+	ledger, err := NewCashService(ms.db).Ledger(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to build cash ledger: %w", err)
+	}
+
 	// For each day in the range, calculate and upsert all metrics
 	for i := 0; i < days; i++ {
 		targetDate := today.AddDate(0, 0, -i)
 
 		// Calculate and upsert treasury value
-		treasuryValue, err := ms.calculateTreasuryValueForDate(targetDate)
+		treasuryValue, err := ms.calculateTreasuryValueForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate treasury value for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(TreasuryValue, treasuryValue, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(TreasuryValue, treasuryValue, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert treasury metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert long value
-		longValue, err := ms.calculateLongValueForDate(targetDate)
+		longValue, err := ms.calculateLongValueForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate long value for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(LongValue, longValue, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(LongValue, longValue, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert long value metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert long count
-		longCount, err := ms.calculateLongCountForDate(targetDate)
+		longCount, err := ms.calculateLongCountForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate long count for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(LongCount, longCount, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(LongCount, longCount, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert long count metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert put exposure
-		putExposure, err := ms.calculatePutExposureForDate(targetDate)
+		putExposure, err := ms.calculatePutExposureForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate put exposure for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(PutExposure, putExposure, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(PutExposure, putExposure, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert put exposure metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert open put premium
-		openPutPremium, err := ms.calculateOpenPutPremiumForDate(targetDate)
+		openPutPremium, err := ms.calculateOpenPutPremiumForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate open put premium for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(OpenPutPremium, openPutPremium, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(OpenPutPremium, openPutPremium, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert open put premium metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert open put count
-		openPutCount, err := ms.calculateOpenPutCountForDate(targetDate)
+		openPutCount, err := ms.calculateOpenPutCountForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate open put count for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(OpenPutCount, openPutCount, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(OpenPutCount, openPutCount, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert open put count metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert open call premium
-		openCallPremium, err := ms.calculateOpenCallPremiumForDate(targetDate)
+		openCallPremium, err := ms.calculateOpenCallPremiumForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate open call premium for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(OpenCallPremium, openCallPremium, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(OpenCallPremium, openCallPremium, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert open call premium metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
 		// Calculate and upsert open call count
-		openCallCount, err := ms.calculateOpenCallCountForDate(targetDate)
+		openCallCount, err := ms.calculateOpenCallCountForDate(targetDate, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to calculate open call count for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
-		if err = ms.upsertMetricForDate(OpenCallCount, openCallCount, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(OpenCallCount, openCallCount, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert open call count metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 
+		// Upsert the cash balance
+		if err = ms.upsertMetricForDate(Cash, ledger.BalanceOn(targetDate), targetDate, accountID); err != nil {
+			return fmt.Errorf("failed to upsert cash metric for %s: %w", targetDate.Format("2006-01-02"), err)
+		}
+
 		// Calculate and upsert total value (treasuries + longs)
 		totalValue := treasuryValue + longValue
-		if err = ms.upsertMetricForDate(TotalValue, totalValue, targetDate); err != nil {
+		if err = ms.upsertMetricForDate(TotalValue, totalValue, targetDate, accountID); err != nil {
 			return fmt.Errorf("failed to upsert total value metric for %s: %w", targetDate.Format("2006-01-02"), err)
 		}
 	}
 
+	// Greeks need today's prices, so there is no history to backfill
+	greeks, err := NewPricingService(ms.db).AccountPortfolioGreeks(accountID, today)
+	if err != nil {
+		return fmt.Errorf("failed to calculate portfolio greeks: %w", err)
+	}
+	if err = ms.upsertMetricForDate(NetDelta, greeks.Delta, today, accountID); err != nil {
+		return fmt.Errorf("failed to upsert net delta metric: %w", err)
+	}
+	if err = ms.upsertMetricForDate(DailyTheta, greeks.Theta, today, accountID); err != nil {
+		return fmt.Errorf("failed to upsert daily theta metric: %w", err)
+	}
+
 	return nil
 }
 
 // calculateTreasuryValueForDate calculates total treasury value as of a specific date
-func (ms *MetricService) calculateTreasuryValueForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateTreasuryValueForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for treasuries that were active on the given date
 	// Since treasuries don't have a sold_date field, we need to handle this differently:
 	// - Include treasuries that were purchased on or before the target date
@@ -289,11 +376,12 @@ func (ms *MetricService) calculateTreasuryValueForDate(date time.Time) (float64,
 			SELECT COALESCE(SUM(amount), 0) as total_value
 			FROM treasuries 
 			WHERE date(purchased) <= date(?) 
+			AND (? IS NULL OR account_id = ?)
 			AND exit_price IS NULL
 		`
 		dateStr := date.Format("2006-01-02")
 		var totalValue float64
-		err := ms.db.QueryRow(query, dateStr).Scan(&totalValue)
+		err := ms.db.QueryRow(query, dateStr, accountID, accountID).Scan(&totalValue)
 		if err != nil {
 			return 0, fmt.Errorf("failed to calculate current treasury value: %w", err)
 		}
@@ -305,12 +393,13 @@ func (ms *MetricService) calculateTreasuryValueForDate(date time.Time) (float64,
 		SELECT COALESCE(SUM(amount), 0) as total_value
 		FROM treasuries 
 		WHERE date(purchased) <= date(?) 
+			AND (? IS NULL OR account_id = ?)
 		AND (exit_price IS NULL OR date(maturity) > date(?))
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalValue float64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalValue)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalValue)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate treasury value: %w", err)
 	}
@@ -319,7 +408,7 @@ func (ms *MetricService) calculateTreasuryValueForDate(date time.Time) (float64,
 }
 
 // calculateLongValueForDate calculates total long position value as of a specific date
-func (ms *MetricService) calculateLongValueForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateLongValueForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for long positions that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date)
 	// Value = shares * buy_price
@@ -327,12 +416,13 @@ func (ms *MetricService) calculateLongValueForDate(date time.Time) (float64, err
 		SELECT COALESCE(SUM(shares * buy_price), 0) as total_value
 		FROM long_positions 
 		WHERE date(opened) <= date(?) 
+		AND (? IS NULL OR account_id = ?)
 		AND (closed IS NULL OR date(closed) > date(?))
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalValue float64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalValue)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalValue)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate long value: %w", err)
 	}
@@ -341,19 +431,20 @@ func (ms *MetricService) calculateLongValueForDate(date time.Time) (float64, err
 }
 
 // calculateLongCountForDate calculates total count of long positions as of a specific date
-func (ms *MetricService) calculateLongCountForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateLongCountForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for long positions that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date)
 	query := `
 		SELECT COALESCE(COUNT(*), 0) as total_count
 		FROM long_positions 
 		WHERE date(opened) <= date(?) 
+		AND (? IS NULL OR account_id = ?)
 		AND (closed IS NULL OR date(closed) > date(?))
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalCount int64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalCount)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalCount)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate long count: %w", err)
 	}
@@ -362,21 +453,27 @@ func (ms *MetricService) calculateLongCountForDate(date time.Time) (float64, err
 }
 
 // calculatePutExposureForDate calculates total put option exposure as of a specific date
-func (ms *MetricService) calculatePutExposureForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculatePutExposureForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for put options that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date) AND type = 'Put'
-	// Exposure = strike * contracts * 100 (standard option contract multiplier)
+	// Exposure = strike * contracts * the symbol's contract multiplier for short puts,
+	// netted against long puts in the same strategy so a put spread only counts its width
 	query := `
-		SELECT COALESCE(SUM(strike * contracts * 100), 0) as total_exposure
-		FROM options 
-		WHERE date(opened) <= date(?) 
-		AND (closed IS NULL OR date(closed) > date(?))
-		AND type = 'Put'
+		SELECT COALESCE(SUM(exposure), 0) as total_exposure
+		FROM (
+			SELECT MAX(0, SUM(CASE WHEN direction = 'long' THEN -1 ELSE 1 END * strike * contracts * COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100))) as exposure
+			FROM options 
+			WHERE date(opened) <= date(?) 
+			AND (? IS NULL OR account_id = ?)
+			AND (closed IS NULL OR date(closed) > date(?))
+			AND type = 'Put'
+			GROUP BY COALESCE('strategy-' || strategy_id, 'option-' || id)
+		)
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalExposure float64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalExposure)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalExposure)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate put exposure: %w", err)
 	}
@@ -385,21 +482,22 @@ func (ms *MetricService) calculatePutExposureForDate(date time.Time) (float64, e
 }
 
 // calculateOpenPutPremiumForDate calculates total premium value of open put options as of a specific date
-func (ms *MetricService) calculateOpenPutPremiumForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateOpenPutPremiumForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for put options that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date) AND type = 'Put'
-	// Premium value = premium * contracts * 100 (standard option contract multiplier)
+	// Premium value = premium * contracts * the symbol's contract multiplier, negative for bought options
 	query := `
-		SELECT COALESCE(SUM(premium * contracts * 100), 0) as total_premium
+		SELECT COALESCE(SUM(CASE WHEN direction = 'long' THEN -1 ELSE 1 END * premium * contracts * COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)), 0) as total_premium
 		FROM options 
 		WHERE date(opened) <= date(?) 
+		AND (? IS NULL OR account_id = ?)
 		AND (closed IS NULL OR date(closed) > date(?))
 		AND type = 'Put'
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalPremium float64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalPremium)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalPremium)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate open put premium: %w", err)
 	}
@@ -408,20 +506,21 @@ func (ms *MetricService) calculateOpenPutPremiumForDate(date time.Time) (float64
 }
 
 // calculateOpenPutCountForDate calculates total count of open put options as of a specific date
-func (ms *MetricService) calculateOpenPutCountForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateOpenPutCountForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for put options that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date) AND type = 'Put'
 	query := `
 		SELECT COALESCE(COUNT(*), 0) as total_count
 		FROM options 
 		WHERE date(opened) <= date(?) 
+		AND (? IS NULL OR account_id = ?)
 		AND (closed IS NULL OR date(closed) > date(?))
 		AND type = 'Put'
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalCount int64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalCount)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalCount)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate open put count: %w", err)
 	}
@@ -430,21 +529,22 @@ func (ms *MetricService) calculateOpenPutCountForDate(date time.Time) (float64,
 }
 
 // calculateOpenCallPremiumForDate calculates total premium value of open call options as of a specific date
-func (ms *MetricService) calculateOpenCallPremiumForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateOpenCallPremiumForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for call options that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date) AND type = 'Call'
-	// Premium value = premium * contracts * 100 (standard option contract multiplier)
+	// Premium value = premium * contracts * the symbol's contract multiplier, negative for bought options
 	query := `
-		SELECT COALESCE(SUM(premium * contracts * 100), 0) as total_premium
+		SELECT COALESCE(SUM(CASE WHEN direction = 'long' THEN -1 ELSE 1 END * premium * contracts * COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)), 0) as total_premium
 		FROM options 
 		WHERE date(opened) <= date(?) 
+		AND (? IS NULL OR account_id = ?)
 		AND (closed IS NULL OR date(closed) > date(?))
 		AND type = 'Call'
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalPremium float64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalPremium)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalPremium)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate open call premium: %w", err)
 	}
@@ -453,20 +553,21 @@ func (ms *MetricService) calculateOpenCallPremiumForDate(date time.Time) (float6
 }
 
 // calculateOpenCallCountForDate calculates total count of open call options as of a specific date
-func (ms *MetricService) calculateOpenCallCountForDate(date time.Time) (float64, error) {
+func (ms *MetricService) calculateOpenCallCountForDate(date time.Time, accountID *int) (float64, error) {
 	// Query for call options that were active on the given date
 	// Active means: opened <= date AND (closed IS NULL OR closed > date) AND type = 'Call'
 	query := `
 		SELECT COALESCE(COUNT(*), 0) as total_count
 		FROM options 
 		WHERE date(opened) <= date(?) 
+		AND (? IS NULL OR account_id = ?)
 		AND (closed IS NULL OR date(closed) > date(?))
 		AND type = 'Call'
 	`
 
 	dateStr := date.Format("2006-01-02")
 	var totalCount int64
-	err := ms.db.QueryRow(query, dateStr, dateStr).Scan(&totalCount)
+	err := ms.db.QueryRow(query, dateStr, accountID, accountID, dateStr).Scan(&totalCount)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate open call count: %w", err)
 	}
@@ -475,20 +576,24 @@ func (ms *MetricService) calculateOpenCallCountForDate(date time.Time) (float64,
 }
 
 // upsertMetricForDate inserts or updates a metric for a specific date
-func (ms *MetricService) upsertMetricForDate(metricType MetricType, value float64, date time.Time) error {
+func (ms *MetricService) upsertMetricForDate(metricType MetricType, value float64, date time.Time, accountID *int) error {
+	if !IsValidMetricType(metricType) {
+		return fmt.Errorf("invalid metric type: %s", metricType)
+	}
+
 	// First, try to find an existing metric for this date and type
 	dateStr := date.Format("2006-01-02")
 
 	var existingID int
-	checkQuery := `SELECT id FROM metrics WHERE type = ? AND date(created) = date(?)`
-	err := ms.db.QueryRow(checkQuery, string(metricType), dateStr).Scan(&existingID)
+	checkQuery := `SELECT id FROM metrics WHERE type = ? AND date(created) = date(?) AND account_id IS ?`
+	err := ms.db.QueryRow(checkQuery, string(metricType), dateStr, accountID).Scan(&existingID)
 
 	if err == sql.ErrNoRows {
 		// No existing metric, insert new one with the target date
 		// Set time to noon for consistent historical snapshots
 		dateWithTime := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, date.Location())
-		insertQuery := `INSERT INTO metrics (created, type, value) VALUES (?, ?, ?)`
-		_, err = ms.db.Exec(insertQuery, dateWithTime, string(metricType), value)
+		insertQuery := `INSERT INTO metrics (created, type, value, account_id) VALUES (?, ?, ?, ?)`
+		_, err = ms.db.Exec(insertQuery, dateWithTime, string(metricType), value, accountID)
 		if err != nil {
 			return fmt.Errorf("failed to insert metric: %w", err)
 		}
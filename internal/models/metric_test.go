@@ -545,4 +545,22 @@ func TestMetricService_ComprehensiveSnapshot(t *testing.T) {
 	} else {
 		t.Errorf("Missing open call count metric for date %s", testDate3Key)
 	}
-}
\ No newline at end of file
+}
+
+func TestMetricService_CreateValidatesType(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+	metricService := NewMetricService(testDB.DB)
+
+	for _, metricType := range []MetricType{Cash, NetDelta, DailyTheta} {
+		if _, err := metricService.Create(metricType, 1); err != nil {
+			t.Errorf("Expected %s metric to be created, got %v", metricType, err)
+		}
+	}
+	if _, err := metricService.Create("bogus", 1); err == nil {
+		t.Error("Expected an unknown metric type to be rejected")
+	}
+}
@@ -0,0 +1,426 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Monte Carlo defaults and limits
+const (
+	DefaultMonteCarloPaths = 10000
+	MaxMonteCarloPaths     = 100000
+	DefaultConfidence      = 95.0
+	monteCarloBins         = 40
+)
+
+// MonteCarloConfig sets up a simulation. Volatility overrides the volatility
+// of individual symbols in percent. Correlation is the pairwise correlation of
+// the symbols' returns through one common market factor, from 0 for
+// independent symbols to 1 for symbols that move together. Confidence is the
+// VaR and CVaR level in percent.
+type MonteCarloConfig struct {
+	Paths       int                `json:"paths"`
+	Seed        int64              `json:"seed"`
+	Confidence  float64            `json:"confidence"`
+	Correlation float64            `json:"correlation"`
+	Volatility  map[string]float64 `json:"volatility"`
+}
+
+// Validate fills in the default paths and confidence, upper-cases the
+// symbols given a volatility and checks the rest
+func (c *MonteCarloConfig) Validate() error {
+	if c.Paths == 0 {
+		c.Paths = DefaultMonteCarloPaths
+	}
+	if c.Confidence == 0 {
+		c.Confidence = DefaultConfidence
+	}
+	if c.Paths < 0 || c.Paths > MaxMonteCarloPaths {
+		return fmt.Errorf("paths must be between 1 and %d", MaxMonteCarloPaths)
+	}
+	if c.Confidence <= 50 || c.Confidence >= 100 {
+		return fmt.Errorf("confidence must be between 50 and 100 percent")
+	}
+	if c.Correlation < 0 || c.Correlation > 1 {
+		return fmt.Errorf("correlation must be between 0 and 1")
+	}
+	volatilities := make(map[string]float64, len(c.Volatility))
+	for symbol, volatility := range c.Volatility {
+		if volatility <= 0 {
+			return fmt.Errorf("volatility of %s must be positive", symbol)
+		}
+		volatilities[strings.TrimSpace(strings.ToUpper(symbol))] = volatility
+	}
+	c.Volatility = volatilities
+	return nil
+}
+
+// MonteCarloSymbol is one simulated underlying, volatility in percent.
+// MeanPrice is its average price at the horizon.
+type MonteCarloSymbol struct {
+	Symbol     string  `json:"symbol"`
+	Price      float64 `json:"price"`
+	Volatility float64 `json:"volatility"`
+	MeanPrice  float64 `json:"mean_price"`
+}
+
+// MonteCarloPut is how often a short put was assigned at its expiration, in
+// percent of paths. Assignment is the average cash it took when assigned and
+// ExpectedAssignment the average across every path.
+type MonteCarloPut struct {
+	OptionID           int       `json:"option_id"`
+	Symbol             string    `json:"symbol"`
+	Strike             float64   `json:"strike"`
+	Expiration         time.Time `json:"expiration"`
+	Contracts          int       `json:"contracts"`
+	Probability        float64   `json:"probability"`
+	Assignment         float64   `json:"assignment"`
+	ExpectedAssignment float64   `json:"expected_assignment"`
+}
+
+// MonteCarloBin counts the paths whose P&L fell in [Low, High), with the share
+// of paths in percent
+type MonteCarloBin struct {
+	Low         float64 `json:"low"`
+	High        float64 `json:"high"`
+	Count       int     `json:"count"`
+	Probability float64 `json:"probability"`
+}
+
+// MonteCarloPercentile is the P&L at one percentile of the distribution
+type MonteCarloPercentile struct {
+	Percentile float64 `json:"percentile"`
+	PnL        float64 `json:"pnl"`
+}
+
+// MonteCarloResult is the distribution of portfolio P&L from today to the last
+// open expiration. Each option settles at its intrinsic value on its own
+// expiration and is measured against its theoretical value today; shares are
+// marked at the horizon. VaR and CVaR are losses at the confidence level,
+// positive when money is lost. Capital is the cash assigned puts take on each
+// path, netted within strategies like the collateral check.
+type MonteCarloResult struct {
+	Seed                  int64                   `json:"seed"`
+	Paths                 int                     `json:"paths"`
+	Confidence            float64                 `json:"confidence"`
+	Correlation           float64                 `json:"correlation"`
+	Horizon               time.Time               `json:"horizon"`
+	Expirations           []time.Time             `json:"expirations"`
+	Symbols               []*MonteCarloSymbol     `json:"symbols"`
+	MeanPnL               float64                 `json:"mean_pnl"`
+	StdDevPnL             float64                 `json:"std_dev_pnl"`
+	ProbabilityOfLoss     float64                 `json:"probability_of_loss"`
+	VaR                   float64                 `json:"var"`
+	CVaR                  float64                 `json:"cvar"`
+	Percentiles           []*MonteCarloPercentile `json:"percentiles"`
+	Histogram             []*MonteCarloBin        `json:"histogram"`
+	Puts                  []*MonteCarloPut        `json:"puts"`
+	AssignmentProbability float64                 `json:"assignment_probability"`
+	ExpectedCapital       float64                 `json:"expected_capital"`
+	CapitalAtConfidence   float64                 `json:"capital_at_confidence"`
+	MaxCapital            float64                 `json:"max_capital"`
+	Unpriced              []string                `json:"unpriced"`
+}
+
+// percentile returns the value at fraction p of sorted values, interpolating
+// between neighbours
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	weight := position - float64(lower)
+	return sorted[lower]*(1-weight) + sorted[upper]*weight
+}
+
+// histogram buckets values into equal-width bins between their minimum and maximum
+func histogram(sorted []float64, bins int) []*MonteCarloBin {
+	histogram := []*MonteCarloBin{}
+	if len(sorted) == 0 {
+		return histogram
+	}
+	low, high := sorted[0], sorted[len(sorted)-1]
+	if high-low < 0.005 {
+		return append(histogram, &MonteCarloBin{Low: low, High: high, Count: len(sorted), Probability: 100})
+	}
+
+	width := (high - low) / float64(bins)
+	for i := 0; i < bins; i++ {
+		histogram = append(histogram, &MonteCarloBin{Low: low + float64(i)*width, High: low + float64(i+1)*width})
+	}
+	for _, value := range sorted {
+		i := int((value - low) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		histogram[i].Count++
+	}
+	for _, bin := range histogram {
+		bin.Probability = float64(bin.Count) / float64(len(sorted)) * 100
+	}
+	return histogram
+}
+
+// SimulatePortfolio runs a Monte Carlo simulation of the open options and long
+// positions. Each path walks every symbol's price to each open expiration with
+// geometric Brownian motion, drifting at the risk-free rate less the symbol's
+// dividend yield. rate and volatility are the risk-free rate and the fallback
+// volatility in percent. The same seed and inputs always give the same result.
+func SimulatePortfolio(options []*Option, longPositions []*LongPosition, instruments Instruments, config MonteCarloConfig, rate, volatility float64, now time.Time) (*MonteCarloResult, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &MonteCarloResult{
+		Seed:        config.Seed,
+		Paths:       config.Paths,
+		Confidence:  config.Confidence,
+		Correlation: config.Correlation,
+		Expirations: []time.Time{},
+		Symbols:     []*MonteCarloSymbol{},
+		Puts:        []*MonteCarloPut{},
+		Unpriced:    []string{},
+	}
+
+	// Symbols are simulated in name order so a seed always draws the same numbers for each
+	unpriced := make(map[string]bool)
+	symbolIndex := make(map[string]int)
+	var symbols []*Symbol
+	include := func(name string) bool {
+		underlying, ok := instruments[name]
+		if !ok || underlying.Price <= 0 {
+			unpriced[name] = true
+			return false
+		}
+		if _, ok := symbolIndex[name]; !ok {
+			symbolIndex[name] = -1
+			symbols = append(symbols, underlying)
+		}
+		return true
+	}
+
+	var priced []*Option
+	expirationIndex := make(map[string]int)
+	for _, option := range options {
+		if option.Closed != nil || !include(option.Symbol) {
+			continue
+		}
+		priced = append(priced, option)
+		if _, ok := expirationIndex[option.Expiration.Format("2006-01-02")]; !ok {
+			expirationIndex[option.Expiration.Format("2006-01-02")] = -1
+			result.Expirations = append(result.Expirations, option.Expiration)
+		}
+	}
+	var stocks []*LongPosition
+	for _, position := range longPositions {
+		if position.Closed != nil || !include(position.Symbol) {
+			continue
+		}
+		stocks = append(stocks, position)
+	}
+	for symbol := range unpriced {
+		result.Unpriced = append(result.Unpriced, symbol)
+	}
+	sort.Strings(result.Unpriced)
+	if len(priced) == 0 {
+		return nil, fmt.Errorf("no open options with a priced underlying to simulate")
+	}
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+	sort.Slice(result.Expirations, func(i, j int) bool { return result.Expirations[i].Before(result.Expirations[j]) })
+	for i, underlying := range symbols {
+		symbolIndex[underlying.Symbol] = i
+	}
+	years := make([]float64, len(result.Expirations))
+	for i, expiration := range result.Expirations {
+		expirationIndex[expiration.Format("2006-01-02")] = i
+		years[i] = YearsToExpiration(expiration, now)
+	}
+	result.Horizon = result.Expirations[len(result.Expirations)-1]
+
+	drift := make([]float64, len(symbols))
+	vol := make([]float64, len(symbols))
+	for i, underlying := range symbols {
+		v := volatility
+		if underlying.Volatility != nil {
+			v = *underlying.Volatility
+		}
+		if override, ok := config.Volatility[underlying.Symbol]; ok {
+			v = override
+		}
+		vol[i] = v / 100
+		drift[i] = rate/100 - underlying.CalculateYield()/100 - vol[i]*vol[i]/2
+		result.Symbols = append(result.Symbols, &MonteCarloSymbol{Symbol: underlying.Symbol, Price: underlying.Price, Volatility: v})
+	}
+
+	// Options are measured against today's value, so P&L is what the path adds to it
+	today := make([]float64, len(priced))
+	for i, option := range priced {
+		valued, err := PriceOption(option, instruments[option.Symbol], rate, volatility, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price option %d: %w", option.ID, err)
+		}
+		today[i] = valued.Position.Value
+	}
+
+	putIndex := make(map[int]int)
+	for _, option := range priced {
+		if option.Type == "Put" && !option.IsLong() {
+			putIndex[option.ID] = len(result.Puts)
+			result.Puts = append(result.Puts, &MonteCarloPut{
+				OptionID:   option.ID,
+				Symbol:     option.Symbol,
+				Strike:     option.Strike,
+				Expiration: option.Expiration,
+				Contracts:  option.Contracts,
+			})
+		}
+	}
+	putTotals := make([]float64, len(result.Puts))
+	putCounts := make([]int, len(result.Puts))
+
+	rng := rand.New(rand.NewSource(config.Seed))
+	market, idiosyncratic := math.Sqrt(config.Correlation), math.Sqrt(1-config.Correlation)
+	prices := make([][]float64, len(result.Expirations))
+	for i := range prices {
+		prices[i] = make([]float64, len(symbols))
+	}
+	current := make([]float64, len(symbols))
+	pnl := make([]float64, config.Paths)
+	capital := make([]float64, config.Paths)
+	assignments := make(map[int]float64)
+
+	for path := 0; path < config.Paths; path++ {
+		for i, underlying := range symbols {
+			current[i] = underlying.Price
+		}
+		elapsed := 0.0
+		for k := range result.Expirations {
+			dt := years[k] - elapsed
+			if dt > 0 {
+				m := rng.NormFloat64()
+				for i := range symbols {
+					z := market*m + idiosyncratic*rng.NormFloat64()
+					current[i] *= math.Exp(drift[i]*dt + vol[i]*math.Sqrt(dt)*z)
+				}
+				elapsed = years[k]
+			}
+			copy(prices[k], current)
+		}
+
+		var total float64
+		for i, option := range priced {
+			underlying := instruments[option.Symbol]
+			price := prices[expirationIndex[option.Expiration.Format("2006-01-02")]][symbolIndex[option.Symbol]]
+			intrinsic := math.Max(0, price-option.Strike)
+			if option.Type == "Put" {
+				intrinsic = math.Max(0, option.Strike-price)
+			}
+			quantity := float64(underlying.Multiplier() * option.Contracts)
+			if !option.IsLong() {
+				quantity = -quantity
+			}
+			total += intrinsic*quantity - today[i]
+
+			assignments[option.ID] = putAssignment(option, underlying, price)
+			if index, ok := putIndex[option.ID]; ok && assignments[option.ID] > 0 {
+				putTotals[index] += assignments[option.ID]
+				putCounts[index]++
+			}
+		}
+		horizon := prices[len(prices)-1]
+		for _, position := range stocks {
+			i := symbolIndex[position.Symbol]
+			total += float64(position.Shares) * (horizon[i] - symbols[i].Price)
+		}
+		for i := range symbols {
+			result.Symbols[i].MeanPrice += horizon[i] / float64(config.Paths)
+		}
+
+		pnl[path] = total
+		capital[path] = netAssignment(priced, func(option *Option) float64 {
+			return assignments[option.ID]
+		})
+	}
+
+	paths := float64(config.Paths)
+	for i, put := range result.Puts {
+		put.Probability = float64(putCounts[i]) / paths * 100
+		put.ExpectedAssignment = putTotals[i] / paths
+		if putCounts[i] > 0 {
+			put.Assignment = putTotals[i] / float64(putCounts[i])
+		}
+	}
+
+	var sum, losses, assigned float64
+	for i := range pnl {
+		sum += pnl[i]
+		if pnl[i] < 0 {
+			losses++
+		}
+		result.ExpectedCapital += capital[i] / paths
+		if capital[i] > 0 {
+			assigned++
+		}
+	}
+	result.MeanPnL = sum / paths
+	for _, value := range pnl {
+		result.StdDevPnL += (value - result.MeanPnL) * (value - result.MeanPnL) / paths
+	}
+	result.StdDevPnL = math.Sqrt(result.StdDevPnL)
+	result.ProbabilityOfLoss = losses / paths * 100
+	result.AssignmentProbability = assigned / paths * 100
+
+	sort.Float64s(pnl)
+	tail := 1 - config.Confidence/100
+	result.VaR = math.Max(0, -percentile(pnl, tail))
+	worst := int(math.Ceil(tail * paths))
+	if worst < 1 {
+		worst = 1
+	}
+	var tailSum float64
+	for _, value := range pnl[:worst] {
+		tailSum += value
+	}
+	result.CVaR = math.Max(0, -tailSum/float64(worst))
+	for _, p := range []float64{5, 25, 50, 75, 95} {
+		result.Percentiles = append(result.Percentiles, &MonteCarloPercentile{Percentile: p, PnL: percentile(pnl, p/100)})
+	}
+	result.Histogram = histogram(pnl, monteCarloBins)
+
+	sort.Float64s(capital)
+	result.CapitalAtConfidence = percentile(capital, config.Confidence/100)
+	result.MaxCapital = capital[len(capital)-1]
+
+	return result, nil
+}
+
+// MonteCarlo simulates the open options and long positions of one account, or
+// of the household if accountID is nil
+func (s *PricingService) MonteCarlo(accountID *int, config MonteCarloConfig, now time.Time) (*MonteCarloResult, error) {
+	filter, err := NewAccountService(s.db).Filter(accountID)
+	if err != nil {
+		return nil, err
+	}
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+	longPositions, err := NewLongPositionService(s.db).GetOpenPositions()
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	rate, volatility := s.Rates()
+
+	return SimulatePortfolio(filter.Options(options), filter.LongPositions(longPositions), instruments, config, rate, volatility, now)
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"stonks/internal/pricing"
+	"testing"
+	"time"
+)
+
+func monteCarloPortfolio() ([]*Option, []*LongPosition, Instruments) {
+	expiration := time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC)
+	strategyID := 1
+	options := []*Option{
+		{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 200, Premium: 6, Contracts: 1, Expiration: expiration},
+		{ID: 2, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 100, Premium: 0.05, Contracts: 1, Expiration: expiration},
+		{ID: 3, Symbol: "KO", Type: "Put", Direction: DirectionShort, Strike: 60, Premium: 1, Contracts: 2, Expiration: expiration.AddDate(0, 1, 0), StrategyID: &strategyID},
+		{ID: 4, Symbol: "KO", Type: "Put", Direction: DirectionLong, Strike: 55, Premium: 0.3, Contracts: 2, Expiration: expiration.AddDate(0, 1, 0), StrategyID: &strategyID},
+	}
+	longPositions := []*LongPosition{{ID: 1, Symbol: "KO", Shares: 100, BuyPrice: 55}}
+	instruments := Instruments{
+		"AAPL": {Symbol: "AAPL", Price: 200},
+		"KO":   {Symbol: "KO", Price: 60},
+	}
+	return options, longPositions, instruments
+}
+
+func TestSimulatePortfolio_DeterministicWithSeed(t *testing.T) {
+	options, longPositions, instruments := monteCarloPortfolio()
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	config := MonteCarloConfig{Paths: 2000, Seed: 42, Correlation: 0.5, Volatility: map[string]float64{"ko": 20}}
+
+	first, err := SimulatePortfolio(options, longPositions, instruments, config, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	second, err := SimulatePortfolio(options, longPositions, instruments, config, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same seed to give the same result")
+	}
+
+	config.Seed = 43
+	other, err := SimulatePortfolio(options, longPositions, instruments, config, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	if other.MeanPnL == first.MeanPnL {
+		t.Errorf("Expected another seed to draw other paths")
+	}
+
+	if first.Symbols[1].Symbol != "KO" || first.Symbols[1].Volatility != 20 {
+		t.Errorf("Expected KO simulated at its 20%% override, got %+v", first.Symbols[1])
+	}
+	if len(first.Expirations) != 2 || !first.Horizon.Equal(options[2].Expiration) {
+		t.Errorf("Expected two expirations ending on the KO spread's, got %v", first.Expirations)
+	}
+	if first.VaR <= 0 || first.CVaR < first.VaR {
+		t.Errorf("Expected a positive VaR no larger than CVaR, got %.2f and %.2f", first.VaR, first.CVaR)
+	}
+
+	var count int
+	for _, bin := range first.Histogram {
+		count += bin.Count
+	}
+	if count != config.Paths {
+		t.Errorf("Expected the histogram to hold every path, got %d", count)
+	}
+}
+
+func TestSimulatePortfolio_AssignmentOdds(t *testing.T) {
+	options, longPositions, instruments := monteCarloPortfolio()
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+
+	result, err := SimulatePortfolio(options, longPositions, instruments, MonteCarloConfig{Paths: 20000, Seed: 7}, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	if len(result.Puts) != 3 {
+		t.Fatalf("Expected the three short puts, got %d", len(result.Puts))
+	}
+
+	// The at-the-money put is assigned about as often as the model says it finishes in the money
+	atm := result.Puts[0]
+	expected := pricing.ProbabilityITM(pricing.Inputs{Spot: 200, Strike: 200, Years: YearsToExpiration(atm.Expiration, now), Rate: 0.045, Volatility: 0.3}) * 100
+	if math.Abs(atm.Probability-expected) > 1.5 {
+		t.Errorf("Expected the at-the-money put assigned %.1f%% of the time, got %.1f%%", expected, atm.Probability)
+	}
+	if atm.Assignment != 20000 {
+		t.Errorf("Expected 20000 to take the assigned put, got %.2f", atm.Assignment)
+	}
+	if result.Puts[1].Probability > 0.1 {
+		t.Errorf("Expected the 50%% out-of-the-money put almost never assigned, got %.2f%%", result.Puts[1].Probability)
+	}
+
+	// The KO spread takes 12000 between the strikes but only its 1000 width below 55
+	if result.MaxCapital > 20000+10000+12000+0.001 {
+		t.Errorf("Expected no more than every put assigned, got max capital %.2f", result.MaxCapital)
+	}
+	if result.ExpectedCapital <= 0 || result.CapitalAtConfidence < result.ExpectedCapital {
+		t.Errorf("Expected capital at confidence above the expected %.2f, got %.2f", result.ExpectedCapital, result.CapitalAtConfidence)
+	}
+
+	if _, err := SimulatePortfolio(nil, longPositions, instruments, MonteCarloConfig{}, 4.5, 30, now); err == nil {
+		t.Errorf("Expected an error without open options")
+	}
+	if _, err := SimulatePortfolio(options, longPositions, instruments, MonteCarloConfig{Correlation: 2}, 4.5, 30, now); err == nil {
+		t.Errorf("Expected an error for a correlation above 1")
+	}
+}
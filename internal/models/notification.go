@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Notification delivery statuses
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// NotificationDelivery is the outcome of sending one event to one channel,
+// after every attempt
+type NotificationDelivery struct {
+	ID        int       `json:"id"`
+	Channel   string    `json:"channel"`
+	EventType string    `json:"event_type"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     *string   `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NotificationService struct {
+	db *sql.DB
+}
+
+func NewNotificationService(db *sql.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// Record adds a delivery to the log
+func (s *NotificationService) Record(delivery *NotificationDelivery) (*NotificationDelivery, error) {
+	err := s.db.QueryRow(`INSERT INTO notification_deliveries (channel, event_type, title, status, attempts, error) VALUES (?, ?, ?, ?, ?, ?) RETURNING id, created_at`,
+		delivery.Channel, delivery.EventType, delivery.Title, delivery.Status, delivery.Attempts, delivery.Error).Scan(&delivery.ID, &delivery.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// GetRecent returns the latest deliveries, newest first
+func (s *NotificationService) GetRecent(limit int) ([]*NotificationDelivery, error) {
+	rows, err := s.db.Query(`SELECT id, channel, event_type, title, status, attempts, error, created_at
+		FROM notification_deliveries ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*NotificationDelivery{}
+	for rows.Next() {
+		var delivery NotificationDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.Channel, &delivery.EventType, &delivery.Title, &delivery.Status,
+			&delivery.Attempts, &delivery.Error, &delivery.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
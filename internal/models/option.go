@@ -10,6 +10,23 @@ import (
 // Commission constants
 const OptionCommissionPerContract = 0.65
 
+// Close reasons recorded on options closed by a workflow rather than a manual buyback
+const (
+	CloseReasonAssigned   = "assigned"
+	CloseReasonCalledAway = "called_away"
+	CloseReasonRolled     = "rolled"
+)
+
+// Option directions: short legs are sold for a credit, long legs bought for a debit
+const (
+	DirectionShort = "short"
+	DirectionLong  = "long"
+)
+
+// LEAPSMinDays is the minimum days to expiration at purchase for a long call to
+// count as a LEAPS that can cover short calls in place of stock
+const LEAPSMinDays = 270
+
 type OptionService struct {
 	db *sql.DB
 }
@@ -25,19 +42,43 @@ func (s *OptionService) Create(symbol, optionType string, opened time.Time, stri
 }
 
 func (s *OptionService) CreateWithCommission(symbol, optionType string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64) (*Option, error) {
+	return s.CreateWithDirection(symbol, optionType, DirectionShort, opened, strike, expiration, premium, contracts, commission)
+}
+
+// CreateWithDirection creates a sold (short) or bought (long) option
+func (s *OptionService) CreateWithDirection(symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64) (*Option, error) {
+	return s.create(nil, symbol, optionType, direction, opened, strike, expiration, premium, contracts, commission, nil, nil)
+}
+
+// CreateClosed records an option that is already closed, as imported trade
+// history is. The commission covers both opening and closing.
+func (s *OptionService) CreateClosed(symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64, closed time.Time, exitPrice float64) (*Option, error) {
+	return s.create(nil, symbol, optionType, direction, opened, strike, expiration, premium, contracts, commission, &closed, &exitPrice)
+}
+
+// CreateInAccount creates an open or closed option directly in an account, so
+// the record never exists unassigned. A nil account leaves it unassigned.
+func (s *OptionService) CreateInAccount(accountID *int, symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64, closed *time.Time, exitPrice *float64) (*Option, error) {
+	return s.create(accountID, symbol, optionType, direction, opened, strike, expiration, premium, contracts, commission, closed, exitPrice)
+}
+
+func (s *OptionService) create(accountID *int, symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64, closed *time.Time, exitPrice *float64) (*Option, error) {
 	if optionType != "Put" && optionType != "Call" {
 		return nil, fmt.Errorf("option type must be 'Put' or 'Call'")
 	}
+	if direction != DirectionShort && direction != DirectionLong {
+		return nil, fmt.Errorf("direction must be 'short' or 'long'")
+	}
 
-	query := `INSERT INTO options (symbol, type, opened, strike, expiration, premium, contracts, commission) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?) 
-			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at`
+	query := `INSERT INTO options (symbol, type, opened, strike, expiration, premium, contracts, commission, direction, closed, exit_price, account_id) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) 
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
 
 	var option Option
-	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission).Scan(
+	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission, direction, closed, exitPrice, accountID).Scan(
 		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed, &option.Strike,
 		&option.Expiration, &option.Premium, &option.Contracts, &option.ExitPrice, &option.Commission,
-		&option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt,
+		&option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create option: %w", err)
@@ -47,7 +88,7 @@ func (s *OptionService) CreateWithCommission(symbol, optionType string, opened t
 }
 
 func (s *OptionService) GetBySymbol(symbol string) ([]*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100) 
 			  FROM options WHERE symbol = ? ORDER BY expiration DESC, opened DESC`
 
 	rows, err := s.db.Query(query, symbol)
@@ -61,7 +102,37 @@ func (s *OptionService) GetBySymbol(symbol string) ([]*Option, error) {
 		var option Option
 		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier); err != nil {
+			return nil, fmt.Errorf("failed to scan option: %w", err)
+		}
+		options = append(options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating options: %w", err)
+	}
+
+	return options, nil
+}
+
+// GetBySymbolInAccount returns the options of a symbol held in an account. A
+// nil account returns the unassigned options.
+func (s *OptionService) GetBySymbolInAccount(symbol string, accountID *int) ([]*Option, error) {
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100) 
+			  FROM options WHERE symbol = ? AND account_id IS ? ORDER BY expiration DESC, opened DESC`
+
+	rows, err := s.db.Query(query, symbol, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options: %w", err)
+	}
+	defer rows.Close()
+
+	var options []*Option
+	for rows.Next() {
+		var option Option
+		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
+			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier); err != nil {
 			return nil, fmt.Errorf("failed to scan option: %w", err)
 		}
 		options = append(options, &option)
@@ -75,7 +146,7 @@ func (s *OptionService) GetBySymbol(symbol string) ([]*Option, error) {
 }
 
 func (s *OptionService) GetAll() ([]*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100) 
 			  FROM options ORDER BY expiration DESC, opened DESC`
 
 	rows, err := s.db.Query(query)
@@ -89,7 +160,7 @@ func (s *OptionService) GetAll() ([]*Option, error) {
 		var option Option
 		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier); err != nil {
 			return nil, fmt.Errorf("failed to scan option: %w", err)
 		}
 		options = append(options, &option)
@@ -102,8 +173,34 @@ func (s *OptionService) GetAll() ([]*Option, error) {
 	return options, nil
 }
 
+// GetClosingCommissions returns the commission charged when each option was
+// closed, keyed by option ID. It is part of the option's total commission.
+func (s *OptionService) GetClosingCommissions() (map[int]float64, error) {
+	rows, err := s.db.Query(`SELECT id, closing_commission FROM options WHERE closing_commission != 0`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closing commissions: %w", err)
+	}
+	defer rows.Close()
+
+	commissions := make(map[int]float64)
+	for rows.Next() {
+		var id int
+		var commission float64
+		if err := rows.Scan(&id, &commission); err != nil {
+			return nil, fmt.Errorf("failed to scan closing commission: %w", err)
+		}
+		commissions[id] = commission
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating closing commissions: %w", err)
+	}
+
+	return commissions, nil
+}
+
 func (s *OptionService) GetOpen() ([]*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100) 
 			  FROM options WHERE closed IS NULL ORDER BY expiration ASC`
 
 	rows, err := s.db.Query(query)
@@ -117,7 +214,7 @@ func (s *OptionService) GetOpen() ([]*Option, error) {
 		var option Option
 		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier); err != nil {
 			return nil, fmt.Errorf("failed to scan option: %w", err)
 		}
 		options = append(options, &option)
@@ -130,15 +227,17 @@ func (s *OptionService) GetOpen() ([]*Option, error) {
 	return options, nil
 }
 
-func (s *OptionService) Close(symbol, optionType string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, closed time.Time, exitPrice float64) error {
+// Close closes the open option with the given terms. Direction is part of the
+// key: a sold and a bought option can otherwise share every term.
+func (s *OptionService) Close(symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, closed time.Time, exitPrice float64) error {
 	// Calculate closing commission: $0.65 per contract
 	closingCommission := OptionCommissionPerContract * float64(contracts)
 
 	query := `UPDATE options 
-			  SET closed = ?, exit_price = ?, commission = commission + ?, updated_at = CURRENT_TIMESTAMP 
-			  WHERE symbol = ? AND type = ? AND opened = ? AND strike = ? AND expiration = ? AND premium = ? AND contracts = ?`
+			  SET closed = ?, exit_price = ?, commission = commission + ?, closing_commission = closing_commission + ?, updated_at = CURRENT_TIMESTAMP 
+			  WHERE symbol = ? AND type = ? AND direction = ? AND opened = ? AND strike = ? AND expiration = ? AND premium = ? AND contracts = ? AND closed IS NULL`
 
-	result, err := s.db.Exec(query, closed, exitPrice, closingCommission, symbol, optionType, opened, strike, expiration, premium, contracts)
+	result, err := s.db.Exec(query, closed, exitPrice, closingCommission, closingCommission, symbol, optionType, direction, opened, strike, expiration, premium, contracts)
 	if err != nil {
 		return fmt.Errorf("failed to close option: %w", err)
 	}
@@ -155,9 +254,17 @@ func (s *OptionService) Close(symbol, optionType string, opened time.Time, strik
 	return nil
 }
 
-func (s *OptionService) Delete(symbol, optionType string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int) error {
-	query := `DELETE FROM options WHERE symbol = ? AND type = ? AND opened = ? AND strike = ? AND expiration = ? AND premium = ? AND contracts = ?`
-	result, err := s.db.Exec(query, symbol, optionType, opened, strike, expiration, premium, contracts)
+// Delete deletes the options with the given terms, including direction
+func (s *OptionService) Delete(symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int) error {
+	// Options rolled from this one start their own chain
+	unlinkQuery := `UPDATE options SET parent_option_id = NULL 
+			  WHERE parent_option_id IN (SELECT id FROM options WHERE symbol = ? AND type = ? AND direction = ? AND opened = ? AND strike = ? AND expiration = ? AND premium = ? AND contracts = ?)`
+	if _, err := s.db.Exec(unlinkQuery, symbol, optionType, direction, opened, strike, expiration, premium, contracts); err != nil {
+		return fmt.Errorf("failed to unlink rolled options: %w", err)
+	}
+
+	query := `DELETE FROM options WHERE symbol = ? AND type = ? AND direction = ? AND opened = ? AND strike = ? AND expiration = ? AND premium = ? AND contracts = ?`
+	result, err := s.db.Exec(query, symbol, optionType, direction, opened, strike, expiration, premium, contracts)
 	if err != nil {
 		return fmt.Errorf("failed to delete option: %w", err)
 	}
@@ -176,14 +283,14 @@ func (s *OptionService) Delete(symbol, optionType string, opened time.Time, stri
 
 // GetByID retrieves an option by its ID
 func (s *OptionService) GetByID(id int) (*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100) 
 			  FROM options WHERE id = ?`
 
 	var option Option
 	err := s.db.QueryRow(query, id).Scan(
 		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 		&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt,
+		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -196,21 +303,25 @@ func (s *OptionService) GetByID(id int) (*Option, error) {
 }
 
 // UpdateByID updates an option by its ID
-func (s *OptionService) UpdateByID(id int, symbol, optionType string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64, closed *time.Time, exitPrice *float64) (*Option, error) {
+func (s *OptionService) UpdateByID(id int, symbol, optionType, direction string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int, commission float64, closed *time.Time, exitPrice *float64) (*Option, error) {
 	if optionType != "Put" && optionType != "Call" {
 		return nil, fmt.Errorf("option type must be 'Put' or 'Call'")
 	}
+	if direction != DirectionShort && direction != DirectionLong {
+		return nil, fmt.Errorf("direction must be 'short' or 'long'")
+	}
 
 	query := `UPDATE options 
-			  SET symbol = ?, type = ?, opened = ?, strike = ?, expiration = ?, premium = ?, contracts = ?, commission = ?, closed = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
+			  SET symbol = ?, type = ?, direction = ?, opened = ?, strike = ?, expiration = ?, premium = ?, contracts = ?, commission = ?, closed = ?, exit_price = ?,
+			      close_reason = CASE WHEN ? IS NULL THEN NULL ELSE close_reason END, updated_at = CURRENT_TIMESTAMP 
 			  WHERE id = ? 
-			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at`
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
 
 	var option Option
-	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission, closed, exitPrice, id).Scan(
+	err := s.db.QueryRow(query, symbol, optionType, direction, opened, strike, expiration, premium, contracts, commission, closed, exitPrice, closed, id).Scan(
 		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 		&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt,
+		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -224,6 +335,11 @@ func (s *OptionService) UpdateByID(id int, symbol, optionType string, opened tim
 
 // DeleteByID deletes an option by its ID
 func (s *OptionService) DeleteByID(id int) error {
+	// Options rolled from this one start their own chain
+	if _, err := s.db.Exec(`UPDATE options SET parent_option_id = NULL WHERE parent_option_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to unlink rolled options: %w", err)
+	}
+
 	query := `DELETE FROM options WHERE id = ?`
 	result, err := s.db.Exec(query, id)
 	if err != nil {
@@ -254,10 +370,10 @@ func (s *OptionService) CloseByID(id int, closed time.Time, exitPrice float64) e
 	closingCommission := OptionCommissionPerContract * float64(option.Contracts)
 
 	query := `UPDATE options 
-			  SET closed = ?, exit_price = ?, commission = commission + ?, updated_at = CURRENT_TIMESTAMP 
+			  SET closed = ?, exit_price = ?, commission = commission + ?, closing_commission = closing_commission + ?, updated_at = CURRENT_TIMESTAMP 
 			  WHERE id = ?`
 
-	result, err := s.db.Exec(query, closed, exitPrice, closingCommission, id)
+	result, err := s.db.Exec(query, closed, exitPrice, closingCommission, closingCommission, id)
 	if err != nil {
 		return fmt.Errorf("failed to close option: %w", err)
 	}
@@ -274,6 +390,452 @@ func (s *OptionService) CloseByID(id int, closed time.Time, exitPrice float64) e
 	return nil
 }
 
+// CloseContracts closes some or all of an open option's contracts. Closing all
+// of them is the same as CloseByID. Otherwise the closed contracts split off
+// into a new closed option carrying their proportional share of the opening
+// commission plus the closing commission, and the original option stays open
+// with the remaining contracts and commission. The split keeps the original's
+// campaign, strategy and roll links. Contracts closed on the same date at the
+// same exit price as an earlier split join that closed option instead of
+// splitting off again. Returns the closed part and the remaining open part
+// (nil when everything was closed).
+func (s *OptionService) CloseContracts(id, contracts int, closed time.Time, exitPrice float64) (*Option, *Option, error) {
+	option, err := s.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if option.Closed != nil {
+		return nil, nil, fmt.Errorf("option is already closed")
+	}
+	if contracts <= 0 || contracts > option.Contracts {
+		return nil, nil, fmt.Errorf("contracts to close must be between 1 and %d", option.Contracts)
+	}
+	if closed.Before(option.Opened) {
+		return nil, nil, fmt.Errorf("closed date cannot be before opened date")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	splitID, err := closedSplitTx(tx, option, closed, exitPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if contracts == option.Contracts {
+		// Fold an earlier split on the same terms back in, it would otherwise be
+		// the same closed option twice
+		var splitContracts int
+		var splitCommission, splitClosingCommission float64
+		if splitID != 0 {
+			if _, err := tx.Exec(`UPDATE options SET parent_option_id = ? WHERE parent_option_id = ?`, id, splitID); err != nil {
+				return nil, nil, fmt.Errorf("failed to relink rolled options: %w", err)
+			}
+			err := tx.QueryRow(`DELETE FROM options WHERE id = ? RETURNING contracts, commission, closing_commission`, splitID).Scan(&splitContracts, &splitCommission, &splitClosingCommission)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to merge closed contracts: %w", err)
+			}
+		}
+
+		query := `UPDATE options 
+				  SET closed = ?, exit_price = ?, contracts = contracts + ?, commission = commission + ?, closing_commission = closing_commission + ?, updated_at = CURRENT_TIMESTAMP 
+				  WHERE id = ? AND closed IS NULL AND contracts = ?
+				  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+
+		closingCommission := OptionCommissionPerContract * float64(contracts)
+		var closedOption Option
+		err := tx.QueryRow(query, closed, exitPrice, splitContracts, splitCommission+closingCommission, splitClosingCommission+closingCommission, id, option.Contracts).Scan(
+			&closedOption.ID, &closedOption.Symbol, &closedOption.Type, &closedOption.Opened, &closedOption.Closed,
+			&closedOption.Strike, &closedOption.Expiration, &closedOption.Premium, &closedOption.Contracts,
+			&closedOption.ExitPrice, &closedOption.Commission, &closedOption.CurrentPrice, &closedOption.CloseReason, &closedOption.ParentOptionID, &closedOption.Direction, &closedOption.StrategyID, &closedOption.CreatedAt, &closedOption.UpdatedAt, &closedOption.ContractMultiplier,
+		)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil, fmt.Errorf("option changed while closing, try again")
+			}
+			return nil, nil, fmt.Errorf("failed to close option: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, nil, fmt.Errorf("failed to commit close: %w", err)
+		}
+		return &closedOption, nil, nil
+	}
+
+	// Round the closed part's share to cents; the remaining part keeps the rest
+	openingShare := math.Round(option.Commission*float64(contracts)/float64(option.Contracts)*100) / 100
+	closingCommission := math.Round(OptionCommissionPerContract*float64(contracts)*100) / 100
+	closedCommission := math.Round((openingShare+closingCommission)*100) / 100
+
+	query := `UPDATE options 
+			  SET contracts = contracts - ?, commission = commission - ?, updated_at = CURRENT_TIMESTAMP 
+			  WHERE id = ? AND closed IS NULL AND contracts = ?
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+
+	var remaining Option
+	err = tx.QueryRow(query, contracts, openingShare, id, option.Contracts).Scan(
+		&remaining.ID, &remaining.Symbol, &remaining.Type, &remaining.Opened, &remaining.Closed,
+		&remaining.Strike, &remaining.Expiration, &remaining.Premium, &remaining.Contracts,
+		&remaining.ExitPrice, &remaining.Commission, &remaining.CurrentPrice, &remaining.CloseReason, &remaining.ParentOptionID, &remaining.Direction, &remaining.StrategyID, &remaining.CreatedAt, &remaining.UpdatedAt, &remaining.ContractMultiplier,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("option changed while closing, try again")
+		}
+		return nil, nil, fmt.Errorf("failed to reduce open option: %w", err)
+	}
+
+	var closedPart Option
+	var row *sql.Row
+	if splitID != 0 {
+		query = `UPDATE options 
+				 SET contracts = contracts + ?, commission = commission + ?, closing_commission = closing_commission + ?, updated_at = CURRENT_TIMESTAMP 
+				 WHERE id = ? 
+				 RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+		row = tx.QueryRow(query, contracts, closedCommission, closingCommission, splitID)
+	} else {
+		query = `INSERT INTO options (symbol, type, direction, opened, closed, strike, expiration, premium, contracts, exit_price, commission, closing_commission, current_price, parent_option_id, strategy_id, campaign_id, account_id, split_from_option_id) 
+				 SELECT symbol, type, direction, opened, ?, strike, expiration, premium, ?, ?, ?, ?, current_price, parent_option_id, strategy_id, campaign_id, account_id, id 
+				 FROM options WHERE id = ? 
+				 RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+		row = tx.QueryRow(query, closed, contracts, exitPrice, closedCommission, closingCommission, id)
+	}
+	err = row.Scan(
+		&closedPart.ID, &closedPart.Symbol, &closedPart.Type, &closedPart.Opened, &closedPart.Closed,
+		&closedPart.Strike, &closedPart.Expiration, &closedPart.Premium, &closedPart.Contracts,
+		&closedPart.ExitPrice, &closedPart.Commission, &closedPart.CurrentPrice, &closedPart.CloseReason, &closedPart.ParentOptionID, &closedPart.Direction, &closedPart.StrategyID, &closedPart.CreatedAt, &closedPart.UpdatedAt, &closedPart.ContractMultiplier,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to split closed contracts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit partial close: %w", err)
+	}
+
+	return &closedPart, &remaining, nil
+}
+
+// closedSplitTx returns the ID of the option already split off from the given
+// one by a partial close on the same date at the same exit price, or 0. Only
+// parts split from this option that are still in its account qualify.
+func closedSplitTx(tx *sql.Tx, option *Option, closed time.Time, exitPrice float64) (int, error) {
+	query := `SELECT id FROM options 
+			  WHERE split_from_option_id = ? AND account_id IS (SELECT account_id FROM options WHERE id = ?) 
+			  AND closed = ? AND exit_price = ? 
+			  LIMIT 1`
+
+	var id int
+	err := tx.QueryRow(query, option.ID, option.ID, closed, exitPrice).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find earlier partial close: %w", err)
+	}
+	return id, nil
+}
+
+// Assign closes an open put as assigned and opens the resulting long position of
+// contracts*multiplier shares at the strike in a single transaction. The new
+// position joins the put's campaign, if any. Cash-settled index options cannot
+// be assigned; they are closed at their settlement value instead.
+func (s *OptionService) Assign(id int, assigned time.Time) (*Option, *LongPosition, error) {
+	option, err := s.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if option.Type != "Put" || option.IsLong() {
+		return nil, nil, fmt.Errorf("only short puts can be assigned")
+	}
+	if option.Closed != nil {
+		return nil, nil, fmt.Errorf("option is already closed")
+	}
+	if assigned.Before(option.Opened) {
+		return nil, nil, fmt.Errorf("assignment date cannot be before opened date")
+	}
+	instrument, err := getInstrument(s.db, option.Symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	if instrument.IsCashSettled() {
+		return nil, nil, fmt.Errorf("%s options are cash settled and cannot be assigned; close the option at its settlement value", option.Symbol)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var campaignID, accountID *int
+	query := `UPDATE options 
+			  SET closed = ?, exit_price = 0, close_reason = ?, updated_at = CURRENT_TIMESTAMP 
+			  WHERE id = ? AND closed IS NULL
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100), campaign_id, account_id`
+
+	var closed Option
+	err = tx.QueryRow(query, assigned, CloseReasonAssigned, id).Scan(
+		&closed.ID, &closed.Symbol, &closed.Type, &closed.Opened, &closed.Closed,
+		&closed.Strike, &closed.Expiration, &closed.Premium, &closed.Contracts,
+		&closed.ExitPrice, &closed.Commission, &closed.CurrentPrice, &closed.CloseReason, &closed.ParentOptionID, &closed.Direction, &closed.StrategyID, &closed.CreatedAt, &closed.UpdatedAt, &closed.ContractMultiplier,
+		&campaignID, &accountID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("option is already closed")
+		}
+		return nil, nil, fmt.Errorf("failed to close assigned put: %w", err)
+	}
+
+	query = `INSERT INTO long_positions (symbol, opened, shares, buy_price, campaign_id, account_id) 
+			 VALUES (?, ?, ?, ?, ?, ?) 
+			 RETURNING id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at`
+
+	var position LongPosition
+	err = tx.QueryRow(query, closed.Symbol, assigned, closed.Contracts*instrument.Multiplier(), closed.Strike, campaignID, accountID).Scan(
+		&position.ID, &position.Symbol, &position.Opened, &position.Closed, &position.Shares,
+		&position.BuyPrice, &position.ExitPrice, &position.CreatedAt, &position.UpdatedAt,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create assigned long position: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit assignment: %w", err)
+	}
+
+	return &closed, &position, nil
+}
+
+// CallAway closes an open call as called away and, in the same transaction,
+// closes contracts*multiplier shares of open stock at the strike. Lots in the call's
+// campaign are used first, then the oldest lots, all from the call's account;
+// an unassigned call only uses unassigned lots. A lot that is only partly
+// needed is split. It fails without changes if there are not enough shares.
+func (s *OptionService) CallAway(id int, calledAway time.Time) (*Option, []*LongPosition, error) {
+	option, err := s.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if option.Type != "Call" || option.IsLong() {
+		return nil, nil, fmt.Errorf("only short calls can be called away")
+	}
+	if option.Closed != nil {
+		return nil, nil, fmt.Errorf("option is already closed")
+	}
+	if calledAway.Before(option.Opened) {
+		return nil, nil, fmt.Errorf("called away date cannot be before opened date")
+	}
+	instrument, err := getInstrument(s.db, option.Symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	if instrument.IsCashSettled() {
+		return nil, nil, fmt.Errorf("%s options are cash settled and cannot be called away; close the option at its settlement value", option.Symbol)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var campaignID, accountID *int
+	if err := tx.QueryRow(`SELECT campaign_id, account_id FROM options WHERE id = ?`, id).Scan(&campaignID, &accountID); err != nil {
+		return nil, nil, fmt.Errorf("failed to get option campaign: %w", err)
+	}
+
+	// A call can only be covered by shares held in the same account, or by
+	// unassigned shares if the call is unassigned
+	rows, err := tx.Query(`SELECT id, shares, campaign_id FROM long_positions 
+			  WHERE symbol = ? AND closed IS NULL AND opened <= ? AND account_id IS ? 
+			  ORDER BY opened ASC, id ASC`, option.Symbol, calledAway, accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get open long positions: %w", err)
+	}
+
+	type openLot struct {
+		id         int
+		shares     int
+		campaignID *int
+	}
+	var campaignLots, otherLots []openLot
+	availableShares := 0
+	for rows.Next() {
+		var lot openLot
+		if err := rows.Scan(&lot.id, &lot.shares, &lot.campaignID); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan open long position: %w", err)
+		}
+		availableShares += lot.shares
+		if campaignID != nil && lot.campaignID != nil && *lot.campaignID == *campaignID {
+			campaignLots = append(campaignLots, lot)
+		} else {
+			otherLots = append(otherLots, lot)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, nil, fmt.Errorf("error iterating open long positions: %w", err)
+	}
+	rows.Close()
+
+	sharesNeeded := option.Contracts * instrument.Multiplier()
+	if availableShares < sharesNeeded {
+		return nil, nil, fmt.Errorf("not enough shares to cover call: need %d, have %d", sharesNeeded, availableShares)
+	}
+
+	var closedLots []*LongPosition
+	remaining := sharesNeeded
+	for _, lot := range append(campaignLots, otherLots...) {
+		if remaining == 0 {
+			break
+		}
+		shares := lot.shares
+		if shares > remaining {
+			shares = remaining
+		}
+		closedLot, err := closeLotTx(tx, lot.id, shares, calledAway, option.Strike)
+		if err != nil {
+			return nil, nil, err
+		}
+		closedLots = append(closedLots, closedLot)
+		remaining -= shares
+	}
+
+	query := `UPDATE options 
+			  SET closed = ?, exit_price = 0, close_reason = ?, updated_at = CURRENT_TIMESTAMP 
+			  WHERE id = ? AND closed IS NULL
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+
+	var closed Option
+	err = tx.QueryRow(query, calledAway, CloseReasonCalledAway, id).Scan(
+		&closed.ID, &closed.Symbol, &closed.Type, &closed.Opened, &closed.Closed,
+		&closed.Strike, &closed.Expiration, &closed.Premium, &closed.Contracts,
+		&closed.ExitPrice, &closed.Commission, &closed.CurrentPrice, &closed.CloseReason, &closed.ParentOptionID, &closed.Direction, &closed.StrategyID, &closed.CreatedAt, &closed.UpdatedAt, &closed.ContractMultiplier,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("option is already closed")
+		}
+		return nil, nil, fmt.Errorf("failed to close called away call: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit called away: %w", err)
+	}
+
+	return &closed, closedLots, nil
+}
+
+// Roll closes an open option as rolled and, in the same transaction, opens its
+// successor of the same type and direction with the given strike, expiration, premium and
+// contracts. Standard commissions are charged on both legs. The successor
+// links back through parent_option_id and joins the rolled option's campaign.
+func (s *OptionService) Roll(id int, rolled time.Time, exitPrice, strike float64, expiration time.Time, premium float64, contracts int) (*Option, *Option, error) {
+	option, err := s.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if option.Closed != nil {
+		return nil, nil, fmt.Errorf("option is already closed")
+	}
+	if rolled.Before(option.Opened) {
+		return nil, nil, fmt.Errorf("roll date cannot be before opened date")
+	}
+	if expiration.Before(rolled) {
+		return nil, nil, fmt.Errorf("new expiration cannot be before roll date")
+	}
+	if contracts <= 0 {
+		return nil, nil, fmt.Errorf("contracts must be greater than 0")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var campaignID, accountID *int
+	closingCommission := OptionCommissionPerContract * float64(option.Contracts)
+	query := `UPDATE options 
+			  SET closed = ?, exit_price = ?, commission = commission + ?, closing_commission = closing_commission + ?, close_reason = ?, updated_at = CURRENT_TIMESTAMP 
+			  WHERE id = ? AND closed IS NULL
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100), campaign_id, account_id`
+
+	var closed Option
+	err = tx.QueryRow(query, rolled, exitPrice, closingCommission, closingCommission, CloseReasonRolled, id).Scan(
+		&closed.ID, &closed.Symbol, &closed.Type, &closed.Opened, &closed.Closed,
+		&closed.Strike, &closed.Expiration, &closed.Premium, &closed.Contracts,
+		&closed.ExitPrice, &closed.Commission, &closed.CurrentPrice, &closed.CloseReason, &closed.ParentOptionID, &closed.Direction, &closed.StrategyID, &closed.CreatedAt, &closed.UpdatedAt, &closed.ContractMultiplier,
+		&campaignID, &accountID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("option is already closed")
+		}
+		return nil, nil, fmt.Errorf("failed to close rolled option: %w", err)
+	}
+
+	openingCommission := OptionCommissionPerContract * float64(contracts)
+	query = `INSERT INTO options (symbol, type, direction, opened, strike, expiration, premium, contracts, commission, parent_option_id, campaign_id, account_id) 
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) 
+			 RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+
+	var opened Option
+	err = tx.QueryRow(query, closed.Symbol, closed.Type, closed.Direction, rolled, strike, expiration, premium, contracts, openingCommission, closed.ID, campaignID, accountID).Scan(
+		&opened.ID, &opened.Symbol, &opened.Type, &opened.Opened, &opened.Closed,
+		&opened.Strike, &opened.Expiration, &opened.Premium, &opened.Contracts,
+		&opened.ExitPrice, &opened.Commission, &opened.CurrentPrice, &opened.CloseReason, &opened.ParentOptionID, &opened.Direction, &opened.StrategyID, &opened.CreatedAt, &opened.UpdatedAt, &opened.ContractMultiplier,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open rolled option: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit roll: %w", err)
+	}
+
+	return &closed, &opened, nil
+}
+
+// GetRollChain returns the roll chain containing the option, or a single-leg
+// chain if the option was never rolled
+func (s *OptionService) GetRollChain(id int) (*RollChain, error) {
+	option, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.GetBySymbol(option.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chain := range BuildRollChains(options) {
+		for _, leg := range chain.Options {
+			if leg.ID == id {
+				return chain, nil
+			}
+		}
+	}
+
+	return NewRollChain([]*Option{option}), nil
+}
+
+// GetRollChains returns every roll chain across all symbols
+func (s *OptionService) GetRollChains() ([]*RollChain, error) {
+	options, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return BuildRollChains(options), nil
+}
+
 func (s *OptionService) DeleteBySymbol(symbol string) error {
 	query := `DELETE FROM options WHERE symbol = ?`
 	result, err := s.db.Exec(query, symbol)
@@ -309,24 +871,26 @@ type OpenPositionData struct {
 	EntryDate        time.Time `json:"entry_date"`
 }
 
-// GetOptionsSummaryBySymbol returns options summary data grouped by symbol
-func (s *OptionService) GetOptionsSummaryBySymbol() ([]*OptionSummary, error) {
+// GetOptionsSummaryBySymbol returns options summary data grouped by symbol, for one account
+// or for all accounts if accountID is nil
+func (s *OptionService) GetOptionsSummaryBySymbol(accountID *int) ([]*OptionSummary, error) {
 	query := `
 		SELECT 
 			symbol,
 			COUNT(*) as total_positions,
 			SUM(CASE WHEN type = 'Put' THEN 1 ELSE 0 END) as put_positions,
 			SUM(CASE WHEN type = 'Call' THEN 1 ELSE 0 END) as call_positions,
-			SUM(premium) as total_premium,
-			SUM(CASE WHEN type = 'Put' THEN premium ELSE 0 END) as put_premium,
-			SUM(CASE WHEN type = 'Call' THEN premium ELSE 0 END) as call_premium,
-			SUM(premium) as net_premium
+			SUM(CASE WHEN direction = 'long' THEN -premium ELSE premium END) as total_premium,
+			SUM(CASE WHEN type = 'Put' THEN CASE WHEN direction = 'long' THEN -premium ELSE premium END ELSE 0 END) as put_premium,
+			SUM(CASE WHEN type = 'Call' THEN CASE WHEN direction = 'long' THEN -premium ELSE premium END ELSE 0 END) as call_premium,
+			SUM(CASE WHEN direction = 'long' THEN -premium ELSE premium END) as net_premium
 		FROM options 
 		WHERE closed IS NULL
+		AND (? IS NULL OR account_id = ?)
 		GROUP BY symbol 
 		ORDER BY symbol`
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query(query, accountID, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get options summary: %w", err)
 	}
@@ -388,24 +952,26 @@ func (s *OptionService) GetOpenPositionsWithDetails() ([]*OpenPositionData, erro
 	return openPositions, nil
 }
 
-// GetOptionsSummaryTotals returns aggregate totals for all options
-func (s *OptionService) GetOptionsSummaryTotals() (*OptionSummary, error) {
+// GetOptionsSummaryTotals returns aggregate totals for all options, for one account or for
+// all accounts if accountID is nil
+func (s *OptionService) GetOptionsSummaryTotals(accountID *int) (*OptionSummary, error) {
 	query := `
 		SELECT 
 			COUNT(*) as total_positions,
-			SUM(CASE WHEN type = 'Put' THEN 1 ELSE 0 END) as put_positions,
-			SUM(CASE WHEN type = 'Call' THEN 1 ELSE 0 END) as call_positions,
-			SUM(premium) as total_premium,
-			SUM(CASE WHEN type = 'Put' THEN premium ELSE 0 END) as put_premium,
-			SUM(CASE WHEN type = 'Call' THEN premium ELSE 0 END) as call_premium,
-			SUM(premium) as net_premium
+			COALESCE(SUM(CASE WHEN type = 'Put' THEN 1 ELSE 0 END), 0) as put_positions,
+			COALESCE(SUM(CASE WHEN type = 'Call' THEN 1 ELSE 0 END), 0) as call_positions,
+			COALESCE(SUM(CASE WHEN direction = 'long' THEN -premium ELSE premium END), 0) as total_premium,
+			COALESCE(SUM(CASE WHEN type = 'Put' THEN CASE WHEN direction = 'long' THEN -premium ELSE premium END ELSE 0 END), 0) as put_premium,
+			COALESCE(SUM(CASE WHEN type = 'Call' THEN CASE WHEN direction = 'long' THEN -premium ELSE premium END ELSE 0 END), 0) as call_premium,
+			COALESCE(SUM(CASE WHEN direction = 'long' THEN -premium ELSE premium END), 0) as net_premium
 		FROM options 
-		WHERE closed IS NULL`
+		WHERE closed IS NULL
+		AND (? IS NULL OR account_id = ?)`
 
 	var totals OptionSummary
 	totals.Symbol = "Total"
 
-	err := s.db.QueryRow(query).Scan(
+	err := s.db.QueryRow(query, accountID, accountID).Scan(
 		&totals.TotalPositions, &totals.PutPositions, &totals.CallPositions,
 		&totals.TotalPremium, &totals.PutPremium, &totals.CallPremium, &totals.NetPremium,
 	)
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"stonks/internal/pricing"
+	"time"
+)
+
+// OptionOdds is how an open option is likely to finish, in percent: the
+// chance it expires out of the money, that the underlying touches the strike
+// before then and that the position is profitable at expiration after its
+// premium. ExpectedMove is the one standard deviation move of the underlying
+// by expiration, MoveLow to MoveHigh around its price. Volatility is the
+// option's latest implied volatility when IsImplied, otherwise the volatility
+// it is priced with.
+type OptionOdds struct {
+	OptionID            int     `json:"option_id"`
+	Volatility          float64 `json:"volatility"`
+	IsImplied           bool    `json:"is_implied"`
+	ProbabilityOTM      float64 `json:"probability_otm"`
+	ProbabilityOfTouch  float64 `json:"probability_of_touch"`
+	ProbabilityOfProfit float64 `json:"probability_of_profit"`
+	Breakeven           float64 `json:"breakeven"`
+	ExpectedMove        float64 `json:"expected_move"`
+	MoveLow             float64 `json:"move_low"`
+	MoveHigh            float64 `json:"move_high"`
+	StrikeInsideMove    bool    `json:"strike_inside_move"`
+}
+
+// Breakeven returns the underlying price at expiration where the option's
+// premium is exactly made back or given up
+func (o *Option) Breakeven() float64 {
+	if o.Type == "Call" {
+		return o.Strike + o.Premium
+	}
+	return o.Strike - o.Premium
+}
+
+// CalculateOdds works out an option's odds from its underlying's price and
+// dividend. implied is the option's latest implied volatility, or nil to use
+// the symbol's volatility or the fallback volatility, in percent like rate.
+func CalculateOdds(option *Option, underlying *Symbol, implied *ImpliedVolatility, rate, volatility float64, now time.Time) (*OptionOdds, error) {
+	if underlying.Price <= 0 {
+		return nil, fmt.Errorf("%s has no price to value options against", underlying.Symbol)
+	}
+
+	odds := &OptionOdds{OptionID: option.ID, Volatility: volatility, Breakeven: option.Breakeven()}
+	if underlying.Volatility != nil {
+		odds.Volatility = *underlying.Volatility
+	}
+	if implied != nil {
+		odds.Volatility = implied.Volatility
+		odds.IsImplied = true
+		rate = implied.Rate
+	}
+
+	in := pricing.Inputs{
+		Spot:          underlying.Price,
+		Strike:        option.Strike,
+		Years:         YearsToExpiration(option.Expiration, now),
+		Rate:          rate / 100,
+		DividendYield: underlying.CalculateYield() / 100,
+		Volatility:    odds.Volatility / 100,
+		Call:          option.Type == "Call",
+	}
+
+	odds.ProbabilityOTM = (1 - pricing.ProbabilityITM(in)) * 100
+	odds.ProbabilityOfTouch = pricing.ProbabilityOfTouch(in) * 100
+
+	// Shorts profit on the side of the breakeven away from the strike
+	above := pricing.ProbabilityAbove(in, odds.Breakeven)
+	profitAbove := (option.Type == "Put") != option.IsLong()
+	if profitAbove {
+		odds.ProbabilityOfProfit = above * 100
+	} else {
+		odds.ProbabilityOfProfit = (1 - above) * 100
+	}
+
+	odds.ExpectedMove = pricing.ExpectedMove(in)
+	odds.MoveLow = underlying.Price - odds.ExpectedMove
+	odds.MoveHigh = underlying.Price + odds.ExpectedMove
+	odds.StrikeInsideMove = math.Abs(option.Strike-underlying.Price) < odds.ExpectedMove
+
+	return odds, nil
+}
+
+// Odds works out the odds of each open option whose underlying has a price,
+// keyed by option ID. implied holds the latest implied volatility of options
+// that have one, as ImpliedVolatilityService.Latest returns it.
+func (s *PricingService) Odds(options []*Option, implied map[int]*ImpliedVolatility, now time.Time) (map[int]*OptionOdds, error) {
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	rate, volatility := s.Rates()
+
+	odds := make(map[int]*OptionOdds)
+	for _, option := range options {
+		underlying, ok := instruments[option.Symbol]
+		if !ok || option.Closed != nil || underlying.Price <= 0 {
+			continue
+		}
+		calculated, err := CalculateOdds(option, underlying, implied[option.ID], rate, volatility, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate odds of option %d: %w", option.ID, err)
+		}
+		odds[option.ID] = calculated
+	}
+
+	return odds, nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateOdds_ShortPutAgainstExpectedMove(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	underlying := &Symbol{Symbol: "AAPL", Price: 100}
+	put := &Option{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 95, Premium: 1.5, Contracts: 1, Expiration: now.AddDate(0, 0, 30)}
+
+	odds, err := CalculateOdds(put, underlying, nil, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to calculate odds: %v", err)
+	}
+	if odds.IsImplied || odds.Volatility != 30 || odds.Breakeven != 93.5 {
+		t.Errorf("Expected the default 30%% volatility and a 93.50 breakeven, got %+v", odds)
+	}
+	if odds.ProbabilityOTM <= 50 || odds.ProbabilityOfProfit <= odds.ProbabilityOTM || odds.ProbabilityOfTouch <= 100-odds.ProbabilityOTM {
+		t.Errorf("Expected P(profit) > P(OTM) > 50%% and P(touch) above P(ITM), got %+v", odds)
+	}
+
+	// 30% over 30.5 days is about an 8.6 point move, so 95 is inside it
+	years := YearsToExpiration(put.Expiration, now)
+	if math.Abs(odds.ExpectedMove-100*0.30*math.Sqrt(years)) > 1e-9 || !odds.StrikeInsideMove || odds.MoveLow >= 95 {
+		t.Errorf("Expected the 95 strike inside a %.2f move, got %+v", 100*0.30*math.Sqrt(years), odds)
+	}
+
+	// A low implied volatility narrows the move and takes precedence
+	implied := &ImpliedVolatility{OptionID: 1, Volatility: 12, Rate: 5}
+	odds, err = CalculateOdds(put, underlying, implied, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to calculate odds: %v", err)
+	}
+	if !odds.IsImplied || odds.Volatility != 12 || odds.StrikeInsideMove || odds.ProbabilityOTM <= 85 {
+		t.Errorf("Expected 12%% IV to put the strike outside the move with high odds, got %+v", odds)
+	}
+
+	// The buyer of the same put profits only below the breakeven
+	long := *put
+	long.Direction = DirectionLong
+	longOdds, _ := CalculateOdds(&long, underlying, implied, 4.5, 30, now)
+	if math.Abs(longOdds.ProbabilityOfProfit+odds.ProbabilityOfProfit-100) > 1e-9 {
+		t.Errorf("Expected the buyer's and seller's odds to add up to 100%%, got %.4f and %.4f", longOdds.ProbabilityOfProfit, odds.ProbabilityOfProfit)
+	}
+
+	if _, err := CalculateOdds(put, &Symbol{Symbol: "AAPL"}, nil, 4.5, 30, now); err == nil {
+		t.Errorf("Expected an unpriced symbol to be rejected")
+	}
+}
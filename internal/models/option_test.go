@@ -0,0 +1,758 @@
+package models
+
+import (
+	"math"
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupOptionTestDB(t *testing.T) *database.DB {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	if _, err := NewSymbolService(testDB.DB).Create("AAPL"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+
+	return testDB
+}
+
+func TestOptionService_Assign(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+	positionService := NewLongPositionService(testDB.DB)
+	campaignService := NewCampaignService(testDB.DB)
+
+	opened := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	put, err := optionService.Create("AAPL", "Put", opened, 170, expiration, 2.50, 2)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+
+	campaign, err := campaignService.Create("AAPL", "AAPL wheel", opened, nil)
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+	if err := campaignService.LinkItems(campaign.ID, CampaignItems{OptionIDs: []int{put.ID}}); err != nil {
+		t.Fatalf("Failed to link put to campaign: %v", err)
+	}
+
+	closed, position, err := optionService.Assign(put.ID, expiration)
+	if err != nil {
+		t.Fatalf("Failed to assign put: %v", err)
+	}
+
+	if closed.Closed == nil || !closed.Closed.Equal(expiration) {
+		t.Errorf("Expected put closed on %v, got %v", expiration, closed.Closed)
+	}
+	if !closed.IsAssigned() {
+		t.Errorf("Expected close reason %q, got %v", CloseReasonAssigned, closed.CloseReason)
+	}
+	if closed.GetExitPriceValue() != 0 {
+		t.Errorf("Expected exit price 0, got %.2f", closed.GetExitPriceValue())
+	}
+	if closed.Commission != put.Commission {
+		t.Errorf("Expected no closing commission on assignment, got %.2f (was %.2f)", closed.Commission, put.Commission)
+	}
+
+	if position.Shares != 200 {
+		t.Errorf("Expected 200 shares, got %d", position.Shares)
+	}
+	if position.BuyPrice != 170 {
+		t.Errorf("Expected buy price 170, got %.2f", position.BuyPrice)
+	}
+	if !position.Opened.Equal(expiration) {
+		t.Errorf("Expected position opened on %v, got %v", expiration, position.Opened)
+	}
+
+	positions, err := positionService.GetBySymbol("AAPL")
+	if err != nil || len(positions) != 1 {
+		t.Fatalf("Expected 1 long position, got %d (err %v)", len(positions), err)
+	}
+
+	loaded, err := campaignService.GetByID(campaign.ID)
+	if err != nil {
+		t.Fatalf("Failed to get campaign: %v", err)
+	}
+	if len(loaded.LongPositions) != 1 || loaded.LongPositions[0].ID != position.ID {
+		t.Errorf("Expected assigned position to join the put's campaign")
+	}
+
+	t.Run("cannot assign twice", func(t *testing.T) {
+		if _, _, err := optionService.Assign(put.ID, expiration); err == nil {
+			t.Error("Expected error assigning a closed put")
+		}
+		positions, _ := positionService.GetBySymbol("AAPL")
+		if len(positions) != 1 {
+			t.Errorf("Expected still 1 long position, got %d", len(positions))
+		}
+	})
+
+	t.Run("calls cannot be assigned", func(t *testing.T) {
+		call, err := optionService.Create("AAPL", "Call", opened, 180, expiration, 1.00, 1)
+		if err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+		if _, _, err := optionService.Assign(call.ID, expiration); err == nil {
+			t.Error("Expected error assigning a call")
+		}
+	})
+
+	t.Run("cash-settled index puts cannot be assigned", func(t *testing.T) {
+		symbolService := NewSymbolService(testDB.DB)
+		if _, err := symbolService.Create("SPX"); err != nil {
+			t.Fatalf("Failed to create symbol: %v", err)
+		}
+		if _, err := symbolService.SetInstrument("SPX", InstrumentIndex, 100); err != nil {
+			t.Fatalf("Failed to classify SPX: %v", err)
+		}
+		spxPut, err := optionService.Create("SPX", "Put", opened, 5000, expiration, 20.00, 1)
+		if err != nil {
+			t.Fatalf("Failed to create put: %v", err)
+		}
+		if _, _, err := optionService.Assign(spxPut.ID, expiration); err == nil {
+			t.Error("Expected error assigning a cash-settled put")
+		}
+		if positions, _ := positionService.GetBySymbol("SPX"); len(positions) != 0 {
+			t.Errorf("Expected no SPX shares, got %d positions", len(positions))
+		}
+	})
+
+	t.Run("reopening clears close reason", func(t *testing.T) {
+		reopened, err := optionService.UpdateByID(closed.ID, closed.Symbol, closed.Type, closed.Direction, closed.Opened, closed.Strike, closed.Expiration, closed.Premium, closed.Contracts, closed.Commission, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to reopen put: %v", err)
+		}
+		if reopened.CloseReason != nil {
+			t.Errorf("Expected close reason cleared, got %q", *reopened.CloseReason)
+		}
+	})
+}
+
+func TestOptionService_CallAway(t *testing.T) {
+	opened := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 4, 19, 0, 0, 0, 0, time.UTC)
+
+	t.Run("partially closes lots oldest first", func(t *testing.T) {
+		testDB := setupOptionTestDB(t)
+		optionService := NewOptionService(testDB.DB)
+		positionService := NewLongPositionService(testDB.DB)
+
+		older, err := positionService.Create("AAPL", opened.AddDate(0, -2, 0), 100, 160)
+		if err != nil {
+			t.Fatalf("Failed to create older lot: %v", err)
+		}
+		newer, err := positionService.Create("AAPL", opened.AddDate(0, -1, 0), 200, 165)
+		if err != nil {
+			t.Fatalf("Failed to create newer lot: %v", err)
+		}
+
+		call, err := optionService.Create("AAPL", "Call", opened, 175, expiration, 1.20, 2)
+		if err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+
+		closed, lots, err := optionService.CallAway(call.ID, expiration)
+		if err != nil {
+			t.Fatalf("Failed to call away: %v", err)
+		}
+
+		if closed.GetCloseReasonLabel() != "Called Away" {
+			t.Errorf("Expected called away close reason, got %v", closed.CloseReason)
+		}
+		if len(lots) != 2 {
+			t.Fatalf("Expected 2 closed lots, got %d", len(lots))
+		}
+		if lots[0].ID != older.ID || lots[0].Shares != 100 {
+			t.Errorf("Expected older lot fully closed, got id %d with %d shares", lots[0].ID, lots[0].Shares)
+		}
+		if lots[1].ID != newer.ID || lots[1].Shares != 100 {
+			t.Errorf("Expected 100 shares of newer lot closed, got id %d with %d shares", lots[1].ID, lots[1].Shares)
+		}
+		for _, lot := range lots {
+			if lot.GetExitPriceValue() != 175 || lot.Closed == nil || !lot.Closed.Equal(expiration) {
+				t.Errorf("Expected lot %d closed at 175 on %v", lot.ID, expiration)
+			}
+		}
+
+		open, err := positionService.GetOpenPositions()
+		if err != nil {
+			t.Fatalf("Failed to get open positions: %v", err)
+		}
+		if len(open) != 1 || open[0].Shares != 100 || open[0].BuyPrice != 165 || !open[0].Opened.Equal(newer.Opened) {
+			t.Errorf("Expected remaining open lot of 100 shares at 165, got %+v", open)
+		}
+	})
+
+	t.Run("refuses without enough shares", func(t *testing.T) {
+		testDB := setupOptionTestDB(t)
+		optionService := NewOptionService(testDB.DB)
+		positionService := NewLongPositionService(testDB.DB)
+
+		if _, err := positionService.Create("AAPL", opened, 150, 160); err != nil {
+			t.Fatalf("Failed to create lot: %v", err)
+		}
+		call, err := optionService.Create("AAPL", "Call", opened, 175, expiration, 1.20, 2)
+		if err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+
+		if _, _, err := optionService.CallAway(call.ID, expiration); err == nil {
+			t.Fatal("Expected error calling away 200 shares with only 150 held")
+		}
+
+		reloaded, err := optionService.GetByID(call.ID)
+		if err != nil {
+			t.Fatalf("Failed to reload call: %v", err)
+		}
+		if reloaded.IsClosed() {
+			t.Error("Expected call to remain open after refused call away")
+		}
+		open, _ := positionService.GetOpenPositions()
+		if len(open) != 1 || open[0].Shares != 150 {
+			t.Errorf("Expected lot untouched, got %+v", open)
+		}
+	})
+
+	t.Run("prefers lots in the call's campaign", func(t *testing.T) {
+		testDB := setupOptionTestDB(t)
+		optionService := NewOptionService(testDB.DB)
+		positionService := NewLongPositionService(testDB.DB)
+		campaignService := NewCampaignService(testDB.DB)
+
+		unrelated, err := positionService.Create("AAPL", opened.AddDate(-1, 0, 0), 100, 120)
+		if err != nil {
+			t.Fatalf("Failed to create unrelated lot: %v", err)
+		}
+		wheelLot, err := positionService.Create("AAPL", opened, 100, 170)
+		if err != nil {
+			t.Fatalf("Failed to create wheel lot: %v", err)
+		}
+		call, err := optionService.Create("AAPL", "Call", opened, 175, expiration, 1.20, 1)
+		if err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+
+		campaign, err := campaignService.Create("AAPL", "AAPL wheel", opened, nil)
+		if err != nil {
+			t.Fatalf("Failed to create campaign: %v", err)
+		}
+		if err := campaignService.LinkItems(campaign.ID, CampaignItems{OptionIDs: []int{call.ID}, LongPositionIDs: []int{wheelLot.ID}}); err != nil {
+			t.Fatalf("Failed to link campaign items: %v", err)
+		}
+
+		_, lots, err := optionService.CallAway(call.ID, expiration)
+		if err != nil {
+			t.Fatalf("Failed to call away: %v", err)
+		}
+		if len(lots) != 1 || lots[0].ID != wheelLot.ID {
+			t.Errorf("Expected campaign lot %d to be closed, got %+v", wheelLot.ID, lots)
+		}
+
+		stillOpen, err := positionService.GetByID(unrelated.ID)
+		if err != nil {
+			t.Fatalf("Failed to get unrelated lot: %v", err)
+		}
+		if stillOpen.Closed != nil {
+			t.Error("Expected unrelated lot to remain open")
+		}
+	})
+
+	t.Run("unassigned call leaves account lots alone", func(t *testing.T) {
+		testDB := setupOptionTestDB(t)
+		optionService := NewOptionService(testDB.DB)
+		positionService := NewLongPositionService(testDB.DB)
+
+		ira, err := NewAccountService(testDB.DB).GetOrCreateByName("IRA")
+		if err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+		if _, err := positionService.CreateInAccount(&ira.ID, "AAPL", opened, 100, 160); err != nil {
+			t.Fatalf("Failed to create IRA lot: %v", err)
+		}
+		call, err := optionService.Create("AAPL", "Call", opened, 175, expiration, 1.20, 1)
+		if err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+
+		if _, _, err := optionService.CallAway(call.ID, expiration); err == nil {
+			t.Fatal("Expected an unassigned call not to be covered by IRA shares")
+		}
+		open, _ := positionService.GetOpenPositions()
+		if len(open) != 1 || open[0].Closed != nil {
+			t.Errorf("Expected the IRA lot untouched, got %+v", open)
+		}
+	})
+}
+
+func TestOptionService_Roll(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+	campaignService := NewCampaignService(testDB.DB)
+
+	opened := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)
+	rolled := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	newExpiration := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+
+	// Put went in the money: buying back for more than it was sold for
+	put, err := optionService.CreateWithCommission("AAPL", "Put", opened, 170, expiration, 2.00, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	campaign, err := campaignService.Create("AAPL", "AAPL wheel", opened, nil)
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+	if err := campaignService.LinkItems(campaign.ID, CampaignItems{OptionIDs: []int{put.ID}}); err != nil {
+		t.Fatalf("Failed to link put to campaign: %v", err)
+	}
+
+	closed, successor, err := optionService.Roll(put.ID, rolled, 4.00, 165, newExpiration, 5.00, 1)
+	if err != nil {
+		t.Fatalf("Failed to roll put: %v", err)
+	}
+
+	if closed.GetCloseReasonLabel() != "Rolled" || closed.GetExitPriceValue() != 4.00 {
+		t.Errorf("Expected put closed as rolled at 4.00, got %v at %.2f", closed.CloseReason, closed.GetExitPriceValue())
+	}
+	if closed.CalculateTotalProfit() >= 0 {
+		t.Errorf("Expected the rolled leg alone to show a loss, got %.2f", closed.CalculateTotalProfit())
+	}
+	if successor.ParentOptionID == nil || *successor.ParentOptionID != put.ID {
+		t.Errorf("Expected successor to link to option %d, got %v", put.ID, successor.ParentOptionID)
+	}
+	if successor.Type != "Put" || successor.Strike != 165 || !successor.Opened.Equal(rolled) || !successor.IsOpen() {
+		t.Errorf("Unexpected successor %+v", successor)
+	}
+
+	loaded, err := campaignService.GetByID(campaign.ID)
+	if err != nil {
+		t.Fatalf("Failed to get campaign: %v", err)
+	}
+	if len(loaded.Options) != 2 {
+		t.Errorf("Expected successor to join the campaign, got %d options", len(loaded.Options))
+	}
+
+	chain, err := optionService.GetRollChain(put.ID)
+	if err != nil {
+		t.Fatalf("Failed to get roll chain: %v", err)
+	}
+	if len(chain.Options) != 2 || chain.Root().ID != put.ID || chain.Current().ID != successor.ID {
+		t.Fatalf("Expected chain of put and successor, got %d legs", len(chain.Options))
+	}
+
+	// (2.00 - 4.00) * 100 - 0.65 closing + 5.00 * 100 - 0.65 opening
+	if math.Abs(chain.NetCredit-298.70) > 0.001 {
+		t.Errorf("Expected net credit 298.70, got %.2f", chain.NetCredit)
+	}
+	if !chain.IsNetCredit() || !chain.IsOpen() || chain.Rolls() != 1 {
+		t.Errorf("Expected open net credit chain with 1 roll")
+	}
+	if chain.CumulativeDTE != 51 {
+		t.Errorf("Expected cumulative DTE 51, got %d", chain.CumulativeDTE)
+	}
+	if chain.CapitalAtRisk != 17000 {
+		t.Errorf("Expected capital at risk 17000, got %.2f", chain.CapitalAtRisk)
+	}
+
+	t.Run("cannot roll a closed option", func(t *testing.T) {
+		if _, _, err := optionService.Roll(put.ID, rolled, 1.00, 160, newExpiration, 2.00, 1); err == nil {
+			t.Error("Expected error rolling a closed option")
+		}
+	})
+
+	t.Run("chains continue through later rolls", func(t *testing.T) {
+		_, third, err := optionService.Roll(successor.ID, newExpiration.AddDate(0, 0, -3), 1.00, 160, newExpiration.AddDate(0, 1, 0), 2.50, 1)
+		if err != nil {
+			t.Fatalf("Failed to roll successor: %v", err)
+		}
+		chains, err := optionService.GetRollChains()
+		if err != nil {
+			t.Fatalf("Failed to get roll chains: %v", err)
+		}
+		if len(chains) != 1 || len(chains[0].Options) != 3 || chains[0].Current().ID != third.ID {
+			t.Fatalf("Expected a single 3-leg chain ending at option %d", third.ID)
+		}
+	})
+
+	t.Run("partly closed legs stay in the chain", func(t *testing.T) {
+		root, err := optionService.CreateWithCommission("AAPL", "Call", opened, 200, expiration, 3.00, 3, 0)
+		if err != nil {
+			t.Fatalf("Failed to create call: %v", err)
+		}
+		_, rolledCall, err := optionService.Roll(root.ID, rolled, 1.00, 205, newExpiration, 4.00, 3)
+		if err != nil {
+			t.Fatalf("Failed to roll call: %v", err)
+		}
+		// Buy back 1 of the 3 rolled contracts, then roll the other 2
+		split, _, err := optionService.CloseContracts(rolledCall.ID, 1, rolled.AddDate(0, 0, 7), 2.00)
+		if err != nil {
+			t.Fatalf("Failed to close 1 contract: %v", err)
+		}
+		_, last, err := optionService.Roll(rolledCall.ID, rolled.AddDate(0, 0, 14), 1.50, 210, newExpiration.AddDate(0, 1, 0), 3.50, 2)
+		if err != nil {
+			t.Fatalf("Failed to roll the other 2 contracts: %v", err)
+		}
+
+		chain, err := optionService.GetRollChain(split.ID)
+		if err != nil {
+			t.Fatalf("Failed to get roll chain: %v", err)
+		}
+		if len(chain.Options) != 4 || chain.Root().ID != root.ID || chain.Current().ID != last.ID || chain.Options[1].ID != split.ID {
+			t.Fatalf("Expected root, closed part, rolled part and last leg, got %d legs", len(chain.Options))
+		}
+		var netCredit float64
+		for _, leg := range chain.Options {
+			netCredit += leg.CalculateTotalProfit()
+		}
+		if chain.Rolls() != 2 || math.Abs(chain.NetCredit-netCredit) > 0.001 {
+			t.Errorf("Expected 2 rolls and net credit %.2f including the closed part, got %d and %.2f", netCredit, chain.Rolls(), chain.NetCredit)
+		}
+		if chain.CapitalAtRisk != 61500 {
+			t.Errorf("Expected capital at risk 61500 for the 3 rolled contracts, got %.2f", chain.CapitalAtRisk)
+		}
+	})
+
+	t.Run("deleting a leg unlinks its successor", func(t *testing.T) {
+		if err := optionService.DeleteByID(put.ID); err != nil {
+			t.Fatalf("Failed to delete root option: %v", err)
+		}
+		reloaded, err := optionService.GetByID(successor.ID)
+		if err != nil {
+			t.Fatalf("Failed to reload successor: %v", err)
+		}
+		if reloaded.ParentOptionID != nil {
+			t.Errorf("Expected successor unlinked, got parent %d", *reloaded.ParentOptionID)
+		}
+	})
+}
+
+func TestOptionService_CreateWithDirection(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+
+	opened := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	sold := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// LEAPS call bought for 12.00 and sold for 15.50
+	leaps, err := optionService.CreateWithDirection("AAPL", "Call", DirectionLong, opened, 300, time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC), 12.00, 1, 0.65)
+	if err != nil {
+		t.Fatalf("Failed to create long call: %v", err)
+	}
+	if !leaps.IsLong() || !leaps.IsLEAPS() {
+		t.Errorf("Expected a long LEAPS call, got direction %s", leaps.Direction)
+	}
+
+	// Protective put that expired worthless
+	put, err := optionService.CreateWithDirection("AAPL", "Put", DirectionLong, opened, 350, time.Date(2024, 2, 16, 0, 0, 0, 0, time.UTC), 4.00, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to create long put: %v", err)
+	}
+	if put.IsLEAPS() {
+		t.Error("Expected a long put not to count as LEAPS")
+	}
+
+	if err := optionService.CloseByID(leaps.ID, sold, 15.50); err != nil {
+		t.Fatalf("Failed to close long call: %v", err)
+	}
+	closed, err := optionService.GetByID(leaps.ID)
+	if err != nil {
+		t.Fatalf("Failed to get long call: %v", err)
+	}
+	if profit := closed.CalculateTotalProfit(); math.Abs(profit-(350-1.30)) > 0.001 {
+		t.Errorf("Expected long call profit 348.70, got %.2f", profit)
+	}
+	if profit := put.CalculateTotalProfit(); profit != -800 {
+		t.Errorf("Expected open long put to show the 800 debit as a loss, got %.2f", profit)
+	}
+
+	if _, _, err := optionService.Assign(put.ID, sold); err == nil {
+		t.Error("Expected assigning a long put to fail")
+	}
+
+	if _, err := optionService.CreateWithDirection("AAPL", "Call", "sideways", opened, 300, sold, 1.00, 1, 0); err == nil {
+		t.Error("Expected invalid direction to be rejected")
+	}
+
+	t.Run("roll keeps direction", func(t *testing.T) {
+		_, successor, err := optionService.Roll(put.ID, opened.AddDate(0, 0, 14), 2.00, 340, time.Date(2024, 4, 19, 0, 0, 0, 0, time.UTC), 5.00, 2)
+		if err != nil {
+			t.Fatalf("Failed to roll long put: %v", err)
+		}
+		if !successor.IsLong() {
+			t.Errorf("Expected rolled long put to stay long, got %s", successor.Direction)
+		}
+	})
+	t.Run("close and delete by terms match the direction", func(t *testing.T) {
+		expiration := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+		short, err := optionService.CreateWithDirection("AAPL", "Call", DirectionShort, opened, 320, expiration, 3.00, 1, 0)
+		if err != nil {
+			t.Fatalf("Failed to create short call: %v", err)
+		}
+		long, err := optionService.CreateWithDirection("AAPL", "Call", DirectionLong, opened, 320, expiration, 3.00, 1, 0)
+		if err != nil {
+			t.Fatalf("Failed to create long call with the same terms: %v", err)
+		}
+
+		if err := optionService.Close("AAPL", "Call", DirectionLong, opened, 320, expiration, 3.00, 1, sold, 4.00); err != nil {
+			t.Fatalf("Failed to close long call: %v", err)
+		}
+		if reloaded, err := optionService.GetByID(short.ID); err != nil || reloaded.Closed != nil {
+			t.Errorf("Expected the short call to stay open, got %v", err)
+		}
+
+		if err := optionService.Delete("AAPL", "Call", DirectionShort, opened, 320, expiration, 3.00, 1); err != nil {
+			t.Fatalf("Failed to delete short call: %v", err)
+		}
+		if _, err := optionService.GetByID(short.ID); err == nil {
+			t.Error("Expected the short call to be deleted")
+		}
+		if reloaded, err := optionService.GetByID(long.ID); err != nil || reloaded.Closed == nil {
+			t.Errorf("Expected the closed long call to remain, got %v", err)
+		}
+	})
+}
+
+func TestOptionService_CloseContracts(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+	campaignService := NewCampaignService(testDB.DB)
+
+	opened := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	closedOn := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	// Five contracts sold at 2.00 with 3.25 opening commission
+	put, err := optionService.CreateWithCommission("AAPL", "Put", opened, 170, expiration, 2.00, 5, 3.25)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	campaign, err := campaignService.Create("AAPL", "AAPL wheel", opened, nil)
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+	if err := campaignService.LinkItems(campaign.ID, CampaignItems{OptionIDs: []int{put.ID}}); err != nil {
+		t.Fatalf("Failed to link put to campaign: %v", err)
+	}
+
+	// Buy back 3 of the 5 at 0.50
+	closed, remaining, err := optionService.CloseContracts(put.ID, 3, closedOn, 0.50)
+	if err != nil {
+		t.Fatalf("Failed to partially close put: %v", err)
+	}
+	if remaining == nil || remaining.ID != put.ID || remaining.Contracts != 2 || !remaining.IsOpen() {
+		t.Fatalf("Expected option %d to stay open with 2 contracts, got %+v", put.ID, remaining)
+	}
+	if closed.ID == put.ID || closed.Contracts != 3 || closed.IsOpen() || closed.GetExitPriceValue() != 0.50 {
+		t.Fatalf("Expected a new closed option for 3 contracts at 0.50, got %+v", closed)
+	}
+
+	// 3/5 of the opening commission plus 0.65 per closed contract
+	if math.Abs(closed.Commission-(1.95+1.95)) > 0.001 {
+		t.Errorf("Expected closed commission 3.90, got %.2f", closed.Commission)
+	}
+	if math.Abs(remaining.Commission-1.30) > 0.001 {
+		t.Errorf("Expected remaining commission 1.30, got %.2f", remaining.Commission)
+	}
+	// (2.00 - 0.50) * 3 * 100 - 3.90
+	if profit := closed.CalculateTotalProfit(); math.Abs(profit-446.10) > 0.001 {
+		t.Errorf("Expected closed profit 446.10, got %.2f", profit)
+	}
+	// 2.00 * 2 * 100 - 1.30
+	if profit := remaining.CalculateTotalProfit(); math.Abs(profit-398.70) > 0.001 {
+		t.Errorf("Expected remaining profit 398.70, got %.2f", profit)
+	}
+
+	loaded, err := campaignService.GetByID(campaign.ID)
+	if err != nil {
+		t.Fatalf("Failed to get campaign: %v", err)
+	}
+	if len(loaded.Options) != 2 {
+		t.Errorf("Expected closed part to stay in the campaign, got %d options", len(loaded.Options))
+	}
+
+	t.Run("rejects more contracts than are open", func(t *testing.T) {
+		if _, _, err := optionService.CloseContracts(put.ID, 3, closedOn, 0.25); err == nil {
+			t.Error("Expected error closing 3 of 2 contracts")
+		}
+	})
+
+	t.Run("closing the rest closes the option", func(t *testing.T) {
+		last, rest, err := optionService.CloseContracts(put.ID, 2, closedOn.AddDate(0, 0, 1), 0.25)
+		if err != nil {
+			t.Fatalf("Failed to close remaining contracts: %v", err)
+		}
+		if rest != nil || last.ID != put.ID || last.IsOpen() || last.Contracts != 2 {
+			t.Errorf("Expected option %d closed with 2 contracts, got %+v and remaining %v", put.ID, last, rest)
+		}
+		if math.Abs(last.Commission-(1.30+1.30)) > 0.001 {
+			t.Errorf("Expected commission 2.60, got %.2f", last.Commission)
+		}
+	})
+}
+
+func TestOptionService_CloseContractsSameDay(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+
+	opened := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	closedOn := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	for _, symbol := range []string{"KO", "PEP", "MO"} {
+		if _, err := NewSymbolService(testDB.DB).Create(symbol); err != nil {
+			t.Fatalf("Failed to create symbol: %v", err)
+		}
+	}
+
+	t.Run("close 1 of 3, then the remaining 2", func(t *testing.T) {
+		put, err := optionService.CreateWithCommission("KO", "Put", opened, 60, expiration, 1.00, 3, 1.95)
+		if err != nil {
+			t.Fatalf("Failed to create put: %v", err)
+		}
+		first, _, err := optionService.CloseContracts(put.ID, 1, closedOn, 0.20)
+		if err != nil {
+			t.Fatalf("Failed to close 1 of 3: %v", err)
+		}
+		last, remaining, err := optionService.CloseContracts(put.ID, 2, closedOn, 0.20)
+		if err != nil {
+			t.Fatalf("Failed to close the remaining 2: %v", err)
+		}
+		if remaining != nil || last.ID != put.ID || last.Contracts != 3 {
+			t.Fatalf("Expected option %d closed with all 3 contracts, got %+v", put.ID, last)
+		}
+		if _, err := optionService.GetByID(first.ID); err == nil {
+			t.Errorf("Expected the first split to be folded into option %d", put.ID)
+		}
+		// The whole opening commission plus 0.65 per contract
+		if math.Abs(last.Commission-(1.95+1.95)) > 0.001 {
+			t.Errorf("Expected commission 3.90, got %.2f", last.Commission)
+		}
+	})
+
+	t.Run("close 1 of 3, then 1 and 1", func(t *testing.T) {
+		put, err := optionService.CreateWithCommission("PEP", "Put", opened, 160, expiration, 2.00, 3, 1.95)
+		if err != nil {
+			t.Fatalf("Failed to create put: %v", err)
+		}
+		first, _, err := optionService.CloseContracts(put.ID, 1, closedOn, 0.40)
+		if err != nil {
+			t.Fatalf("Failed to close 1 of 3: %v", err)
+		}
+		second, remaining, err := optionService.CloseContracts(put.ID, 1, closedOn, 0.40)
+		if err != nil {
+			t.Fatalf("Failed to close 1 of 2: %v", err)
+		}
+		if second.ID != first.ID || second.Contracts != 2 || remaining.Contracts != 1 {
+			t.Fatalf("Expected the second close to join option %d, got %+v and remaining %+v", first.ID, second, remaining)
+		}
+		if _, _, err := optionService.CloseContracts(put.ID, 1, closedOn, 0.40); err != nil {
+			t.Fatalf("Failed to close the last contract: %v", err)
+		}
+
+		options, err := optionService.GetBySymbol("PEP")
+		if err != nil {
+			t.Fatalf("Failed to get options: %v", err)
+		}
+		if len(options) != 1 || options[0].Contracts != 3 || options[0].IsOpen() {
+			t.Errorf("Expected one closed option for 3 contracts, got %d options", len(options))
+		}
+		// (2.00 - 0.40) * 3 * 100 - 3.90
+		if profit := options[0].CalculateTotalProfit(); math.Abs(profit-476.10) > 0.001 {
+			t.Errorf("Expected profit 476.10, got %.2f", profit)
+		}
+	})
+
+	t.Run("identical options in two accounts close apart", func(t *testing.T) {
+		accountService := NewAccountService(testDB.DB)
+		brokerage, err := accountService.GetOrCreateByName("Brokerage")
+		if err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+		ira, err := accountService.GetOrCreateByName("IRA")
+		if err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+
+		a, err := optionService.CreateInAccount(&brokerage.ID, "MO", "Put", DirectionShort, opened, 45, expiration, 1.00, 3, 1.95, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to create put A: %v", err)
+		}
+		b, err := optionService.CreateInAccount(&ira.ID, "MO", "Put", DirectionShort, opened, 45, expiration, 1.00, 3, 1.95, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to create put B: %v", err)
+		}
+
+		closedA, _, err := optionService.CloseContracts(a.ID, 1, closedOn, 0.20)
+		if err != nil {
+			t.Fatalf("Failed to close 1 of A: %v", err)
+		}
+		closedB, _, err := optionService.CloseContracts(b.ID, 1, closedOn, 0.20)
+		if err != nil {
+			t.Fatalf("Failed to close 1 of B: %v", err)
+		}
+		if closedB.ID == closedA.ID || closedA.Contracts != 1 || closedB.Contracts != 1 {
+			t.Fatalf("Expected separate closed parts of 1 contract, got %+v and %+v", closedA, closedB)
+		}
+
+		var accountID int
+		if err := testDB.DB.QueryRow(`SELECT account_id FROM options WHERE id = ?`, closedB.ID).Scan(&accountID); err != nil {
+			t.Fatalf("Failed to get account: %v", err)
+		}
+		if accountID != ira.ID {
+			t.Errorf("Expected B's closed part in account %d, got %d", ira.ID, accountID)
+		}
+	})
+}
+
+func TestOption_ContractMultiplier(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	optionService := NewOptionService(testDB.DB)
+	symbolService := NewSymbolService(testDB.DB)
+
+	if _, err := symbolService.Create("XSP"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	if _, err := symbolService.SetInstrument("XSP", InstrumentIndex, 10); err != nil {
+		t.Fatalf("Failed to set instrument: %v", err)
+	}
+
+	opened := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	created, err := optionService.CreateWithCommission("XSP", "Put", opened, 500, expiration, 4.00, 2, 1.30)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	if created.Multiplier() != 10 {
+		t.Errorf("Expected the created put to carry multiplier 10, got %d", created.Multiplier())
+	}
+
+	put, err := optionService.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get put: %v", err)
+	}
+	// 4.00 * 2 * 10 - 1.30
+	if profit := put.CalculateTotalProfit(); math.Abs(profit-78.70) > 0.001 {
+		t.Errorf("Expected profit 78.70, got %.2f", profit)
+	}
+	if collateral := CalculatePutCollateral([]*Option{put}); collateral != 10000 {
+		t.Errorf("Expected collateral 10000, got %.2f", collateral)
+	}
+	if chain := NewRollChain([]*Option{put}); chain.CapitalAtRisk != 10000 {
+		t.Errorf("Expected chain capital at risk 10000, got %.2f", chain.CapitalAtRisk)
+	}
+
+	// Options on untracked symbols and built by hand keep the standard 100
+	if (&Option{}).Multiplier() != DefaultContractMultiplier {
+		t.Errorf("Expected the default multiplier for an option without one")
+	}
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchmarkSetting names the symbol that portfolio delta is beta-weighted against
+const BenchmarkSetting = "BETA_BENCHMARK"
+
+// DefaultBenchmark is the benchmark used when the setting is missing
+const DefaultBenchmark = "SPY"
+
+// BetaOrDefault returns the symbol's beta to the benchmark, or 1 if it isn't set
+func (s *Symbol) BetaOrDefault() float64 {
+	if s.Beta == nil {
+		return 1
+	}
+	return *s.Beta
+}
+
+// SymbolGreeks adds up the Greeks of one symbol's open options and shares.
+// Delta is in shares of the symbol, theta in dollars per day and vega in
+// dollars per volatility point. BetaWeightedDelta is the delta in shares of
+// the benchmark, zero if the benchmark has no price.
+type SymbolGreeks struct {
+	Symbol            string  `json:"symbol"`
+	Price             float64 `json:"price"`
+	Beta              float64 `json:"beta"`
+	Shares            float64 `json:"shares"`
+	OptionDelta       float64 `json:"option_delta"`
+	Delta             float64 `json:"delta"`
+	Gamma             float64 `json:"gamma"`
+	Theta             float64 `json:"theta"`
+	Vega              float64 `json:"vega"`
+	DollarDelta       float64 `json:"dollar_delta"`
+	BetaDollarDelta   float64 `json:"beta_dollar_delta"`
+	BetaWeightedDelta float64 `json:"beta_weighted_delta"`
+	Options           int     `json:"options"`
+}
+
+// PortfolioGreeks adds up SymbolGreeks across the portfolio. Delta and gamma
+// are summed in shares of each symbol, so the dollar and beta-weighted deltas
+// are the ones comparable across symbols. Unpriced lists symbols with open
+// options or shares but no price, which are left out of everything but Shares.
+type PortfolioGreeks struct {
+	Benchmark         string          `json:"benchmark"`
+	BenchmarkPrice    float64         `json:"benchmark_price"`
+	Delta             float64         `json:"delta"`
+	Gamma             float64         `json:"gamma"`
+	Theta             float64         `json:"theta"`
+	Vega              float64         `json:"vega"`
+	DollarDelta       float64         `json:"dollar_delta"`
+	BetaDollarDelta   float64         `json:"beta_dollar_delta"`
+	BetaWeightedDelta float64         `json:"beta_weighted_delta"`
+	Symbols           []*SymbolGreeks `json:"symbols"`
+	Unpriced          []string        `json:"unpriced"`
+}
+
+// BuildPortfolioGreeks aggregates the Greeks of open options, keyed by option
+// ID as PricingService.Greeks returns them, and the delta of open long
+// positions at one share each, per symbol and for the portfolio
+func BuildPortfolioGreeks(options []*Option, greeks map[int]*OptionGreeks, longPositions []*LongPosition, instruments Instruments, benchmark string) *PortfolioGreeks {
+	portfolio := &PortfolioGreeks{Benchmark: benchmark, Symbols: []*SymbolGreeks{}, Unpriced: []string{}}
+	if symbol, ok := instruments[benchmark]; ok {
+		portfolio.BenchmarkPrice = symbol.Price
+	}
+
+	bySymbol := make(map[string]*SymbolGreeks)
+	unpriced := make(map[string]bool)
+	get := func(name string) *SymbolGreeks {
+		if sg, ok := bySymbol[name]; ok {
+			return sg
+		}
+		sg := &SymbolGreeks{Symbol: name, Beta: 1}
+		if symbol, ok := instruments[name]; ok {
+			sg.Price = symbol.Price
+			sg.Beta = symbol.BetaOrDefault()
+		}
+		bySymbol[name] = sg
+		return sg
+	}
+
+	for _, position := range longPositions {
+		if position.Closed != nil {
+			continue
+		}
+		get(position.Symbol).Shares += float64(position.Shares)
+	}
+	for _, option := range options {
+		if option.Closed != nil {
+			continue
+		}
+		valued, ok := greeks[option.ID]
+		if !ok {
+			unpriced[option.Symbol] = true
+			continue
+		}
+		sg := get(option.Symbol)
+		sg.OptionDelta += valued.Position.Delta
+		sg.Gamma += valued.Position.Gamma
+		sg.Theta += valued.Position.Theta
+		sg.Vega += valued.Position.Vega
+		sg.Options++
+	}
+
+	for _, sg := range bySymbol {
+		if sg.Price <= 0 {
+			unpriced[sg.Symbol] = true
+		}
+		sg.Delta = sg.Shares + sg.OptionDelta
+		sg.DollarDelta = sg.Delta * sg.Price
+		sg.BetaDollarDelta = sg.DollarDelta * sg.Beta
+		if portfolio.BenchmarkPrice > 0 {
+			sg.BetaWeightedDelta = sg.BetaDollarDelta / portfolio.BenchmarkPrice
+		}
+
+		portfolio.Delta += sg.Delta
+		portfolio.Gamma += sg.Gamma
+		portfolio.Theta += sg.Theta
+		portfolio.Vega += sg.Vega
+		portfolio.DollarDelta += sg.DollarDelta
+		portfolio.BetaDollarDelta += sg.BetaDollarDelta
+		portfolio.BetaWeightedDelta += sg.BetaWeightedDelta
+		portfolio.Symbols = append(portfolio.Symbols, sg)
+	}
+	sort.Slice(portfolio.Symbols, func(i, j int) bool {
+		return portfolio.Symbols[i].Symbol < portfolio.Symbols[j].Symbol
+	})
+
+	for symbol := range unpriced {
+		portfolio.Unpriced = append(portfolio.Unpriced, symbol)
+	}
+	sort.Strings(portfolio.Unpriced)
+
+	return portfolio
+}
+
+// Benchmark returns the BETA_BENCHMARK setting, or DefaultBenchmark if it is not set
+func (s *PricingService) Benchmark() string {
+	benchmark := strings.TrimSpace(strings.ToUpper(NewSettingService(s.db).GetValue(BenchmarkSetting)))
+	if benchmark == "" {
+		return DefaultBenchmark
+	}
+	return benchmark
+}
+
+// PortfolioGreeks prices the open options and aggregates them with the open
+// long positions
+func (s *PricingService) PortfolioGreeks(options []*Option, longPositions []*LongPosition, now time.Time) (*PortfolioGreeks, error) {
+	greeks, err := s.Greeks(options, now)
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	return BuildPortfolioGreeks(options, greeks, longPositions, instruments, s.Benchmark()), nil
+}
+
+// AccountPortfolioGreeks returns the portfolio Greeks of one account, or of
+// the household if accountID is nil
+func (s *PricingService) AccountPortfolioGreeks(accountID *int, now time.Time) (*PortfolioGreeks, error) {
+	filter, err := NewAccountService(s.db).Filter(accountID)
+	if err != nil {
+		return nil, err
+	}
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+	longPositions, err := NewLongPositionService(s.db).GetOpenPositions()
+	if err != nil {
+		return nil, err
+	}
+	return s.PortfolioGreeks(filter.Options(options), filter.LongPositions(longPositions), now)
+}
+
+// SetBeta sets a symbol's beta to the benchmark, or clears it so the symbol
+// counts with a beta of 1 if beta is nil
+func (s *SymbolService) SetBeta(symbol string, beta *float64) (*Symbol, error) {
+	symbol = strings.TrimSpace(strings.ToUpper(symbol))
+
+	query := `UPDATE symbols SET beta = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ? RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at`
+	var sym Symbol
+	err := s.db.QueryRow(query, beta, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.InstrumentClass, &sym.ContractMultiplier, &sym.Volatility, &sym.Beta, &sym.CreatedAt, &sym.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("symbol not found")
+		}
+		return nil, fmt.Errorf("failed to set beta: %w", err)
+	}
+
+	return &sym, nil
+}
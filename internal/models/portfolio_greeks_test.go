@@ -0,0 +1,102 @@
+package models
+
+import (
+	"math"
+	"stonks/internal/pricing"
+	"testing"
+	"time"
+)
+
+func TestBuildPortfolioGreeks_SharesOptionsAndBeta(t *testing.T) {
+	beta := 1.5
+	instruments := NewInstruments([]*Symbol{
+		{Symbol: "SPY", Price: 500},
+		{Symbol: "AAPL", Price: 200, Beta: &beta},
+		{Symbol: "KO", Price: 60},
+		{Symbol: "XYZ"},
+	})
+	closed := time.Now()
+	longPositions := []*LongPosition{
+		{Symbol: "AAPL", Shares: 100},
+		{Symbol: "KO", Shares: 200},
+		{Symbol: "KO", Shares: 50, Closed: &closed},
+	}
+	options := []*Option{
+		{ID: 1, Symbol: "AAPL", Type: "Call"},
+		{ID: 2, Symbol: "KO", Type: "Put"},
+		{ID: 3, Symbol: "XYZ", Type: "Put"},
+	}
+	greeks := map[int]*OptionGreeks{
+		1: {OptionID: 1, Position: pricing.Valuation{Delta: -30, Gamma: -2, Theta: 5, Vega: -10}},
+		2: {OptionID: 2, Position: pricing.Valuation{Delta: 40, Gamma: -3, Theta: 2, Vega: -6}},
+	}
+
+	portfolio := BuildPortfolioGreeks(options, greeks, longPositions, instruments, "SPY")
+
+	if len(portfolio.Symbols) != 2 || portfolio.Symbols[0].Symbol != "AAPL" || portfolio.Symbols[1].Symbol != "KO" {
+		t.Fatalf("Expected AAPL and KO, got %+v", portfolio.Symbols)
+	}
+	aapl, ko := portfolio.Symbols[0], portfolio.Symbols[1]
+	if aapl.Shares != 100 || aapl.Delta != 70 || aapl.DollarDelta != 14000 {
+		t.Errorf("Expected AAPL delta 100 - 30 = 70 shares, $14000, got %+v", aapl)
+	}
+	// 70 shares x $200 x 1.5 beta in $500 SPY shares
+	if math.Abs(aapl.BetaWeightedDelta-42) > 1e-9 {
+		t.Errorf("Expected AAPL beta-weighted delta of 42 SPY shares, got %.4f", aapl.BetaWeightedDelta)
+	}
+	if ko.Shares != 200 || ko.Delta != 240 || ko.Beta != 1 {
+		t.Errorf("Expected open KO shares only and a default beta, got %+v", ko)
+	}
+
+	if portfolio.Delta != 310 || portfolio.Theta != 7 || portfolio.Gamma != -5 || portfolio.Vega != -16 {
+		t.Errorf("Expected portfolio delta 310, theta 7, gamma -5, vega -16, got %+v", portfolio)
+	}
+	if math.Abs(portfolio.BetaWeightedDelta-(42+240*60.0/500)) > 1e-9 {
+		t.Errorf("Expected beta-weighted delta of %.2f, got %.4f", 42+240*60.0/500, portfolio.BetaWeightedDelta)
+	}
+	if len(portfolio.Unpriced) != 1 || portfolio.Unpriced[0] != "XYZ" {
+		t.Errorf("Expected the unpriced XYZ put to be reported, got %v", portfolio.Unpriced)
+	}
+
+	// Without a benchmark price only the dollar deltas are known
+	unbenchmarked := BuildPortfolioGreeks(options, greeks, longPositions, instruments, "QQQ")
+	if unbenchmarked.BetaWeightedDelta != 0 || unbenchmarked.BetaDollarDelta != portfolio.BetaDollarDelta {
+		t.Errorf("Expected no beta-weighted delta without a benchmark price, got %+v", unbenchmarked)
+	}
+}
+
+func TestMetricService_SnapshotsNetDeltaAndDailyTheta(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	now := time.Now()
+
+	if _, err := NewSymbolService(testDB.DB).Update("AAPL", 150, 0, nil, nil); err != nil {
+		t.Fatalf("Failed to price symbol: %v", err)
+	}
+	if _, err := NewLongPositionService(testDB.DB).Create("AAPL", now, 100, 140); err != nil {
+		t.Fatalf("Failed to create long position: %v", err)
+	}
+	if _, err := NewOptionService(testDB.DB).Create("AAPL", "Call", now, 160, now.AddDate(0, 0, 30), 1.5, 1); err != nil {
+		t.Fatalf("Failed to create call: %v", err)
+	}
+
+	metricService := NewMetricService(testDB.DB)
+	if err := metricService.ComprehensiveSnapshot(3); err != nil {
+		t.Fatalf("Failed to snapshot metrics: %v", err)
+	}
+
+	netDelta, err := metricService.GetByType(NetDelta)
+	if err != nil {
+		t.Fatalf("Failed to get net delta: %v", err)
+	}
+	if len(netDelta) != 1 || netDelta[0].Value <= 0 || netDelta[0].Value >= 100 {
+		t.Errorf("Expected one net delta between 0 and 100 shares for a covered call, got %+v", netDelta)
+	}
+
+	dailyTheta, err := metricService.GetByType(DailyTheta)
+	if err != nil {
+		t.Fatalf("Failed to get daily theta: %v", err)
+	}
+	if len(dailyTheta) != 1 || dailyTheta[0].Value <= 0 {
+		t.Errorf("Expected one positive daily theta for a short call, got %+v", dailyTheta)
+	}
+}
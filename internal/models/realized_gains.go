@@ -0,0 +1,419 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Realized gain kinds
+const (
+	RealizedKindStock  = "Stock"
+	RealizedKindOption = "Option"
+)
+
+// RealizedGain is one closed stock lot or option realized in a tax year.
+// PremiumAdjustment is option premium folded into the stock's basis (assigned
+// puts) or proceeds (called away calls) instead of being reported on its own.
+// WashSaleBasis is disallowed loss carried into CostBasis from earlier wash
+// sales, and WashSaleDisallowed the part of this loss added back to Gain.
+// Section 1256 contracts are taxed 60/40 long/short-term whatever the holding
+// period, and those still open at a year end are MarkedToMarket: treated as
+// sold on December 31 at their mark, which becomes their basis for the next year.
+type RealizedGain struct {
+	Kind               string    `json:"kind"`
+	ID                 int       `json:"id"`
+	Symbol             string    `json:"symbol"`
+	Description        string    `json:"description"`
+	Quantity           int       `json:"quantity"`
+	Acquired           time.Time `json:"acquired"`
+	Sold               time.Time `json:"sold"`
+	Proceeds           float64   `json:"proceeds"`
+	CostBasis          float64   `json:"cost_basis"`
+	PremiumAdjustment  float64   `json:"premium_adjustment"`
+	WashSaleBasis      float64   `json:"wash_sale_basis"`
+	WashSaleDisallowed float64   `json:"wash_sale_disallowed"`
+	Gain               float64   `json:"gain"`
+	LongTerm           bool      `json:"long_term"`
+	Section1256        bool      `json:"section_1256"`
+	MarkedToMarket     bool      `json:"marked_to_market"`
+	// MissingMark is set from the first past year end a Section 1256 option
+	// has no recorded mark for, as its gain and basis are unknown from then on
+	MissingMark bool `json:"missing_mark"`
+}
+
+// Term returns "Long-term", "Short-term" or "Section 1256"
+func (g *RealizedGain) Term() string {
+	if g.Section1256 {
+		return "Section 1256"
+	}
+	if g.LongTerm {
+		return "Long-term"
+	}
+	return "Short-term"
+}
+
+// RealizedGainGroup is a list of realized gains with their totals
+type RealizedGainGroup struct {
+	Gains     []*RealizedGain `json:"gains"`
+	Proceeds  float64         `json:"proceeds"`
+	CostBasis float64         `json:"cost_basis"`
+	Gain      float64         `json:"gain"`
+}
+
+func (g *RealizedGainGroup) add(gain *RealizedGain) {
+	g.Gains = append(g.Gains, gain)
+	g.Proceeds += gain.Proceeds
+	g.CostBasis += gain.CostBasis
+	g.Gain += gain.Gain
+}
+
+// RealizedGainsReport splits a tax year's realized gains by holding period.
+// Section 1256 contracts are reported on their own; NetShortTerm and
+// NetLongTerm include their 40% and 60% shares.
+type RealizedGainsReport struct {
+	Year         int                `json:"year"`
+	ShortTerm    *RealizedGainGroup `json:"short_term"`
+	LongTerm     *RealizedGainGroup `json:"long_term"`
+	Section1256  *RealizedGainGroup `json:"section_1256"`
+	NetShortTerm float64            `json:"net_short_term"`
+	NetLongTerm  float64            `json:"net_long_term"`
+	Total        float64            `json:"total"`
+
+	WashSales          []*WashSale `json:"wash_sales"`
+	WashSaleDisallowed float64     `json:"wash_sale_disallowed"`
+
+	// MissingMarks are the Section 1256 gains left out for a missing year-end mark
+	MissingMarks []*RealizedGain `json:"missing_marks"`
+}
+
+// All returns the short-term gains followed by the long-term and Section 1256 gains
+func (r *RealizedGainsReport) All() []*RealizedGain {
+	all := append(append([]*RealizedGain{}, r.ShortTerm.Gains...), r.LongTerm.Gains...)
+	return append(all, r.Section1256.Gains...)
+}
+
+// IsLongTermHolding reports whether an asset acquired and sold on the given
+// dates was held for more than one year. The holding period starts the day
+// after acquisition, so a sale on the one-year anniversary is still short-term.
+func IsLongTermHolding(acquired, sold time.Time) bool {
+	return sold.After(acquired.AddDate(1, 0, 0))
+}
+
+// premiumKey matches an assigned put or called away call to the stock lots it
+// created or closed: same symbol, same day, at the strike
+type premiumKey struct {
+	symbol string
+	date   string
+	price  float64
+}
+
+// rolledPremium is the net premium of the assigned puts or called away calls
+// matching a premiumKey, and the earliest date one of them was sold
+type rolledPremium struct {
+	premium float64
+	shares  int
+	opened  time.Time
+}
+
+func (p rolledPremium) perShare() float64 {
+	if p.shares == 0 {
+		return 0
+	}
+	return p.premium / float64(p.shares)
+}
+
+// rollPremiums matches assigned puts to the lots they opened and called away
+// calls to the lots they closed
+func rollPremiums(positions []*LongPosition, options []*Option, instruments Instruments) (assignedPuts, calledAwayCalls map[premiumKey]rolledPremium) {
+	openedLots := make(map[premiumKey]bool)
+	closedLots := make(map[premiumKey]bool)
+	for _, lot := range positions {
+		openedLots[premiumKey{lot.Symbol, lot.Opened.Format("2006-01-02"), lot.BuyPrice}] = true
+		if lot.Closed != nil {
+			closedLots[premiumKey{lot.Symbol, lot.Closed.Format("2006-01-02"), lot.GetExitPriceValue()}] = true
+		}
+	}
+
+	assignedPuts = make(map[premiumKey]rolledPremium)
+	calledAwayCalls = make(map[premiumKey]rolledPremium)
+	for _, option := range options {
+		if option.Closed == nil || option.CloseReason == nil || option.IsLong() {
+			continue
+		}
+		key := premiumKey{option.Symbol, option.Closed.Format("2006-01-02"), option.Strike}
+		var rollups map[premiumKey]rolledPremium
+		switch {
+		case *option.CloseReason == CloseReasonAssigned && openedLots[key]:
+			rollups = assignedPuts
+		case *option.CloseReason == CloseReasonCalledAway && closedLots[key]:
+			rollups = calledAwayCalls
+		default:
+			continue
+		}
+		rolled := rollups[key]
+		rolled.premium += option.CalculateTotalProfit()
+		rolled.shares += option.Contracts * instruments.Multiplier(option.Symbol)
+		if rolled.opened.IsZero() || option.Opened.Before(rolled.opened) {
+			rolled.opened = option.Opened
+		}
+		rollups[key] = rolled
+	}
+
+	return assignedPuts, calledAwayCalls
+}
+
+// YearEndMarks holds the last recorded mark of each option in each year, by
+// option ID and year
+type YearEndMarks map[int]map[int]float64
+
+// Mark returns the option's mark at the end of year. Only the current year,
+// which has not ended yet, falls back to the option's current price.
+func (m YearEndMarks) Mark(option *Option, year int) (float64, bool) {
+	if mark, ok := m[option.ID][year]; ok {
+		return mark, true
+	}
+	if year >= time.Now().Year() && option.CurrentPrice != nil {
+		return *option.CurrentPrice, true
+	}
+	return 0, false
+}
+
+// section1256Gains returns a Section 1256 option's gain for each year end it
+// was open through, marked to market, followed by its realized gain if it was
+// closed. Each mark is the basis of the next gain. Commission is charged when
+// the option is closed. Open options are marked through the year given. A
+// missing mark flags that year's gain and every later one as MissingMark.
+func section1256Gains(option *Option, multiplier float64, marks YearEndMarks, through int) []*RealizedGain {
+	description := fmt.Sprintf("%s %s %.2f %s", option.Symbol, option.Expiration.Format("01/02/2006"), option.Strike, option.Type)
+
+	gainAt := func(basis, price float64) *RealizedGain {
+		gain := &RealizedGain{
+			Kind:        RealizedKindOption,
+			ID:          option.ID,
+			Symbol:      option.Symbol,
+			Description: description,
+			Quantity:    option.Contracts,
+			Acquired:    option.Opened,
+			Section1256: true,
+		}
+		if option.IsLong() {
+			gain.Proceeds, gain.CostBasis = price*multiplier, basis*multiplier
+		} else {
+			gain.Proceeds, gain.CostBasis = basis*multiplier, price*multiplier
+		}
+		return gain
+	}
+
+	var gains []*RealizedGain
+	basis := option.Premium
+	missing := false
+	for year := option.Opened.Year(); ; year++ {
+		yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+		if option.Closed != nil && !option.Closed.After(yearEnd) {
+			break
+		}
+		if option.Closed == nil && year > through {
+			return gains
+		}
+
+		mark, ok := marks.Mark(option, year)
+		if !ok {
+			missing, mark = true, basis
+		}
+		gain := gainAt(basis, mark)
+		gain.Description += " (year-end mark)"
+		gain.Sold = yearEnd
+		gain.MarkedToMarket = true
+		gain.MissingMark = missing
+		gain.Gain = gain.Proceeds - gain.CostBasis
+		gains = append(gains, gain)
+		basis = mark
+	}
+
+	gain := gainAt(basis, option.GetExitPriceValue())
+	gain.Sold = *option.Closed
+	gain.MissingMark = missing
+	gain.CostBasis += option.Commission
+	gain.Gain = gain.Proceeds - gain.CostBasis
+	return append(gains, gain)
+}
+
+// buildRealizedGains returns every closed stock lot and option as a realized
+// gain, oldest sale first, before any wash sale adjustments. Section 1256
+// options are also marked to market at each year end through the year given.
+func buildRealizedGains(positions []*LongPosition, options []*Option, instruments Instruments, marks YearEndMarks, through int) []*RealizedGain {
+	assignedPuts, calledAwayCalls := rollPremiums(positions, options, instruments)
+
+	var gains []*RealizedGain
+	for _, lot := range positions {
+		if lot.Closed == nil {
+			continue
+		}
+		shares := float64(lot.Shares)
+		putPremium := assignedPuts[premiumKey{lot.Symbol, lot.Opened.Format("2006-01-02"), lot.BuyPrice}].perShare() * shares
+		callPremium := calledAwayCalls[premiumKey{lot.Symbol, lot.Closed.Format("2006-01-02"), lot.GetExitPriceValue()}].perShare() * shares
+
+		gain := &RealizedGain{
+			Kind:              RealizedKindStock,
+			ID:                lot.ID,
+			Symbol:            lot.Symbol,
+			Description:       fmt.Sprintf("%d sh %s", lot.Shares, lot.Symbol),
+			Quantity:          lot.Shares,
+			Acquired:          lot.Opened,
+			Sold:              *lot.Closed,
+			Proceeds:          lot.GetExitPriceValue()*shares + callPremium,
+			CostBasis:         lot.BuyPrice*shares - putPremium,
+			PremiumAdjustment: putPremium + callPremium,
+			LongTerm:          IsLongTermHolding(lot.Opened, *lot.Closed),
+		}
+		gain.Gain = gain.Proceeds - gain.CostBasis
+		gains = append(gains, gain)
+	}
+
+	for _, option := range options {
+		if instruments.IsSection1256(option.Symbol) {
+			multiplier := float64(instruments.Multiplier(option.Symbol) * option.Contracts)
+			gains = append(gains, section1256Gains(option, multiplier, marks, through)...)
+			continue
+		}
+		if option.Closed == nil {
+			continue
+		}
+		key := premiumKey{option.Symbol, option.Closed.Format("2006-01-02"), option.Strike}
+		if option.CloseReason != nil && !option.IsLong() {
+			if *option.CloseReason == CloseReasonAssigned && assignedPuts[key].shares > 0 {
+				continue
+			}
+			if *option.CloseReason == CloseReasonCalledAway && calledAwayCalls[key].shares > 0 {
+				continue
+			}
+		}
+
+		multiplier := float64(instruments.Multiplier(option.Symbol) * option.Contracts)
+		gain := &RealizedGain{
+			Kind:        RealizedKindOption,
+			ID:          option.ID,
+			Symbol:      option.Symbol,
+			Description: fmt.Sprintf("%s %s %.2f %s", option.Symbol, option.Expiration.Format("01/02/2006"), option.Strike, option.Type),
+			Quantity:    option.Contracts,
+			Acquired:    option.Opened,
+			Sold:        *option.Closed,
+		}
+		if option.IsLong() {
+			gain.Proceeds = option.GetExitPriceValue() * multiplier
+			gain.CostBasis = option.Premium*multiplier + option.Commission
+			gain.LongTerm = IsLongTermHolding(option.Opened, *option.Closed)
+		} else {
+			gain.Proceeds = option.Premium * multiplier
+			gain.CostBasis = option.GetExitPriceValue()*multiplier + option.Commission
+		}
+		gain.Gain = gain.Proceeds - gain.CostBasis
+		gains = append(gains, gain)
+	}
+
+	sort.SliceStable(gains, func(i, j int) bool {
+		if !gains[i].Sold.Equal(gains[j].Sold) {
+			return gains[i].Sold.Before(gains[j].Sold)
+		}
+		if gains[i].Symbol != gains[j].Symbol {
+			return gains[i].Symbol < gains[j].Symbol
+		}
+		return gains[i].ID < gains[j].ID
+	})
+
+	return gains
+}
+
+// BuildRealizedGainsReport classifies the stock lots and options closed in year
+// as short- or long-term. Net premium of an assigned put lowers the basis of the
+// shares it put to us, whose holding period starts on the assignment date; net
+// premium of a called away call is added to the proceeds of the shares it took.
+// Neither is reported on its own unless no matching lot is found. Gains on sold
+// (short) options are always short-term; bought options use their own holding
+// period. Options on broad-based indexes are Section 1256 contracts, split 60%
+// long-term and 40% short-term, and marked to market at their year-end mark
+// if still open. From the first past year end without a recorded mark, such an
+// option's gains are unknown; they are left out and listed in MissingMarks.
+// Losses disallowed as wash sales are added back and carried into the basis of
+// the replacement.
+//
+// Wash sales are found across every lot and option passed in, so pass the whole
+// household: a replacement bought in another account, or in an IRA, still
+// disallows a loss. Only the gains and wash sales the taxable and account
+// filters keep are reported; nil filters keep everything.
+func BuildRealizedGainsReport(year int, positions []*LongPosition, options []*Option, instruments Instruments, marks YearEndMarks, taxable *TaxableFilter, filter *AccountFilter) *RealizedGainsReport {
+	report := &RealizedGainsReport{
+		Year:         year,
+		ShortTerm:    &RealizedGainGroup{Gains: []*RealizedGain{}},
+		LongTerm:     &RealizedGainGroup{Gains: []*RealizedGain{}},
+		Section1256:  &RealizedGainGroup{Gains: []*RealizedGain{}},
+		WashSales:    []*WashSale{},
+		MissingMarks: []*RealizedGain{},
+	}
+
+	gains := buildRealizedGains(positions, options, instruments, marks, year)
+	washSales := applyWashSales(gains, positions, options, instruments, taxable)
+
+	for _, gain := range gains {
+		if gain.Sold.Year() != year || !taxable.IsTaxable(gain.Kind, gain.ID) || !filter.HasRealized(gain.Kind, gain.ID) {
+			continue
+		}
+		if gain.MissingMark {
+			report.MissingMarks = append(report.MissingMarks, gain)
+			continue
+		}
+		switch {
+		case gain.Section1256:
+			report.Section1256.add(gain)
+		case gain.LongTerm:
+			report.LongTerm.add(gain)
+		default:
+			report.ShortTerm.add(gain)
+		}
+	}
+	report.NetLongTerm = report.LongTerm.Gain + report.Section1256.Gain*Section1256LongTermShare
+	report.NetShortTerm = report.ShortTerm.Gain + report.Section1256.Gain*(1-Section1256LongTermShare)
+	report.Total = report.ShortTerm.Gain + report.LongTerm.Gain + report.Section1256.Gain
+
+	for _, washSale := range washSales {
+		if washSale.Sold.Year() == year && filter.HasRealized(washSale.LossKind, washSale.LossID) {
+			report.WashSales = append(report.WashSales, washSale)
+			report.WashSaleDisallowed += washSale.DisallowedLoss
+		}
+	}
+
+	return report
+}
+
+// RealizedGainYears returns the tax years with closed stock lots or options,
+// or with Section 1256 options open at the year end, most recent first
+func RealizedGainYears(positions []*LongPosition, options []*Option, instruments Instruments) []int {
+	seen := make(map[int]bool)
+	for _, lot := range positions {
+		if lot.Closed != nil {
+			seen[lot.Closed.Year()] = true
+		}
+	}
+	for _, option := range options {
+		if option.Closed != nil {
+			seen[option.Closed.Year()] = true
+		}
+		if instruments.IsSection1256(option.Symbol) {
+			last := time.Now().Year()
+			if option.Closed != nil {
+				last = option.Closed.Year()
+			}
+			for year := option.Opened.Year(); year <= last; year++ {
+				seen[year] = true
+			}
+		}
+	}
+
+	years := make([]int, 0, len(seen))
+	for year := range seen {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return years
+}
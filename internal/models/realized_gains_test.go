@@ -0,0 +1,199 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestIsLongTermHolding(t *testing.T) {
+	acquired := time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	if IsLongTermHolding(acquired, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Expected a sale on the one-year anniversary to be short-term")
+	}
+	if !IsLongTermHolding(acquired, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Expected a sale the day after the anniversary to be long-term")
+	}
+}
+
+func TestBuildRealizedGainsReport(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closedOn := func(year int, month time.Month, day int) *time.Time {
+		closed := date(year, month, day)
+		return &closed
+	}
+	price := func(value float64) *float64 { return &value }
+	reason := func(value string) *string { return &value }
+
+	positions := []*LongPosition{
+		// Put to us on 2023-03-15 at 170 and sold a year later: short-term
+		{ID: 1, Symbol: "AAPL", Opened: date(2023, 3, 15), Closed: closedOn(2024, 3, 15), Shares: 100, BuyPrice: 170, ExitPrice: price(180)},
+		// Bought outright and called away at 110 more than a year later: long-term
+		{ID: 2, Symbol: "KO", Opened: date(2022, 1, 10), Closed: closedOn(2024, 6, 21), Shares: 100, BuyPrice: 95, ExitPrice: price(110)},
+		// Sold in another tax year
+		{ID: 3, Symbol: "KO", Opened: date(2022, 1, 10), Closed: closedOn(2023, 2, 1), Shares: 100, BuyPrice: 95, ExitPrice: price(100)},
+	}
+	options := []*Option{
+		// Assigned put: 2.00 premium less 0.65 commission rolls into the AAPL lot's basis
+		{ID: 10, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Opened: date(2023, 2, 15), Closed: closedOn(2023, 3, 15),
+			Strike: 170, Expiration: date(2023, 3, 17), Premium: 2.00, Contracts: 1, ExitPrice: price(0), Commission: 0.65, CloseReason: reason(CloseReasonAssigned)},
+		// Called away call: 1.50 premium less 0.65 commission adds to the KO proceeds
+		{ID: 11, Symbol: "KO", Type: "Call", Direction: DirectionShort, Opened: date(2024, 5, 20), Closed: closedOn(2024, 6, 21),
+			Strike: 110, Expiration: date(2024, 6, 21), Premium: 1.50, Contracts: 1, ExitPrice: price(0), Commission: 0.65, CloseReason: reason(CloseReasonCalledAway)},
+		// Covered call opened in 2022 and bought back in 2024: short-term however long it was open
+		{ID: 12, Symbol: "KO", Type: "Call", Direction: DirectionShort, Opened: date(2022, 12, 1), Closed: closedOn(2024, 1, 5),
+			Strike: 120, Expiration: date(2024, 1, 19), Premium: 3.00, Contracts: 1, ExitPrice: price(0.50), Commission: 1.30},
+		// LEAPS held more than a year: long-term
+		{ID: 13, Symbol: "AAPL", Type: "Call", Direction: DirectionLong, Opened: date(2023, 1, 3), Closed: closedOn(2024, 2, 1),
+			Strike: 150, Expiration: date(2025, 1, 17), Premium: 20.00, Contracts: 1, ExitPrice: price(35.00), Commission: 1.30},
+	}
+
+	report := BuildRealizedGainsReport(2024, positions, options, nil, nil, nil, nil)
+
+	if len(report.ShortTerm.Gains) != 2 || len(report.LongTerm.Gains) != 2 {
+		t.Fatalf("Expected 2 short-term and 2 long-term gains, got %d and %d", len(report.ShortTerm.Gains), len(report.LongTerm.Gains))
+	}
+
+	byID := make(map[int]*RealizedGain)
+	for _, gain := range report.All() {
+		byID[gain.ID] = gain
+	}
+	if _, ok := byID[10]; ok {
+		t.Error("Expected the assigned put to be folded into the stock basis")
+	}
+	if _, ok := byID[11]; ok {
+		t.Error("Expected the called away call to be folded into the stock proceeds")
+	}
+
+	aapl := byID[1]
+	if aapl.LongTerm || math.Abs(aapl.CostBasis-(17000-199.35)) > 0.001 || math.Abs(aapl.Gain-1199.35) > 0.001 {
+		t.Errorf("Expected short-term AAPL gain 1199.35 on basis 16800.65, got %.2f on %.2f (long-term %v)", aapl.Gain, aapl.CostBasis, aapl.LongTerm)
+	}
+	ko := byID[2]
+	if !ko.LongTerm || math.Abs(ko.Proceeds-(11000+149.35)) > 0.001 || math.Abs(ko.PremiumAdjustment-149.35) > 0.001 {
+		t.Errorf("Expected long-term KO proceeds 11149.35, got %.2f (long-term %v)", ko.Proceeds, ko.LongTerm)
+	}
+	if call := byID[12]; call.LongTerm || math.Abs(call.Gain-248.70) > 0.001 {
+		t.Errorf("Expected short-term covered call gain 248.70, got %.2f (long-term %v)", call.Gain, call.LongTerm)
+	}
+	if leaps := byID[13]; !leaps.LongTerm || math.Abs(leaps.Gain-1498.70) > 0.001 {
+		t.Errorf("Expected long-term LEAPS gain 1498.70, got %.2f (long-term %v)", leaps.Gain, leaps.LongTerm)
+	}
+
+	expectedTotal := 1199.35 + 248.70 + 1649.35 + 1498.70
+	if math.Abs(report.Total-expectedTotal) > 0.001 {
+		t.Errorf("Expected total %.2f, got %.2f", expectedTotal, report.Total)
+	}
+
+	if years := RealizedGainYears(positions, options, nil); len(years) != 2 || years[0] != 2024 || years[1] != 2023 {
+		t.Errorf("Expected years [2024 2023], got %v", years)
+	}
+}
+
+func TestBuildRealizedGainsReport_Section1256(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closedOn := func(month time.Month, day int) *time.Time {
+		closed := date(month, day)
+		return &closed
+	}
+	price := func(value float64) *float64 { return &value }
+
+	instruments := NewInstruments([]*Symbol{{Symbol: "XSP", InstrumentClass: InstrumentIndex, ContractMultiplier: 100}})
+	options := []*Option{
+		// Sold for 3.00, bought back for 1.00: 200 gain, held a month
+		{ID: 1, Symbol: "XSP", Type: "Put", Direction: DirectionShort, Opened: date(3, 1), Closed: closedOn(4, 1),
+			Strike: 500, Expiration: date(4, 19), Premium: 3.00, Contracts: 1, ExitPrice: price(1.00)},
+		// Same contract on an equity: ordinary short-term
+		{ID: 2, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Opened: date(3, 1), Closed: closedOn(4, 1),
+			Strike: 170, Expiration: date(4, 19), Premium: 3.00, Contracts: 1, ExitPrice: price(1.00)},
+	}
+
+	report := BuildRealizedGainsReport(2024, nil, options, instruments, nil, nil, nil)
+
+	if len(report.Section1256.Gains) != 1 || report.Section1256.Gains[0].ID != 1 {
+		t.Fatalf("Expected the XSP put as the only Section 1256 gain, got %d", len(report.Section1256.Gains))
+	}
+	if len(report.ShortTerm.Gains) != 1 || len(report.LongTerm.Gains) != 0 {
+		t.Errorf("Expected 1 short-term and no long-term gains, got %d and %d", len(report.ShortTerm.Gains), len(report.LongTerm.Gains))
+	}
+	if term := report.Section1256.Gains[0].Term(); term != "Section 1256" {
+		t.Errorf("Expected term %q, got %q", "Section 1256", term)
+	}
+	// 200 of ordinary short-term plus 40% of the 200 Section 1256 gain
+	if math.Abs(report.NetShortTerm-280) > 0.001 || math.Abs(report.NetLongTerm-120) > 0.001 {
+		t.Errorf("Expected net short-term 280 and long-term 120, got %.2f and %.2f", report.NetShortTerm, report.NetLongTerm)
+	}
+	if math.Abs(report.Total-400) > 0.001 {
+		t.Errorf("Expected total 400, got %.2f", report.Total)
+	}
+}
+
+func TestBuildRealizedGainsReport_Section1256MarkToMarket(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closed := date(2024, 2, 1)
+	price := func(value float64) *float64 { return &value }
+
+	instruments := NewInstruments([]*Symbol{{Symbol: "XSP", InstrumentClass: InstrumentIndex, ContractMultiplier: 100}})
+	options := []*Option{
+		// Sold for 5.00 in 2023, marked at 3.00 on December 31, bought back for 1.00 in 2024
+		{ID: 1, Symbol: "XSP", Type: "Put", Direction: DirectionShort, Opened: date(2023, 11, 15), Closed: &closed,
+			Strike: 450, Expiration: date(2024, 3, 15), Premium: 5.00, Contracts: 1, ExitPrice: price(1.00), Commission: 1.30},
+		// Bought for 2.00 and still open, with no mark recorded but a current price of 3.50
+		{ID: 2, Symbol: "XSP", Type: "Call", Direction: DirectionLong, Opened: date(2024, 6, 3),
+			Strike: 520, Expiration: date(2025, 6, 20), Premium: 2.00, Contracts: 1, CurrentPrice: price(3.50), Commission: 0.65},
+		// Bought for 2.00 this year and still open at a current price of 3.50
+		{ID: 3, Symbol: "XSP", Type: "Call", Direction: DirectionLong, Opened: date(time.Now().Year(), 1, 1),
+			Strike: 600, Expiration: date(time.Now().Year()+1, 6, 20), Premium: 2.00, Contracts: 1, CurrentPrice: price(3.50), Commission: 0.65},
+	}
+	marks := YearEndMarks{1: {2023: 3.00}}
+
+	report := BuildRealizedGainsReport(2023, nil, options, instruments, marks, nil, nil)
+	if len(report.Section1256.Gains) != 1 {
+		t.Fatalf("Expected the put marked to market at the end of 2023, got %d gains", len(report.Section1256.Gains))
+	}
+	marked := report.Section1256.Gains[0]
+	if !marked.MarkedToMarket || !marked.Sold.Equal(date(2023, 12, 31)) || math.Abs(marked.Gain-200) > 0.001 {
+		t.Errorf("Expected a 200 gain marked on 12/31/2023, got %+v", marked)
+	}
+	// 60/40 split of the marked gain
+	if math.Abs(report.NetLongTerm-120) > 0.001 || math.Abs(report.NetShortTerm-80) > 0.001 {
+		t.Errorf("Expected net long-term 120 and short-term 80, got %.2f and %.2f", report.NetLongTerm, report.NetShortTerm)
+	}
+
+	report = BuildRealizedGainsReport(2024, nil, options, instruments, marks, nil, nil)
+	if len(report.Section1256.Gains) != 1 {
+		t.Fatalf("Expected only the closed put in 2024, got %d gains", len(report.Section1256.Gains))
+	}
+	// The 3.00 mark is the basis: 300 - 100 - 1.30
+	if put := report.Section1256.Gains[0]; put.MarkedToMarket || put.ID != 1 || math.Abs(put.Gain-198.70) > 0.001 {
+		t.Errorf("Expected the put's 2024 gain 198.70 from its year-end mark, got %+v", put)
+	}
+	// A past year end is never marked at today's price
+	if len(report.MissingMarks) != 1 || report.MissingMarks[0].ID != 2 || math.Abs(report.Total-198.70) > 0.001 {
+		t.Errorf("Expected the call without a 2024 mark left out, got %+v and total %.2f", report.MissingMarks, report.Total)
+	}
+
+	report = BuildRealizedGainsReport(time.Now().Year(), nil, options, instruments, marks, nil, nil)
+	if len(report.Section1256.Gains) != 1 {
+		t.Fatalf("Expected this year's call marked, got %d gains", len(report.Section1256.Gains))
+	}
+	// 350 - 200, commission waits for the close
+	if call := report.Section1256.Gains[0]; !call.MarkedToMarket || call.ID != 3 || math.Abs(call.Gain-150) > 0.001 {
+		t.Errorf("Expected the open call marked at its current price for a 150 gain, got %+v", call)
+	}
+	if len(report.MissingMarks) != 1 || report.MissingMarks[0].ID != 2 {
+		t.Errorf("Expected the call without a 2024 mark still left out, got %+v", report.MissingMarks)
+	}
+
+	years := RealizedGainYears(nil, options, instruments)
+	if len(years) == 0 || years[len(years)-1] != 2023 {
+		t.Errorf("Expected the years to reach back to 2023, got %v", years)
+	}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// RollChain is a sequence of options where each leg was opened by rolling the
+// previous one, ordered from the original option to the current leg. A leg
+// that was partly closed before it was rolled is split into several options,
+// which follow each other with the part carried forward last.
+type RollChain struct {
+	Symbol        string    `json:"symbol"`
+	Type          string    `json:"type"`
+	Options       []*Option `json:"options"`
+	NetCredit     float64   `json:"net_credit"`
+	CumulativeDTE int       `json:"cumulative_dte"`
+	DaysInTrade   int       `json:"days_in_trade"`
+	CapitalAtRisk float64   `json:"capital_at_risk"`
+	AROI          float64   `json:"aroi"`
+}
+
+// NewRollChain calculates the chain totals for legs ordered oldest first
+func NewRollChain(legs []*Option) *RollChain {
+	chain := &RollChain{Options: legs}
+	if len(legs) == 0 {
+		return chain
+	}
+
+	first, current := legs[0], legs[len(legs)-1]
+	chain.Symbol = first.Symbol
+	chain.Type = first.Type
+
+	var capital float64
+	for i, leg := range legs {
+		chain.NetCredit += leg.CalculateTotalProfit()
+		if i > 0 && !isSameLeg(leg, legs[i-1]) {
+			capital = 0
+		}
+		if leg.IsLong() {
+			capital += leg.Premium * float64(leg.Contracts*leg.Multiplier())
+		} else {
+			capital += leg.Strike * float64(leg.Contracts*leg.Multiplier())
+		}
+		if capital > chain.CapitalAtRisk {
+			chain.CapitalAtRisk = capital
+		}
+	}
+
+	if current.Expiration.After(first.Opened) {
+		chain.CumulativeDTE = int(current.Expiration.Sub(first.Opened).Hours() / 24)
+	}
+
+	endDate := time.Now()
+	if current.Closed != nil {
+		endDate = *current.Closed
+	}
+	chain.DaysInTrade = int(endDate.Sub(first.Opened).Hours() / 24)
+	if chain.DaysInTrade < 1 {
+		chain.DaysInTrade = 1
+	}
+
+	if chain.CapitalAtRisk > 0 {
+		chain.AROI = (chain.NetCredit / chain.CapitalAtRisk) * 100 * (365.25 / float64(chain.DaysInTrade))
+	}
+
+	return chain
+}
+
+// Root returns the original option of the chain
+func (c *RollChain) Root() *Option {
+	return c.Options[0]
+}
+
+// Current returns the latest leg of the chain
+func (c *RollChain) Current() *Option {
+	return c.Options[len(c.Options)-1]
+}
+
+// Rolls returns the number of times the position was rolled
+func (c *RollChain) Rolls() int {
+	rolls := 0
+	for i := 1; i < len(c.Options); i++ {
+		if !isSameLeg(c.Options[i], c.Options[i-1]) {
+			rolls++
+		}
+	}
+	return rolls
+}
+
+// IsOpen returns true if the latest leg is still open
+func (c *RollChain) IsOpen() bool {
+	return c.Current().IsOpen()
+}
+
+// IsNetCredit returns true if the chain has collected more than it paid
+func (c *RollChain) IsNetCredit() bool {
+	return c.NetCredit >= 0
+}
+
+// isSameLeg returns true if a and b are parts of one option split by a
+// partial close, which keeps every term but the contracts and the close
+func isSameLeg(a, b *Option) bool {
+	return a.Symbol == b.Symbol && a.Type == b.Type && a.Direction == b.Direction &&
+		a.Opened.Equal(b.Opened) && a.Strike == b.Strike && a.Expiration.Equal(b.Expiration) && a.Premium == b.Premium
+}
+
+// BuildRollChains groups rolled options into chains by following
+// parent_option_id. Options that were never rolled are left out. Chains are
+// returned open first, then by most recently started.
+//
+// A partial close splits the closed contracts off into a new option with the
+// same parent, so every child that is part of the earliest successor joins
+// the chain. The part that was rolled on, or is still open, continues it.
+func BuildRollChains(options []*Option) []*RollChain {
+	byID := make(map[int]*Option, len(options))
+	for _, option := range options {
+		byID[option.ID] = option
+	}
+
+	children := make(map[int][]*Option)
+	for _, option := range options {
+		if option.ParentOptionID == nil {
+			continue
+		}
+		if parentID := *option.ParentOptionID; byID[parentID] != nil {
+			children[parentID] = append(children[parentID], option)
+		}
+	}
+
+	// Each option continues into the parts of its earliest successor
+	next := make(map[int][]*Option)
+	inChain := make(map[int]bool)
+	for parentID, successors := range children {
+		earliest := successors[0]
+		for _, option := range successors[1:] {
+			if option.Opened.Before(earliest.Opened) || (option.Opened.Equal(earliest.Opened) && option.ID < earliest.ID) {
+				earliest = option
+			}
+		}
+
+		var parts []*Option
+		for _, option := range successors {
+			if isSameLeg(option, earliest) {
+				parts = append(parts, option)
+				inChain[option.ID] = true
+			}
+		}
+		continues := func(option *Option) bool {
+			return len(children[option.ID]) > 0 || option.IsOpen()
+		}
+		sort.Slice(parts, func(i, j int) bool {
+			if continues(parts[i]) != continues(parts[j]) {
+				return continues(parts[j])
+			}
+			return parts[i].ID < parts[j].ID
+		})
+		next[parentID] = parts
+	}
+
+	var chains []*RollChain
+	for _, option := range options {
+		if inChain[option.ID] || next[option.ID] == nil {
+			continue
+		}
+
+		legs := []*Option{option}
+		for parts := next[option.ID]; parts != nil; parts = next[parts[len(parts)-1].ID] {
+			legs = append(legs, parts...)
+		}
+		chains = append(chains, NewRollChain(legs))
+	}
+
+	sort.Slice(chains, func(i, j int) bool {
+		if chains[i].IsOpen() != chains[j].IsOpen() {
+			return chains[i].IsOpen()
+		}
+		return chains[i].Root().Opened.After(chains[j].Root().Opened)
+	})
+
+	return chains
+}
@@ -0,0 +1,559 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// minScenarioVolatility keeps a shocked volatility, in percent, from reaching zero
+const minScenarioVolatility = 1.0
+
+// ScenarioMove overrides a scenario's shock for one symbol. A nil field keeps
+// the scenario's portfolio-wide value.
+type ScenarioMove struct {
+	Symbol           string   `json:"symbol"`
+	PriceMove        *float64 `json:"price_move"`
+	VolatilityChange *float64 `json:"volatility_change"`
+}
+
+// Scenario is a what-if shock to the portfolio: every underlying moves by
+// PriceMove percent and its volatility by VolatilityChange points, valued
+// DaysForward days from now. Moves override the shock for individual symbols.
+type Scenario struct {
+	ID               int             `json:"id"`
+	Name             string          `json:"name"`
+	PriceMove        float64         `json:"price_move"`
+	VolatilityChange float64         `json:"volatility_change"`
+	DaysForward      int             `json:"days_forward"`
+	Moves            []*ScenarioMove `json:"moves"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// Shock returns the price move in percent and the volatility change in points
+// that the scenario applies to symbol
+func (sc *Scenario) Shock(symbol string) (float64, float64) {
+	priceMove, volatilityChange := sc.PriceMove, sc.VolatilityChange
+	for _, move := range sc.Moves {
+		if move.Symbol != symbol {
+			continue
+		}
+		if move.PriceMove != nil {
+			priceMove = *move.PriceMove
+		}
+		if move.VolatilityChange != nil {
+			volatilityChange = *move.VolatilityChange
+		}
+	}
+	return priceMove, volatilityChange
+}
+
+// Validate normalizes the per-symbol moves and checks that the scenario can be run
+func (sc *Scenario) Validate() error {
+	sc.Name = strings.TrimSpace(sc.Name)
+	if sc.PriceMove <= -100 {
+		return fmt.Errorf("price move must be above -100%%")
+	}
+	if sc.DaysForward < 0 {
+		return fmt.Errorf("days forward cannot be negative")
+	}
+
+	seen := make(map[string]bool)
+	for _, move := range sc.Moves {
+		move.Symbol = strings.TrimSpace(strings.ToUpper(move.Symbol))
+		if move.Symbol == "" {
+			return fmt.Errorf("symbol is required for each move")
+		}
+		if seen[move.Symbol] {
+			return fmt.Errorf("%s is moved more than once", move.Symbol)
+		}
+		seen[move.Symbol] = true
+		if move.PriceMove != nil && *move.PriceMove <= -100 {
+			return fmt.Errorf("price move of %s must be above -100%%", move.Symbol)
+		}
+	}
+	if sc.Moves == nil {
+		sc.Moves = []*ScenarioMove{}
+	}
+	return nil
+}
+
+// ScenarioSymbol is one underlying before and after the shock, volatility in percent
+type ScenarioSymbol struct {
+	Symbol            string  `json:"symbol"`
+	Price             float64 `json:"price"`
+	ShockedPrice      float64 `json:"shocked_price"`
+	PriceMove         float64 `json:"price_move"`
+	Volatility        float64 `json:"volatility"`
+	ShockedVolatility float64 `json:"shocked_volatility"`
+}
+
+// ScenarioOption is an open option valued today and under the scenario.
+// Values are for the whole position, negative for a short. Assignment is the
+// cash an in-the-money put takes to settle: the strike for physically settled
+// shares, the intrinsic value for cash-settled indexes.
+type ScenarioOption struct {
+	OptionID     int       `json:"option_id"`
+	Symbol       string    `json:"symbol"`
+	Type         string    `json:"type"`
+	Direction    string    `json:"direction"`
+	Strike       float64   `json:"strike"`
+	Expiration   time.Time `json:"expiration"`
+	Contracts    int       `json:"contracts"`
+	Value        float64   `json:"value"`
+	ShockedValue float64   `json:"shocked_value"`
+	PnL          float64   `json:"pnl"`
+	ITM          bool      `json:"itm"`
+	Assignment   float64   `json:"assignment"`
+}
+
+// ScenarioStock is an open long position before and after the shock
+type ScenarioStock struct {
+	PositionID   int     `json:"position_id"`
+	Symbol       string  `json:"symbol"`
+	Shares       int     `json:"shares"`
+	Price        float64 `json:"price"`
+	ShockedPrice float64 `json:"shocked_price"`
+	PnL          float64 `json:"pnl"`
+}
+
+// ScenarioResult is the portfolio repriced under a scenario on Date. Options
+// are valued with the pricing models both today and under the shock, so their
+// P&L is the change in theoretical value. AssignmentCapital adds up the puts
+// that would be assigned, netting long puts in the same strategy, and the
+// Shortfall is what cash and open treasuries at face value can't cover.
+// Unpriced lists symbols with open positions but no price, which are left out.
+type ScenarioResult struct {
+	Scenario          *Scenario         `json:"scenario"`
+	Date              time.Time         `json:"date"`
+	Symbols           []*ScenarioSymbol `json:"symbols"`
+	Options           []*ScenarioOption `json:"options"`
+	Stocks            []*ScenarioStock  `json:"stocks"`
+	OptionPnL         float64           `json:"option_pnl"`
+	StockPnL          float64           `json:"stock_pnl"`
+	PnL               float64           `json:"pnl"`
+	ITMPuts           []*ScenarioOption `json:"itm_puts"`
+	AssignmentCapital float64           `json:"assignment_capital"`
+	Cash              float64           `json:"cash"`
+	Treasuries        float64           `json:"treasuries"`
+	Shortfall         float64           `json:"shortfall"`
+	Unpriced          []string          `json:"unpriced"`
+}
+
+// putAssignment returns the cash a put takes to settle if the underlying is at
+// price on expiration: the strike for physically settled shares, the intrinsic
+// value for cash-settled indexes, or zero if it is out of the money
+func putAssignment(option *Option, underlying *Symbol, price float64) float64 {
+	if option.Type != "Put" || price >= option.Strike {
+		return 0
+	}
+	shares := float64(underlying.Multiplier() * option.Contracts)
+	if underlying.IsCashSettled() {
+		return (option.Strike - price) * shares
+	}
+	return option.Strike * shares
+}
+
+// netAssignment adds up the assignment of short puts. Long puts in the same
+// strategy are exercised to pay for the assigned short put, so a strategy
+// needs at most its width; long puts on their own need nothing.
+func netAssignment(options []*Option, assignment func(option *Option) float64) float64 {
+	var total float64
+	strategies := make(map[int]float64)
+	for _, option := range options {
+		amount := assignment(option)
+		if amount == 0 {
+			continue
+		}
+		switch {
+		case option.StrategyID != nil && option.IsLong():
+			strategies[*option.StrategyID] -= amount
+		case option.StrategyID != nil:
+			strategies[*option.StrategyID] += amount
+		case !option.IsLong():
+			total += amount
+		}
+	}
+	for _, strategy := range strategies {
+		total += math.Max(0, strategy)
+	}
+	return total
+}
+
+// RunScenario shocks the underlyings of the open options and long positions
+// and reprices them DaysForward days from now. rate and volatility are the
+// risk-free rate and the fallback volatility in percent; balance is the cash
+// balance that assignments are paid from.
+func RunScenario(scenario *Scenario, options []*Option, longPositions []*LongPosition, instruments Instruments, treasuries []*Treasury, balance, rate, volatility float64, now time.Time) (*ScenarioResult, error) {
+	result := &ScenarioResult{
+		Scenario: scenario,
+		Date:     now.AddDate(0, 0, scenario.DaysForward),
+		Symbols:  []*ScenarioSymbol{},
+		Options:  []*ScenarioOption{},
+		Stocks:   []*ScenarioStock{},
+		ITMPuts:  []*ScenarioOption{},
+		Cash:     balance,
+		Unpriced: []string{},
+	}
+
+	shocked := make(map[string]*Symbol)
+	unpriced := make(map[string]bool)
+	shock := func(name string) (*Symbol, *Symbol) {
+		underlying, ok := instruments[name]
+		if !ok || underlying.Price <= 0 {
+			unpriced[name] = true
+			return nil, nil
+		}
+		if moved, ok := shocked[name]; ok {
+			return underlying, moved
+		}
+
+		priceMove, volatilityChange := scenario.Shock(name)
+		current := volatility
+		if underlying.Volatility != nil {
+			current = *underlying.Volatility
+		}
+		moved := *underlying
+		moved.Price = underlying.Price * (1 + priceMove/100)
+		movedVolatility := math.Max(current+volatilityChange, minScenarioVolatility)
+		moved.Volatility = &movedVolatility
+		shocked[name] = &moved
+
+		result.Symbols = append(result.Symbols, &ScenarioSymbol{
+			Symbol:            name,
+			Price:             underlying.Price,
+			ShockedPrice:      moved.Price,
+			PriceMove:         priceMove,
+			Volatility:        current,
+			ShockedVolatility: movedVolatility,
+		})
+		return underlying, &moved
+	}
+
+	for _, position := range longPositions {
+		if position.Closed != nil {
+			continue
+		}
+		underlying, moved := shock(position.Symbol)
+		if underlying == nil {
+			continue
+		}
+		stock := &ScenarioStock{
+			PositionID:   position.ID,
+			Symbol:       position.Symbol,
+			Shares:       position.Shares,
+			Price:        underlying.Price,
+			ShockedPrice: moved.Price,
+			PnL:          float64(position.Shares) * (moved.Price - underlying.Price),
+		}
+		result.StockPnL += stock.PnL
+		result.Stocks = append(result.Stocks, stock)
+	}
+
+	var priced []*Option
+	assignments := make(map[int]float64)
+	for _, option := range options {
+		if option.Closed != nil {
+			continue
+		}
+		underlying, moved := shock(option.Symbol)
+		if underlying == nil {
+			continue
+		}
+
+		today, err := PriceOption(option, underlying, rate, volatility, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price option %d: %w", option.ID, err)
+		}
+		after, err := PriceOption(option, moved, rate, volatility, result.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price option %d under the scenario: %w", option.ID, err)
+		}
+
+		valued := &ScenarioOption{
+			OptionID:     option.ID,
+			Symbol:       option.Symbol,
+			Type:         option.Type,
+			Direction:    option.Direction,
+			Strike:       option.Strike,
+			Expiration:   option.Expiration,
+			Contracts:    option.Contracts,
+			Value:        today.Position.Value,
+			ShockedValue: after.Position.Value,
+			PnL:          after.Position.Value - today.Position.Value,
+		}
+		if option.Type == "Call" {
+			valued.ITM = moved.Price > option.Strike
+		} else {
+			valued.ITM = moved.Price < option.Strike
+		}
+		result.OptionPnL += valued.PnL
+		result.Options = append(result.Options, valued)
+
+		valued.Assignment = putAssignment(option, moved, moved.Price)
+		assignments[option.ID] = valued.Assignment
+		priced = append(priced, option)
+		if valued.Assignment > 0 && !option.IsLong() {
+			result.ITMPuts = append(result.ITMPuts, valued)
+		}
+	}
+	result.AssignmentCapital = netAssignment(priced, func(option *Option) float64 {
+		return assignments[option.ID]
+	})
+
+	for _, treasury := range treasuries {
+		if treasury.ExitPrice == nil {
+			result.Treasuries += treasury.Amount
+		}
+	}
+	result.Shortfall = math.Max(0, result.AssignmentCapital-result.Cash-result.Treasuries)
+	result.PnL = result.OptionPnL + result.StockPnL
+
+	sort.Slice(result.Symbols, func(i, j int) bool {
+		return result.Symbols[i].Symbol < result.Symbols[j].Symbol
+	})
+	sort.SliceStable(result.ITMPuts, func(i, j int) bool {
+		return result.ITMPuts[i].Expiration.Before(result.ITMPuts[j].Expiration)
+	})
+	for symbol := range unpriced {
+		result.Unpriced = append(result.Unpriced, symbol)
+	}
+	sort.Strings(result.Unpriced)
+
+	return result, nil
+}
+
+type ScenarioService struct {
+	db *sql.DB
+}
+
+func NewScenarioService(db *sql.DB) *ScenarioService {
+	return &ScenarioService{db: db}
+}
+
+// saveMoves replaces the per-symbol moves of a scenario
+func saveMoves(tx *sql.Tx, scenarioID int, moves []*ScenarioMove) error {
+	if _, err := tx.Exec(`DELETE FROM scenario_moves WHERE scenario_id = ?`, scenarioID); err != nil {
+		return fmt.Errorf("failed to clear scenario moves: %w", err)
+	}
+	for _, move := range moves {
+		_, err := tx.Exec(`INSERT INTO scenario_moves (scenario_id, symbol, price_move, volatility_change) VALUES (?, ?, ?, ?)`,
+			scenarioID, move.Symbol, move.PriceMove, move.VolatilityChange)
+		if err != nil {
+			return fmt.Errorf("failed to save move of %s: %w", move.Symbol, err)
+		}
+	}
+	return nil
+}
+
+// Create saves a scenario and its per-symbol moves
+func (s *ScenarioService) Create(scenario *Scenario) (*Scenario, error) {
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	if scenario.Name == "" {
+		return nil, fmt.Errorf("scenario name is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO scenarios (name, price_move, volatility_change, days_forward) VALUES (?, ?, ?, ?) RETURNING id`,
+		scenario.Name, scenario.PriceMove, scenario.VolatilityChange, scenario.DaysForward).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scenario: %w", err)
+	}
+	if err := saveMoves(tx, id, scenario.Moves); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit scenario: %w", err)
+	}
+
+	return s.GetByID(id)
+}
+
+// Update replaces a scenario's name, shock and per-symbol moves
+func (s *ScenarioService) Update(id int, scenario *Scenario) (*Scenario, error) {
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	if scenario.Name == "" {
+		return nil, fmt.Errorf("scenario name is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE scenarios SET name = ?, price_move = ?, volatility_change = ?, days_forward = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		scenario.Name, scenario.PriceMove, scenario.VolatilityChange, scenario.DaysForward, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("scenario not found")
+	}
+	if err := saveMoves(tx, id, scenario.Moves); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit scenario: %w", err)
+	}
+
+	return s.GetByID(id)
+}
+
+// moves loads the per-symbol moves of every scenario, keyed by scenario ID
+func (s *ScenarioService) moves() (map[int][]*ScenarioMove, error) {
+	rows, err := s.db.Query(`SELECT scenario_id, symbol, price_move, volatility_change FROM scenario_moves ORDER BY symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenario moves: %w", err)
+	}
+	defer rows.Close()
+
+	moves := make(map[int][]*ScenarioMove)
+	for rows.Next() {
+		var scenarioID int
+		var move ScenarioMove
+		if err := rows.Scan(&scenarioID, &move.Symbol, &move.PriceMove, &move.VolatilityChange); err != nil {
+			return nil, fmt.Errorf("failed to scan scenario move: %w", err)
+		}
+		moves[scenarioID] = append(moves[scenarioID], &move)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scenario moves: %w", err)
+	}
+
+	return moves, nil
+}
+
+// GetAll returns the saved scenarios by name with their moves
+func (s *ScenarioService) GetAll() ([]*Scenario, error) {
+	rows, err := s.db.Query(`SELECT id, name, price_move, volatility_change, days_forward, created_at, updated_at FROM scenarios ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenarios: %w", err)
+	}
+	defer rows.Close()
+
+	scenarios := []*Scenario{}
+	for rows.Next() {
+		var scenario Scenario
+		if err := rows.Scan(&scenario.ID, &scenario.Name, &scenario.PriceMove, &scenario.VolatilityChange, &scenario.DaysForward,
+			&scenario.CreatedAt, &scenario.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scenario: %w", err)
+		}
+		scenarios = append(scenarios, &scenario)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scenarios: %w", err)
+	}
+	rows.Close()
+
+	moves, err := s.moves()
+	if err != nil {
+		return nil, err
+	}
+	for _, scenario := range scenarios {
+		scenario.Moves = moves[scenario.ID]
+		if scenario.Moves == nil {
+			scenario.Moves = []*ScenarioMove{}
+		}
+	}
+
+	return scenarios, nil
+}
+
+func (s *ScenarioService) GetByID(id int) (*Scenario, error) {
+	var scenario Scenario
+	err := s.db.QueryRow(`SELECT id, name, price_move, volatility_change, days_forward, created_at, updated_at FROM scenarios WHERE id = ?`, id).Scan(
+		&scenario.ID, &scenario.Name, &scenario.PriceMove, &scenario.VolatilityChange, &scenario.DaysForward, &scenario.CreatedAt, &scenario.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scenario not found")
+		}
+		return nil, fmt.Errorf("failed to get scenario: %w", err)
+	}
+
+	moves, err := s.moves()
+	if err != nil {
+		return nil, err
+	}
+	scenario.Moves = moves[id]
+	if scenario.Moves == nil {
+		scenario.Moves = []*ScenarioMove{}
+	}
+
+	return &scenario, nil
+}
+
+// Delete removes a scenario and its moves
+func (s *ScenarioService) Delete(id int) error {
+	result, err := s.db.Exec(`DELETE FROM scenarios WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete scenario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("scenario not found")
+	}
+
+	return nil
+}
+
+// Run runs a scenario, saved or not, against the open positions, treasuries
+// and cash of one account, or of the household if accountID is nil
+func (s *ScenarioService) Run(scenario *Scenario, accountID *int, now time.Time) (*ScenarioResult, error) {
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter, err := NewAccountService(s.db).Filter(accountID)
+	if err != nil {
+		return nil, err
+	}
+	options, err := NewOptionService(s.db).GetOpen()
+	if err != nil {
+		return nil, err
+	}
+	longPositions, err := NewLongPositionService(s.db).GetOpenPositions()
+	if err != nil {
+		return nil, err
+	}
+	treasuries, err := NewTreasuryService(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := NewSymbolService(s.db).GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	ledger, err := NewCashService(s.db).Ledger(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cash ledger: %w", err)
+	}
+	rate, volatility := NewPricingService(s.db).Rates()
+
+	return RunScenario(scenario, filter.Options(options), filter.LongPositions(longPositions), instruments,
+		filter.Treasuries(treasuries), ledger.Balance, rate, volatility, now)
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRunScenario_MarketDrop(t *testing.T) {
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	expiration := time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC)
+	strategyID := 3
+
+	instruments := Instruments{
+		"AAPL": {Symbol: "AAPL", Price: 200},
+		"KO":   {Symbol: "KO", Price: 60},
+		"XYZ":  {Symbol: "XYZ"},
+	}
+	options := []*Option{
+		// 190 put goes in the money at 170
+		{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 190, Premium: 3, Contracts: 1, Expiration: expiration},
+		// 180 call goes out of the money
+		{ID: 2, Symbol: "AAPL", Type: "Call", Direction: DirectionShort, Strike: 180, Premium: 25, Contracts: 1, Expiration: expiration},
+		// KO is held flat, so its 55 put stays out of the money
+		{ID: 3, Symbol: "KO", Type: "Put", Direction: DirectionShort, Strike: 55, Premium: 1, Contracts: 2, Expiration: expiration},
+		// 195/185 put spread: both legs in the money, the long put pays for all but the width
+		{ID: 4, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 195, Premium: 4, Contracts: 1, Expiration: expiration, StrategyID: &strategyID},
+		{ID: 5, Symbol: "AAPL", Type: "Put", Direction: DirectionLong, Strike: 185, Premium: 2, Contracts: 1, Expiration: expiration, StrategyID: &strategyID},
+		// No price to shock
+		{ID: 6, Symbol: "XYZ", Type: "Put", Direction: DirectionShort, Strike: 10, Premium: 1, Contracts: 1, Expiration: expiration},
+	}
+	longPositions := []*LongPosition{
+		{ID: 1, Symbol: "AAPL", Shares: 100, BuyPrice: 150},
+		{ID: 2, Symbol: "KO", Shares: 200, BuyPrice: 50},
+	}
+	treasuries := []*Treasury{{CUSPID: "912797AA1", Amount: 5000}}
+
+	flat := 0.0
+	scenario := &Scenario{Name: "Crash", PriceMove: -15, VolatilityChange: 10, DaysForward: 1, Moves: []*ScenarioMove{{Symbol: "ko", PriceMove: &flat}}}
+	if err := scenario.Validate(); err != nil {
+		t.Fatalf("Expected a valid scenario: %v", err)
+	}
+
+	result, err := RunScenario(scenario, options, longPositions, instruments, treasuries, 10000, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to run scenario: %v", err)
+	}
+
+	if !result.Date.Equal(now.AddDate(0, 0, 1)) {
+		t.Errorf("Expected the scenario a day forward, got %s", result.Date)
+	}
+	if len(result.Symbols) != 2 || result.Symbols[0].ShockedPrice != 170 || result.Symbols[1].ShockedPrice != 60 {
+		t.Fatalf("Expected AAPL shocked to 170 and KO held at 60, got %+v", result.Symbols)
+	}
+	if result.Symbols[0].ShockedVolatility != 40 {
+		t.Errorf("Expected AAPL volatility up 10 points to 40, got %.2f", result.Symbols[0].ShockedVolatility)
+	}
+	if len(result.Unpriced) != 1 || result.Unpriced[0] != "XYZ" {
+		t.Errorf("Expected XYZ to be unpriced, got %v", result.Unpriced)
+	}
+
+	// Stock loses 30 a share on AAPL and nothing on KO
+	if math.Abs(result.StockPnL-(-3000)) > 0.001 {
+		t.Errorf("Expected stock P&L of -3000, got %.2f", result.StockPnL)
+	}
+
+	// The short put loses and the short call gains as the stock falls
+	byID := make(map[int]*ScenarioOption)
+	for _, option := range result.Options {
+		byID[option.OptionID] = option
+	}
+	if byID[1].PnL >= 0 || !byID[1].ITM {
+		t.Errorf("Expected the short put to lose and go in the money, got %+v", byID[1])
+	}
+	if byID[2].PnL <= 0 || byID[2].ITM {
+		t.Errorf("Expected the short call to gain and go out of the money, got %+v", byID[2])
+	}
+	if byID[3].ITM {
+		t.Errorf("Expected the KO put to stay out of the money")
+	}
+	if math.Abs(result.PnL-(result.OptionPnL+result.StockPnL)) > 0.001 {
+		t.Errorf("Expected P&L to add option and stock P&L, got %.2f", result.PnL)
+	}
+
+	// 19000 for the naked put and 1000 of spread width
+	if len(result.ITMPuts) != 2 {
+		t.Errorf("Expected the naked put and the spread's short put to be in the money, got %d", len(result.ITMPuts))
+	}
+	if math.Abs(result.AssignmentCapital-20000) > 0.001 {
+		t.Errorf("Expected assignment capital of 20000, got %.2f", result.AssignmentCapital)
+	}
+	if math.Abs(result.Shortfall-5000) > 0.001 {
+		t.Errorf("Expected a 5000 shortfall after 10000 cash and 5000 of treasuries, got %.2f", result.Shortfall)
+	}
+}
+
+func TestRunScenario_NoShockNoPnL(t *testing.T) {
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	instruments := Instruments{"AAPL": {Symbol: "AAPL", Price: 200}}
+	options := []*Option{
+		{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Strike: 190, Premium: 3, Contracts: 1, Expiration: time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	result, err := RunScenario(&Scenario{}, options, nil, instruments, nil, 0, 4.5, 30, now)
+	if err != nil {
+		t.Fatalf("Failed to run scenario: %v", err)
+	}
+	if math.Abs(result.PnL) > 0.001 || result.AssignmentCapital != 0 {
+		t.Errorf("Expected no P&L or assignment without a shock, got %.2f and %.2f", result.PnL, result.AssignmentCapital)
+	}
+}
+
+func TestScenarioService_SaveWithMoves(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	service := NewScenarioService(testDB.DB)
+
+	drop := -30.0
+	scenario, err := service.Create(&Scenario{Name: "Tech selloff", PriceMove: -5, DaysForward: 7, Moves: []*ScenarioMove{{Symbol: "aapl", PriceMove: &drop}}})
+	if err != nil {
+		t.Fatalf("Failed to create scenario: %v", err)
+	}
+	if len(scenario.Moves) != 1 || scenario.Moves[0].Symbol != "AAPL" || *scenario.Moves[0].PriceMove != -30 || scenario.Moves[0].VolatilityChange != nil {
+		t.Fatalf("Expected the AAPL move to be saved, got %+v", scenario.Moves)
+	}
+	if priceMove, _ := scenario.Shock("MSFT"); priceMove != -5 {
+		t.Errorf("Expected other symbols to take the portfolio move, got %.2f", priceMove)
+	}
+
+	if _, err := service.Create(&Scenario{Name: "Tech selloff"}); err == nil {
+		t.Errorf("Expected a duplicate name to fail")
+	}
+	if _, err := service.Create(&Scenario{Name: "Wipeout", PriceMove: -100}); err == nil {
+		t.Errorf("Expected a 100%% drop to be rejected")
+	}
+
+	scenario.VolatilityChange = 15
+	scenario.Moves = nil
+	updated, err := service.Update(scenario.ID, scenario)
+	if err != nil {
+		t.Fatalf("Failed to update scenario: %v", err)
+	}
+	if updated.VolatilityChange != 15 || len(updated.Moves) != 0 {
+		t.Errorf("Expected the volatility change saved and the moves cleared, got %+v", updated)
+	}
+
+	if err := service.Delete(scenario.ID); err != nil {
+		t.Fatalf("Failed to delete scenario: %v", err)
+	}
+	scenarios, err := service.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get scenarios: %v", err)
+	}
+	if len(scenarios) != 0 {
+		t.Errorf("Expected no scenarios after delete, got %d", len(scenarios))
+	}
+}
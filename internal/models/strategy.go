@@ -0,0 +1,533 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Strategy types
+const (
+	StrategyPutCreditSpread  = "put_credit_spread"
+	StrategyCallCreditSpread = "call_credit_spread"
+	StrategyStrangle         = "strangle"
+	StrategyIronCondor       = "iron_condor"
+	StrategyJadeLizard       = "jade_lizard"
+)
+
+// Strategy groups the option legs of a multi-leg position
+type Strategy struct {
+	ID        int       `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Type      string    `json:"type"`
+	Opened    time.Time `json:"opened"`
+	Notes     *string   `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Legs      []*Option `json:"legs"`
+}
+
+// StrategyLeg describes one leg of a strategy to be opened
+type StrategyLeg struct {
+	Type       string    `json:"type"`
+	Direction  string    `json:"direction"`
+	Strike     float64   `json:"strike"`
+	Expiration time.Time `json:"expiration"`
+	Premium    float64   `json:"premium"`
+	Contracts  int       `json:"contracts"`
+}
+
+// StrategyAnalysis holds the risk profile of a strategy at expiration. Dollar
+// amounts cover all contracts and exclude commissions.
+type StrategyAnalysis struct {
+	NetCredit          float64   `json:"net_credit"`
+	MaxProfit          float64   `json:"max_profit"`
+	MaxProfitUnlimited bool      `json:"max_profit_unlimited"`
+	MaxLoss            float64   `json:"max_loss"`
+	MaxLossUnlimited   bool      `json:"max_loss_unlimited"`
+	Breakevens         []float64 `json:"breakevens"`
+	BuyingPower        float64   `json:"buying_power"`
+	PutCollateral      float64   `json:"put_collateral"`
+}
+
+// GetTypeLabel returns a display label for the strategy type
+func (s *Strategy) GetTypeLabel() string {
+	switch s.Type {
+	case StrategyPutCreditSpread:
+		return "Put Credit Spread"
+	case StrategyCallCreditSpread:
+		return "Call Credit Spread"
+	case StrategyStrangle:
+		return "Strangle"
+	case StrategyIronCondor:
+		return "Iron Condor"
+	case StrategyJadeLizard:
+		return "Jade Lizard"
+	default:
+		return s.Type
+	}
+}
+
+// IsOpen returns true while any leg is open
+func (s *Strategy) IsOpen() bool {
+	for _, leg := range s.Legs {
+		if leg.IsOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+// CalculateTotalProfit sums the net profit of every leg
+func (s *Strategy) CalculateTotalProfit() float64 {
+	var total float64
+	for _, leg := range s.Legs {
+		total += leg.CalculateTotalProfit()
+	}
+	return total
+}
+
+// Analyze calculates max profit, max loss, breakevens and buying power
+func (s *Strategy) Analyze() StrategyAnalysis {
+	return AnalyzeLegs(s.Legs)
+}
+
+// AnalyzeLegs calculates the expiration risk profile of a set of option legs.
+// The payoff is piecewise linear in the underlying price, so it is evaluated at
+// zero and at every strike, and extended past the highest strike by its slope.
+// The legs must share one expiration, as validateStrategyLegs requires.
+func AnalyzeLegs(legs []*Option) StrategyAnalysis {
+	analysis := StrategyAnalysis{Breakevens: []float64{}}
+	if len(legs) == 0 {
+		return analysis
+	}
+
+	prices := []float64{0}
+	seen := map[float64]bool{0: true}
+	var slope float64
+	for _, leg := range legs {
+		quantity := float64(leg.Contracts * leg.Multiplier())
+		if leg.IsLong() {
+			analysis.NetCredit -= leg.Premium * quantity
+		} else {
+			analysis.NetCredit += leg.Premium * quantity
+		}
+		if leg.Type == "Call" {
+			if leg.IsLong() {
+				slope += quantity
+			} else {
+				slope -= quantity
+			}
+		}
+		if !seen[leg.Strike] {
+			seen[leg.Strike] = true
+			prices = append(prices, leg.Strike)
+		}
+	}
+	sort.Float64s(prices)
+
+	values := make([]float64, len(prices))
+	for i, price := range prices {
+		values[i] = payoffAt(legs, price)
+	}
+
+	analysis.MaxProfit, analysis.MaxLoss = values[0], values[0]
+	for _, value := range values {
+		analysis.MaxProfit = math.Max(analysis.MaxProfit, value)
+		analysis.MaxLoss = math.Min(analysis.MaxLoss, value)
+	}
+	analysis.MaxProfitUnlimited = slope > 0
+	analysis.MaxLossUnlimited = slope < 0
+	// Report losses as a positive amount
+	analysis.MaxLoss = math.Max(0, -analysis.MaxLoss)
+
+	// Extend one point past the highest strike so crossings beyond it are found.
+	// A zero only counts as a breakeven when the payoff changes sign across it.
+	last := len(prices) - 1
+	step := 1.0
+	if slope != 0 {
+		step += math.Abs(values[last] / slope)
+	}
+	prices = append(prices, prices[last]+step)
+	values = append(values, values[last]+slope*step)
+	previous := values[0]
+	for i := 0; i < len(prices)-1; i++ {
+		if values[i] == 0 && i > 0 && previous != 0 {
+			for j := i + 1; j < len(values); j++ {
+				if values[j] != 0 {
+					if values[j]*previous < 0 {
+						analysis.Breakevens = append(analysis.Breakevens, prices[i])
+					}
+					break
+				}
+			}
+		} else if values[i]*values[i+1] < 0 {
+			analysis.Breakevens = append(analysis.Breakevens, prices[i]+(prices[i+1]-prices[i])*values[i]/(values[i]-values[i+1]))
+		}
+		if values[i] != 0 {
+			previous = values[i]
+		}
+	}
+
+	analysis.PutCollateral = CalculatePutCollateral(legs)
+
+	// Defined risk ties up the max loss. Undefined upside risk is secured like
+	// the put side: the loss if the underlying goes to zero.
+	if analysis.MaxLossUnlimited {
+		analysis.BuyingPower = math.Max(0, -values[0])
+	} else {
+		analysis.BuyingPower = analysis.MaxLoss
+	}
+
+	return analysis
+}
+
+// payoffAt returns the combined profit of the legs if held to expiration with
+// the underlying at price
+func payoffAt(legs []*Option, price float64) float64 {
+	var total float64
+	for _, leg := range legs {
+		var intrinsic float64
+		if leg.Type == "Put" {
+			intrinsic = math.Max(0, leg.Strike-price)
+		} else {
+			intrinsic = math.Max(0, price-leg.Strike)
+		}
+		quantity := float64(leg.Contracts * leg.Multiplier())
+		if leg.IsLong() {
+			total += (intrinsic - leg.Premium) * quantity
+		} else {
+			total += (leg.Premium - intrinsic) * quantity
+		}
+	}
+	return total
+}
+
+// CalculatePutCollateral returns the cash needed to cover the put legs if the
+// underlying goes to zero: short put strikes less the long puts protecting them.
+// A lone short put needs strike * contracts * multiplier; a put credit spread only its width.
+func CalculatePutCollateral(legs []*Option) float64 {
+	var collateral float64
+	for _, leg := range legs {
+		if leg.Type != "Put" {
+			continue
+		}
+		amount := leg.Strike * float64(leg.Contracts*leg.Multiplier())
+		if leg.IsLong() {
+			collateral -= amount
+		} else {
+			collateral += amount
+		}
+	}
+	return math.Max(0, collateral)
+}
+
+// CalculatePutExposureBySymbol returns the collateral of the open puts per
+// symbol. Puts in the same strategy are netted together; other puts each count
+// on their own.
+func CalculatePutExposureBySymbol(options []*Option) map[string]float64 {
+	exposure := make(map[string]float64)
+	strategyLegs := make(map[int][]*Option)
+	for _, option := range options {
+		if option.Closed != nil || option.Type != "Put" {
+			continue
+		}
+		if option.StrategyID != nil {
+			strategyLegs[*option.StrategyID] = append(strategyLegs[*option.StrategyID], option)
+			continue
+		}
+		// Protective puts on their own secure nothing
+		if !option.IsLong() {
+			exposure[option.Symbol] += CalculatePutCollateral([]*Option{option})
+		}
+	}
+	for _, legs := range strategyLegs {
+		if collateral := CalculatePutCollateral(legs); collateral > 0 {
+			exposure[legs[0].Symbol] += collateral
+		}
+	}
+	return exposure
+}
+
+// validateStrategyLegs checks that the legs form the given strategy type
+func validateStrategyLegs(strategyType string, legs []StrategyLeg) error {
+	var shortPuts, longPuts, shortCalls, longCalls []StrategyLeg
+	for _, leg := range legs {
+		if leg.Type != "Put" && leg.Type != "Call" {
+			return fmt.Errorf("leg type must be 'Put' or 'Call'")
+		}
+		if leg.Direction != DirectionShort && leg.Direction != DirectionLong {
+			return fmt.Errorf("leg direction must be '%s' or '%s'", DirectionShort, DirectionLong)
+		}
+		if leg.Strike <= 0 || leg.Premium < 0 || leg.Contracts <= 0 {
+			return fmt.Errorf("legs need a positive strike and contracts and a non-negative premium")
+		}
+		// The analysis values every leg at one expiration, so calendars and diagonals are not supported
+		if !leg.Expiration.Equal(legs[0].Expiration) {
+			return fmt.Errorf("all legs must share one expiration")
+		}
+		switch {
+		case leg.Type == "Put" && leg.Direction == DirectionShort:
+			shortPuts = append(shortPuts, leg)
+		case leg.Type == "Put":
+			longPuts = append(longPuts, leg)
+		case leg.Direction == DirectionShort:
+			shortCalls = append(shortCalls, leg)
+		default:
+			longCalls = append(longCalls, leg)
+		}
+	}
+
+	counts := func(sp, lp, sc, lc int) bool {
+		return len(shortPuts) == sp && len(longPuts) == lp && len(shortCalls) == sc && len(longCalls) == lc
+	}
+
+	switch strategyType {
+	case StrategyPutCreditSpread:
+		if !counts(1, 1, 0, 0) {
+			return fmt.Errorf("a put credit spread needs one short put and one long put")
+		}
+		if longPuts[0].Strike >= shortPuts[0].Strike {
+			return fmt.Errorf("the long put strike must be below the short put strike")
+		}
+	case StrategyCallCreditSpread:
+		if !counts(0, 0, 1, 1) {
+			return fmt.Errorf("a call credit spread needs one short call and one long call")
+		}
+		if longCalls[0].Strike <= shortCalls[0].Strike {
+			return fmt.Errorf("the long call strike must be above the short call strike")
+		}
+	case StrategyStrangle:
+		if !counts(1, 0, 1, 0) {
+			return fmt.Errorf("a strangle needs one short put and one short call")
+		}
+		if shortPuts[0].Strike > shortCalls[0].Strike {
+			return fmt.Errorf("the put strike cannot be above the call strike")
+		}
+	case StrategyIronCondor:
+		if !counts(1, 1, 1, 1) {
+			return fmt.Errorf("an iron condor needs a short and a long put and a short and a long call")
+		}
+		if !(longPuts[0].Strike < shortPuts[0].Strike && shortPuts[0].Strike <= shortCalls[0].Strike && shortCalls[0].Strike < longCalls[0].Strike) {
+			return fmt.Errorf("iron condor strikes must be ordered long put < short put <= short call < long call")
+		}
+	case StrategyJadeLizard:
+		if !counts(1, 0, 1, 1) {
+			return fmt.Errorf("a jade lizard needs one short put, one short call and one long call")
+		}
+		if !(shortPuts[0].Strike < shortCalls[0].Strike && shortCalls[0].Strike < longCalls[0].Strike) {
+			return fmt.Errorf("jade lizard strikes must be ordered short put < short call < long call")
+		}
+	default:
+		return fmt.Errorf("unknown strategy type: %s", strategyType)
+	}
+
+	return nil
+}
+
+type StrategyService struct {
+	db *sql.DB
+}
+
+func NewStrategyService(db *sql.DB) *StrategyService {
+	return &StrategyService{db: db}
+}
+
+// Create opens a strategy and all of its legs in one transaction. Each leg is
+// charged the standard opening commission.
+func (s *StrategyService) Create(symbol, strategyType string, opened time.Time, legs []StrategyLeg, notes *string) (*Strategy, error) {
+	if err := validateStrategyLegs(strategyType, legs); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO strategies (symbol, type, opened, notes)
+			  VALUES (?, ?, ?, ?)
+			  RETURNING id, symbol, type, opened, notes, created_at, updated_at`
+
+	var strategy Strategy
+	err = tx.QueryRow(query, symbol, strategyType, opened, notes).Scan(
+		&strategy.ID, &strategy.Symbol, &strategy.Type, &strategy.Opened,
+		&strategy.Notes, &strategy.CreatedAt, &strategy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create strategy: %w", err)
+	}
+
+	query = `INSERT INTO options (symbol, type, opened, strike, expiration, premium, contracts, commission, direction, strategy_id)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)`
+
+	for _, leg := range legs {
+		commission := OptionCommissionPerContract * float64(leg.Contracts)
+		var option Option
+		err := tx.QueryRow(query, symbol, leg.Type, opened, leg.Strike, leg.Expiration, leg.Premium, leg.Contracts, commission, leg.Direction, strategy.ID).Scan(
+			&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
+			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s %s leg: %w", leg.Direction, leg.Type, err)
+		}
+		strategy.Legs = append(strategy.Legs, &option)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit strategy: %w", err)
+	}
+
+	return &strategy, nil
+}
+
+// GetByID retrieves a strategy with its legs
+func (s *StrategyService) GetByID(id int) (*Strategy, error) {
+	query := `SELECT id, symbol, type, opened, notes, created_at, updated_at
+			  FROM strategies WHERE id = ?`
+
+	var strategy Strategy
+	err := s.db.QueryRow(query, id).Scan(
+		&strategy.ID, &strategy.Symbol, &strategy.Type, &strategy.Opened,
+		&strategy.Notes, &strategy.CreatedAt, &strategy.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("strategy not found")
+		}
+		return nil, fmt.Errorf("failed to get strategy: %w", err)
+	}
+
+	if strategy.Legs, err = s.getLegs(strategy.ID); err != nil {
+		return nil, err
+	}
+
+	return &strategy, nil
+}
+
+// GetBySymbol retrieves all strategies for a symbol with their legs
+func (s *StrategyService) GetBySymbol(symbol string) ([]*Strategy, error) {
+	query := `SELECT id, symbol, type, opened, notes, created_at, updated_at
+			  FROM strategies WHERE symbol = ? ORDER BY opened DESC, id DESC`
+
+	return s.query(query, symbol)
+}
+
+// GetAll retrieves all strategies with their legs
+func (s *StrategyService) GetAll() ([]*Strategy, error) {
+	query := `SELECT id, symbol, type, opened, notes, created_at, updated_at
+			  FROM strategies ORDER BY opened DESC, id DESC`
+
+	return s.query(query)
+}
+
+func (s *StrategyService) query(query string, args ...interface{}) ([]*Strategy, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategies: %w", err)
+	}
+	defer rows.Close()
+
+	var strategies []*Strategy
+	for rows.Next() {
+		var strategy Strategy
+		if err := rows.Scan(&strategy.ID, &strategy.Symbol, &strategy.Type, &strategy.Opened,
+			&strategy.Notes, &strategy.CreatedAt, &strategy.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan strategy: %w", err)
+		}
+		strategies = append(strategies, &strategy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating strategies: %w", err)
+	}
+
+	for _, strategy := range strategies {
+		if strategy.Legs, err = s.getLegs(strategy.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return strategies, nil
+}
+
+func (s *StrategyService) getLegs(strategyID int) ([]*Option, error) {
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, close_reason, parent_option_id, direction, strategy_id, created_at, updated_at, COALESCE((SELECT contract_multiplier FROM symbols WHERE symbols.symbol = options.symbol), 100)
+			  FROM options WHERE strategy_id = ? ORDER BY type DESC, strike ASC, id ASC`
+
+	rows, err := s.db.Query(query, strategyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy legs: %w", err)
+	}
+	defer rows.Close()
+
+	var legs []*Option
+	for rows.Next() {
+		var option Option
+		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
+			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CloseReason, &option.ParentOptionID, &option.Direction, &option.StrategyID, &option.CreatedAt, &option.UpdatedAt, &option.ContractMultiplier); err != nil {
+			return nil, fmt.Errorf("failed to scan strategy leg: %w", err)
+		}
+		legs = append(legs, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating strategy legs: %w", err)
+	}
+
+	return legs, nil
+}
+
+// Delete removes a strategy, leaving its legs in place as individual options
+func (s *StrategyService) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE options SET strategy_id = NULL WHERE strategy_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to unlink strategy legs: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM strategies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete strategy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("strategy not found")
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBySymbol removes all strategies for a symbol
+func (s *StrategyService) DeleteBySymbol(symbol string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE options SET strategy_id = NULL WHERE strategy_id IN (SELECT id FROM strategies WHERE symbol = ?)`, symbol); err != nil {
+		return fmt.Errorf("failed to unlink strategy legs: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM strategies WHERE symbol = ?`, symbol); err != nil {
+		return fmt.Errorf("failed to delete strategies: %w", err)
+	}
+
+	return tx.Commit()
+}
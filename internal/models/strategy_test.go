@@ -0,0 +1,187 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeLegs(t *testing.T) {
+	leg := func(optionType, direction string, strike, premium float64) *Option {
+		return &Option{Symbol: "SPY", Type: optionType, Direction: direction, Strike: strike, Premium: premium, Contracts: 1}
+	}
+
+	tests := []struct {
+		name             string
+		legs             []*Option
+		maxProfit        float64
+		maxLoss          float64
+		maxLossUnlimited bool
+		breakevens       []float64
+		buyingPower      float64
+		putCollateral    float64
+	}{
+		{
+			name:          "put credit spread",
+			legs:          []*Option{leg("Put", DirectionShort, 100, 2.00), leg("Put", DirectionLong, 95, 0.50)},
+			maxProfit:     150,
+			maxLoss:       350,
+			breakevens:    []float64{98.50},
+			buyingPower:   350,
+			putCollateral: 500,
+		},
+		{
+			name:             "strangle",
+			legs:             []*Option{leg("Put", DirectionShort, 90, 1.00), leg("Call", DirectionShort, 110, 1.50)},
+			maxProfit:        250,
+			maxLoss:          8750,
+			maxLossUnlimited: true,
+			breakevens:       []float64{87.50, 112.50},
+			buyingPower:      8750,
+			putCollateral:    9000,
+		},
+		{
+			name: "iron condor",
+			legs: []*Option{
+				leg("Put", DirectionLong, 85, 0.40), leg("Put", DirectionShort, 90, 1.20),
+				leg("Call", DirectionShort, 110, 1.10), leg("Call", DirectionLong, 115, 0.30),
+			},
+			maxProfit:     160,
+			maxLoss:       340,
+			breakevens:    []float64{88.40, 111.60},
+			buyingPower:   340,
+			putCollateral: 500,
+		},
+		{
+			// Credit exceeds the call spread width, so there is no upside risk
+			name: "jade lizard",
+			legs: []*Option{
+				leg("Put", DirectionShort, 90, 2.00), leg("Call", DirectionShort, 105, 1.50),
+				leg("Call", DirectionLong, 108, 0.50),
+			},
+			maxProfit:     300,
+			maxLoss:       8700,
+			breakevens:    []float64{87.00},
+			buyingPower:   8700,
+			putCollateral: 9000,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			analysis := AnalyzeLegs(test.legs)
+
+			if math.Abs(analysis.MaxProfit-test.maxProfit) > 0.001 || analysis.MaxProfitUnlimited {
+				t.Errorf("Expected max profit %.2f, got %.2f (unlimited %v)", test.maxProfit, analysis.MaxProfit, analysis.MaxProfitUnlimited)
+			}
+			if analysis.MaxLossUnlimited != test.maxLossUnlimited {
+				t.Errorf("Expected unlimited max loss %v, got %v", test.maxLossUnlimited, analysis.MaxLossUnlimited)
+			}
+			if math.Abs(analysis.MaxLoss-test.maxLoss) > 0.001 {
+				t.Errorf("Expected max loss %.2f, got %.2f", test.maxLoss, analysis.MaxLoss)
+			}
+			if math.Abs(analysis.BuyingPower-test.buyingPower) > 0.001 {
+				t.Errorf("Expected buying power %.2f, got %.2f", test.buyingPower, analysis.BuyingPower)
+			}
+			if math.Abs(analysis.PutCollateral-test.putCollateral) > 0.001 {
+				t.Errorf("Expected put collateral %.2f, got %.2f", test.putCollateral, analysis.PutCollateral)
+			}
+			if len(analysis.Breakevens) != len(test.breakevens) {
+				t.Fatalf("Expected breakevens %v, got %v", test.breakevens, analysis.Breakevens)
+			}
+			for i, breakeven := range test.breakevens {
+				if math.Abs(analysis.Breakevens[i]-breakeven) > 0.001 {
+					t.Errorf("Expected breakevens %v, got %v", test.breakevens, analysis.Breakevens)
+				}
+			}
+		})
+	}
+}
+
+func TestStrategyService_Create(t *testing.T) {
+	testDB := setupOptionTestDB(t)
+	strategyService := NewStrategyService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	metricService := NewMetricService(testDB.DB)
+
+	opened := time.Now().Truncate(24*time.Hour).AddDate(0, 0, -7)
+	expiration := opened.AddDate(0, 0, 30)
+
+	strategy, err := strategyService.Create("AAPL", StrategyPutCreditSpread, opened, []StrategyLeg{
+		{Type: "Put", Direction: DirectionShort, Strike: 170, Expiration: expiration, Premium: 2.00, Contracts: 2},
+		{Type: "Put", Direction: DirectionLong, Strike: 165, Expiration: expiration, Premium: 0.80, Contracts: 2},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create put credit spread: %v", err)
+	}
+	if len(strategy.Legs) != 2 {
+		t.Fatalf("Expected 2 legs, got %d", len(strategy.Legs))
+	}
+
+	loaded, err := strategyService.GetByID(strategy.ID)
+	if err != nil {
+		t.Fatalf("Failed to get strategy: %v", err)
+	}
+	analysis := loaded.Analyze()
+	if analysis.MaxProfit != 240 || analysis.MaxLoss != 760 {
+		t.Errorf("Expected max profit 240 and max loss 760, got %.2f and %.2f", analysis.MaxProfit, analysis.MaxLoss)
+	}
+
+	// Long leg P&L is the debit paid, so the open spread nets the credit less commissions
+	if total := loaded.CalculateTotalProfit(); math.Abs(total-(240-2.60)) > 0.001 {
+		t.Errorf("Expected strategy total profit 237.40, got %.2f", total)
+	}
+
+	// A plain cash-secured put alongside the spread
+	if _, err := optionService.CreateWithCommission("AAPL", "Put", opened, 150, expiration, 1.00, 1, 0); err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	options, err := optionService.GetBySymbol("AAPL")
+	if err != nil {
+		t.Fatalf("Failed to get options: %v", err)
+	}
+	expectedExposure := 1000.0 + 15000.0
+	if exposure := CalculatePutExposureBySymbol(options)["AAPL"]; exposure != expectedExposure {
+		t.Errorf("Expected put exposure %.2f, got %.2f", expectedExposure, exposure)
+	}
+	metricExposure, err := metricService.calculatePutExposureForDate(time.Now(), nil)
+	if err != nil {
+		t.Fatalf("Failed to calculate put exposure metric: %v", err)
+	}
+	if metricExposure != expectedExposure {
+		t.Errorf("Expected put exposure metric %.2f, got %.2f", expectedExposure, metricExposure)
+	}
+
+	t.Run("rejects legs that do not match the type", func(t *testing.T) {
+		_, err := strategyService.Create("AAPL", StrategyPutCreditSpread, opened, []StrategyLeg{
+			{Type: "Put", Direction: DirectionShort, Strike: 160, Expiration: expiration, Premium: 0.50, Contracts: 1},
+			{Type: "Put", Direction: DirectionLong, Strike: 165, Expiration: expiration, Premium: 1.50, Contracts: 1},
+		}, nil)
+		if err == nil {
+			t.Error("Expected error for a long put above the short put")
+		}
+	})
+
+	t.Run("rejects legs with different expirations", func(t *testing.T) {
+		_, err := strategyService.Create("AAPL", StrategyPutCreditSpread, opened, []StrategyLeg{
+			{Type: "Put", Direction: DirectionShort, Strike: 160, Expiration: expiration, Premium: 1.50, Contracts: 1},
+			{Type: "Put", Direction: DirectionLong, Strike: 155, Expiration: expiration.AddDate(0, 0, 7), Premium: 0.80, Contracts: 1},
+		}, nil)
+		if err == nil {
+			t.Error("Expected error for legs with different expirations")
+		}
+	})
+
+	t.Run("delete leaves legs as individual options", func(t *testing.T) {
+		if err := strategyService.Delete(strategy.ID); err != nil {
+			t.Fatalf("Failed to delete strategy: %v", err)
+		}
+		leg, err := optionService.GetByID(strategy.Legs[0].ID)
+		if err != nil {
+			t.Fatalf("Expected leg to survive strategy deletion: %v", err)
+		}
+		if leg.StrategyID != nil {
+			t.Errorf("Expected leg unlinked from strategy")
+		}
+	})
+}
@@ -9,13 +9,17 @@ import (
 )
 
 type Symbol struct {
-	Symbol         string     `json:"symbol"`
-	Price          float64    `json:"price"`
-	Dividend       float64    `json:"dividend"`
-	ExDividendDate *time.Time `json:"ex_dividend_date"`
-	PERatio        *float64   `json:"pe_ratio"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	Symbol             string     `json:"symbol"`
+	Price              float64    `json:"price"`
+	Dividend           float64    `json:"dividend"`
+	ExDividendDate     *time.Time `json:"ex_dividend_date"`
+	PERatio            *float64   `json:"pe_ratio"`
+	InstrumentClass    string     `json:"instrument_class"`
+	ContractMultiplier int        `json:"contract_multiplier"`
+	Volatility         *float64   `json:"volatility"`
+	Beta               *float64   `json:"beta"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 // CalculateYield calculates the annualized dividend yield percentage
@@ -81,20 +85,27 @@ func (lp *LongPosition) GetExitPriceValue() float64 {
 }
 
 type Option struct {
-	ID           int        `json:"id"`
-	Symbol       string     `json:"symbol"`
-	Type         string     `json:"type"`
-	Opened       time.Time  `json:"opened"`
-	Closed       *time.Time `json:"closed"`
-	Strike       float64    `json:"strike"`
-	Expiration   time.Time  `json:"expiration"`
-	Premium      float64    `json:"premium"`
-	Contracts    int        `json:"contracts"`
-	ExitPrice    *float64   `json:"exit_price"`
-	Commission   float64    `json:"commission"`
-	CurrentPrice *float64   `json:"current_price"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID             int        `json:"id"`
+	Symbol         string     `json:"symbol"`
+	Type           string     `json:"type"`
+	Opened         time.Time  `json:"opened"`
+	Closed         *time.Time `json:"closed"`
+	Strike         float64    `json:"strike"`
+	Expiration     time.Time  `json:"expiration"`
+	Premium        float64    `json:"premium"`
+	Contracts      int        `json:"contracts"`
+	ExitPrice      *float64   `json:"exit_price"`
+	Commission     float64    `json:"commission"`
+	CurrentPrice   *float64   `json:"current_price"`
+	CloseReason    *string    `json:"close_reason"`
+	ParentOptionID *int       `json:"parent_option_id"`
+	Direction      string     `json:"direction"`
+	StrategyID     *int       `json:"strategy_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// ContractMultiplier is the underlying symbol's, loaded with the option
+	ContractMultiplier int `json:"contract_multiplier"`
 }
 
 func (o *Option) CalculatePercentOTM(currentPrice float64) float64 {
@@ -133,12 +144,25 @@ func (o *Option) CalculateDaysRemaining() int {
 	return int(math.Ceil(o.Expiration.Sub(now).Hours() / 24))
 }
 
+// Multiplier returns the shares or index units one contract covers
+func (o *Option) Multiplier() int {
+	if o.ContractMultiplier <= 0 {
+		return DefaultContractMultiplier
+	}
+	return o.ContractMultiplier
+}
+
 func (o *Option) CalculateTotalProfit() float64 {
 	exitPrice := 0.0
 	if o.ExitPrice != nil {
 		exitPrice = *o.ExitPrice
 	}
-	profit := math.Floor((o.Premium - exitPrice) * float64(o.Contracts) * 100)
+	// Long options are bought for the premium and sold at the exit price
+	if o.IsLong() {
+		profit := math.Floor((exitPrice - o.Premium) * float64(o.Contracts*o.Multiplier()))
+		return profit - o.Commission
+	}
+	profit := math.Floor((o.Premium - exitPrice) * float64(o.Contracts*o.Multiplier()))
 	return profit - o.Commission // Subtract commission for accurate net profit
 }
 
@@ -146,7 +170,7 @@ func (o *Option) CalculatePercentOfProfit() float64 {
 	if o.Premium == 0 {
 		return 0
 	}
-	maxProfit := o.Premium * float64(o.Contracts) * 100
+	maxProfit := o.Premium * float64(o.Contracts*o.Multiplier())
 	actualProfit := o.CalculateTotalProfit()
 	return (actualProfit / maxProfit) * 100
 }
@@ -211,15 +235,17 @@ func (o *Option) CalculateAROI() float64 {
 	// Calculate total profit
 	profit := o.CalculateTotalProfit()
 
-	// Calculate the capital base (exposure for puts, long value for calls)
+	// Calculate the capital base (debit paid for long options, exposure for puts, long value for calls)
 	var capitalBase float64
-	if o.Type == "Put" {
-		// For puts, use strike * contracts * 100 as the exposure/capital at risk
-		capitalBase = o.Strike * float64(o.Contracts) * 100
+	if o.IsLong() {
+		capitalBase = o.Premium * float64(o.Contracts*o.Multiplier())
+	} else if o.Type == "Put" {
+		// For puts, use strike * contracts * multiplier as the exposure/capital at risk
+		capitalBase = o.Strike * float64(o.Contracts*o.Multiplier())
 	} else if o.Type == "Call" {
 		// For calls, we need the underlying stock value, but we don't have current price here
 		// Use strike as approximation for now - this should be enhanced with current price
-		capitalBase = o.Strike * float64(o.Contracts) * 100
+		capitalBase = o.Strike * float64(o.Contracts*o.Multiplier())
 	}
 
 	if capitalBase <= 0 {
@@ -242,6 +268,28 @@ func (o *Option) GetExitPriceValue() float64 {
 	return 0.0
 }
 
+// IsAssigned returns true if the option was closed by assignment
+func (o *Option) IsAssigned() bool {
+	return o.CloseReason != nil && *o.CloseReason == CloseReasonAssigned
+}
+
+// GetCloseReasonLabel returns a display label for the close reason, or "" for manual closes
+func (o *Option) GetCloseReasonLabel() string {
+	if o.CloseReason == nil {
+		return ""
+	}
+	switch *o.CloseReason {
+	case CloseReasonAssigned:
+		return "Assigned"
+	case CloseReasonCalledAway:
+		return "Called Away"
+	case CloseReasonRolled:
+		return "Rolled"
+	default:
+		return *o.CloseReason
+	}
+}
+
 // IsProfit returns true if the option generated a profit
 func (o *Option) IsProfit() bool {
 	return o.CalculateTotalProfit() > 0
@@ -252,6 +300,17 @@ func (o *Option) IsLoss() bool {
 	return o.CalculateTotalProfit() < 0
 }
 
+// IsLong returns true if the option was bought rather than sold
+func (o *Option) IsLong() bool {
+	return o.Direction == DirectionLong
+}
+
+// IsLEAPS returns true for a long call bought with at least LEAPSMinDays to
+// expiration, which covers short calls like 100 shares per contract would
+func (o *Option) IsLEAPS() bool {
+	return o.Type == "Call" && o.IsLong() && o.Expiration.Sub(o.Opened).Hours()/24 >= LEAPSMinDays
+}
+
 // IsOpen returns true if the option position is still open
 func (o *Option) IsOpen() bool {
 	return o.Closed == nil
@@ -312,9 +371,9 @@ func (s *SymbolService) Create(symbol string) (*Symbol, error) {
 		return nil, fmt.Errorf("symbol cannot be empty")
 	}
 
-	query := `INSERT INTO symbols (symbol) VALUES (?) RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, created_at, updated_at`
+	query := `INSERT INTO symbols (symbol) VALUES (?) RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at`
 	var sym Symbol
-	err := s.db.QueryRow(query, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.CreatedAt, &sym.UpdatedAt)
+	err := s.db.QueryRow(query, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.InstrumentClass, &sym.ContractMultiplier, &sym.Volatility, &sym.Beta, &sym.CreatedAt, &sym.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create symbol: %w", err)
 	}
@@ -323,9 +382,9 @@ func (s *SymbolService) Create(symbol string) (*Symbol, error) {
 }
 
 func (s *SymbolService) GetBySymbol(symbol string) (*Symbol, error) {
-	query := `SELECT symbol, price, dividend, ex_dividend_date, pe_ratio, created_at, updated_at FROM symbols WHERE symbol = ?`
+	query := `SELECT symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at FROM symbols WHERE symbol = ?`
 	var sym Symbol
-	err := s.db.QueryRow(query, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.CreatedAt, &sym.UpdatedAt)
+	err := s.db.QueryRow(query, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.InstrumentClass, &sym.ContractMultiplier, &sym.Volatility, &sym.Beta, &sym.CreatedAt, &sym.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("symbol not found")
@@ -337,7 +396,7 @@ func (s *SymbolService) GetBySymbol(symbol string) (*Symbol, error) {
 }
 
 func (s *SymbolService) GetAll() ([]*Symbol, error) {
-	query := `SELECT symbol, price, dividend, ex_dividend_date, pe_ratio, created_at, updated_at FROM symbols ORDER BY symbol`
+	query := `SELECT symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at FROM symbols ORDER BY symbol`
 	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get symbols: %w", err)
@@ -347,7 +406,7 @@ func (s *SymbolService) GetAll() ([]*Symbol, error) {
 	var symbols []*Symbol
 	for rows.Next() {
 		var symbol Symbol
-		if err := rows.Scan(&symbol.Symbol, &symbol.Price, &symbol.Dividend, &symbol.ExDividendDate, &symbol.PERatio, &symbol.CreatedAt, &symbol.UpdatedAt); err != nil {
+		if err := rows.Scan(&symbol.Symbol, &symbol.Price, &symbol.Dividend, &symbol.ExDividendDate, &symbol.PERatio, &symbol.InstrumentClass, &symbol.ContractMultiplier, &symbol.Volatility, &symbol.Beta, &symbol.CreatedAt, &symbol.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan symbol: %w", err)
 		}
 		symbols = append(symbols, &symbol)
@@ -401,9 +460,9 @@ func (s *SymbolService) Update(symbol string, price float64, dividend float64, e
 		return nil, fmt.Errorf("symbol cannot be empty")
 	}
 
-	query := `UPDATE symbols SET price = ?, dividend = ?, ex_dividend_date = ?, pe_ratio = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ? RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, created_at, updated_at`
+	query := `UPDATE symbols SET price = ?, dividend = ?, ex_dividend_date = ?, pe_ratio = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ? RETURNING symbol, price, dividend, ex_dividend_date, pe_ratio, instrument_class, contract_multiplier, volatility, beta, created_at, updated_at`
 	var sym Symbol
-	err := s.db.QueryRow(query, price, dividend, exDividendDate, peRatio, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.CreatedAt, &sym.UpdatedAt)
+	err := s.db.QueryRow(query, price, dividend, exDividendDate, peRatio, symbol).Scan(&sym.Symbol, &sym.Price, &sym.Dividend, &sym.ExDividendDate, &sym.PERatio, &sym.InstrumentClass, &sym.ContractMultiplier, &sym.Volatility, &sym.Beta, &sym.CreatedAt, &sym.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("symbol not found")
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lot methods decide which open lots a stock sale consumes first
+const (
+	LotMethodFIFO        = "fifo"
+	LotMethodLIFO        = "lifo"
+	LotMethodHighestCost = "highest_cost"
+	LotMethodSpecific    = "specific"
+)
+
+// LotMethodSetting names the setting holding the default lot method
+const LotMethodSetting = "LOT_METHOD"
+
+// IsValidLotMethod reports whether method is a supported lot method
+func IsValidLotMethod(method string) bool {
+	switch method {
+	case LotMethodFIFO, LotMethodLIFO, LotMethodHighestCost, LotMethodSpecific:
+		return true
+	}
+	return false
+}
+
+// LotSelection picks shares of one lot for a specific-lot sale
+type LotSelection struct {
+	LotID  int `json:"lot_id"`
+	Shares int `json:"shares"`
+}
+
+// RealizedLot is the closed portion of one lot consumed by a sale
+type RealizedLot struct {
+	Lot       *LongPosition `json:"lot"`
+	CostBasis float64       `json:"cost_basis"`
+	Proceeds  float64       `json:"proceeds"`
+	Gain      float64       `json:"gain"`
+}
+
+// LotSale is the result of selling shares of a symbol across its open lots
+type LotSale struct {
+	Symbol    string         `json:"symbol"`
+	Sold      time.Time      `json:"sold"`
+	Shares    int            `json:"shares"`
+	Price     float64        `json:"price"`
+	Method    string         `json:"method"`
+	Lots      []*RealizedLot `json:"lots"`
+	CostBasis float64        `json:"cost_basis"`
+	Proceeds  float64        `json:"proceeds"`
+	Gain      float64        `json:"gain"`
+}
+
+// lotOrder is the ORDER BY clause used to consume open lots for each method
+var lotOrder = map[string]string{
+	LotMethodFIFO:        "opened ASC, id ASC",
+	LotMethodLIFO:        "opened DESC, id DESC",
+	LotMethodHighestCost: "buy_price DESC, opened ASC, id ASC",
+}
+
+// Sell sells shares of symbol at price on sold, consuming open lots by method in
+// a single transaction. Lots only partly needed are split, so each consumed lot
+// becomes its own closed row carrying the realized gain. For LotMethodSpecific
+// the selections name the lots and shares to sell, and shares may be 0 to sell
+// exactly the selected shares. Only lots in the account are sold, or lots in
+// any account if accountID is nil. It fails without changes if there are not
+// enough open shares.
+func (s *LongPositionService) Sell(accountID *int, symbol string, shares int, sold time.Time, price float64, method string, selections []LotSelection) (*LotSale, error) {
+	if !IsValidLotMethod(method) {
+		return nil, fmt.Errorf("invalid lot method: %s", method)
+	}
+	if price < 0 {
+		return nil, fmt.Errorf("sale price cannot be negative")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var picks []LotSelection
+	if method == LotMethodSpecific {
+		if len(selections) == 0 {
+			return nil, fmt.Errorf("specific lot sales must select at least one lot")
+		}
+		selected := 0
+		for _, selection := range selections {
+			var lotSymbol string
+			var opened time.Time
+			var closed *time.Time
+			var lotAccountID *int
+			err := tx.QueryRow(`SELECT symbol, opened, closed, account_id FROM long_positions WHERE id = ?`, selection.LotID).Scan(&lotSymbol, &opened, &closed, &lotAccountID)
+			if err != nil {
+				return nil, fmt.Errorf("lot %d not found", selection.LotID)
+			}
+			if lotSymbol != symbol || closed != nil {
+				return nil, fmt.Errorf("lot %d is not an open %s lot", selection.LotID, symbol)
+			}
+			if accountID != nil && (lotAccountID == nil || *lotAccountID != *accountID) {
+				return nil, fmt.Errorf("lot %d is not in account %d", selection.LotID, *accountID)
+			}
+			if opened.After(sold) {
+				return nil, fmt.Errorf("lot %d was opened after the sale date", selection.LotID)
+			}
+			selected += selection.Shares
+		}
+		if shares == 0 {
+			shares = selected
+		}
+		if selected != shares {
+			return nil, fmt.Errorf("selected lots total %d shares, not %d", selected, shares)
+		}
+		picks = selections
+	} else {
+		if shares <= 0 {
+			return nil, fmt.Errorf("shares to sell must be positive")
+		}
+		rows, err := tx.Query(`SELECT id, shares FROM long_positions
+				  WHERE symbol = ? AND closed IS NULL AND opened <= ? AND (? IS NULL OR account_id = ?)
+				  ORDER BY `+lotOrder[method], symbol, sold, accountID, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get open lots: %w", err)
+		}
+		remaining := shares
+		for rows.Next() && remaining > 0 {
+			var lot LotSelection
+			if err := rows.Scan(&lot.LotID, &lot.Shares); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan open lot: %w", err)
+			}
+			if lot.Shares > remaining {
+				lot.Shares = remaining
+			}
+			picks = append(picks, lot)
+			remaining -= lot.Shares
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error iterating open lots: %w", err)
+		}
+		rows.Close()
+		if remaining > 0 {
+			return nil, fmt.Errorf("not enough open shares of %s: need %d, have %d", symbol, shares, shares-remaining)
+		}
+	}
+
+	sale := &LotSale{Symbol: symbol, Sold: sold, Shares: shares, Price: price, Method: method}
+	for _, pick := range picks {
+		closedLot, err := closeLotTx(tx, pick.LotID, pick.Shares, sold, price)
+		if err != nil {
+			return nil, err
+		}
+		realized := &RealizedLot{
+			Lot:       closedLot,
+			CostBasis: closedLot.BuyPrice * float64(closedLot.Shares),
+			Proceeds:  price * float64(closedLot.Shares),
+		}
+		realized.Gain = realized.Proceeds - realized.CostBasis
+		sale.Lots = append(sale.Lots, realized)
+		sale.CostBasis += realized.CostBasis
+		sale.Proceeds += realized.Proceeds
+		sale.Gain += realized.Gain
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit sale: %w", err)
+	}
+
+	return sale, nil
+}
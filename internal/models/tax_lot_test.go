@@ -0,0 +1,136 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLongPositionService_Sell(t *testing.T) {
+	sold := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	// Three lots: 100 @ 150 (Jan), 100 @ 180 (Feb), 100 @ 160 (Mar)
+	setupLots := func(t *testing.T) (*LongPositionService, []*LongPosition) {
+		testDB := setupOptionTestDB(t)
+		positionService := NewLongPositionService(testDB.DB)
+		var lots []*LongPosition
+		for i, price := range []float64{150, 180, 160} {
+			lot, err := positionService.Create("AAPL", time.Date(2024, time.Month(i+1), 2, 0, 0, 0, 0, time.UTC), 100, price)
+			if err != nil {
+				t.Fatalf("Failed to create lot: %v", err)
+			}
+			lots = append(lots, lot)
+		}
+		return positionService, lots
+	}
+
+	tests := []struct {
+		method     string
+		lotPrices  []float64
+		lotShares  []int
+		gain       float64
+		openShares int
+	}{
+		{method: LotMethodFIFO, lotPrices: []float64{150, 180}, lotShares: []int{100, 50}, gain: 2000 + (-500), openShares: 150},
+		{method: LotMethodLIFO, lotPrices: []float64{160, 180}, lotShares: []int{100, 50}, gain: 1000 + (-500), openShares: 150},
+		{method: LotMethodHighestCost, lotPrices: []float64{180, 160}, lotShares: []int{100, 50}, gain: -1000 + 500, openShares: 150},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			positionService, _ := setupLots(t)
+
+			sale, err := positionService.Sell(nil, "AAPL", 150, sold, 170, test.method, nil)
+			if err != nil {
+				t.Fatalf("Failed to sell: %v", err)
+			}
+			if len(sale.Lots) != len(test.lotPrices) {
+				t.Fatalf("Expected %d lots consumed, got %d", len(test.lotPrices), len(sale.Lots))
+			}
+			for i, realized := range sale.Lots {
+				if realized.Lot.BuyPrice != test.lotPrices[i] || realized.Lot.Shares != test.lotShares[i] || realized.Lot.Closed == nil {
+					t.Errorf("Expected lot %d to close %d shares @ %.2f, got %d @ %.2f", i, test.lotShares[i], test.lotPrices[i], realized.Lot.Shares, realized.Lot.BuyPrice)
+				}
+			}
+			if math.Abs(sale.Gain-test.gain) > 0.001 || math.Abs(sale.Proceeds-25500) > 0.001 {
+				t.Errorf("Expected gain %.2f on proceeds 25500, got %.2f on %.2f", test.gain, sale.Gain, sale.Proceeds)
+			}
+
+			open, err := positionService.GetOpenPositions()
+			if err != nil {
+				t.Fatalf("Failed to get open positions: %v", err)
+			}
+			openShares := 0
+			for _, lot := range open {
+				openShares += lot.Shares
+			}
+			if openShares != test.openShares {
+				t.Errorf("Expected %d open shares, got %d", test.openShares, openShares)
+			}
+		})
+	}
+
+	t.Run("specific lot", func(t *testing.T) {
+		positionService, lots := setupLots(t)
+
+		sale, err := positionService.Sell(nil, "AAPL", 0, sold, 170, LotMethodSpecific, []LotSelection{
+			{LotID: lots[2].ID, Shares: 40},
+			{LotID: lots[0].ID, Shares: 100},
+		})
+		if err != nil {
+			t.Fatalf("Failed to sell specific lots: %v", err)
+		}
+		if sale.Shares != 140 || len(sale.Lots) != 2 {
+			t.Fatalf("Expected 140 shares from 2 lots, got %d from %d", sale.Shares, len(sale.Lots))
+		}
+		if sale.Lots[0].Lot.ID != lots[2].ID || math.Abs(sale.Lots[0].Gain-400) > 0.001 {
+			t.Errorf("Expected 400 gain on the March lot, got %.2f on lot %d", sale.Lots[0].Gain, sale.Lots[0].Lot.ID)
+		}
+
+		if _, err := positionService.Sell(nil, "AAPL", 0, sold, 170, LotMethodSpecific, []LotSelection{{LotID: lots[0].ID, Shares: 10}}); err == nil {
+			t.Error("Expected error selling from a closed lot")
+		}
+	})
+
+	t.Run("not enough shares", func(t *testing.T) {
+		positionService, _ := setupLots(t)
+
+		if _, err := positionService.Sell(nil, "AAPL", 301, sold, 170, LotMethodFIFO, nil); err == nil {
+			t.Fatal("Expected error selling more shares than are open")
+		}
+		open, err := positionService.GetOpenPositions()
+		if err != nil {
+			t.Fatalf("Failed to get open positions: %v", err)
+		}
+		if len(open) != 3 {
+			t.Errorf("Expected failed sale to leave 3 open lots, got %d", len(open))
+		}
+	})
+	t.Run("account", func(t *testing.T) {
+		positionService, lots := setupLots(t)
+		accountService := NewAccountService(positionService.db)
+		brokerage, err := accountService.Create("Brokerage", nil, AccountTaxable)
+		if err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+		// Only the March lot is in the brokerage account
+		if err := accountService.AssignItems(&brokerage.ID, AccountItems{LongPositionIDs: []int{lots[2].ID}}); err != nil {
+			t.Fatalf("Failed to assign lot: %v", err)
+		}
+
+		sale, err := positionService.Sell(&brokerage.ID, "AAPL", 50, sold, 170, LotMethodFIFO, nil)
+		if err != nil {
+			t.Fatalf("Failed to sell from the account: %v", err)
+		}
+		if len(sale.Lots) != 1 || sale.Lots[0].Lot.BuyPrice != 160 {
+			t.Errorf("Expected FIFO to sell the account's March lot, got %d lots", len(sale.Lots))
+		}
+
+		if _, err := positionService.Sell(&brokerage.ID, "AAPL", 100, sold, 170, LotMethodFIFO, nil); err == nil {
+			t.Error("Expected error selling more shares than the account holds")
+		}
+		if _, err := positionService.Sell(&brokerage.ID, "AAPL", 0, sold, 170, LotMethodSpecific, []LotSelection{{LotID: lots[0].ID, Shares: 10}}); err == nil {
+			t.Error("Expected error selecting a lot in another account")
+		}
+	})
+}
@@ -16,10 +16,20 @@ type Treasury struct {
 	BuyPrice     float64    `json:"buy_price"`
 	CurrentValue *float64   `json:"current_value"`
 	ExitPrice    *float64   `json:"exit_price"`
+	Redeemed     *time.Time `json:"redeemed"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
+// redemptionDate is the day a treasury is taken as redeemed when its exit price
+// is recorded: today, or its maturity if that came first
+func redemptionDate(maturity time.Time) time.Time {
+	if now := time.Now(); now.Before(maturity) {
+		return now
+	}
+	return maturity
+}
+
 func (t *Treasury) CalculateProfitLoss() float64 {
 	// Use exit price if bond was sold
 	if t.ExitPrice != nil {
@@ -110,7 +120,7 @@ func (s *TreasuryService) Create(cuspid string, purchased, maturity time.Time, a
 
 	query := `INSERT INTO treasuries (cuspid, purchased, maturity, amount, yield, buy_price) 
 			  VALUES (?, ?, ?, ?, ?, ?) 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, created_at, updated_at`
 	
 	log.Printf("[TREASURY SERVICE] Create: Executing SQL query for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] Create: SQL = %s", query)
@@ -118,7 +128,7 @@ func (s *TreasuryService) Create(cuspid string, purchased, maturity time.Time, a
 	var treasury Treasury
 	err := s.db.QueryRow(query, cuspid, purchased, maturity, amount, yield, buyPrice).Scan(
 		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.Redeemed,
 		&treasury.CreatedAt, &treasury.UpdatedAt,
 	)
 	if err != nil {
@@ -137,6 +147,16 @@ func (s *TreasuryService) Create(cuspid string, purchased, maturity time.Time, a
 
 // CreateFull creates a new treasury with all fields including optional current value and exit price
 func (s *TreasuryService) CreateFull(cuspid string, purchased, maturity time.Time, amount, yield, buyPrice float64, currentValue, exitPrice *float64) (*Treasury, error) {
+	return s.createFull(nil, cuspid, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice)
+}
+
+// CreateInAccount creates a treasury with all fields directly in an account.
+// A nil account leaves it unassigned.
+func (s *TreasuryService) CreateInAccount(accountID *int, cuspid string, purchased, maturity time.Time, amount, yield, buyPrice float64, currentValue, exitPrice *float64) (*Treasury, error) {
+	return s.createFull(accountID, cuspid, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice)
+}
+
+func (s *TreasuryService) createFull(accountID *int, cuspid string, purchased, maturity time.Time, amount, yield, buyPrice float64, currentValue, exitPrice *float64) (*Treasury, error) {
 	log.Printf("[TREASURY SERVICE] CreateFull: Starting creation for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] CreateFull: Parameters - Purchased=%v, Maturity=%v, Amount=%.2f, Yield=%.3f, BuyPrice=%.2f", 
 		purchased, maturity, amount, yield, buyPrice)
@@ -156,17 +176,23 @@ func (s *TreasuryService) CreateFull(cuspid string, purchased, maturity time.Tim
 		return nil, fmt.Errorf("CUSPID cannot be empty")
 	}
 
-	query := `INSERT INTO treasuries (cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?) 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+	var redeemed *time.Time
+	if exitPrice != nil {
+		date := redemptionDate(maturity)
+		redeemed = &date
+	}
+
+	query := `INSERT INTO treasuries (cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, account_id) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) 
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, created_at, updated_at`
 	
 	log.Printf("[TREASURY SERVICE] CreateFull: Executing SQL query for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] CreateFull: SQL = %s", query)
 	
 	var treasury Treasury
-	err := s.db.QueryRow(query, cuspid, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice).Scan(
+	err := s.db.QueryRow(query, cuspid, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice, redeemed, accountID).Scan(
 		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.Redeemed,
 		&treasury.CreatedAt, &treasury.UpdatedAt,
 	)
 	if err != nil {
@@ -186,7 +212,7 @@ func (s *TreasuryService) CreateFull(cuspid string, purchased, maturity time.Tim
 func (s *TreasuryService) GetAll() ([]*Treasury, error) {
 	log.Printf("[TREASURY SERVICE] GetAll: Starting to retrieve all treasuries")
 	
-	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at 
+	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, created_at, updated_at 
 			  FROM treasuries ORDER BY maturity DESC, purchased DESC`
 	
 	log.Printf("[TREASURY SERVICE] GetAll: Executing SQL query")
@@ -204,7 +230,7 @@ func (s *TreasuryService) GetAll() ([]*Treasury, error) {
 	for rows.Next() {
 		var treasury Treasury
 		if err := rows.Scan(&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-			&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+			&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.Redeemed,
 			&treasury.CreatedAt, &treasury.UpdatedAt); err != nil {
 			log.Printf("[TREASURY SERVICE] GetAll: ERROR - Failed to scan row %d: %v", rowCount, err)
 			return nil, fmt.Errorf("failed to scan treasury: %w", err)
@@ -246,7 +272,7 @@ func (s *TreasuryService) GetTotalOpenValue() (float64, error) {
 func (s *TreasuryService) GetByCUSPID(cuspid string) (*Treasury, error) {
 	log.Printf("[TREASURY SERVICE] GetByCUSPID: Starting to retrieve treasury for CUSPID=%s", cuspid)
 	
-	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at 
+	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, created_at, updated_at 
 			  FROM treasuries WHERE cuspid = ?`
 	
 	log.Printf("[TREASURY SERVICE] GetByCUSPID: Executing SQL query for CUSPID=%s", cuspid)
@@ -254,7 +280,7 @@ func (s *TreasuryService) GetByCUSPID(cuspid string) (*Treasury, error) {
 	
 	var treasury Treasury
 	err := s.db.QueryRow(query, cuspid).Scan(&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity,
-		&treasury.Amount, &treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Amount, &treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.Redeemed,
 		&treasury.CreatedAt, &treasury.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -273,14 +299,15 @@ func (s *TreasuryService) GetByCUSPID(cuspid string) (*Treasury, error) {
 }
 
 func (s *TreasuryService) Update(cuspid string, currentValue, exitPrice *float64) (*Treasury, error) {
-	query := `UPDATE treasuries SET current_value = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
+	// The redemption date is set when the exit price is first recorded and kept through later edits
+	query := `UPDATE treasuries SET current_value = ?, exit_price = ?, redeemed = CASE WHEN ? IS NULL THEN NULL ELSE COALESCE(redeemed, MIN(maturity, ?)) END, updated_at = CURRENT_TIMESTAMP 
 			  WHERE cuspid = ? 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, created_at, updated_at`
 	
 	var treasury Treasury
-	err := s.db.QueryRow(query, currentValue, exitPrice, cuspid).Scan(&treasury.CUSPID, &treasury.Purchased,
+	err := s.db.QueryRow(query, currentValue, exitPrice, exitPrice, time.Now(), cuspid).Scan(&treasury.CUSPID, &treasury.Purchased,
 		&treasury.Maturity, &treasury.Amount, &treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue,
-		&treasury.ExitPrice, &treasury.CreatedAt, &treasury.UpdatedAt)
+		&treasury.ExitPrice, &treasury.Redeemed, &treasury.CreatedAt, &treasury.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("treasury not found")
@@ -307,17 +334,17 @@ func (s *TreasuryService) UpdateFull(cuspid string, purchased, maturity time.Tim
 		log.Printf("[TREASURY SERVICE] UpdateFull: ExitPrice=nil")
 	}
 	
-	query := `UPDATE treasuries SET purchased = ?, maturity = ?, amount = ?, yield = ?, buy_price = ?, current_value = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
+	query := `UPDATE treasuries SET purchased = ?, maturity = ?, amount = ?, yield = ?, buy_price = ?, current_value = ?, exit_price = ?, redeemed = CASE WHEN ? IS NULL THEN NULL ELSE COALESCE(redeemed, ?) END, updated_at = CURRENT_TIMESTAMP 
 			  WHERE cuspid = ? 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, redeemed, created_at, updated_at`
 	
 	log.Printf("[TREASURY SERVICE] UpdateFull: Executing SQL query for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] UpdateFull: SQL = %s", query)
 	
 	var treasury Treasury
-	err := s.db.QueryRow(query, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice, cuspid).Scan(
+	err := s.db.QueryRow(query, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice, exitPrice, redemptionDate(maturity), cuspid).Scan(
 		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.Redeemed,
 		&treasury.CreatedAt, &treasury.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// WashSaleWindowDays is how many days before or after a loss sale acquiring a
+// replacement makes the sale a wash sale
+const WashSaleWindowDays = 30
+
+// WashSale is the part of a realized loss disallowed because a replacement was
+// acquired within the wash sale window. Quantity is in the loss's units (shares
+// or contracts) and the disallowed loss is added to the replacement's basis.
+type WashSale struct {
+	Symbol                 string    `json:"symbol"`
+	Sold                   time.Time `json:"sold"`
+	LossKind               string    `json:"loss_kind"`
+	LossID                 int       `json:"loss_id"`
+	LossDescription        string    `json:"loss_description"`
+	Loss                   float64   `json:"loss"`
+	Quantity               int       `json:"quantity"`
+	ReplacementKind        string    `json:"replacement_kind"`
+	ReplacementID          int       `json:"replacement_id"`
+	ReplacementDescription string    `json:"replacement_description"`
+	ReplacementAcquired    time.Time `json:"replacement_acquired"`
+	DisallowedLoss         float64   `json:"disallowed_loss"`
+}
+
+// BasisAdjustmentPerUnit returns the disallowed loss carried to the replacement
+// per share (or contract, for option losses) replaced
+func (w *WashSale) BasisAdjustmentPerUnit() float64 {
+	if w.Quantity == 0 {
+		return 0
+	}
+	return w.DisallowedLoss / float64(w.Quantity)
+}
+
+// washKey identifies a stock lot or option across realized gains and replacements
+type washKey struct {
+	kind string
+	id   int
+}
+
+// washReplacement is a stock lot or option that can replace sold shares or contracts
+type washReplacement struct {
+	washKey
+	description string
+	acquired    time.Time
+	// contracted is when a put assigned into this lot was sold, which also
+	// counts as acquiring a contract to buy the shares
+	contracted *time.Time
+	units      int
+}
+
+func (r *washReplacement) inWindow(sold time.Time) bool {
+	if withinWashWindow(r.acquired, sold) {
+		return true
+	}
+	return r.contracted != nil && withinWashWindow(*r.contracted, sold)
+}
+
+func withinWashWindow(date, sold time.Time) bool {
+	return math.Abs(sold.Sub(date).Hours()/24) <= WashSaleWindowDays
+}
+
+// DetectWashSales scans realized stock and option losses for replacements
+// acquired within 30 days before or after the sale
+func DetectWashSales(positions []*LongPosition, options []*Option, instruments Instruments) []*WashSale {
+	return applyWashSales(buildRealizedGains(positions, options, instruments, nil, time.Now().Year()), positions, options, instruments, nil)
+}
+
+// applyWashSales finds wash sales among gains, oldest sale first, and adjusts
+// gains in place: the disallowed part of a loss is added back to its gain and
+// carried into the cost basis of the replacement if it has been sold too, and
+// the loss position's holding period is added to the replacement's.
+//
+// Stock losses are replaced by other lots of the symbol (lots put to us count
+// from the day the put was sold) and by long calls on it; lots from the same
+// purchase as the sold shares do not count. Option losses are replaced by the
+// same contract: symbol, type, direction, strike and expiration. Each
+// replacement share or contract is used for one loss only, earliest acquired
+// first. Section 1256 contracts are marked to market and exempt, and so are
+// losses in the IRA and Roth accounts the taxable filter drops, although
+// their lots and options still replace losses elsewhere.
+func applyWashSales(gains []*RealizedGain, positions []*LongPosition, options []*Option, instruments Instruments, taxable *TaxableFilter) []*WashSale {
+	assignedPuts, _ := rollPremiums(positions, options, instruments)
+
+	lots := make(map[int]*LongPosition)
+	var lotReplacements []*washReplacement
+	for _, lot := range positions {
+		lots[lot.ID] = lot
+		replacement := &washReplacement{
+			washKey:     washKey{RealizedKindStock, lot.ID},
+			description: fmt.Sprintf("%d sh %s", lot.Shares, lot.Symbol),
+			acquired:    lot.Opened,
+			units:       lot.Shares,
+		}
+		if rolled := assignedPuts[premiumKey{lot.Symbol, lot.Opened.Format("2006-01-02"), lot.BuyPrice}]; rolled.shares > 0 {
+			contracted := rolled.opened
+			replacement.contracted = &contracted
+		}
+		lotReplacements = append(lotReplacements, replacement)
+	}
+
+	contracts := make(map[int]*Option)
+	optionReplacements := make(map[int]*washReplacement)
+	for _, option := range options {
+		contracts[option.ID] = option
+		optionReplacements[option.ID] = &washReplacement{
+			washKey:     washKey{RealizedKindOption, option.ID},
+			description: fmt.Sprintf("%s %s %.2f %s", option.Symbol, option.Expiration.Format("01/02/2006"), option.Strike, option.Type),
+			acquired:    option.Opened,
+			units:       option.Contracts,
+		}
+	}
+
+	realized := make(map[washKey]*RealizedGain)
+	for _, gain := range gains {
+		realized[washKey{gain.Kind, gain.ID}] = gain
+	}
+
+	used := make(map[washKey]int)
+	washSales := []*WashSale{}
+	for _, gain := range gains {
+		if gain.Gain >= 0 || gain.Section1256 || !taxable.IsTaxable(gain.Kind, gain.ID) {
+			continue
+		}
+
+		// Candidate replacements and how many loss units one replacement unit covers
+		var candidates []*washReplacement
+		unitsPer := make(map[washKey]int)
+		if gain.Kind == RealizedKindStock {
+			sold := lots[gain.ID]
+			for _, replacement := range lotReplacements {
+				lot := lots[replacement.id]
+				if lot.ID == sold.ID || lot.Symbol != sold.Symbol {
+					continue
+				}
+				if lot.Opened.Equal(sold.Opened) && lot.BuyPrice == sold.BuyPrice {
+					continue
+				}
+				candidates = append(candidates, replacement)
+				unitsPer[replacement.washKey] = 1
+			}
+			for _, option := range options {
+				if option.Symbol == sold.Symbol && option.Type == "Call" && option.IsLong() {
+					candidates = append(candidates, optionReplacements[option.ID])
+					unitsPer[optionReplacements[option.ID].washKey] = instruments.Multiplier(option.Symbol)
+				}
+			}
+		} else {
+			sold := contracts[gain.ID]
+			for _, option := range options {
+				if option.ID == sold.ID || option.Symbol != sold.Symbol || option.Type != sold.Type ||
+					option.Direction != sold.Direction || option.Strike != sold.Strike || !option.Expiration.Equal(sold.Expiration) {
+					continue
+				}
+				if option.Opened.Equal(sold.Opened) && option.Premium == sold.Premium {
+					continue
+				}
+				candidates = append(candidates, optionReplacements[option.ID])
+				unitsPer[optionReplacements[option.ID].washKey] = 1
+			}
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].acquired.Before(candidates[j].acquired)
+		})
+
+		loss := -gain.Gain
+		remaining := gain.Quantity
+		for _, candidate := range candidates {
+			if remaining == 0 {
+				break
+			}
+			if !candidate.inWindow(gain.Sold) {
+				continue
+			}
+			available := (candidate.units - used[candidate.washKey]) * unitsPer[candidate.washKey]
+			if available <= 0 {
+				continue
+			}
+			quantity := remaining
+			if quantity > available {
+				quantity = available
+			}
+			used[candidate.washKey] += (quantity + unitsPer[candidate.washKey] - 1) / unitsPer[candidate.washKey]
+			remaining -= quantity
+
+			disallowed := loss * float64(quantity) / float64(gain.Quantity)
+			gain.WashSaleDisallowed += disallowed
+			gain.Gain += disallowed
+			if replacement, ok := realized[candidate.washKey]; ok {
+				replacement.WashSaleBasis += disallowed
+				replacement.CostBasis += disallowed
+				replacement.Gain -= disallowed
+
+				// The replacement's holding period includes the loss position's
+				acquired := replacement.Acquired.Add(-gain.Sold.Sub(gain.Acquired))
+				if acquired.Before(replacement.Acquired) {
+					replacement.Acquired = acquired
+					replacement.LongTerm = !replacement.Section1256 && IsLongTermHolding(acquired, replacement.Sold)
+				}
+			}
+
+			washSales = append(washSales, &WashSale{
+				Symbol:                 gain.Symbol,
+				Sold:                   gain.Sold,
+				LossKind:               gain.Kind,
+				LossID:                 gain.ID,
+				LossDescription:        gain.Description,
+				Loss:                   loss,
+				Quantity:               quantity,
+				ReplacementKind:        candidate.kind,
+				ReplacementID:          candidate.id,
+				ReplacementDescription: candidate.description,
+				ReplacementAcquired:    candidate.acquired,
+				DisallowedLoss:         disallowed,
+			})
+		}
+	}
+
+	return washSales
+}
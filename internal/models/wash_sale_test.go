@@ -0,0 +1,158 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDetectWashSales(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	closedOn := func(month time.Month, day int) *time.Time {
+		closed := date(month, day)
+		return &closed
+	}
+	price := func(value float64) *float64 { return &value }
+	assigned := CloseReasonAssigned
+
+	t.Run("put sold within the window and assigned later replaces the shares", func(t *testing.T) {
+		positions := []*LongPosition{
+			// 100 bought at 50 and sold at 40: a 1000 loss
+			{ID: 1, Symbol: "AAPL", Opened: date(1, 2), Closed: closedOn(3, 1), Shares: 100, BuyPrice: 50, ExitPrice: price(40)},
+			// Put to us on 4/20 (outside the window) by a put sold on 3/10 (inside it), then sold at 50
+			{ID: 2, Symbol: "AAPL", Opened: date(4, 20), Closed: closedOn(6, 3), Shares: 100, BuyPrice: 45, ExitPrice: price(50)},
+		}
+		options := []*Option{
+			{ID: 10, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Opened: date(3, 10), Closed: closedOn(4, 20),
+				Strike: 45, Expiration: date(4, 19), Premium: 1.00, Contracts: 1, ExitPrice: price(0), CloseReason: &assigned},
+		}
+
+		washSales := DetectWashSales(positions, options, nil)
+		if len(washSales) != 1 {
+			t.Fatalf("Expected 1 wash sale, got %d", len(washSales))
+		}
+		if washSales[0].LossID != 1 || washSales[0].ReplacementID != 2 || math.Abs(washSales[0].DisallowedLoss-1000) > 0.001 {
+			t.Errorf("Expected 1000 of lot 1's loss carried to lot 2, got %.2f from %d to %d", washSales[0].DisallowedLoss, washSales[0].LossID, washSales[0].ReplacementID)
+		}
+		if washSales[0].BasisAdjustmentPerUnit() != 10 {
+			t.Errorf("Expected a 10.00 per share basis adjustment, got %.2f", washSales[0].BasisAdjustmentPerUnit())
+		}
+
+		report := BuildRealizedGainsReport(2024, positions, options, nil, nil, nil, nil)
+		byID := make(map[int]*RealizedGain)
+		for _, gain := range report.All() {
+			byID[gain.ID] = gain
+		}
+		if loss := byID[1]; loss.Gain != 0 || loss.WashSaleDisallowed != 1000 {
+			t.Errorf("Expected the loss fully disallowed, got gain %.2f with %.2f disallowed", loss.Gain, loss.WashSaleDisallowed)
+		}
+		// 4500 - 100 premium + 1000 wash sale basis
+		if replacement := byID[2]; replacement.CostBasis != 5400 || replacement.WashSaleBasis != 1000 || replacement.Gain != -400 {
+			t.Errorf("Expected replacement basis 5400 and loss 400, got %.2f and %.2f", replacement.CostBasis, replacement.Gain)
+		}
+		if report.WashSaleDisallowed != 1000 || report.Total != -400 {
+			t.Errorf("Expected 1000 disallowed and -400 total, got %.2f and %.2f", report.WashSaleDisallowed, report.Total)
+		}
+	})
+
+	t.Run("replacement takes over the holding period of the loss", func(t *testing.T) {
+		// Held for 10 months and sold at a 500 loss, rebought 8 days later and held 3 months
+		positions := []*LongPosition{
+			{ID: 1, Symbol: "AAPL", Opened: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), Closed: closedOn(1, 2), Shares: 100, BuyPrice: 50, ExitPrice: price(45)},
+			{ID: 2, Symbol: "AAPL", Opened: date(1, 10), Closed: closedOn(4, 1), Shares: 100, BuyPrice: 46, ExitPrice: price(52)},
+		}
+
+		report := BuildRealizedGainsReport(2024, positions, nil, nil, nil, nil, nil)
+		byID := make(map[int]*RealizedGain)
+		for _, gain := range report.All() {
+			byID[gain.ID] = gain
+		}
+		replacement := byID[2]
+		// 307 days of the loss lot plus 82 of the replacement cross the one-year line
+		if want := time.Date(2023, 3, 9, 0, 0, 0, 0, time.UTC); !replacement.Acquired.Equal(want) {
+			t.Errorf("Expected the replacement acquired %v, got %v", want, replacement.Acquired)
+		}
+		if !replacement.LongTerm {
+			t.Errorf("Expected the replacement to be long-term")
+		}
+		// 5200 - 4600 - 500 disallowed
+		if replacement.Gain != 100 || report.LongTerm.Gain != 100 {
+			t.Errorf("Expected a 100 long-term gain, got %.2f and %.2f long-term", replacement.Gain, report.LongTerm.Gain)
+		}
+	})
+
+	t.Run("partial replacement disallows part of the loss", func(t *testing.T) {
+		positions := []*LongPosition{
+			{ID: 1, Symbol: "AAPL", Opened: date(1, 2), Closed: closedOn(3, 1), Shares: 100, BuyPrice: 50, ExitPrice: price(40)},
+			// Rest of the same purchase is not a replacement
+			{ID: 2, Symbol: "AAPL", Opened: date(1, 2), Shares: 50, BuyPrice: 50},
+			{ID: 3, Symbol: "AAPL", Opened: date(3, 20), Shares: 30, BuyPrice: 41},
+			// Too late to count
+			{ID: 4, Symbol: "AAPL", Opened: date(4, 5), Shares: 100, BuyPrice: 42},
+		}
+
+		washSales := DetectWashSales(positions, nil, nil)
+		if len(washSales) != 1 || washSales[0].ReplacementID != 3 || washSales[0].Quantity != 30 {
+			t.Fatalf("Expected 30 shares replaced by lot 3, got %+v", washSales)
+		}
+		if math.Abs(washSales[0].DisallowedLoss-300) > 0.001 {
+			t.Errorf("Expected 300 disallowed, got %.2f", washSales[0].DisallowedLoss)
+		}
+	})
+
+	t.Run("option losses are replaced by the same contract only", func(t *testing.T) {
+		expiration := date(5, 17)
+		options := []*Option{
+			// Sold for 2.00 and bought back for 3.00: a 100 loss
+			{ID: 1, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Opened: date(3, 1), Closed: closedOn(3, 15),
+				Strike: 170, Expiration: expiration, Premium: 2.00, Contracts: 1, ExitPrice: price(3.00)},
+			// Rolled down and out: a different contract
+			{ID: 2, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Opened: date(3, 15),
+				Strike: 165, Expiration: date(6, 21), Premium: 3.50, Contracts: 1},
+			// Same contract sold again
+			{ID: 3, Symbol: "AAPL", Type: "Put", Direction: DirectionShort, Opened: date(4, 1),
+				Strike: 170, Expiration: expiration, Premium: 2.50, Contracts: 1},
+		}
+
+		washSales := DetectWashSales(nil, options, nil)
+		if len(washSales) != 1 || washSales[0].ReplacementID != 3 || math.Abs(washSales[0].DisallowedLoss-100) > 0.001 {
+			t.Fatalf("Expected the 100 loss carried to option 3, got %+v", washSales)
+		}
+	})
+	t.Run("replacements in other accounts count but only filtered gains are reported", func(t *testing.T) {
+		positions := []*LongPosition{
+			// Account 1: 1000 loss, replaced by lot 2 in account 2
+			{ID: 1, Symbol: "AAPL", Opened: date(1, 2), Closed: closedOn(3, 1), Shares: 100, BuyPrice: 50, ExitPrice: price(40)},
+			{ID: 2, Symbol: "AAPL", Opened: date(3, 10), Shares: 100, BuyPrice: 45},
+			// IRA: 5000 loss, which is never disallowed, then bought back in account 2
+			{ID: 3, Symbol: "MSFT", Opened: date(1, 2), Closed: closedOn(3, 1), Shares: 100, BuyPrice: 300, ExitPrice: price(250)},
+			{ID: 4, Symbol: "MSFT", Opened: date(3, 5), Closed: closedOn(6, 3), Shares: 100, BuyPrice: 260, ExitPrice: price(270)},
+		}
+		taxable := &TaxableFilter{options: map[int]bool{}, longPositions: map[int]bool{3: true}}
+		account1 := &AccountFilter{AccountID: 1, options: map[int]bool{}, longPositions: map[int]bool{1: true}}
+		account2 := &AccountFilter{AccountID: 2, options: map[int]bool{}, longPositions: map[int]bool{2: true, 4: true}}
+
+		report := BuildRealizedGainsReport(2024, positions, nil, nil, nil, taxable, account1)
+		if len(report.All()) != 1 || report.All()[0].ID != 1 || report.All()[0].WashSaleDisallowed != 1000 {
+			t.Fatalf("Expected lot 1's loss disallowed by lot 2 in the other account, got %+v", report.All())
+		}
+		if len(report.WashSales) != 1 || report.WashSaleDisallowed != 1000 {
+			t.Errorf("Expected 1 wash sale disallowing 1000, got %d disallowing %.2f", len(report.WashSales), report.WashSaleDisallowed)
+		}
+
+		report = BuildRealizedGainsReport(2024, positions, nil, nil, nil, taxable, account2)
+		if len(report.All()) != 1 || report.All()[0].ID != 4 || report.All()[0].WashSaleBasis != 0 || report.Total != 1000 {
+			t.Fatalf("Expected only lot 4's 1000 gain with no IRA wash sale basis, got %+v", report.All())
+		}
+		if len(report.WashSales) != 0 {
+			t.Errorf("Expected no wash sales on account 2's losses, got %d", len(report.WashSales))
+		}
+
+		report = BuildRealizedGainsReport(2024, positions, nil, nil, nil, taxable, nil)
+		if len(report.All()) != 2 || report.Total != 1000 || report.WashSaleDisallowed != 1000 {
+			t.Errorf("Expected lots 1 and 4 totalling 1000 with 1000 disallowed, got %d totalling %.2f with %.2f", len(report.All()), report.Total, report.WashSaleDisallowed)
+		}
+	})
+}
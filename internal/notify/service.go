@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types
+const (
+	EventAlert        = "alert"
+	EventImport       = "import"
+	EventPriceRefresh = "price_refresh"
+	EventTest         = "test"
+)
+
+// Settings that configure the notifier and its channels
+const (
+	EventsSetting        = "NOTIFY_EVENTS"
+	MaxAttemptsSetting   = "NOTIFY_MAX_ATTEMPTS"
+	WebhookURLSetting    = "WEBHOOK_URL"
+	WebhookSecretSetting = "WEBHOOK_SECRET"
+	SMTPHostSetting      = "SMTP_HOST"
+	SMTPPortSetting      = "SMTP_PORT"
+	SMTPUsernameSetting  = "SMTP_USERNAME"
+	SMTPPasswordSetting  = "SMTP_PASSWORD"
+	SMTPFromSetting      = "SMTP_FROM"
+	SMTPToSetting        = "SMTP_TO"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a delivery is tried when NOTIFY_MAX_ATTEMPTS isn't set
+	DefaultMaxAttempts = 3
+	// maxAttempts caps NOTIFY_MAX_ATTEMPTS so a failing channel can't hold a delivery for long
+	maxAttempts = 10
+)
+
+// Event is something noteworthy that happened in Wheeler. Data carries
+// details for webhook consumers, such as the alerts that fired.
+type Event struct {
+	Type    string      `json:"type"`
+	Title   string      `json:"title"`
+	Message string      `json:"message"`
+	Time    time.Time   `json:"time"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Channel delivers events to one destination
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+// Service sends events to the channels configured in settings and logs each delivery
+type Service struct {
+	settingService      *models.SettingService
+	notificationService *models.NotificationService
+	backoff             time.Duration
+}
+
+// NewService creates a notifier that reads its channels from settings
+func NewService(settingService *models.SettingService, notificationService *models.NotificationService) *Service {
+	return &Service{
+		settingService:      settingService,
+		notificationService: notificationService,
+		backoff:             2 * time.Second,
+	}
+}
+
+// Channels returns the channels that are configured, read from settings on
+// every call so changes apply without a restart
+func (s *Service) Channels() []Channel {
+	var channels []Channel
+	if webhook := s.webhook(); webhook != nil {
+		channels = append(channels, webhook)
+	}
+	if smtp := s.smtp(); smtp != nil {
+		channels = append(channels, smtp)
+	}
+	return channels
+}
+
+// Channel returns the named channel, or an error if it isn't configured
+func (s *Service) Channel(name string) (Channel, error) {
+	switch name {
+	case ChannelWebhook:
+		if webhook := s.webhook(); webhook != nil {
+			return webhook, nil
+		}
+		return nil, fmt.Errorf("webhook is not configured - set %s", WebhookURLSetting)
+	case ChannelSMTP:
+		if smtp := s.smtp(); smtp != nil {
+			return smtp, nil
+		}
+		return nil, fmt.Errorf("email is not configured - set %s and %s", SMTPHostSetting, SMTPToSetting)
+	}
+	return nil, fmt.Errorf("unknown channel: %s", name)
+}
+
+func (s *Service) webhook() *Webhook {
+	url := strings.TrimSpace(s.settingService.GetValue(WebhookURLSetting))
+	if url == "" {
+		return nil
+	}
+	return NewWebhook(url, s.settingService.GetValue(WebhookSecretSetting))
+}
+
+func (s *Service) smtp() *SMTP {
+	host := strings.TrimSpace(s.settingService.GetValue(SMTPHostSetting))
+	to := splitList(s.settingService.GetValue(SMTPToSetting))
+	if host == "" || len(to) == 0 {
+		return nil
+	}
+
+	port, err := strconv.Atoi(s.settingService.GetValue(SMTPPortSetting))
+	if err != nil || port <= 0 {
+		port = 587
+	}
+	username := strings.TrimSpace(s.settingService.GetValue(SMTPUsernameSetting))
+	from := strings.TrimSpace(s.settingService.GetValue(SMTPFromSetting))
+	if from == "" {
+		from = username
+	}
+	if from == "" {
+		from = "wheeler@localhost"
+	}
+
+	return &SMTP{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: s.settingService.GetValue(SMTPPasswordSetting),
+		From:     from,
+		To:       to,
+	}
+}
+
+// splitList splits a comma-separated setting into its trimmed, non-empty values
+func splitList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// wants reports whether NOTIFY_EVENTS includes the event type. Test events are always sent.
+func (s *Service) wants(eventType string) bool {
+	if eventType == EventTest {
+		return true
+	}
+	for _, wanted := range splitList(s.settingService.GetValue(EventsSetting)) {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) attempts() int {
+	attempts, err := strconv.Atoi(s.settingService.GetValue(MaxAttemptsSetting))
+	if err != nil || attempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	if attempts > maxAttempts {
+		return maxAttempts
+	}
+	return attempts
+}
+
+// Notify sends the event to every configured channel in the background, so
+// callers aren't held up by slow or failing channels
+func (s *Service) Notify(event Event) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		s.Dispatch(ctx, event)
+	}()
+}
+
+// Dispatch sends the event to every configured channel if NOTIFY_EVENTS
+// includes its type, and returns the deliveries
+func (s *Service) Dispatch(ctx context.Context, event Event) []*models.NotificationDelivery {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if !s.wants(event.Type) {
+		return nil
+	}
+
+	var deliveries []*models.NotificationDelivery
+	for _, channel := range s.Channels() {
+		deliveries = append(deliveries, s.Deliver(ctx, channel, event))
+	}
+	return deliveries
+}
+
+// Deliver sends the event to one channel, retrying with a doubling backoff up
+// to NOTIFY_MAX_ATTEMPTS times, and logs the outcome
+func (s *Service) Deliver(ctx context.Context, channel Channel, event Event) *models.NotificationDelivery {
+	delivery := &models.NotificationDelivery{
+		Channel:   channel.Name(),
+		EventType: event.Type,
+		Title:     event.Title,
+		Status:    models.DeliveryFailed,
+	}
+
+	attempts := s.attempts()
+	wait := s.backoff
+	for delivery.Attempts < attempts {
+		delivery.Attempts++
+		err := channel.Send(ctx, event)
+		if err == nil {
+			delivery.Status = models.DeliverySent
+			delivery.Error = nil
+			break
+		}
+
+		message := err.Error()
+		delivery.Error = &message
+		log.Printf("[NOTIFY] %s attempt %d/%d for %q failed: %v", channel.Name(), delivery.Attempts, attempts, event.Title, err)
+		if delivery.Attempts == attempts {
+			break
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			attempts = delivery.Attempts
+		}
+		wait *= 2
+	}
+
+	if delivery.Status == models.DeliverySent {
+		log.Printf("[NOTIFY] Sent %q via %s", event.Title, channel.Name())
+	}
+	if _, err := s.notificationService.Record(delivery); err != nil {
+		log.Printf("[NOTIFY] ERROR: %v", err)
+	}
+	return delivery
+}
+
+// Test sends a test event to the named channel and returns its delivery
+func (s *Service) Test(ctx context.Context, name string) (*models.NotificationDelivery, error) {
+	channel, err := s.Channel(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Deliver(ctx, channel, Event{
+		Type:    EventTest,
+		Title:   "Test notification",
+		Message: fmt.Sprintf("Wheeler can reach you through %s.", channel.Name()),
+		Time:    time.Now(),
+	}), nil
+}
@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupNotifyTestService(t *testing.T) (*Service, *models.SettingService, *models.NotificationService) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	settingService := models.NewSettingService(testDB.DB)
+	notificationService := models.NewNotificationService(testDB.DB)
+	service := NewService(settingService, notificationService)
+	service.backoff = time.Millisecond
+	return service, settingService, notificationService
+}
+
+func setSetting(t *testing.T, settingService *models.SettingService, name, value string) {
+	if err := settingService.SetValue(name, value, ""); err != nil {
+		t.Fatalf("Failed to set %s: %v", name, err)
+	}
+}
+
+func TestService_WebhookRetriesAndLogs(t *testing.T) {
+	service, settingService, notificationService := setupNotifyTestService(t)
+
+	var mu sync.Mutex
+	var requests int
+	var received Event
+	var signature string
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests <= failures {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Wheeler-Signature")
+		if signature != Sign("s3cret", body) {
+			t.Errorf("Signature %s doesn't match the body", signature)
+		}
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	setSetting(t, settingService, WebhookURLSetting, server.URL)
+	setSetting(t, settingService, WebhookSecretSetting, "s3cret")
+
+	event := Event{Type: EventAlert, Title: "1 alert fired", Message: "Short 1 KO 66.00 Put expires in 13 days"}
+	deliveries := service.Dispatch(context.Background(), event)
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected only the webhook to be configured, got %d deliveries", len(deliveries))
+	}
+	if deliveries[0].Status != models.DeliverySent || deliveries[0].Attempts != 2 || deliveries[0].Error != nil {
+		t.Errorf("Expected the second attempt to succeed, got %+v", deliveries[0])
+	}
+	if received.Type != EventAlert || received.Message != event.Message || received.Time.IsZero() {
+		t.Errorf("Expected the event as JSON, got %+v", received)
+	}
+
+	// Every attempt fails
+	mu.Lock()
+	failures = 100
+	mu.Unlock()
+	delivery, err := service.Test(context.Background(), ChannelWebhook)
+	if err != nil {
+		t.Fatalf("Failed to send test: %v", err)
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != DefaultMaxAttempts || delivery.Error == nil ||
+		!strings.Contains(*delivery.Error, "503") {
+		t.Errorf("Expected the test to fail after %d attempts, got %+v", DefaultMaxAttempts, delivery)
+	}
+
+	// Event types left out of NOTIFY_EVENTS aren't sent
+	setSetting(t, settingService, EventsSetting, "import")
+	if deliveries := service.Dispatch(context.Background(), event); len(deliveries) != 0 {
+		t.Errorf("Expected alerts not to be sent, got %d deliveries", len(deliveries))
+	}
+
+	log, err := notificationService.GetRecent(10)
+	if err != nil {
+		t.Fatalf("Failed to get delivery log: %v", err)
+	}
+	if len(log) != 2 || log[0].EventType != EventTest || log[0].Status != models.DeliveryFailed || log[1].Status != models.DeliverySent {
+		t.Errorf("Expected the failed test and the sent alert in the log, got %d deliveries", len(log))
+	}
+
+	if _, err := service.Test(context.Background(), ChannelSMTP); err == nil {
+		t.Errorf("Expected testing unconfigured email to fail")
+	}
+}
+
+// smtpStandIn accepts one SMTP session on a local port and records the
+// envelope and message it receives
+type smtpStandIn struct {
+	listener   net.Listener
+	done       chan struct{}
+	from       string
+	recipients []string
+	data       string
+}
+
+func startSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	standIn := &smtpStandIn{listener: listener, done: make(chan struct{})}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		defer close(standIn.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP stand-in")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimSpace(line)
+			switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL":
+				standIn.from = command
+				reply("250 OK")
+			case "RCPT":
+				standIn.recipients = append(standIn.recipients, command)
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				standIn.data = data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	return standIn
+}
+
+func TestService_SMTP(t *testing.T) {
+	service, settingService, _ := setupNotifyTestService(t)
+	standIn := startSMTPStandIn(t)
+	_, port, _ := net.SplitHostPort(standIn.listener.Addr().String())
+
+	setSetting(t, settingService, SMTPHostSetting, "127.0.0.1")
+	setSetting(t, settingService, SMTPPortSetting, port)
+	setSetting(t, settingService, SMTPFromSetting, "wheeler@example.com")
+	setSetting(t, settingService, SMTPToSetting, "me@example.com, spouse@example.com")
+
+	event := Event{
+		Type:    EventImport,
+		Title:   "Options import completed",
+		Message: "12 imported, 3 skipped\n.hidden line",
+		Time:    time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+	}
+	deliveries := service.Dispatch(context.Background(), event)
+	if len(deliveries) != 1 || deliveries[0].Channel != ChannelSMTP || deliveries[0].Status != models.DeliverySent {
+		t.Fatalf("Expected the email to be sent, got %+v", deliveries)
+	}
+	<-standIn.done
+
+	if standIn.from != "MAIL FROM:<wheeler@example.com>" {
+		t.Errorf("Unexpected sender: %s", standIn.from)
+	}
+	if len(standIn.recipients) != 2 || standIn.recipients[1] != "RCPT TO:<spouse@example.com>" {
+		t.Errorf("Expected both recipients, got %v", standIn.recipients)
+	}
+	for _, expected := range []string{
+		"Subject: [Wheeler] Options import completed\r\n",
+		"To: me@example.com, spouse@example.com\r\n",
+		"\r\n12 imported, 3 skipped\r\n",
+		// Lines starting with a dot are escaped on the wire
+		"\r\n..hidden line\r\n",
+	} {
+		if !strings.Contains(standIn.data, expected) {
+			t.Errorf("Expected the message to contain %q, got:\n%s", expected, standIn.data)
+		}
+	}
+
+	// Nothing is listening once the stand-in has hung up
+	standIn.listener.Close()
+	setSetting(t, settingService, MaxAttemptsSetting, strconv.Itoa(2))
+	delivery, err := service.Test(context.Background(), ChannelSMTP)
+	if err != nil {
+		t.Fatalf("Failed to send test: %v", err)
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != 2 {
+		t.Errorf("Expected the test to fail after 2 attempts, got %+v", delivery)
+	}
+}
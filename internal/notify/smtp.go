@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelSMTP names the email channel
+const ChannelSMTP = "smtp"
+
+// SMTP emails each event as plain text. Port 465 connects with TLS; other
+// ports upgrade with STARTTLS when the server offers it. Without a username
+// mail is sent unauthenticated.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (m *SMTP) Name() string {
+	return ChannelSMTP
+}
+
+// Send delivers the event to every recipient
+func (m *SMTP) Send(ctx context.Context, event Event) error {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if m.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if m.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+				return fmt.Errorf("failed to start TLS: %w", err)
+			}
+		}
+	}
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.From); err != nil {
+		return fmt.Errorf("sender %s rejected: %w", m.From, err)
+	}
+	for _, to := range m.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := writer.Write(m.message(event)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message renders the event as a plain text email
+func (m *SMTP) message(event Event) []byte {
+	// Keep line breaks in the title from starting new headers
+	subject := strings.Join(strings.Fields("[Wheeler] "+event.Title), " ")
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", m.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	for _, line := range strings.Split(event.Message, "\n") {
+		message.WriteString(strings.TrimRight(line, "\r") + "\r\n")
+	}
+	fmt.Fprintf(&message, "\r\n-- \r\nWheeler, %s\r\n", event.Time.Format("2006-01-02 15:04"))
+	return message.Bytes()
+}
@@ -0,0 +1,86 @@
+package pricing
+
+import "math"
+
+// Binomial values an American option on a Cox-Ross-Rubinstein tree with the
+// given number of steps. Delta, gamma and theta are read off the first steps
+// of the tree; vega and rho are central differences of repriced trees.
+func Binomial(in Inputs, steps int) Valuation {
+	if in.Years <= 0 || in.Spot <= 0 || in.Strike <= 0 {
+		return intrinsic(in)
+	}
+	if steps < 3 {
+		steps = 3
+	}
+
+	v := binomialTree(in, steps)
+
+	const volBump, rateBump = 0.01, 0.0001
+	up, down := in, in
+	up.Volatility += volBump
+	down.Volatility = math.Max(in.Volatility-volBump, minVolatility)
+	upValue := binomialTree(up, steps)
+	downValue := binomialTree(down, steps)
+	v.Vega = (upValue.Value - downValue.Value) / (up.Volatility - down.Volatility) / 100
+
+	up, down = in, in
+	up.Rate += rateBump
+	down.Rate -= rateBump
+	upValue = binomialTree(up, steps)
+	downValue = binomialTree(down, steps)
+	v.Rho = (upValue.Value - downValue.Value) / (2 * rateBump) / 100
+
+	return v
+}
+
+// binomialTree prices the option and reads delta, gamma and theta from the
+// nodes after one and two steps
+func binomialTree(in Inputs, steps int) Valuation {
+	vol := math.Max(in.Volatility, minVolatility)
+	dt := in.Years / float64(steps)
+	u := math.Exp(vol * math.Sqrt(dt))
+	d := 1 / u
+	growth := math.Exp((in.Rate - in.DividendYield) * dt)
+	p := (growth - d) / (u - d)
+	p = math.Min(1, math.Max(0, p))
+	discount := math.Exp(-in.Rate * dt)
+
+	payoff := func(spot float64) float64 {
+		if in.Call {
+			return math.Max(spot-in.Strike, 0)
+		}
+		return math.Max(in.Strike-spot, 0)
+	}
+
+	// values[j] is the node with j up moves
+	values := make([]float64, steps+1)
+	for j := 0; j <= steps; j++ {
+		values[j] = payoff(in.Spot * math.Pow(u, float64(2*j-steps)))
+	}
+
+	var step1, step2 [3]float64
+	for i := steps - 1; i >= 0; i-- {
+		for j := 0; j <= i; j++ {
+			hold := discount * (p*values[j+1] + (1-p)*values[j])
+			values[j] = math.Max(hold, payoff(in.Spot*math.Pow(u, float64(2*j-i))))
+		}
+		switch i {
+		case 2:
+			copy(step2[:], values[:3])
+		case 1:
+			copy(step1[:2], values[:2])
+		}
+	}
+
+	spotUp, spotDown := in.Spot*u, in.Spot*d
+	spotUpUp, spotDownDown := in.Spot*u*u, in.Spot*d*d
+	deltaUp := (step2[2] - step2[1]) / (spotUpUp - in.Spot)
+	deltaDown := (step2[1] - step2[0]) / (in.Spot - spotDownDown)
+
+	return Valuation{
+		Value: values[0],
+		Delta: (step1[1] - step1[0]) / (spotUp - spotDown),
+		Gamma: (deltaUp - deltaDown) / ((spotUpUp - spotDownDown) / 2),
+		Theta: (step2[1] - values[0]) / (2 * dt) / 365,
+	}
+}
@@ -0,0 +1,63 @@
+package pricing
+
+import "math"
+
+// BlackScholes values a European option on a stock paying a continuous
+// dividend yield (the Merton form of Black-Scholes)
+func BlackScholes(in Inputs) Valuation {
+	if in.Years <= 0 || in.Spot <= 0 || in.Strike <= 0 {
+		return intrinsic(in)
+	}
+	vol := math.Max(in.Volatility, minVolatility)
+	sqrtT := math.Sqrt(in.Years)
+	d1 := (math.Log(in.Spot/in.Strike) + (in.Rate-in.DividendYield+vol*vol/2)*in.Years) / (vol * sqrtT)
+	d2 := d1 - vol*sqrtT
+	carry := math.Exp(-in.DividendYield * in.Years)
+	discount := math.Exp(-in.Rate * in.Years)
+
+	v := Valuation{
+		Gamma: carry * normPDF(d1) / (in.Spot * vol * sqrtT),
+		Vega:  in.Spot * carry * normPDF(d1) * sqrtT / 100,
+	}
+	decay := -in.Spot * carry * normPDF(d1) * vol / (2 * sqrtT)
+	if in.Call {
+		v.Value = in.Spot*carry*normCDF(d1) - in.Strike*discount*normCDF(d2)
+		v.Delta = carry * normCDF(d1)
+		v.Theta = (decay - in.Rate*in.Strike*discount*normCDF(d2) + in.DividendYield*in.Spot*carry*normCDF(d1)) / 365
+		v.Rho = in.Strike * in.Years * discount * normCDF(d2) / 100
+	} else {
+		v.Value = in.Strike*discount*normCDF(-d2) - in.Spot*carry*normCDF(-d1)
+		v.Delta = -carry * normCDF(-d1)
+		v.Theta = (decay + in.Rate*in.Strike*discount*normCDF(-d2) - in.DividendYield*in.Spot*carry*normCDF(-d1)) / 365
+		v.Rho = -in.Strike * in.Years * discount * normCDF(-d2) / 100
+	}
+	return v
+}
+
+// Black76 values a European option on a forward or future, with in.Spot as
+// the forward price. Delta and gamma are with respect to the forward.
+func Black76(in Inputs) Valuation {
+	if in.Years <= 0 || in.Spot <= 0 || in.Strike <= 0 {
+		return intrinsic(in)
+	}
+	vol := math.Max(in.Volatility, minVolatility)
+	sqrtT := math.Sqrt(in.Years)
+	d1 := (math.Log(in.Spot/in.Strike) + vol*vol/2*in.Years) / (vol * sqrtT)
+	d2 := d1 - vol*sqrtT
+	discount := math.Exp(-in.Rate * in.Years)
+
+	v := Valuation{
+		Gamma: discount * normPDF(d1) / (in.Spot * vol * sqrtT),
+		Vega:  in.Spot * discount * normPDF(d1) * sqrtT / 100,
+	}
+	if in.Call {
+		v.Value = discount * (in.Spot*normCDF(d1) - in.Strike*normCDF(d2))
+		v.Delta = discount * normCDF(d1)
+	} else {
+		v.Value = discount * (in.Strike*normCDF(-d2) - in.Spot*normCDF(-d1))
+		v.Delta = -discount * normCDF(-d1)
+	}
+	v.Theta = (-in.Spot*discount*normPDF(d1)*vol/(2*sqrtT) + in.Rate*v.Value) / 365
+	v.Rho = -in.Years * v.Value / 100
+	return v
+}
@@ -0,0 +1,84 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxVolatility bounds the implied volatility search at 500%
+const maxVolatility = 5.0
+
+// ImpliedVolatility returns the volatility at which the named model values the
+// option at price. It takes Newton steps on vega and falls back to bisection
+// whenever a step leaves the bracket around the root or vega vanishes. It
+// fails if price is outside what the model can produce, such as below the
+// option's intrinsic value.
+func ImpliedVolatility(model string, in Inputs, price float64) (float64, error) {
+	if price <= 0 {
+		return 0, fmt.Errorf("price must be positive")
+	}
+	if in.Years <= 0 {
+		return 0, fmt.Errorf("option has expired")
+	}
+
+	valueAt := func(volatility float64) (Valuation, error) {
+		in.Volatility = volatility
+		return Price(model, in)
+	}
+
+	low, high := minVolatility, maxVolatility
+	lowValue, err := valueAt(low)
+	if err != nil {
+		return 0, err
+	}
+	highValue, err := valueAt(high)
+	if err != nil {
+		return 0, err
+	}
+	if price < lowValue.Value {
+		return 0, fmt.Errorf("price %.4f is below the option's minimum value %.4f", price, lowValue.Value)
+	}
+	if price > highValue.Value {
+		return 0, fmt.Errorf("price %.4f is above the option's value at %.0f%% volatility", price, maxVolatility*100)
+	}
+
+	const tolerance, maxIterations = 1e-6, 100
+	const bracketWidth = 1e-8
+
+	// Start from the Brenner-Subrahmanyam at-the-money approximation
+	volatility := math.Sqrt(2*math.Pi/in.Years) * price / in.Spot
+	if volatility <= low || volatility >= high {
+		volatility = 0.3
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		v, err := valueAt(volatility)
+		if err != nil {
+			return 0, err
+		}
+		diff := v.Value - price
+		if math.Abs(diff) < tolerance {
+			return volatility, nil
+		}
+
+		// Value rises with volatility, so the root stays between low and high
+		if diff > 0 {
+			high = volatility
+		} else {
+			low = volatility
+		}
+		// A binomial value steps with volatility, so it may never land within tolerance
+		if high-low < bracketWidth {
+			return volatility, nil
+		}
+
+		// Vega is per volatility point
+		next := volatility - diff/(v.Vega*100)
+		if v.Vega <= 0 || math.IsNaN(next) || next <= low || next >= high {
+			next = (low + high) / 2
+		}
+		volatility = next
+	}
+
+	return volatility, fmt.Errorf("implied volatility did not converge after %d iterations", maxIterations)
+}
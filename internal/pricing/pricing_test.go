@@ -0,0 +1,136 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func near(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("Expected %s %.5f, got %.5f", name, want, got)
+	}
+}
+
+func TestBlackScholes_TextbookValues(t *testing.T) {
+	in := Inputs{Spot: 100, Strike: 100, Years: 1, Rate: 0.05, Volatility: 0.20, Call: true}
+
+	call := BlackScholes(in)
+	near(t, "call value", call.Value, 10.4506, 0.0005)
+	near(t, "call delta", call.Delta, 0.6368, 0.0005)
+	near(t, "gamma", call.Gamma, 0.01876, 0.00005)
+	near(t, "vega", call.Vega, 0.3752, 0.0005)
+	near(t, "call theta", call.Theta, -6.4140/365, 0.00005)
+	near(t, "call rho", call.Rho, 0.5323, 0.0005)
+
+	in.Call = false
+	put := BlackScholes(in)
+	near(t, "put value", put.Value, 5.5735, 0.0005)
+	near(t, "put delta", put.Delta, call.Delta-1, 0.00001)
+
+	// Put-call parity with a dividend yield: C - P = S e^(-qT) - K e^(-rT)
+	in.DividendYield = 0.03
+	in.Call = true
+	call = BlackScholes(in)
+	in.Call = false
+	put = BlackScholes(in)
+	near(t, "parity", call.Value-put.Value, 100*math.Exp(-0.03)-100*math.Exp(-0.05), 0.00001)
+}
+
+func TestBlack76_MatchesBlackScholesOnTheForward(t *testing.T) {
+	spot := Inputs{Spot: 4500, Strike: 4400, Years: 0.25, Rate: 0.045, DividendYield: 0.015, Volatility: 0.18}
+	forward := spot
+	forward.Spot = spot.Spot * math.Exp((spot.Rate-spot.DividendYield)*spot.Years)
+
+	near(t, "put value", Black76(forward).Value, BlackScholes(spot).Value, 0.0001)
+	near(t, "vega", Black76(forward).Vega, BlackScholes(spot).Vega, 0.0001)
+}
+
+func TestBinomial_AmericanEarlyExercise(t *testing.T) {
+	// With no dividend an American call is worth the European one
+	call := Inputs{Spot: 100, Strike: 100, Years: 1, Rate: 0.05, Volatility: 0.20, Call: true}
+	american := Binomial(call, DefaultBinomialSteps)
+	european := BlackScholes(call)
+	near(t, "call value", american.Value, european.Value, 0.02)
+	near(t, "call delta", american.Delta, european.Delta, 0.005)
+	near(t, "gamma", american.Gamma, european.Gamma, 0.0005)
+	near(t, "vega", american.Vega, european.Vega, 0.005)
+	near(t, "theta", american.Theta, european.Theta, 0.0005)
+	near(t, "rho", american.Rho, european.Rho, 0.01)
+
+	// A deep in-the-money put is worth exercising early, so it is worth more than the European put
+	put := Inputs{Spot: 80, Strike: 100, Years: 1, Rate: 0.05, Volatility: 0.20}
+	if Binomial(put, DefaultBinomialSteps).Value <= BlackScholes(put).Value+0.1 {
+		t.Errorf("Expected the early exercise premium to lift the American put above %.4f", BlackScholes(put).Value)
+	}
+	if value := Binomial(put, DefaultBinomialSteps).Value; value < 20 {
+		t.Errorf("Expected an American put to be worth at least its intrinsic value, got %.4f", value)
+	}
+}
+
+func TestPrice_ExpiredAndUnknownModel(t *testing.T) {
+	expired, err := Price(ModelBinomial, Inputs{Spot: 95, Strike: 100, Volatility: 0.3})
+	if err != nil {
+		t.Fatalf("Failed to price expired put: %v", err)
+	}
+	if expired.Value != 5 || expired.Delta != -1 || expired.Theta != 0 {
+		t.Errorf("Expected an expired put at intrinsic value, got %+v", expired)
+	}
+
+	if _, err := Price("monte-carlo", Inputs{}); err == nil {
+		t.Errorf("Expected an unknown model to be rejected")
+	}
+
+	short := BlackScholes(Inputs{Spot: 100, Strike: 95, Years: 0.1, Rate: 0.04, Volatility: 0.25}).Scale(-100)
+	if short.Delta <= 0 || short.Theta <= 0 || short.Vega >= 0 {
+		t.Errorf("Expected a short put to have positive delta and theta and negative vega, got %+v", short)
+	}
+}
+
+func TestImpliedVolatility_RecoversModelVolatility(t *testing.T) {
+	for _, model := range []string{ModelBlackScholes, ModelBinomial} {
+		for _, volatility := range []float64{0.08, 0.35, 1.2} {
+			in := Inputs{Spot: 100, Strike: 95, Years: 45.0 / 365, Rate: 0.045, DividendYield: 0.01, Volatility: volatility}
+			price, _ := Price(model, in)
+
+			implied, err := ImpliedVolatility(model, in, price.Value)
+			if err != nil {
+				t.Fatalf("Failed to solve %s at %.2f: %v", model, volatility, err)
+			}
+			near(t, model+" implied volatility", implied, volatility, 0.001)
+		}
+	}
+
+	// A deep in-the-money put can't trade below what exercising it pays
+	deep := Inputs{Spot: 60, Strike: 100, Years: 0.5, Rate: 0.045}
+	if _, err := ImpliedVolatility(ModelBinomial, deep, 39); err == nil {
+		t.Errorf("Expected a price below intrinsic value to have no implied volatility")
+	}
+	if _, err := ImpliedVolatility(ModelBlackScholes, Inputs{Spot: 100, Strike: 100}, 2); err == nil {
+		t.Errorf("Expected an expired option to have no implied volatility")
+	}
+}
+
+func TestProbabilities_TouchAndExpectedMove(t *testing.T) {
+	call := Inputs{Spot: 100, Strike: 110, Years: 0.25, Rate: 0.045, DividendYield: 0.01, Volatility: 0.20, Call: true}
+	put := call
+	put.Call = false
+	near(t, "call and put ITM", ProbabilityITM(call)+ProbabilityITM(put), 1, 1e-12)
+
+	// Black-Scholes N(d2) is the risk-neutral probability of finishing in the money
+	near(t, "call ITM", ProbabilityITM(call), normCDF((math.Log(100.0/110)+(0.045-0.01-0.02)*0.25)/(0.20*0.5)), 1e-12)
+
+	// Without drift the reflection principle doubles the probability of finishing past the strike
+	driftless := Inputs{Spot: 100, Strike: 90, Years: 0.25, Rate: 0.02, Volatility: 0.20}
+	near(t, "driftless put touch", ProbabilityOfTouch(driftless), 2*ProbabilityITM(driftless), 1e-9)
+	driftless.Strike, driftless.Call = 110, true
+	near(t, "driftless call touch", ProbabilityOfTouch(driftless), 2*ProbabilityITM(driftless), 1e-9)
+
+	if ProbabilityOfTouch(put) != 1 {
+		t.Errorf("Expected an in-the-money put to have touched its strike")
+	}
+	near(t, "expected move", ExpectedMove(call), 10, 1e-9)
+	if ExpectedMove(Inputs{Spot: 100, Volatility: 0.2}) != 0 {
+		t.Errorf("Expected no move left at expiration")
+	}
+}
@@ -0,0 +1,63 @@
+package pricing
+
+import "math"
+
+// The probabilities below are risk-neutral: the underlying drifts at the rate
+// less the dividend yield with lognormal returns at in.Volatility
+
+// ProbabilityAbove returns the probability that the underlying finishes above
+// level at expiration
+func ProbabilityAbove(in Inputs, level float64) float64 {
+	if in.Years <= 0 || in.Spot <= 0 || level <= 0 {
+		if in.Spot > level {
+			return 1
+		}
+		return 0
+	}
+	vol := math.Max(in.Volatility, minVolatility)
+	sqrtT := math.Sqrt(in.Years)
+	d2 := (math.Log(in.Spot/level) + (in.Rate-in.DividendYield-vol*vol/2)*in.Years) / (vol * sqrtT)
+	return normCDF(d2)
+}
+
+// ProbabilityITM returns the probability that the option expires in the money
+func ProbabilityITM(in Inputs) float64 {
+	above := ProbabilityAbove(in, in.Strike)
+	if in.Call {
+		return above
+	}
+	return 1 - above
+}
+
+// ProbabilityOfTouch returns the probability that the underlying trades at
+// the strike at any time before expiration, from the first-passage time of
+// drifting Brownian motion. An option already in the money has touched.
+func ProbabilityOfTouch(in Inputs) float64 {
+	if (in.Call && in.Spot >= in.Strike) || (!in.Call && in.Spot <= in.Strike) {
+		return 1
+	}
+	if in.Years <= 0 || in.Spot <= 0 || in.Strike <= 0 {
+		return 0
+	}
+	vol := math.Max(in.Volatility, minVolatility)
+	sqrtT := math.Sqrt(in.Years)
+	drift := in.Rate - in.DividendYield - vol*vol/2
+	barrier := math.Log(in.Strike / in.Spot)
+
+	// Reflect a put's barrier below the spot into one above it
+	if !in.Call {
+		barrier, drift = -barrier, -drift
+	}
+	touch := normCDF((-barrier+drift*in.Years)/(vol*sqrtT)) +
+		math.Exp(2*drift*barrier/(vol*vol))*normCDF((-barrier-drift*in.Years)/(vol*sqrtT))
+	return math.Min(1, touch)
+}
+
+// ExpectedMove returns the one standard deviation move of the underlying by
+// expiration, in price
+func ExpectedMove(in Inputs) float64 {
+	if in.Years <= 0 {
+		return 0
+	}
+	return in.Spot * in.Volatility * math.Sqrt(in.Years)
+}
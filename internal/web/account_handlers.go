@@ -0,0 +1,244 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+)
+
+// accountCookie remembers the account selected in the sidebar
+const accountCookie = "account"
+
+// selectedAccountID returns the account chosen by ?account= or the sidebar
+// selection, or nil for all accounts
+func (s *Server) selectedAccountID(r *http.Request) *int {
+	if r.URL.Query().Get("account") != "" {
+		return requestedAccountID(r)
+	}
+	if cookie, err := r.Cookie(accountCookie); err == nil {
+		return parseAccountID(cookie.Value)
+	}
+	return nil
+}
+
+// requestedAccountID returns the account named by ?account= alone, ignoring
+// the sidebar selection, or nil for all accounts
+func requestedAccountID(r *http.Request) *int {
+	return parseAccountID(r.URL.Query().Get("account"))
+}
+
+// parseAccountID parses an account id, returning nil when it is not one
+func parseAccountID(value string) *int {
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return nil
+	}
+	return &id
+}
+
+// accountFilter loads the filter for the selected account. It is nil, keeping
+// everything, when no account is selected or the account cannot be loaded.
+func (s *Server) accountFilter(r *http.Request) *models.AccountFilter {
+	filter, err := s.accountService.Filter(s.selectedAccountID(r))
+	if err != nil {
+		log.Printf("[ACCOUNTS] WARNING: Failed to load account filter, showing all accounts: %v", err)
+		return nil
+	}
+	return filter
+}
+
+// openTreasuryValue returns the face value of open treasuries in the accounts the filter keeps
+func (s *Server) openTreasuryValue(filter *models.AccountFilter) (float64, error) {
+	if filter == nil {
+		return s.treasuryService.GetTotalOpenValue()
+	}
+
+	treasuries, err := s.treasuryService.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, treasury := range filter.Treasuries(treasuries) {
+		if treasury.ExitPrice == nil {
+			total += treasury.Amount
+		}
+	}
+	return total, nil
+}
+
+// accountOptionsIndex indexes the options in the selected account
+func (s *Server) accountOptionsIndex(r *http.Request) (map[string]interface{}, error) {
+	options, err := s.optionService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all options: %w", err)
+	}
+	return models.Index(s.accountFilter(r).Options(options))
+}
+
+// accountsHandler serves the account management page
+func (s *Server) accountsHandler(w http.ResponseWriter, r *http.Request) {
+	accounts, err := s.accountService.GetAll()
+	if err != nil {
+		log.Printf("[ACCOUNTS] ERROR: Failed to get accounts: %v", err)
+		accounts = []*models.Account{}
+	}
+
+	data := AccountsPageData{
+		PageData: PageData{
+			Title:      "Accounts",
+			ActivePage: "accounts",
+			CurrentDB:  s.getCurrentDatabaseName(),
+			AllSymbols: s.getAllSymbolsList(),
+		},
+		Accounts: accounts,
+	}
+
+	s.renderTemplate(w, "accounts.html", data)
+}
+
+// accountsAPIHandler handles listing and creating brokerage accounts
+func (s *Server) accountsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ACCOUNT API] %s %s", r.Method, r.URL.Path)
+
+	switch r.Method {
+	case http.MethodGet:
+		accounts, err := s.accountService.GetAll()
+		if err != nil {
+			log.Printf("[ACCOUNT API] ERROR: Failed to get accounts: %v", err)
+			http.Error(w, "Failed to get accounts", http.StatusInternalServerError)
+			return
+		}
+		if accounts == nil {
+			accounts = []*models.Account{}
+		}
+		s.writeAccountJSON(w, accounts)
+	case http.MethodPost:
+		var req AccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if req.Type == "" {
+			req.Type = models.AccountTaxable
+		}
+
+		account, err := s.accountService.Create(req.Name, req.Broker, req.Type)
+		if err != nil {
+			log.Printf("[ACCOUNT API] ERROR: Failed to create account: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to create account: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("[ACCOUNT API] Created account %d (%s)", account.ID, account.Name)
+		w.WriteHeader(http.StatusCreated)
+		s.writeAccountJSON(w, account)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// accountAPIHandler handles /api/accounts/{id} and /api/accounts/{id}/items
+func (s *Server) accountAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ACCOUNT API] %s %s", r.Method, r.URL.Path)
+
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/accounts/"), "/")
+	if len(pathSegments) == 0 || pathSegments[0] == "" {
+		http.Error(w, "Account ID is required", http.StatusBadRequest)
+		return
+	}
+
+	accountID, err := strconv.Atoi(pathSegments[0])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(pathSegments) > 1 && pathSegments[1] == "items" {
+		s.accountItemsHandler(w, r, accountID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		account, err := s.accountService.GetByID(accountID)
+		if err != nil {
+			log.Printf("[ACCOUNT API] ERROR: Failed to get account %d: %v", accountID, err)
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+		s.writeAccountJSON(w, account)
+	case http.MethodPut:
+		var req AccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		account, err := s.accountService.Update(accountID, req.Name, req.Broker, req.Type)
+		if err != nil {
+			log.Printf("[ACCOUNT API] ERROR: Failed to update account %d: %v", accountID, err)
+			http.Error(w, fmt.Sprintf("Failed to update account: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.writeAccountJSON(w, account)
+	case http.MethodDelete:
+		if err := s.accountService.Delete(accountID); err != nil {
+			log.Printf("[ACCOUNT API] ERROR: Failed to delete account %d: %v", accountID, err)
+			http.Error(w, fmt.Sprintf("Failed to delete account: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Account deleted successfully"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// accountItemsHandler moves options, long positions, dividends and treasuries
+// into (POST) or out of (DELETE) an account. POST with "unassigned": true
+// moves every record that has no account yet.
+func (s *Server) accountItemsHandler(w http.ResponseWriter, r *http.Request, accountID int) {
+	var req AccountItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch {
+	case r.Method == http.MethodPost && req.Unassigned:
+		err = s.accountService.AssignUnassigned(accountID)
+	case r.Method == http.MethodPost:
+		err = s.accountService.AssignItems(&accountID, req.AccountItems)
+	case r.Method == http.MethodDelete:
+		err = s.accountService.AssignItems(nil, req.AccountItems)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		log.Printf("[ACCOUNT API] ERROR: Failed to update items for account %d: %v", accountID, err)
+		http.Error(w, fmt.Sprintf("Failed to update account items: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	account, err := s.accountService.GetByID(accountID)
+	if err != nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	s.writeAccountJSON(w, account)
+}
+
+func (s *Server) writeAccountJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[ACCOUNT API] ERROR: Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartAlertScheduler evaluates the alert rules now and then every
+// ALERT_INTERVAL_MINUTES until the server is closed. The interval is read
+// again after each evaluation so a changed setting applies without a restart.
+// Each evaluation holds servicesMu, so switching databases waits for it.
+func (s *Server) StartAlertScheduler() {
+	stop := make(chan struct{})
+	s.stopAlerts = stop
+	go func() {
+		for {
+			s.servicesMu.RLock()
+			s.evaluateAlerts()
+			interval := s.alertInterval()
+			s.servicesMu.RUnlock()
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	log.Printf("[ALERTS] Scheduler started, evaluating every %v", s.alertInterval())
+}
+
+// alertInterval reads ALERT_INTERVAL_MINUTES, falling back to the default if
+// it is missing or not a positive number
+func (s *Server) alertInterval() time.Duration {
+	minutes, err := strconv.Atoi(s.settingService.GetValue(models.AlertIntervalSetting))
+	if err != nil || minutes <= 0 {
+		minutes = models.DefaultAlertInterval
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// evaluateAlerts runs one evaluation of the alert rules, then logs and sends
+// what fired
+func (s *Server) evaluateAlerts() {
+	fired, err := s.alertService.Evaluate(time.Now())
+	if err != nil {
+		log.Printf("[ALERTS] ERROR: Failed to evaluate alert rules: %v", err)
+		return
+	}
+	for _, alert := range fired {
+		log.Printf("[ALERTS] %s: %s", alert.RuleName, alert.Message)
+	}
+	s.notifyAlerts(fired)
+}
+
+// alertsHandler serves the alert rules and alert history page
+func (s *Server) alertsHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.alertService.GetRules()
+	if err != nil {
+		log.Printf("[ALERTS] ERROR: Failed to get alert rules: %v", err)
+		rules = []*models.AlertRule{}
+	}
+	alerts, err := s.alertService.GetAlerts(true)
+	if err != nil {
+		log.Printf("[ALERTS] ERROR: Failed to get alerts: %v", err)
+		alerts = []*models.Alert{}
+	}
+
+	data := AlertsPageData{
+		PageData: PageData{
+			Title:      "Alerts",
+			ActivePage: "alerts",
+			CurrentDB:  s.getCurrentDatabaseName(),
+			AllSymbols: s.getAllSymbolsList(),
+		},
+		Rules:     rules,
+		Alerts:    alerts,
+		RuleTypes: models.AlertRuleTypes,
+		Interval:  int(s.alertInterval().Minutes()),
+	}
+
+	s.renderTemplate(w, "alerts.html", data)
+}
+
+// alertsAPIHandler returns the alert inbox and its unread count
+func (s *Server) alertsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	alerts, err := s.alertService.GetAlerts(r.URL.Query().Get("all") == "true")
+	if err != nil {
+		log.Printf("[ALERT API] ERROR: Failed to get alerts: %v", err)
+		http.Error(w, "Failed to get alerts", http.StatusInternalServerError)
+		return
+	}
+	unread, err := s.alertService.UnreadCount()
+	if err != nil {
+		log.Printf("[ALERT API] ERROR: Failed to count unread alerts: %v", err)
+		http.Error(w, "Failed to count unread alerts", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeAlertJSON(w, map[string]interface{}{"unread": unread, "alerts": alerts})
+}
+
+// alertEvaluateHandler evaluates the alert rules now and returns the alerts that fired
+func (s *Server) alertEvaluateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ALERT API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fired, err := s.alertService.Evaluate(time.Now())
+	if err != nil {
+		log.Printf("[ALERT API] ERROR: Failed to evaluate alert rules: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to evaluate alert rules: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.notifyAlerts(fired)
+	s.writeAlertJSON(w, fired)
+}
+
+// alertReadAllHandler marks every alert in the inbox as read
+func (s *Server) alertReadAllHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.alertService.MarkAllRead(); err != nil {
+		log.Printf("[ALERT API] ERROR: Failed to mark alerts read: %v", err)
+		http.Error(w, "Failed to mark alerts read", http.StatusInternalServerError)
+		return
+	}
+	s.writeAlertJSON(w, map[string]string{"message": "Alerts marked read"})
+}
+
+// alertAPIHandler handles POST /api/alerts/{id}/read and DELETE /api/alerts/{id},
+// which dismisses the alert
+func (s *Server) alertAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ALERT API] %s %s", r.Method, r.URL.Path)
+
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/")
+	alertID, err := strconv.Atoi(pathSegments[0])
+	if err != nil {
+		http.Error(w, "Invalid alert ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(pathSegments) > 1 && pathSegments[1] == "read" && r.Method == http.MethodPost:
+		err = s.alertService.MarkRead(alertID)
+	case len(pathSegments) == 1 && r.Method == http.MethodDelete:
+		err = s.alertService.Dismiss(alertID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		log.Printf("[ALERT API] ERROR: Failed to update alert %d: %v", alertID, err)
+		http.Error(w, "Alert not found", http.StatusNotFound)
+		return
+	}
+	s.writeAlertJSON(w, map[string]string{"message": "Alert updated successfully"})
+}
+
+// alertRulesAPIHandler handles listing and creating alert rules
+func (s *Server) alertRulesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ALERT API] %s %s", r.Method, r.URL.Path)
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.alertService.GetRules()
+		if err != nil {
+			log.Printf("[ALERT API] ERROR: Failed to get alert rules: %v", err)
+			http.Error(w, "Failed to get alert rules", http.StatusInternalServerError)
+			return
+		}
+		s.writeAlertJSON(w, rules)
+	case http.MethodPost:
+		// Rules are enabled unless the request says otherwise
+		req := models.AlertRule{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		rule, err := s.alertService.CreateRule(&req)
+		if err != nil {
+			log.Printf("[ALERT API] ERROR: Failed to create alert rule: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to create alert rule: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("[ALERT API] Created alert rule %d (%s)", rule.ID, rule.Name)
+		w.WriteHeader(http.StatusCreated)
+		s.writeAlertJSON(w, rule)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// alertRuleAPIHandler handles /api/alert-rules/{id}
+func (s *Server) alertRuleAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ALERT API] %s %s", r.Method, r.URL.Path)
+
+	ruleID, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/alert-rules/"), "/"))
+	if err != nil {
+		http.Error(w, "Invalid alert rule ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rule, err := s.alertService.GetRule(ruleID)
+		if err != nil {
+			http.Error(w, "Alert rule not found", http.StatusNotFound)
+			return
+		}
+		s.writeAlertJSON(w, rule)
+	case http.MethodPut:
+		req := models.AlertRule{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		rule, err := s.alertService.UpdateRule(ruleID, &req)
+		if err != nil {
+			log.Printf("[ALERT API] ERROR: Failed to update alert rule %d: %v", ruleID, err)
+			http.Error(w, fmt.Sprintf("Failed to update alert rule: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.writeAlertJSON(w, rule)
+	case http.MethodDelete:
+		if err := s.alertService.DeleteRule(ruleID); err != nil {
+			log.Printf("[ALERT API] ERROR: Failed to delete alert rule %d: %v", ruleID, err)
+			http.Error(w, fmt.Sprintf("Failed to delete alert rule: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.writeAlertJSON(w, map[string]string{"message": "Alert rule deleted successfully"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) writeAlertJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[ALERT API] ERROR: Failed to encode response: %v", err)
+	}
+}
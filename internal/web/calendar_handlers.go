@@ -0,0 +1,62 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarHandler serves /calendar.ics, an iCalendar feed of option
+// expirations, ex-dividend dates and treasury maturities to subscribe to.
+// ?account= narrows it to one account, ?types= to a comma-separated list of
+// event types and ?alarm= sets the days of warning before expirations and
+// maturities, 0 for none. The sidebar selection is ignored so a subscribed
+// feed does not change with whichever account the browser last picked.
+func (s *Server) calendarHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CALENDAR] %s %s", r.Method, r.URL.String())
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	types := models.CalendarEventTypes
+	if value := r.URL.Query().Get("types"); value != "" {
+		types = nil
+		for _, eventType := range strings.Split(value, ",") {
+			eventType = strings.TrimSpace(strings.ToLower(eventType))
+			if !models.IsValidCalendarEventType(eventType) {
+				http.Error(w, fmt.Sprintf("Unknown event type %q, expected %s", eventType, strings.Join(models.CalendarEventTypes, ", ")), http.StatusBadRequest)
+				return
+			}
+			types = append(types, eventType)
+		}
+	}
+
+	alarmDays := models.DefaultCalendarAlarmDays
+	if value := r.URL.Query().Get("alarm"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			http.Error(w, "Alarm must be a number of days", http.StatusBadRequest)
+			return
+		}
+		alarmDays = days
+	}
+
+	calendar, err := s.calendarService.Calendar(requestedAccountID(r), types, alarmDays)
+	if err != nil {
+		log.Printf("[CALENDAR] ERROR: Failed to build calendar: %v", err)
+		http.Error(w, "Failed to build calendar", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="wheeler.ics"`)
+	if _, err := w.Write([]byte(calendar.ICS(time.Now()))); err != nil {
+		log.Printf("[CALENDAR] ERROR: Failed to write calendar: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// campaignsAPIHandler handles listing and creating wheel campaigns
+func (s *Server) campaignsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CAMPAIGN API] %s %s", r.Method, r.URL.Path)
+
+	switch r.Method {
+	case http.MethodGet:
+		s.listCampaigns(w, r)
+	case http.MethodPost:
+		s.createCampaign(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// campaignAPIHandler handles /api/campaigns/{id} and /api/campaigns/{id}/items
+func (s *Server) campaignAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CAMPAIGN API] %s %s", r.Method, r.URL.Path)
+
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/campaigns/"), "/")
+	if len(pathSegments) == 0 || pathSegments[0] == "" {
+		http.Error(w, "Campaign ID is required", http.StatusBadRequest)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(pathSegments[0])
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(pathSegments) > 1 && pathSegments[1] == "items" {
+		s.campaignItemsHandler(w, r, campaignID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		campaign, err := s.campaignService.GetByID(campaignID)
+		if err != nil {
+			log.Printf("[CAMPAIGN API] ERROR: Failed to get campaign %d: %v", campaignID, err)
+			http.Error(w, "Campaign not found", http.StatusNotFound)
+			return
+		}
+		s.writeCampaignJSON(w, s.buildCampaignView(campaign))
+	case http.MethodPut:
+		s.updateCampaign(w, r, campaignID)
+	case http.MethodDelete:
+		if err := s.campaignService.Delete(campaignID); err != nil {
+			log.Printf("[CAMPAIGN API] ERROR: Failed to delete campaign %d: %v", campaignID, err)
+			http.Error(w, fmt.Sprintf("Failed to delete campaign: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Campaign deleted successfully"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// listCampaigns returns all campaigns, optionally filtered by ?symbol=
+func (s *Server) listCampaigns(w http.ResponseWriter, r *http.Request) {
+	var campaigns []*models.Campaign
+	var err error
+	if symbol := strings.ToUpper(r.URL.Query().Get("symbol")); symbol != "" {
+		campaigns, err = s.campaignService.GetBySymbol(symbol)
+	} else {
+		campaigns, err = s.campaignService.GetAll()
+	}
+	if err != nil {
+		log.Printf("[CAMPAIGN API] ERROR: Failed to get campaigns: %v", err)
+		http.Error(w, "Failed to get campaigns", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeCampaignJSON(w, s.buildCampaignViews(campaigns))
+}
+
+// createCampaign handles POST requests to start a new campaign
+func (s *Server) createCampaign(w http.ResponseWriter, r *http.Request) {
+	var req CampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if req.Symbol == "" || req.Name == "" {
+		http.Error(w, "Symbol and name are required", http.StatusBadRequest)
+		return
+	}
+
+	started := time.Now().Truncate(24 * time.Hour)
+	if req.Started != "" {
+		parsed, err := time.Parse("2006-01-02", req.Started)
+		if err != nil {
+			http.Error(w, "Invalid started date format", http.StatusBadRequest)
+			return
+		}
+		started = parsed
+	}
+
+	campaign, err := s.campaignService.Create(req.Symbol, req.Name, started, req.Notes)
+	if err != nil {
+		log.Printf("[CAMPAIGN API] ERROR: Failed to create campaign: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to create campaign: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[CAMPAIGN API] Created campaign %d for %s", campaign.ID, campaign.Symbol)
+	w.WriteHeader(http.StatusCreated)
+	s.writeCampaignJSON(w, s.buildCampaignView(campaign))
+}
+
+// updateCampaign handles PUT requests to rename, re-date or end a campaign
+func (s *Server) updateCampaign(w http.ResponseWriter, r *http.Request, campaignID int) {
+	var req CampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	started, err := time.Parse("2006-01-02", req.Started)
+	if err != nil {
+		http.Error(w, "Invalid started date format", http.StatusBadRequest)
+		return
+	}
+
+	var ended *time.Time
+	if req.Ended != nil && *req.Ended != "" {
+		parsed, err := time.Parse("2006-01-02", *req.Ended)
+		if err != nil {
+			http.Error(w, "Invalid ended date format", http.StatusBadRequest)
+			return
+		}
+		ended = &parsed
+	}
+
+	campaign, err := s.campaignService.Update(campaignID, req.Name, started, ended, req.Notes)
+	if err != nil {
+		log.Printf("[CAMPAIGN API] ERROR: Failed to update campaign %d: %v", campaignID, err)
+		http.Error(w, fmt.Sprintf("Failed to update campaign: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.writeCampaignJSON(w, s.buildCampaignView(campaign))
+}
+
+// campaignItemsHandler links (POST) or unlinks (DELETE) options, long positions and dividends
+func (s *Server) campaignItemsHandler(w http.ResponseWriter, r *http.Request, campaignID int) {
+	var items models.CampaignItems
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = s.campaignService.LinkItems(campaignID, items)
+	case http.MethodDelete:
+		err = s.campaignService.UnlinkItems(items)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		log.Printf("[CAMPAIGN API] ERROR: Failed to update items for campaign %d: %v", campaignID, err)
+		http.Error(w, fmt.Sprintf("Failed to update campaign items: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := s.campaignService.GetByID(campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	s.writeCampaignJSON(w, s.buildCampaignView(campaign))
+}
+
+// buildCampaignView calculates a campaign summary using the symbol's current price
+func (s *Server) buildCampaignView(campaign *models.Campaign) CampaignView {
+	var currentPrice float64
+	if symbol, err := s.symbolService.GetBySymbol(campaign.Symbol); err == nil && symbol != nil {
+		currentPrice = symbol.Price
+	}
+
+	return CampaignView{
+		Campaign: campaign,
+		Summary:  campaign.Summary(currentPrice),
+	}
+}
+
+func (s *Server) buildCampaignViews(campaigns []*models.Campaign) []CampaignView {
+	views := make([]CampaignView, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		views = append(views, s.buildCampaignView(campaign))
+	}
+	return views
+}
+
+func (s *Server) writeCampaignJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[CAMPAIGN API] ERROR: Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cashHandler serves the cash ledger of the selected account, newest flow first
+func (s *Server) cashHandler(w http.ResponseWriter, r *http.Request) {
+	ledger, err := s.cashService.Ledger(s.selectedAccountID(r))
+	if err != nil {
+		log.Printf("[CASH] ERROR: Failed to build cash ledger: %v", err)
+		ledger = &models.CashLedger{Flows: []*models.CashFlow{}, ByKind: map[string]float64{}}
+	}
+
+	recent := make([]*models.CashFlow, len(ledger.Flows))
+	for i, flow := range ledger.Flows {
+		recent[len(ledger.Flows)-1-i] = flow
+	}
+
+	data := CashPageData{
+		PageData: PageData{
+			Title:      "Cash",
+			ActivePage: "cash",
+			CurrentDB:  s.getCurrentDatabaseName(),
+			AllSymbols: s.getAllSymbolsList(),
+		},
+		Ledger: ledger,
+		Recent: recent,
+	}
+
+	s.renderTemplate(w, "cash.html", data)
+}
+
+// cashBalance returns the cash balance of the accounts the filter keeps
+func (s *Server) cashBalance(filter *models.AccountFilter) (float64, error) {
+	var accountID *int
+	if filter != nil {
+		accountID = &filter.AccountID
+	}
+	ledger, err := s.cashService.Ledger(accountID)
+	if err != nil {
+		return 0, err
+	}
+	return ledger.Balance, nil
+}
+
+// cashAPIHandler returns the cash ledger with its running balance (GET) or
+// records a deposit, withdrawal or interest payment (POST)
+func (s *Server) cashAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CASH API] %s %s", r.Method, r.URL.Path)
+
+	switch r.Method {
+	case http.MethodGet:
+		ledger, err := s.cashService.Ledger(s.selectedAccountID(r))
+		if err != nil {
+			log.Printf("[CASH API] ERROR: Failed to build cash ledger: %v", err)
+			http.Error(w, "Failed to build cash ledger", http.StatusInternalServerError)
+			return
+		}
+		s.writeCashJSON(w, ledger)
+	case http.MethodPost:
+		var req CashEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		accountID := req.AccountID
+		if accountID == nil {
+			accountID = s.selectedAccountID(r)
+		}
+
+		entry, err := s.cashService.Create(date, req.Type, req.Amount, req.Notes, accountID)
+		if err != nil {
+			log.Printf("[CASH API] ERROR: Failed to create cash entry: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to create cash entry: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("[CASH API] Recorded %s of $%.2f on %s", entry.Type, entry.Amount, entry.Date.Format("2006-01-02"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		s.writeCashJSON(w, entry)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// cashEntryAPIHandler handles GET, PUT and DELETE of /api/cash/{id}
+func (s *Server) cashEntryAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CASH API] %s %s", r.Method, r.URL.Path)
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/cash/"))
+	if err != nil {
+		http.Error(w, "Invalid cash entry ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entry, err := s.cashService.GetByID(id)
+		if err != nil {
+			http.Error(w, "Cash entry not found", http.StatusNotFound)
+			return
+		}
+		s.writeCashJSON(w, entry)
+	case http.MethodPut:
+		var req CashEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		entry, err := s.cashService.Update(id, date, req.Type, req.Amount, req.Notes)
+		if err != nil {
+			log.Printf("[CASH API] ERROR: Failed to update cash entry %d: %v", id, err)
+			http.Error(w, fmt.Sprintf("Failed to update cash entry: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.writeCashJSON(w, entry)
+	case http.MethodDelete:
+		if err := s.cashService.Delete(id); err != nil {
+			log.Printf("[CASH API] ERROR: Failed to delete cash entry %d: %v", id, err)
+			http.Error(w, fmt.Sprintf("Failed to delete cash entry: %v", err), http.StatusNotFound)
+			return
+		}
+		s.writeCashJSON(w, map[string]string{"message": "Cash entry deleted successfully"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) writeCashJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[CASH API] ERROR: Failed to encode response: %v", err)
+	}
+}
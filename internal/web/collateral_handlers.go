@@ -0,0 +1,65 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"time"
+)
+
+// collateralAPIHandler returns the collateral coverage of the open puts of the
+// selected account, week by week
+func (s *Server) collateralAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[COLLATERAL API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := s.collateralService.Report(s.selectedAccountID(r))
+	if err != nil {
+		log.Printf("[COLLATERAL API] ERROR: Failed to build collateral report: %v", err)
+		http.Error(w, "Failed to build collateral report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("[COLLATERAL API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// collateralWarning checks the collateral of a new option's account after it is
+// opened. It returns "" unless the option is a short put that creates a
+// shortfall, or makes an existing one worse, in some week; otherwise it
+// describes the first such week.
+func (s *Server) collateralWarning(option *models.Option) string {
+	if option.Type != "Put" || option.IsLong() || option.Closed != nil {
+		return ""
+	}
+
+	report, without, err := s.collateralService.PutImpact(option.ID)
+	if err != nil {
+		log.Printf("[COLLATERAL] ERROR: Failed to check collateral for option %d: %v", option.ID, err)
+		return ""
+	}
+
+	before := make(map[time.Time]float64)
+	for _, week := range without.Weeks {
+		before[week.WeekOf] = week.Shortfall
+	}
+	for _, week := range report.Weeks {
+		if week.Shortfall-before[week.WeekOf] < 0.005 {
+			continue
+		}
+
+		warning := fmt.Sprintf("Puts expiring through the week of %s need $%.2f but only $%.2f in cash and maturing treasuries is available, a shortfall of $%.2f",
+			week.WeekOf.Format("2006-01-02"), week.Cumulative, week.Collateral, week.Shortfall)
+		log.Printf("[COLLATERAL] WARNING: %s %.2f put %d leaves its account under-collateralized: %s", option.Symbol, option.Strike, option.ID, warning)
+		return warning
+	}
+	return ""
+}
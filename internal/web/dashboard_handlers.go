@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sort"
 	"stonks/internal/models"
+	"time"
 )
 
 // dashboardHandler serves the TraderVue-style dashboard
@@ -22,7 +23,7 @@ func (s *Server) dashboardHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[DASHBOARD] Found %d symbols for navigation: %v", len(symbols), symbols)
 
 	// Build comprehensive dashboard data
-	data, err := s.buildDashboardData(symbols)
+	data, err := s.buildDashboardData(symbols, s.accountFilter(r))
 	if err != nil {
 		log.Printf("Error building dashboard data: %v", err)
 		// Fallback to basic data structure
@@ -33,17 +34,23 @@ func (s *Server) dashboardHandler(w http.ResponseWriter, r *http.Request) {
 			ActivePage: "dashboard",
 		}
 	}
+	data.CollateralWarning = r.URL.Query().Get("collateral_warning")
 
 	s.renderTemplate(w, "dashboard.html", data)
 }
 
-// buildDashboardData creates comprehensive dashboard data
-func (s *Server) buildDashboardData(symbols []string) (DashboardData, error) {
+// buildDashboardData creates comprehensive dashboard data for the accounts the filter keeps
+func (s *Server) buildDashboardData(symbols []string, filter *models.AccountFilter) (DashboardData, error) {
 	// Get all data
 	options, _ := s.optionService.GetAll()
 	longPositions, _ := s.longPositionService.GetAll()
 	dividends, _ := s.dividendService.GetAll()
-	totalTreasuries, _ := s.treasuryService.GetTotalOpenValue()
+	totalTreasuries, _ := s.openTreasuryValue(filter)
+	cash, _ := s.cashBalance(filter)
+
+	options = filter.Options(options)
+	longPositions = filter.LongPositions(longPositions)
+	dividends = filter.Dividends(dividends)
 
 	// Build symbol summaries
 	symbolSummaries := s.buildSymbolSummaries(symbols, options, longPositions, dividends)
@@ -51,11 +58,17 @@ func (s *Server) buildDashboardData(symbols []string) (DashboardData, error) {
 	// Build chart data
 	longByTicker := s.buildLongByTickerChart(longPositions)
 	putsByTicker := s.buildPutsByTickerChart(options)
-	totalAllocation := s.buildTotalAllocationChart(longPositions, options, totalTreasuries)
+	totalAllocation := s.buildTotalAllocationChart(longPositions, options, totalTreasuries, cash)
 
 	// Calculate totals
 	totals := s.calculateDashboardTotals(symbolSummaries, totalTreasuries)
 
+	// Aggregate option and share Greeks
+	greeks, err := s.pricingService.PortfolioGreeks(options, longPositions, time.Now())
+	if err != nil {
+		log.Printf("[DASHBOARD] Error calculating portfolio greeks: %v", err)
+	}
+
 	log.Printf("[DASHBOARD] Building dashboard data with %d symbols: %v", len(symbols), symbols)
 	log.Printf("[DASHBOARD] Built %d symbol summaries", len(symbolSummaries))
 
@@ -67,6 +80,7 @@ func (s *Server) buildDashboardData(symbols []string) (DashboardData, error) {
 		PutsByTicker:    putsByTicker,
 		TotalAllocation: totalAllocation,
 		Totals:          totals,
+		Greeks:          greeks,
 		CurrentDB:       s.getCurrentDatabaseName(),
 		ActivePage:      "dashboard",
 	}, nil
@@ -109,10 +123,6 @@ func (s *Server) buildSymbolSummaries(symbols []string, options []*models.Option
 	for _, opt := range options {
 		if summary, exists := summaryMap[opt.Symbol]; exists {
 			if opt.Type == "Put" {
-				// Count put exposure for all open puts
-				if opt.Closed == nil {
-					summary.PutExposed += opt.Strike * float64(opt.Contracts) * 100
-				}
 				// Count premium for all puts (closed and open)
 				premium := opt.CalculateTotalProfit()
 				summary.Puts += premium
@@ -120,14 +130,30 @@ func (s *Server) buildSymbolSummaries(symbols []string, options []*models.Option
 				// Count premium for all calls (closed and open)
 				premium := opt.CalculateTotalProfit()
 				summary.Calls += premium
-				// Track call coverage for open calls
-				if opt.Closed == nil {
+				// Track call coverage for open short calls
+				if opt.Closed == nil && !opt.IsLong() {
 					callCoverage[opt.Symbol] = true
 				}
 			}
 		}
 	}
 
+	// Open LEAPS without short calls against them can be written on like stock
+	for _, opt := range options {
+		if summary, exists := summaryMap[opt.Symbol]; exists {
+			if opt.Closed == nil && opt.IsLEAPS() && !callCoverage[opt.Symbol] {
+				summary.Optionable += opt.Premium * float64(opt.Contracts*opt.Multiplier())
+			}
+		}
+	}
+
+	// Count put exposure for all open puts, with spreads counted at their width
+	for symbol, exposure := range models.CalculatePutExposureBySymbol(options) {
+		if summary, exists := summaryMap[symbol]; exists {
+			summary.PutExposed += exposure
+		}
+	}
+
 	// Calculate optionable amounts (long positions without call coverage and with 100+ shares)
 	for _, pos := range longPositions {
 		if summary, exists := summaryMap[pos.Symbol]; exists {
@@ -194,14 +220,10 @@ func (s *Server) buildLongByTickerChart(longPositions []*models.LongPosition) []
 }
 
 func (s *Server) buildPutsByTickerChart(options []*models.Option) []ChartData {
-	putExposure := make(map[string]float64)
 	colors := []string{"#FF6384", "#36A2EB", "#FFCE56", "#4BC0C0", "#9966FF", "#FF9F40"}
 
-	for _, opt := range options {
-		if opt.Type == "Put" && opt.Closed == nil { // Only include open puts
-			putExposure[opt.Symbol] += opt.Strike * float64(opt.Contracts) * 100
-		}
-	}
+	// Only include open puts, with spreads counted at their width
+	putExposure := models.CalculatePutExposureBySymbol(options)
 
 	// Sort tickers alphabetically for consistent legend colors
 	var tickers []string
@@ -222,7 +244,7 @@ func (s *Server) buildPutsByTickerChart(options []*models.Option) []ChartData {
 	return chartData
 }
 
-func (s *Server) buildTotalAllocationChart(longPositions []*models.LongPosition, options []*models.Option, totalTreasuries float64) []ChartData {
+func (s *Server) buildTotalAllocationChart(longPositions []*models.LongPosition, options []*models.Option, totalTreasuries, cash float64) []ChartData {
 	var totalLong, totalPuts float64
 
 	// Only count open long positions for current allocation
@@ -233,19 +255,27 @@ func (s *Server) buildTotalAllocationChart(longPositions []*models.LongPosition,
 	}
 
 	// Only count open put options for current exposure
-	for _, opt := range options {
-		if opt.Type == "Put" && opt.Closed == nil {
-			totalPuts += opt.Strike * float64(opt.Contracts) * 100
-		}
+	for _, exposure := range models.CalculatePutExposureBySymbol(options) {
+		totalPuts += exposure
 	}
 
 	return []ChartData{
 		{Label: "Long Stock", Value: totalLong, Color: "#36A2EB"},
 		{Label: "Put Exposure", Value: totalPuts, Color: "#FF6384"},
 		{Label: "Treasuries", Value: totalTreasuries, Color: "#FFCE56"},
+		{Label: "Cash", Value: idleCash(cash), Color: "#4BC0C0"},
 	}
 }
 
+// idleCash is the cash slice of the allocation chart; an overdrawn ledger
+// (trades recorded without the deposits that paid for them) shows as none
+func idleCash(balance float64) float64 {
+	if balance < 0 {
+		return 0
+	}
+	return balance
+}
+
 func (s *Server) calculateDashboardTotals(symbolSummaries []SymbolSummary, totalTreasuries float64) DashboardTotals {
 	var totalLong, totalPuts, totalPutPremiums, totalCallPremiums, totalCapGains, totalDividends, totalOptionable float64
 
@@ -302,10 +332,14 @@ func (s *Server) premiumDataHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	options = s.accountFilter(r).Options(options)
 
 	var putPremium, callPremium float64
 	for _, option := range options {
-		totalPremium := option.Premium * float64(option.Contracts) * 100
+		totalPremium := option.Premium * float64(option.Contracts*option.Multiplier())
+		if option.IsLong() {
+			totalPremium = -totalPremium // Long options are paid for
+		}
 
 		if option.Type == "Put" {
 			putPremium += totalPremium
@@ -330,14 +364,23 @@ func (s *Server) allocationDataHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter := s.accountFilter(r)
+
 	// Get total open treasuries value
-	totalTreasuries, err := s.treasuryService.GetTotalOpenValue()
+	totalTreasuries, err := s.openTreasuryValue(filter)
 	if err != nil {
 		log.Printf("[ALLOCATION API] Error getting treasury total: %v", err)
 		http.Error(w, "Failed to get treasuries", http.StatusInternalServerError)
 		return
 	}
 
+	cash, err := s.cashBalance(filter)
+	if err != nil {
+		log.Printf("[ALLOCATION API] Error getting cash balance: %v", err)
+		http.Error(w, "Failed to get cash balance", http.StatusInternalServerError)
+		return
+	}
+
 	// Get open long positions (no exit price)
 	longPositions, err := s.longPositionService.GetAll()
 	if err != nil {
@@ -345,6 +388,7 @@ func (s *Server) allocationDataHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get long positions", http.StatusInternalServerError)
 		return
 	}
+	longPositions = filter.LongPositions(longPositions)
 
 	var totalLong float64
 	longByTicker := make(map[string]float64)
@@ -363,21 +407,33 @@ func (s *Server) allocationDataHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get options", http.StatusInternalServerError)
 		return
 	}
+	options = filter.Options(options)
 
 	var totalPuts, totalPutPremiums, totalCallPremiums float64
-	putsByTicker := make(map[string]float64)
 	callCoverage := make(map[string]bool)
+	var leaps []*models.Option
 	
+	// Spreads only tie up their width, so exposure nets long puts against short puts
+	putsByTicker := models.CalculatePutExposureBySymbol(options)
+	for _, exposure := range putsByTicker {
+		totalPuts += exposure
+	}
+
 	for _, opt := range options {
 		if opt.Closed == nil { // Only open options
+			premium := opt.Premium * float64(opt.Contracts*opt.Multiplier())
+			if opt.IsLong() {
+				premium = -premium // Long legs are paid for
+			}
 			if opt.Type == "Put" {
-				exposure := opt.Strike * float64(opt.Contracts) * 100
-				totalPuts += exposure
-				putsByTicker[opt.Symbol] += exposure
-				totalPutPremiums += opt.Premium * float64(opt.Contracts) * 100
+				totalPutPremiums += premium
 			} else if opt.Type == "Call" {
-				totalCallPremiums += opt.Premium * float64(opt.Contracts) * 100
-				callCoverage[opt.Symbol] = true
+				totalCallPremiums += premium
+				if !opt.IsLong() {
+					callCoverage[opt.Symbol] = true
+				} else if opt.IsLEAPS() {
+					leaps = append(leaps, opt)
+				}
 			}
 		}
 	}
@@ -395,8 +451,29 @@ func (s *Server) allocationDataHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// LEAPS cover short calls the same way stock does (poor man's covered call)
+	for _, opt := range leaps {
+		amount := opt.Premium * float64(opt.Contracts*opt.Multiplier())
+		if callCoverage[opt.Symbol] {
+			totalCallCovered += amount
+		} else {
+			totalOptionable += amount
+		}
+	}
+
 	log.Printf("[ALLOCATION API] Calculated totals - Long: $%.2f, Puts: $%.2f, Treasuries: $%.2f", totalLong, totalPuts, totalTreasuries)
 
+	// Put exposure is only meaningful next to whether it is actually secured
+	collateral, err := s.collateralService.Report(s.selectedAccountID(r))
+	if err != nil {
+		log.Printf("[ALLOCATION API] Error building collateral report: %v", err)
+		http.Error(w, "Failed to check collateral", http.StatusInternalServerError)
+		return
+	}
+	if !collateral.IsCovered() {
+		log.Printf("[ALLOCATION API] WARNING: Open puts are short $%.2f of collateral", collateral.Shortfall)
+	}
+
 	// Build response data
 	var longByTickerChart []ChartData
 	var putsByTickerChart []ChartData
@@ -436,6 +513,7 @@ func (s *Server) allocationDataHandler(w http.ResponseWriter, r *http.Request) {
 		{Label: "Long Stock", Value: totalLong, Color: "#36A2EB"},
 		{Label: "Put Exposure", Value: totalPuts, Color: "#FF6384"},
 		{Label: "Treasuries", Value: totalTreasuries, Color: "#FFCE56"},
+		{Label: "Cash", Value: idleCash(cash), Color: "#4BC0C0"},
 	}
 
 	callsToLongs := []ChartData{
@@ -455,16 +533,17 @@ func (s *Server) allocationDataHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := AllocationData{
-		LongByTicker:      longByTickerChart,
-		PutsByTicker:      putsByTickerChart,
-		CallsToLongs:      callsToLongs,
-		TotalAllocation:   totalAllocation,
-		PutROI:            putROI,
-		LongROI:           longROI,
-		TotalPutPremiums:  totalPutPremiums,
-		TotalCallPremiums: totalCallPremiums,
-		TotalCallCovered:  totalCallCovered,
-		TotalOptionable:   totalOptionable,
+		LongByTicker:        longByTickerChart,
+		PutsByTicker:        putsByTickerChart,
+		CallsToLongs:        callsToLongs,
+		TotalAllocation:     totalAllocation,
+		PutROI:              putROI,
+		LongROI:             longROI,
+		TotalPutPremiums:    totalPutPremiums,
+		TotalCallPremiums:   totalCallPremiums,
+		TotalCallCovered:    totalCallCovered,
+		TotalOptionable:     totalOptionable,
+		CollateralShortfall: collateral.Shortfall,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -481,6 +560,8 @@ func (s *Server) optionablePositionsHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	filter := s.accountFilter(r)
+
 	// Get all open long positions
 	longPositions, err := s.longPositionService.GetAll()
 	if err != nil {
@@ -488,6 +569,7 @@ func (s *Server) optionablePositionsHandler(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "Failed to get long positions", http.StatusInternalServerError)
 		return
 	}
+	longPositions = filter.LongPositions(longPositions)
 
 	// Get all open call options
 	options, err := s.optionService.GetAll()
@@ -496,17 +578,19 @@ func (s *Server) optionablePositionsHandler(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "Failed to get options", http.StatusInternalServerError)
 		return
 	}
+	options = filter.Options(options)
 
-	// Build map of symbols with open call coverage
+	// Build map of symbols with open call coverage (short calls only)
 	callCoverage := make(map[string]bool)
 	for _, opt := range options {
-		if opt.Type == "Call" && opt.Closed == nil {
+		if opt.Type == "Call" && opt.Closed == nil && !opt.IsLong() {
 			callCoverage[opt.Symbol] = true
 		}
 	}
 
-	// Find long positions without call coverage
+	// Find long positions and LEAPS without call coverage
 	type OptionablePosition struct {
+		Type       string  `json:"type"`
 		Symbol     string  `json:"symbol"`
 		Shares     int     `json:"shares"`
 		Amount     float64 `json:"amount"`
@@ -530,6 +614,7 @@ func (s *Server) optionablePositionsHandler(w http.ResponseWriter, r *http.Reque
 				}
 				
 				optionablePositions = append(optionablePositions, OptionablePosition{
+					Type:         "Stock",
 					Symbol:       pos.Symbol,
 					Shares:       pos.Shares,
 					Amount:       amount,
@@ -542,6 +627,27 @@ func (s *Server) optionablePositionsHandler(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	// Each LEAPS contract can cover one short call, like a contract's worth of shares
+	for _, opt := range options {
+		if opt.Closed == nil && opt.IsLEAPS() && !callCoverage[opt.Symbol] {
+			amount := opt.Premium * float64(opt.Contracts*opt.Multiplier())
+			currentValue := amount
+			if opt.CurrentPrice != nil {
+				currentValue = *opt.CurrentPrice * float64(opt.Contracts*opt.Multiplier())
+			}
+			optionablePositions = append(optionablePositions, OptionablePosition{
+				Type:         "LEAPS",
+				Symbol:       opt.Symbol,
+				Shares:       opt.Contracts * opt.Multiplier(),
+				Amount:       amount,
+				BuyPrice:     opt.Premium,
+				Opened:       opt.Opened.Format("2006-01-02"),
+				CurrentValue: currentValue,
+			})
+			totalOptionableValue += amount
+		}
+	}
+
 	log.Printf("[OPTIONABLE API] Found %d optionable positions worth $%.2f", len(optionablePositions), totalOptionableValue)
 
 	response := map[string]interface{}{
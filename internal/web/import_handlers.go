@@ -145,6 +145,7 @@ func (s *Server) HandleImportUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("[IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	s.notifyImport("Options", importedCount, skippedCount)
 	response := ImportResponse{
 		Success:       true,
 		ImportedCount: importedCount,
@@ -204,6 +205,7 @@ func (s *Server) HandleStocksImportUpload(w http.ResponseWriter, r *http.Request
 	}
 
 	log.Printf("[STOCKS_IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	s.notifyImport("Stocks", importedCount, skippedCount)
 	response := ImportResponse{
 		Success:       true,
 		ImportedCount: importedCount,
@@ -265,6 +267,7 @@ func (s *Server) HandleDividendsImportUpload(w http.ResponseWriter, r *http.Requ
 	}
 
 	log.Printf("[DIVIDENDS_IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	s.notifyImport("Dividends", importedCount, skippedCount)
 	response := ImportResponse{
 		Success:       true,
 		ImportedCount: importedCount,
@@ -326,6 +329,7 @@ func (s *Server) HandleTreasuriesImportUpload(w http.ResponseWriter, r *http.Req
 	}
 
 	log.Printf("[TREASURIES_IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	s.notifyImport("Treasuries", importedCount, skippedCount)
 	response := ImportResponse{
 		Success:       true,
 		ImportedCount: importedCount,
@@ -339,7 +343,7 @@ func (s *Server) HandleTreasuriesImportUpload(w http.ResponseWriter, r *http.Req
 // importOptionsFromCSV parses the CSV file and imports options
 func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = 10 // Expect exactly 10 fields
+	reader.FieldsPerRecord = 0 // Every row must have as many fields as the header
 
 	// Read header row
 	headers, err := reader.Read()
@@ -349,8 +353,18 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 
 	// Validate headers (accept both 'commission' and 'total_commission' for backward compatibility)
 	expectedHeaders := []string{"symbol", "opened", "closed", "type", "strike", "expiration", "premium", "contracts", "exit_price", "commission"}
-	if len(headers) != len(expectedHeaders) {
-		return 0, 0, fmt.Errorf("CSV must have exactly %d columns, got %d", len(expectedHeaders), len(headers))
+	// An optional last account column names the brokerage account of each row
+	hasAccount := hasAccountColumn(headers)
+	columns := len(headers)
+	if hasAccount {
+		columns--
+	}
+	// An optional trailing direction column records bought (long) options
+	if columns == len(expectedHeaders)+1 {
+		expectedHeaders = append(expectedHeaders, "direction")
+	}
+	if columns != len(expectedHeaders) {
+		return 0, 0, fmt.Errorf("CSV must have %d or %d columns plus an optional account column, got %d", len(expectedHeaders), len(expectedHeaders)+1, len(headers))
 	}
 
 	for i, expected := range expectedHeaders {
@@ -391,6 +405,12 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 			ExitPrice:  strings.TrimSpace(record[8]),
 			Commission: strings.TrimSpace(record[9]),
 		}
+		if len(expectedHeaders) > 10 {
+			csvRecord.Direction = strings.TrimSpace(record[10])
+		}
+		if hasAccount {
+			csvRecord.Account = strings.TrimSpace(record[len(record)-1])
+		}
 
 		// Convert to Option struct
 		option, err := s.convertCSVRecordToOption(csvRecord, rowNumber)
@@ -404,36 +424,51 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 			return importedCount, skippedCount, fmt.Errorf("error ensuring symbol exists for row %d: %w", rowNumber, err)
 		}
 
-		// Try to create the option (skip if duplicate) - use CreateWithCommission to set custom commission
-		_, err = s.optionService.CreateWithCommission(option.Symbol, option.Type, option.Opened, option.Strike, option.Expiration, option.Premium, option.Contracts, option.Commission)
+		accountID, err := s.importAccountID(csvRecord.Account)
 		if err != nil {
-			if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate") {
-				log.Printf("[IMPORT] Skipping duplicate option at row %d: %s %s %v", rowNumber, option.Symbol, option.Type, option.Opened)
-				skippedCount++
+			return importedCount, skippedCount, fmt.Errorf("error resolving account at row %d: %w", rowNumber, err)
+		}
+
+		// Rows already recorded, including as one part of a partial close, are skipped
+		if s.isOptionImported(option, accountID) {
+			log.Printf("[IMPORT] Skipping duplicate option at row %d: %s %s %v", rowNumber, option.Symbol, option.Type, option.Opened)
+			skippedCount++
+			continue
+		}
+
+		// A closed row for fewer contracts than a matching open option is a partial close of it
+		if option.Closed != nil {
+			if open := s.findOptionToPartiallyClose(option, accountID); open != nil {
+				closedPart, _, err := s.optionService.CloseContracts(open.ID, option.Contracts, *option.Closed, option.GetExitPriceValue())
+				if err != nil {
+					return importedCount, skippedCount, fmt.Errorf("error partially closing option at row %d: %w", rowNumber, err)
+				}
+				// The row's commission is the total for the closed contracts
+				_, updateErr := s.optionService.UpdateByID(closedPart.ID, closedPart.Symbol, closedPart.Type, closedPart.Direction, closedPart.Opened, closedPart.Strike, closedPart.Expiration, closedPart.Premium, closedPart.Contracts, option.Commission, closedPart.Closed, closedPart.ExitPrice)
+				if updateErr != nil {
+					log.Printf("[IMPORT] Warning: Failed to set commission of partially closed option for row %d: %v", rowNumber, updateErr)
+				}
+				log.Printf("[IMPORT] Row %d closed %d of %d contracts of option %d", rowNumber, option.Contracts, open.Contracts, open.ID)
+				importedCount++
 				continue
 			}
-			return importedCount, skippedCount, fmt.Errorf("error creating option at row %d: %w", rowNumber, err)
 		}
 
-		// If the option was closed, update it with exit information
+		// Try to create the option (skip if duplicate) - closed rows are created closed so that
+		// the closed and open parts of a partially closed position are told apart
+		var exitPrice *float64
 		if option.Closed != nil {
-			// We need to get the created option to update it
-			options, err := s.optionService.GetBySymbol(option.Symbol)
-			if err == nil {
-				// Find the option we just created (match by key fields)
-				for _, opt := range options {
-					if opt.Symbol == option.Symbol && opt.Type == option.Type &&
-						opt.Opened.Equal(option.Opened) && opt.Strike == option.Strike &&
-						opt.Expiration.Equal(option.Expiration) && opt.Premium == option.Premium &&
-						opt.Contracts == option.Contracts {
-						_, updateErr := s.optionService.UpdateByID(opt.ID, opt.Symbol, opt.Type, opt.Opened, opt.Strike, opt.Expiration, opt.Premium, opt.Contracts, opt.Commission, option.Closed, option.ExitPrice)
-						if updateErr != nil {
-							log.Printf("[IMPORT] Warning: Failed to update option exit info for row %d: %v", rowNumber, updateErr)
-						}
-						break
-					}
-				}
+			price := option.GetExitPriceValue()
+			exitPrice = &price
+		}
+		_, err = s.optionService.CreateInAccount(accountID, option.Symbol, option.Type, option.Direction, option.Opened, option.Strike, option.Expiration, option.Premium, option.Contracts, option.Commission, option.Closed, exitPrice)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate") {
+				log.Printf("[IMPORT] Skipping duplicate option at row %d: %s %s %v", rowNumber, option.Symbol, option.Type, option.Opened)
+				skippedCount++
+				continue
 			}
+			return importedCount, skippedCount, fmt.Errorf("error creating option at row %d: %w", rowNumber, err)
 		}
 
 		importedCount++
@@ -445,10 +480,57 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 	return importedCount, skippedCount, nil
 }
 
+// findOptionToPartiallyClose returns an open option in the record's account with
+// the same terms as the closed record but more contracts, or nil if there is none
+func (s *Server) findOptionToPartiallyClose(record *models.Option, accountID *int) *models.Option {
+	options, err := s.optionService.GetBySymbolInAccount(record.Symbol, accountID)
+	if err != nil {
+		return nil
+	}
+	for _, opt := range options {
+		if opt.Closed == nil && opt.Type == record.Type && opt.Direction == record.Direction &&
+			opt.Opened.Equal(record.Opened) && opt.Strike == record.Strike &&
+			opt.Expiration.Equal(record.Expiration) && opt.Premium == record.Premium &&
+			opt.Contracts > record.Contracts {
+			return opt
+		}
+	}
+	return nil
+}
+
+// isOptionImported reports whether the record is already in its account. A
+// closed record matches a closed option with the same terms, close date and
+// exit price that covers its contracts, which may have been split off an open
+// option by an earlier import. An open record matches when the options with the
+// same terms, open or closed, already account for its contracts.
+func (s *Server) isOptionImported(record *models.Option, accountID *int) bool {
+	options, err := s.optionService.GetBySymbolInAccount(record.Symbol, accountID)
+	if err != nil {
+		return false
+	}
+
+	recorded := 0
+	for _, opt := range options {
+		if opt.Type != record.Type || opt.Direction != record.Direction || !opt.Opened.Equal(record.Opened) ||
+			opt.Strike != record.Strike || !opt.Expiration.Equal(record.Expiration) || opt.Premium != record.Premium {
+			continue
+		}
+		if record.Closed == nil {
+			recorded += opt.Contracts
+			continue
+		}
+		if opt.Closed != nil && opt.Closed.Equal(*record.Closed) && opt.GetExitPriceValue() == record.GetExitPriceValue() &&
+			opt.Contracts >= record.Contracts {
+			return true
+		}
+	}
+	return record.Closed == nil && recorded >= record.Contracts
+}
+
 // importStocksFromCSV parses the CSV file and imports stock positions
 func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = 6 // Expect exactly 6 fields
+	reader.FieldsPerRecord = 0 // Expect 6 fields, or 7 with an account column
 
 	records, err := reader.ReadAll()
 	if err != nil {
@@ -464,12 +546,17 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 		return 0, 0, fmt.Errorf("CSV file must contain data rows beyond the header")
 	}
 
+	columns := 6
+	if hasAccountColumn(records[0]) {
+		columns++
+	}
+
 	log.Printf("[STOCKS_IMPORT] Processing %d stock records", len(records)-1)
 
 	for i, record := range records[1:] { // Skip header row
-		if len(record) != 6 {
-			log.Printf("[STOCKS_IMPORT] Row %d: Invalid column count (expected 6, got %d)", i+2, len(record))
-			return importedCount, skippedCount, fmt.Errorf("row %d: expected 6 columns, got %d", i+2, len(record))
+		if len(record) != columns {
+			log.Printf("[STOCKS_IMPORT] Row %d: Invalid column count (expected %d, got %d)", i+2, columns, len(record))
+			return importedCount, skippedCount, fmt.Errorf("row %d: expected %d columns, got %d", i+2, columns, len(record))
 		}
 
 		csvRecord := CSVStockRecord{
@@ -480,6 +567,9 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 			BuyPrice:   strings.TrimSpace(record[4]),
 			ExitPrice:  strings.TrimSpace(record[5]),
 		}
+		if columns > 6 {
+			csvRecord.Account = strings.TrimSpace(record[6])
+		}
 
 		// Convert CSV record to LongPosition
 		position, err := s.csvStockRecordToLongPosition(csvRecord)
@@ -494,8 +584,14 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 			return importedCount, skippedCount, fmt.Errorf("row %d: failed to create symbol: %w", i+2, err)
 		}
 
+		accountID, err := s.importAccountID(csvRecord.Account)
+		if err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: failed to resolve account: %w", i+2, err)
+		}
+
 		// Create long position
-		_, err = s.longPositionService.Create(
+		_, err = s.longPositionService.CreateInAccount(
+			accountID,
 			position.Symbol,
 			position.Opened,
 			position.Shares,
@@ -552,7 +648,7 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 // importDividendsFromCSV parses the CSV file and imports dividend records
 func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = 3 // Expect exactly 3 fields: Symbol, Date Received, Amount
+	reader.FieldsPerRecord = 0 // Expect 3 fields: Symbol, Date Received, Amount, and an optional Account
 
 	records, err := reader.ReadAll()
 	if err != nil {
@@ -568,12 +664,17 @@ func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skip
 		return 0, 0, fmt.Errorf("CSV file must contain data rows beyond the header")
 	}
 
+	columns := 3
+	if hasAccountColumn(records[0]) {
+		columns++
+	}
+
 	log.Printf("[DIVIDENDS_IMPORT] Processing %d dividend records", len(records)-1)
 
 	for i, record := range records[1:] { // Skip header row
-		if len(record) != 3 {
-			log.Printf("[DIVIDENDS_IMPORT] Row %d: Invalid column count (expected 3, got %d)", i+2, len(record))
-			return importedCount, skippedCount, fmt.Errorf("row %d: expected 3 columns, got %d", i+2, len(record))
+		if len(record) != columns {
+			log.Printf("[DIVIDENDS_IMPORT] Row %d: Invalid column count (expected %d, got %d)", i+2, columns, len(record))
+			return importedCount, skippedCount, fmt.Errorf("row %d: expected %d columns, got %d", i+2, columns, len(record))
 		}
 
 		csvRecord := CSVDividendRecord{
@@ -581,8 +682,16 @@ func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skip
 			DateReceived: strings.TrimSpace(record[1]),
 			Amount:       strings.TrimSpace(record[2]),
 		}
+		if columns > 3 {
+			csvRecord.Account = strings.TrimSpace(record[3])
+		}
 
-		dividend, created, err := s.processDividendRecord(csvRecord, i+2)
+		accountID, err := s.importAccountID(csvRecord.Account)
+		if err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: failed to resolve account: %w", i+2, err)
+		}
+
+		dividend, created, err := s.processDividendRecord(csvRecord, accountID, i+2)
 		if err != nil {
 			log.Printf("[DIVIDENDS_IMPORT] Row %d: %v", i+2, err)
 			return importedCount, skippedCount, err
@@ -605,7 +714,7 @@ func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skip
 // importTreasuriesFromCSV parses the CSV file and imports treasury records
 func (s *Server) importTreasuriesFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = 8 // Expect exactly 8 fields: CUSPID, Purchased, Maturity, Amount, Yield, BuyPrice, CurrentValue, ExitPrice
+	reader.FieldsPerRecord = 0 // Expect 8 fields: CUSPID, Purchased, Maturity, Amount, Yield, BuyPrice, CurrentValue, ExitPrice, and an optional Account
 
 	records, err := reader.ReadAll()
 	if err != nil {
@@ -621,12 +730,17 @@ func (s *Server) importTreasuriesFromCSV(file io.Reader) (importedCount int, ski
 		return 0, 0, fmt.Errorf("CSV file must contain data rows beyond the header")
 	}
 
+	columns := 8
+	if hasAccountColumn(records[0]) {
+		columns++
+	}
+
 	log.Printf("[TREASURIES_IMPORT] Processing %d treasury records", len(records)-1)
 
 	for i, record := range records[1:] { // Skip header row
-		if len(record) != 8 {
-			log.Printf("[TREASURIES_IMPORT] Row %d: Invalid column count (expected 8, got %d)", i+2, len(record))
-			return importedCount, skippedCount, fmt.Errorf("row %d: expected 8 columns, got %d", i+2, len(record))
+		if len(record) != columns {
+			log.Printf("[TREASURIES_IMPORT] Row %d: Invalid column count (expected %d, got %d)", i+2, columns, len(record))
+			return importedCount, skippedCount, fmt.Errorf("row %d: expected %d columns, got %d", i+2, columns, len(record))
 		}
 
 		csvRecord := CSVTreasuryRecord{
@@ -639,8 +753,16 @@ func (s *Server) importTreasuriesFromCSV(file io.Reader) (importedCount int, ski
 			CurrentValue: strings.TrimSpace(record[6]),
 			ExitPrice:    strings.TrimSpace(record[7]),
 		}
+		if columns > 8 {
+			csvRecord.Account = strings.TrimSpace(record[8])
+		}
+
+		accountID, err := s.importAccountID(csvRecord.Account)
+		if err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: failed to resolve account: %w", i+2, err)
+		}
 
-		treasury, created, err := s.processTreasuryRecord(csvRecord, i+2)
+		treasury, created, err := s.processTreasuryRecord(csvRecord, accountID, i+2)
 		if err != nil {
 			log.Printf("[TREASURIES_IMPORT] Row %d: %v", i+2, err)
 			return importedCount, skippedCount, err
@@ -676,6 +798,14 @@ func (s *Server) convertCSVRecordToOption(record CSVOptionRecord, rowNumber int)
 		return nil, fmt.Errorf("type must be 'Put' or 'Call', got '%s'", record.Type)
 	}
 
+	direction := strings.ToLower(record.Direction)
+	if direction == "" {
+		direction = models.DirectionShort
+	}
+	if direction != models.DirectionShort && direction != models.DirectionLong {
+		return nil, fmt.Errorf("direction must be 'short' or 'long', got '%s'", record.Direction)
+	}
+
 	// Parse dates
 	opened, err := time.Parse("2006-01-02", record.Opened)
 	if err != nil {
@@ -749,6 +879,7 @@ func (s *Server) convertCSVRecordToOption(record CSVOptionRecord, rowNumber int)
 	option := &models.Option{
 		Symbol:     record.Symbol,
 		Type:       record.Type,
+		Direction:  direction,
 		Opened:     opened,
 		Closed:     closed,
 		Strike:     strike,
@@ -840,8 +971,8 @@ func (s *Server) csvStockRecordToLongPosition(record CSVStockRecord) (*models.Lo
 	return position, nil
 }
 
-// processDividendRecord processes a single dividend record from CSV
-func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int) (*models.Dividend, bool, error) {
+// processDividendRecord processes a single dividend record from CSV into the given account
+func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, accountID *int, rowNum int) (*models.Dividend, bool, error) {
 	// Validate symbol
 	if csvRecord.Symbol == "" {
 		return nil, false, fmt.Errorf("symbol cannot be empty")
@@ -896,7 +1027,7 @@ func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int)
 	}
 
 	// Check if dividend already exists (to avoid duplicates)
-	existingDividends, err := s.dividendService.GetBySymbol(symbol.Symbol)
+	existingDividends, err := s.dividendService.GetBySymbolInAccount(symbol.Symbol, accountID)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to check existing dividends: %v", err)
 	}
@@ -908,7 +1039,7 @@ func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int)
 	}
 
 	// Create the dividend
-	dividend, err := s.dividendService.Create(symbol.Symbol, receivedDate, amount)
+	dividend, err := s.dividendService.CreateInAccount(accountID, symbol.Symbol, receivedDate, amount)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create dividend: %v", err)
 	}
@@ -916,8 +1047,8 @@ func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int)
 	return dividend, true, nil
 }
 
-// processTreasuryRecord processes a single treasury record from CSV
-func (s *Server) processTreasuryRecord(csvRecord CSVTreasuryRecord, rowNum int) (*models.Treasury, bool, error) {
+// processTreasuryRecord processes a single treasury record from CSV into the given account
+func (s *Server) processTreasuryRecord(csvRecord CSVTreasuryRecord, accountID *int, rowNum int) (*models.Treasury, bool, error) {
 	// Validate CUSPID
 	if csvRecord.CUSPID == "" {
 		return nil, false, fmt.Errorf("CUSPID cannot be empty")
@@ -1019,20 +1150,31 @@ func (s *Server) processTreasuryRecord(csvRecord CSVTreasuryRecord, rowNum int)
 	}
 
 	// Create the treasury
-	treasury, err := s.treasuryService.Create(csvRecord.CUSPID, purchasedDate, maturityDate, amount, yield, buyPrice)
+	treasury, err := s.treasuryService.CreateInAccount(accountID, csvRecord.CUSPID, purchasedDate, maturityDate, amount, yield, buyPrice, currentValue, exitPrice)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create treasury: %v", err)
 	}
 
-	// Update with optional fields if provided
-	if currentValue != nil || exitPrice != nil {
-		_, err = s.treasuryService.Update(treasury.CUSPID, currentValue, exitPrice)
-		if err != nil {
-			log.Printf("[TREASURIES_IMPORT] Warning: Failed to update treasury with optional fields: %v", err)
-		}
+	return treasury, true, nil
+}
+
+// hasAccountColumn reports whether the last column of a CSV header is an account column
+func hasAccountColumn(headers []string) bool {
+	return len(headers) > 0 && strings.TrimSpace(strings.ToLower(headers[len(headers)-1])) == "account"
+}
+
+// importAccountID returns the ID of the account named in an imported row,
+// creating the account if needed. Rows without an account stay unassigned.
+func (s *Server) importAccountID(name string) (*int, error) {
+	if name == "" {
+		return nil, nil
 	}
 
-	return treasury, true, nil
+	account, err := s.accountService.GetOrCreateByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return &account.ID, nil
 }
 
 // ensureSymbolExists creates a symbol if it doesn't exist
@@ -1323,6 +1465,10 @@ func (s *Server) handleSetCurrentDatabase(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Keep the alert scheduler off the services while they are replaced
+	s.servicesMu.Lock()
+	defer s.servicesMu.Unlock()
+
 	// Close existing database connection
 	log.Printf("[SET_DATABASE] Closing existing database connection")
 	if err := s.db.Close(); err != nil {
@@ -1347,14 +1493,7 @@ func (s *Server) handleSetCurrentDatabase(w http.ResponseWriter, r *http.Request
 
 	// Update server's database connection and reinitialize all services
 	log.Printf("[SET_DATABASE] Reinitializing services with new database connection")
-	s.db = dbWrapper.DB
-	s.optionService = models.NewOptionService(dbWrapper.DB)
-	s.symbolService = models.NewSymbolService(dbWrapper.DB)
-	s.treasuryService = models.NewTreasuryService(dbWrapper.DB)
-	s.longPositionService = models.NewLongPositionService(dbWrapper.DB)
-	s.dividendService = models.NewDividendService(dbWrapper.DB)
-	s.settingService = models.NewSettingService(dbWrapper.DB)
-	s.metricService = models.NewMetricService(dbWrapper.DB)
+	s.initServices(dbWrapper.DB)
 
 	log.Printf("[SET_DATABASE] Successfully switched to database: %s", dbName)
 
@@ -22,9 +22,9 @@ func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[METRICS PAGE] Retrieved %d symbols for navigation", len(symbols))
 	}
 
-	// Get all metrics
+	// Get all metrics of the selected account, or household metrics
 	log.Printf("[METRICS PAGE] Fetching all metrics")
-	metrics, err := s.metricService.GetAll()
+	metrics, err := s.metricService.GetAllForAccount(s.selectedAccountID(r))
 	if err != nil {
 		log.Printf("[METRICS PAGE] ERROR: Failed to get metrics: %v", err)
 		metrics = []*models.Metric{}
@@ -103,7 +103,7 @@ func (s *Server) getMetricsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metrics, err := s.metricService.GetAll()
+	metrics, err := s.metricService.GetAllForAccount(s.selectedAccountID(r))
 	if err != nil {
 		log.Printf("[API] GET /api/metrics - Failed to get metrics: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to get metrics: %v", err), http.StatusInternalServerError)
@@ -278,14 +278,15 @@ func (s *Server) getMetricsChartDataHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Get all metrics ordered by date and type
+	// Get all metrics of the selected account, or household metrics, ordered by date and type
 	query := `
 		SELECT DATE(created) as date, type, value 
 		FROM metrics 
+		WHERE account_id IS ?
 		ORDER BY created ASC, type ASC
 	`
 	
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query(query, s.selectedAccountID(r))
 	if err != nil {
 		log.Printf("[API] GET /api/metrics/chart-data - Failed to query metrics: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to query metrics: %v", err), http.StatusInternalServerError)
@@ -37,7 +37,7 @@ func (s *Server) monthlyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create options index for advanced filtering
-	optionsIndex, err := s.optionService.Index()
+	optionsIndex, err := s.accountOptionsIndex(r)
 	if err != nil {
 		optionsIndex = make(map[string]interface{})
 	}
@@ -54,6 +54,11 @@ func (s *Server) monthlyHandler(w http.ResponseWriter, r *http.Request) {
 		longPositions = []*models.LongPosition{}
 	}
 
+	filter := s.accountFilter(r)
+	options = filter.Options(options)
+	dividends = filter.Dividends(dividends)
+	longPositions = filter.LongPositions(longPositions)
+
 	// Build monthly data with month filtering
 	data := s.buildMonthlyData(symbols, options, dividends, longPositions, optionsIndex, fromMonth, toMonth)
 
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"stonks/internal/notify"
+	"strings"
+	"time"
+)
+
+// notificationsAPIHandler returns the latest notification deliveries
+func (s *Server) notificationsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	deliveries, err := s.notificationService.GetRecent(50)
+	if err != nil {
+		log.Printf("[NOTIFY API] ERROR: Failed to get delivery log: %v", err)
+		http.Error(w, "Failed to get delivery log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// notificationTestHandler sends a test notification through the channel in
+// the request body and returns its delivery
+func (s *Server) notificationTestHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[NOTIFY API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Channel string `json:"channel"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	delivery, err := s.notifier.Test(ctx, req.Channel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// notifyAlerts sends the alerts from one evaluation as a single notification
+func (s *Server) notifyAlerts(fired []*models.Alert) {
+	if len(fired) == 0 {
+		return
+	}
+
+	title := fmt.Sprintf("%d alerts fired", len(fired))
+	if len(fired) == 1 {
+		title = fired[0].RuleName
+	}
+	var lines []string
+	for _, alert := range fired {
+		lines = append(lines, fmt.Sprintf("%s: %s", alert.RuleName, alert.Message))
+	}
+
+	s.notifier.Notify(notify.Event{
+		Type:    notify.EventAlert,
+		Title:   title,
+		Message: strings.Join(lines, "\n"),
+		Time:    time.Now(),
+		Data:    fired,
+	})
+}
+
+// notifyImport reports a completed CSV import of the given kind, e.g. "Options"
+func (s *Server) notifyImport(kind string, importedCount, skippedCount int) {
+	s.notifier.Notify(notify.Event{
+		Type:    notify.EventImport,
+		Title:   kind + " import completed",
+		Message: fmt.Sprintf("%d imported, %d skipped", importedCount, skippedCount),
+		Time:    time.Now(),
+		Data:    map[string]interface{}{"kind": strings.ToLower(kind), "imported": importedCount, "skipped": skippedCount},
+	})
+}
+
+// notifyPriceRefreshFailed reports the symbols whose prices couldn't be refreshed
+func (s *Server) notifyPriceRefreshFailed(errors []string) {
+	if len(errors) == 0 {
+		return
+	}
+
+	title := fmt.Sprintf("Price refresh failed for %d symbols", len(errors))
+	if len(errors) == 1 {
+		title = "Price refresh failed for 1 symbol"
+	}
+
+	s.notifier.Notify(notify.Event{
+		Type:    notify.EventPriceRefresh,
+		Title:   title,
+		Message: strings.Join(errors, "\n"),
+		Time:    time.Now(),
+		Data:    map[string]interface{}{"errors": errors},
+	})
+}
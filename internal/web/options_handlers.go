@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"stonks/internal/models"
 	"strconv"
 	"strings"
@@ -25,9 +26,12 @@ func (s *Server) optionsHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[OPTIONS PAGE] Retrieved %d symbols for navigation", len(symbols))
 	}
 
+	accountID := s.selectedAccountID(r)
+	filter := s.accountFilter(r)
+
 	// Get options summary by symbol
 	log.Printf("[OPTIONS PAGE] Fetching options summary data")
-	optionsSummary, err := s.optionService.GetOptionsSummaryBySymbol()
+	optionsSummary, err := s.optionService.GetOptionsSummaryBySymbol(accountID)
 	if err != nil {
 		log.Printf("[OPTIONS PAGE] ERROR: Failed to get options summary: %v", err)
 		optionsSummary = []*models.OptionSummary{}
@@ -44,10 +48,44 @@ func (s *Server) optionsHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		log.Printf("[OPTIONS PAGE] Retrieved %d open positions", len(openPositions))
 	}
+	if filter != nil {
+		var accountPositions []*models.OpenPositionData
+		for _, position := range openPositions {
+			if filter.HasOption(position.ID) {
+				accountPositions = append(accountPositions, position)
+			}
+		}
+		openPositions = accountPositions
+	}
+
+	// Value the open positions from symbol prices and the pricing settings
+	openOptions := make([]*models.Option, len(openPositions))
+	for i, position := range openPositions {
+		openOptions[i] = position.Option
+	}
+	greeks, err := s.pricingService.Greeks(openOptions, time.Now())
+	if err != nil {
+		log.Printf("[OPTIONS PAGE] ERROR: Failed to price open positions: %v", err)
+		greeks = map[int]*models.OptionGreeks{}
+	} else {
+		log.Printf("[OPTIONS PAGE] Priced %d of %d open positions", len(greeks), len(openPositions))
+	}
+
+	impliedVolatility, err := s.ivService.Latest()
+	if err != nil {
+		log.Printf("[OPTIONS PAGE] ERROR: Failed to get implied volatility: %v", err)
+		impliedVolatility = map[int]*models.ImpliedVolatility{}
+	}
+
+	odds, err := s.pricingService.Odds(openOptions, impliedVolatility, time.Now())
+	if err != nil {
+		log.Printf("[OPTIONS PAGE] ERROR: Failed to calculate odds of open positions: %v", err)
+		odds = map[int]*models.OptionOdds{}
+	}
 
 	// Get summary totals
 	log.Printf("[OPTIONS PAGE] Calculating summary totals")
-	summaryTotals, err := s.optionService.GetOptionsSummaryTotals()
+	summaryTotals, err := s.optionService.GetOptionsSummaryTotals(accountID)
 	if err != nil {
 		log.Printf("[OPTIONS PAGE] ERROR: Failed to get summary totals: %v", err)
 		summaryTotals = &models.OptionSummary{}
@@ -55,14 +93,29 @@ func (s *Server) optionsHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[OPTIONS PAGE] Calculated summary totals: %d total positions", summaryTotals.TotalPositions)
 	}
 
+	// Get roll chains
+	log.Printf("[OPTIONS PAGE] Building roll chains")
+	var rollChains []*models.RollChain
+	if options, err := s.optionService.GetAll(); err != nil {
+		log.Printf("[OPTIONS PAGE] ERROR: Failed to get roll chains: %v", err)
+		rollChains = []*models.RollChain{}
+	} else {
+		rollChains = models.BuildRollChains(filter.Options(options))
+		log.Printf("[OPTIONS PAGE] Built %d roll chains", len(rollChains))
+	}
+
 	data := OptionsData{
-		Symbols:        symbols,
-		AllSymbols:     symbols, // For navigation compatibility
-		OptionsSummary: optionsSummary,
-		OpenPositions:  openPositions,
-		SummaryTotals:  summaryTotals,
-		CurrentDB:      s.getCurrentDatabaseName(),
-		ActivePage:     "options",
+		Symbols:           symbols,
+		AllSymbols:        symbols, // For navigation compatibility
+		OptionsSummary:    optionsSummary,
+		OpenPositions:     openPositions,
+		SummaryTotals:     summaryTotals,
+		RollChains:        rollChains,
+		Greeks:            greeks,
+		ImpliedVolatility: impliedVolatility,
+		Odds:              odds,
+		CurrentDB:         s.getCurrentDatabaseName(),
+		ActivePage:        "options",
 	}
 
 	log.Printf("[OPTIONS PAGE] Rendering options.html template with %d summaries and %d open positions", len(optionsSummary), len(openPositions))
@@ -84,7 +137,7 @@ func (s *Server) allOptionsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create the options index using the OptionService Index method
 	log.Printf("[ALL OPTIONS PAGE] Creating options index")
-	optionsIndex, err := s.optionService.Index()
+	optionsIndex, err := s.accountOptionsIndex(r)
 	if err != nil {
 		log.Printf("[ALL OPTIONS PAGE] ERROR: Failed to create options index: %v", err)
 		optionsIndex = make(map[string]interface{})
@@ -132,6 +185,10 @@ func (s *Server) addOptionHandler(w http.ResponseWriter, r *http.Request) {
 	expirationStr := r.FormValue("expiration")
 	premiumStr := r.FormValue("premium")
 	contractsStr := r.FormValue("contracts")
+	direction := r.FormValue("direction")
+	if direction == "" {
+		direction = models.DirectionShort
+	}
 
 	strike, err := strconv.ParseFloat(strikeStr, 64)
 	if err != nil {
@@ -157,13 +214,21 @@ func (s *Server) addOptionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = s.optionService.Create(symbol, optionType, time.Now(), strike, expiration, premium, contracts)
+	commission := models.OptionCommissionPerContract * float64(contracts)
+	option, err := s.optionService.CreateWithDirection(symbol, optionType, direction, time.Now(), strike, expiration, premium, contracts, commission)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	// The put is recorded either way; the warning travels with the redirect
+	// and the dashboard shows it
+	redirect := "/"
+	if warning := s.collateralWarning(option); warning != "" {
+		redirect += "?collateral_warning=" + url.QueryEscape(warning)
+	}
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
 }
 
 // optionAPIHandler handles CRUD operations for options
@@ -187,27 +252,73 @@ func (s *Server) optionAPIHandler(w http.ResponseWriter, r *http.Request) {
 func (s *Server) individualOptionAPIHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[INDIVIDUAL OPTION API] %s %s - Processing individual option API request", r.Method, r.URL.Path)
 
-	if r.Method != http.MethodGet {
-		log.Printf("[INDIVIDUAL OPTION API] ERROR: Method not allowed: %s", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Extract option ID from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/api/options/")
-	if path == "" {
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/options/"), "/")
+	if pathSegments[0] == "" {
 		log.Printf("[INDIVIDUAL OPTION API] ERROR: No option ID provided")
 		http.Error(w, "Option ID is required", http.StatusBadRequest)
 		return
 	}
 
-	optionID, err := strconv.Atoi(path)
+	optionID, err := strconv.Atoi(pathSegments[0])
 	if err != nil {
-		log.Printf("[INDIVIDUAL OPTION API] ERROR: Invalid option ID: %s", path)
+		log.Printf("[INDIVIDUAL OPTION API] ERROR: Invalid option ID: %s", pathSegments[0])
 		http.Error(w, "Invalid option ID", http.StatusBadRequest)
 		return
 	}
 
+	// Check if this is an assignment request
+	if len(pathSegments) > 1 && pathSegments[1] == "assign" {
+		s.assignOptionHandler(w, r, optionID)
+		return
+	}
+
+	// Check if this is a called away request
+	if len(pathSegments) > 1 && pathSegments[1] == "called-away" {
+		s.calledAwayOptionHandler(w, r, optionID)
+		return
+	}
+
+	// Check if this is a (partial) close request
+	if len(pathSegments) > 1 && pathSegments[1] == "close" {
+		s.closeOptionHandler(w, r, optionID)
+		return
+	}
+
+	// Check if this is a roll request
+	if len(pathSegments) > 1 && pathSegments[1] == "roll" {
+		s.rollOptionHandler(w, r, optionID)
+		return
+	}
+
+	// Check if this is a roll chain request
+	if len(pathSegments) > 1 && pathSegments[1] == "chain" {
+		s.optionChainHandler(w, r, optionID)
+		return
+	}
+
+	// Check if this is a theoretical value and Greeks request
+	if len(pathSegments) > 1 && pathSegments[1] == "greeks" {
+		s.optionGreeksHandler(w, r, optionID)
+		return
+	}
+
+	// Check if this is a mark or implied volatility request
+	if len(pathSegments) > 1 && pathSegments[1] == "mark" {
+		s.optionMarkHandler(w, r, optionID)
+		return
+	}
+	if len(pathSegments) > 1 && pathSegments[1] == "iv" {
+		s.optionImpliedVolatilityHandler(w, r, optionID)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		log.Printf("[INDIVIDUAL OPTION API] ERROR: Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	// Fetch option by ID
 	option, err := s.optionService.GetByID(optionID)
 	if err != nil {
@@ -227,6 +338,292 @@ func (s *Server) individualOptionAPIHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// assignOptionHandler handles POST /api/options/{id}/assign, closing the put as
+// assigned and opening the resulting long position
+func (s *Server) assignOptionHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	log.Printf("[ASSIGN OPTION] Starting assignment for option %d", optionID)
+
+	if r.Method != http.MethodPost {
+		log.Printf("[ASSIGN OPTION] ERROR: Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req AssignmentRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("[ASSIGN OPTION] ERROR: Invalid JSON payload: %v", err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Default the assignment date to the option's expiration
+	var assigned time.Time
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			log.Printf("[ASSIGN OPTION] ERROR: Invalid date %s: %v", req.Date, err)
+			http.Error(w, "Invalid date format", http.StatusBadRequest)
+			return
+		}
+		assigned = parsed
+	} else {
+		option, err := s.optionService.GetByID(optionID)
+		if err != nil {
+			log.Printf("[ASSIGN OPTION] ERROR: Failed to get option %d: %v", optionID, err)
+			http.Error(w, "Option not found", http.StatusNotFound)
+			return
+		}
+		assigned = option.Expiration
+	}
+
+	option, position, err := s.optionService.Assign(optionID, assigned)
+	if err != nil {
+		log.Printf("[ASSIGN OPTION] ERROR: Failed to assign option %d: %v", optionID, err)
+		status := http.StatusBadRequest
+		if err.Error() == "option not found" {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Failed to assign option: %v", err), status)
+		return
+	}
+
+	log.Printf("[ASSIGN OPTION] Option %d assigned: created long position %d (%d shares of %s at $%.2f)",
+		option.ID, position.ID, position.Shares, position.Symbol, position.BuyPrice)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AssignmentResponse{
+		Option:       option,
+		LongPosition: position,
+	})
+}
+
+// calledAwayOptionHandler handles POST /api/options/{id}/called-away, closing the
+// call and the shares it covered at the strike
+func (s *Server) calledAwayOptionHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	log.Printf("[CALLED AWAY] Starting called away for option %d", optionID)
+
+	if r.Method != http.MethodPost {
+		log.Printf("[CALLED AWAY] ERROR: Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req AssignmentRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("[CALLED AWAY] ERROR: Invalid JSON payload: %v", err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Default the called away date to the option's expiration
+	var calledAway time.Time
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			log.Printf("[CALLED AWAY] ERROR: Invalid date %s: %v", req.Date, err)
+			http.Error(w, "Invalid date format", http.StatusBadRequest)
+			return
+		}
+		calledAway = parsed
+	} else {
+		option, err := s.optionService.GetByID(optionID)
+		if err != nil {
+			log.Printf("[CALLED AWAY] ERROR: Failed to get option %d: %v", optionID, err)
+			http.Error(w, "Option not found", http.StatusNotFound)
+			return
+		}
+		calledAway = option.Expiration
+	}
+
+	option, positions, err := s.optionService.CallAway(optionID, calledAway)
+	if err != nil {
+		log.Printf("[CALLED AWAY] ERROR: Failed to call away option %d: %v", optionID, err)
+		status := http.StatusBadRequest
+		if err.Error() == "option not found" {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Failed to call away option: %v", err), status)
+		return
+	}
+
+	log.Printf("[CALLED AWAY] Option %d called away: closed %d lot(s) of %s at $%.2f",
+		option.ID, len(positions), option.Symbol, option.Strike)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CalledAwayResponse{
+		Option:        option,
+		LongPositions: positions,
+	})
+}
+
+// rollOptionHandler handles POST /api/options/{id}/roll, closing the option and
+// opening its successor in one call
+func (s *Server) rollOptionHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	log.Printf("[ROLL OPTION] Starting roll for option %d", optionID)
+
+	if r.Method != http.MethodPost {
+		log.Printf("[ROLL OPTION] ERROR: Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[ROLL OPTION] ERROR: Invalid JSON payload: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Strike <= 0 || req.Premium < 0 || req.ExitPrice < 0 {
+		http.Error(w, "Strike is required and prices cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	expiration, err := time.Parse("2006-01-02", req.Expiration)
+	if err != nil {
+		log.Printf("[ROLL OPTION] ERROR: Invalid expiration %s: %v", req.Expiration, err)
+		http.Error(w, "Invalid expiration date format", http.StatusBadRequest)
+		return
+	}
+
+	option, err := s.optionService.GetByID(optionID)
+	if err != nil {
+		log.Printf("[ROLL OPTION] ERROR: Failed to get option %d: %v", optionID, err)
+		http.Error(w, "Option not found", http.StatusNotFound)
+		return
+	}
+
+	// Default the roll date to today and the contracts to the rolled option's
+	rolled := time.Now().Truncate(24 * time.Hour)
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			log.Printf("[ROLL OPTION] ERROR: Invalid date %s: %v", req.Date, err)
+			http.Error(w, "Invalid date format", http.StatusBadRequest)
+			return
+		}
+		rolled = parsed
+	}
+	contracts := req.Contracts
+	if contracts == 0 {
+		contracts = option.Contracts
+	}
+
+	closed, opened, err := s.optionService.Roll(optionID, rolled, req.ExitPrice, req.Strike, expiration, req.Premium, contracts)
+	if err != nil {
+		log.Printf("[ROLL OPTION] ERROR: Failed to roll option %d: %v", optionID, err)
+		http.Error(w, fmt.Sprintf("Failed to roll option: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	chain, err := s.optionService.GetRollChain(opened.ID)
+	if err != nil {
+		log.Printf("[ROLL OPTION] ERROR: Failed to get roll chain for option %d: %v", opened.ID, err)
+		http.Error(w, "Failed to get roll chain", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[ROLL OPTION] Option %d rolled into option %d (%s %s $%.2f exp %s), chain net credit $%.2f",
+		closed.ID, opened.ID, opened.Symbol, opened.Type, opened.Strike, opened.Expiration.Format("2006-01-02"), chain.NetCredit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RollResponse{
+		Closed: closed,
+		Opened: opened,
+		Chain:  chain,
+	})
+}
+
+// closeOptionHandler handles POST /api/options/{id}/close, closing some or all
+// of the option's contracts
+func (s *Server) closeOptionHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	log.Printf("[CLOSE OPTION] Starting close for option %d", optionID)
+
+	if r.Method != http.MethodPost {
+		log.Printf("[CLOSE OPTION] ERROR: Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CloseOptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[CLOSE OPTION] ERROR: Invalid JSON payload: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.ExitPrice < 0 {
+		http.Error(w, "Exit price cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	option, err := s.optionService.GetByID(optionID)
+	if err != nil {
+		log.Printf("[CLOSE OPTION] ERROR: Failed to get option %d: %v", optionID, err)
+		http.Error(w, "Option not found", http.StatusNotFound)
+		return
+	}
+
+	// Default the close date to today and the contracts to all of them
+	closed := time.Now().Truncate(24 * time.Hour)
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			log.Printf("[CLOSE OPTION] ERROR: Invalid date %s: %v", req.Date, err)
+			http.Error(w, "Invalid date format", http.StatusBadRequest)
+			return
+		}
+		closed = parsed
+	}
+	contracts := req.Contracts
+	if contracts == 0 {
+		contracts = option.Contracts
+	}
+
+	closedPart, remaining, err := s.optionService.CloseContracts(optionID, contracts, closed, req.ExitPrice)
+	if err != nil {
+		log.Printf("[CLOSE OPTION] ERROR: Failed to close option %d: %v", optionID, err)
+		http.Error(w, fmt.Sprintf("Failed to close option: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if remaining != nil {
+		log.Printf("[CLOSE OPTION] Closed %d of %d contracts of option %d as option %d, %d remain open",
+			closedPart.Contracts, option.Contracts, optionID, closedPart.ID, remaining.Contracts)
+	} else {
+		log.Printf("[CLOSE OPTION] Closed all %d contracts of option %d", closedPart.Contracts, optionID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CloseOptionResponse{
+		Closed:    closedPart,
+		Remaining: remaining,
+	})
+}
+
+// optionChainHandler handles GET /api/options/{id}/chain, returning the roll
+// chain the option belongs to
+func (s *Server) optionChainHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chain, err := s.optionService.GetRollChain(optionID)
+	if err != nil {
+		log.Printf("[INDIVIDUAL OPTION API] ERROR: Failed to get roll chain for option %d: %v", optionID, err)
+		http.Error(w, "Option not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chain)
+}
+
 // createOption handles POST requests to create new options
 func (s *Server) createOption(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[CREATE OPTION] Starting POST request")
@@ -249,6 +646,15 @@ func (s *Server) createOption(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Options are sold unless the request says they were bought
+	if req.Direction == "" {
+		req.Direction = models.DirectionShort
+	}
+	if req.Direction != models.DirectionShort && req.Direction != models.DirectionLong {
+		http.Error(w, "Direction must be 'short' or 'long'", http.StatusBadRequest)
+		return
+	}
+
 	// Parse dates
 	opened, err := time.Parse("2006-01-02", req.Opened)
 	if err != nil {
@@ -263,7 +669,7 @@ func (s *Server) createOption(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the option
-	option, err := s.optionService.CreateWithCommission(req.Symbol, req.Type, opened, req.Strike, expiration, req.Premium, req.Contracts, req.Commission)
+	option, err := s.optionService.CreateWithDirection(req.Symbol, req.Type, req.Direction, opened, req.Strike, expiration, req.Premium, req.Contracts, req.Commission)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create option: %v", err), http.StatusInternalServerError)
 		return
@@ -282,15 +688,24 @@ func (s *Server) createOption(w http.ResponseWriter, r *http.Request) {
 			exitPrice = *req.ExitPrice
 		}
 
-		err = s.optionService.Close(req.Symbol, req.Type, opened, req.Strike, expiration, req.Premium, req.Contracts, closed, exitPrice)
+		err = s.optionService.CloseByID(option.ID, closed, exitPrice)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to close option: %v", err), http.StatusInternalServerError)
 			return
 		}
 	}
 
+	// A new put is recorded even if it is not fully secured, but the response says so
+	response := OptionCreateResponse{Option: option}
+	if req.Closed == nil || *req.Closed == "" {
+		response.CollateralWarning = s.collateralWarning(option)
+	}
+	if response.CollateralWarning != "" {
+		w.Header().Set("X-Collateral-Warning", response.CollateralWarning)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(option)
+	json.NewEncoder(w).Encode(response)
 }
 
 // updateOption handles PUT requests to update existing options
@@ -342,8 +757,18 @@ func (s *Server) updateOption(w http.ResponseWriter, r *http.Request) {
 		closed = &closedDate
 	}
 
+	// Keep the stored direction when the request leaves it out
+	if req.Direction == "" {
+		existing, err := s.optionService.GetByID(*req.ID)
+		if err != nil {
+			http.Error(w, "Option not found", http.StatusNotFound)
+			return
+		}
+		req.Direction = existing.Direction
+	}
+
 	// Update the option
-	option, err := s.optionService.UpdateByID(*req.ID, req.Symbol, req.Type, opened, req.Strike, expiration, req.Premium, req.Contracts, req.Commission, closed, req.ExitPrice)
+	option, err := s.optionService.UpdateByID(*req.ID, req.Symbol, req.Type, req.Direction, opened, req.Strike, expiration, req.Premium, req.Contracts, req.Commission, closed, req.ExitPrice)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update option: %v", err), http.StatusInternalServerError)
 		return
@@ -404,8 +829,14 @@ func (s *Server) deleteOption(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[DELETE OPTION] Attempting compound key deletion: Symbol=%s, Type=%s, Opened=%s, Strike=%f, Expiration=%s",
 			req.Symbol, req.Type, req.Opened, req.Strike, req.Expiration)
 
+		// Options are sold unless the request says they were bought
+		direction := req.Direction
+		if direction == "" {
+			direction = models.DirectionShort
+		}
+
 		// Delete the option using compound key
-		err = s.optionService.Delete(req.Symbol, req.Type, opened, req.Strike, expiration, req.Premium, req.Contracts)
+		err = s.optionService.Delete(req.Symbol, req.Type, direction, opened, req.Strike, expiration, req.Premium, req.Contracts)
 		if err != nil {
 			log.Printf("[DELETE OPTION] ERROR: Compound key deletion failed: %v", err)
 			http.Error(w, fmt.Sprintf("Failed to delete option: %v", err), http.StatusInternalServerError)
@@ -444,7 +875,7 @@ func (s *Server) optionsFilterHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[OPTIONS FILTER API] Filter request: %+v", filters)
 
 	// Get the options index
-	optionsIndex, err := s.optionService.Index()
+	optionsIndex, err := s.accountOptionsIndex(r)
 	if err != nil {
 		log.Printf("[OPTIONS FILTER API] ERROR: Failed to create options index: %v", err)
 		http.Error(w, "Failed to create index", http.StatusInternalServerError)
@@ -129,6 +129,7 @@ func (s *Server) polygonUpdatePricesHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	log.Printf("[POLYGON API] Price update completed: %d updated, %d failed", updated, failed)
+	s.notifyPriceRefreshFailed(errors)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"sort"
 	"stonks/internal/models"
+	"strings"
 	"time"
 )
 
@@ -466,3 +467,58 @@ func (s *Server) deleteLongPositionHandler(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"success": true}`))
 }
+
+// sellSharesHandler handles POST /api/long-positions/sell, selling shares of a
+// symbol across its open lots in the requested or selected account by the
+// requested or default lot method
+func (s *Server) sellSharesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SellSharesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if symbol == "" {
+		http.Error(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	sold := time.Now().Truncate(24 * time.Hour)
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format", http.StatusBadRequest)
+			return
+		}
+		sold = parsed
+	}
+
+	method := req.Method
+	if method == "" {
+		method = s.settingService.GetValueWithDefault(models.LotMethodSetting, models.LotMethodFIFO)
+	}
+	method = strings.ToLower(method)
+
+	accountID := req.AccountID
+	if accountID == nil {
+		accountID = s.selectedAccountID(r)
+	}
+
+	sale, err := s.longPositionService.Sell(accountID, symbol, req.Shares, sold, req.Price, method, req.Lots)
+	if err != nil {
+		log.Printf("Error selling %d shares of %s: %v", req.Shares, symbol, err)
+		http.Error(w, fmt.Sprintf("Failed to sell shares: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Sold %d shares of %s at %.2f by %s across %d lots, realized %.2f", sale.Shares, symbol, sale.Price, sale.Method, len(sale.Lots), sale.Gain)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sale)
+}
@@ -0,0 +1,277 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// greeksAPIHandler returns the theoretical value and Greeks of every open
+// option of the selected account whose underlying has a price
+func (s *Server) greeksAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GREEKS API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	options, err := s.optionService.GetOpen()
+	if err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to get open options: %v", err)
+		http.Error(w, "Failed to get open options", http.StatusInternalServerError)
+		return
+	}
+	options = s.accountFilter(r).Options(options)
+
+	greeks, err := s.pricingService.Greeks(options, time.Now())
+	if err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to price options: %v", err)
+		http.Error(w, "Failed to price options", http.StatusInternalServerError)
+		return
+	}
+
+	valued := []*models.OptionGreeks{}
+	for _, option := range options {
+		if g, ok := greeks[option.ID]; ok {
+			valued = append(valued, g)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(valued); err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// portfolioGreeksAPIHandler returns the Greeks of the selected account's open
+// options and shares, per symbol and in total, with beta-weighted delta
+func (s *Server) portfolioGreeksAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GREEKS API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	greeks, err := s.pricingService.AccountPortfolioGreeks(s.selectedAccountID(r), time.Now())
+	if err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to calculate portfolio greeks: %v", err)
+		http.Error(w, "Failed to calculate portfolio greeks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(greeks); err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// optionGreeksHandler handles GET /api/options/{id}/greeks
+func (s *Server) optionGreeksHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	option, err := s.optionService.GetByID(optionID)
+	if err != nil {
+		http.Error(w, "Option not found", http.StatusNotFound)
+		return
+	}
+
+	underlying, err := s.symbolService.GetBySymbol(option.Symbol)
+	if err != nil {
+		http.Error(w, "Symbol not found", http.StatusNotFound)
+		return
+	}
+
+	rate, volatility := s.pricingService.Rates()
+	greeks, err := models.PriceOption(option, underlying, rate, volatility, time.Now())
+	if err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to price option %d: %v", optionID, err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(greeks); err != nil {
+		log.Printf("[GREEKS API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// optionMarkHandler handles PUT /api/options/{id}/mark, setting the option's
+// current price and recording today's implied volatility from it
+func (s *Server) optionMarkHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req OptionMarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Mark != nil && *req.Mark < 0 {
+		http.Error(w, "Mark cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	option, err := s.optionService.SetMark(optionID, req.Mark)
+	if err != nil {
+		log.Printf("[IV API] ERROR: Failed to mark option %d: %v", optionID, err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := OptionMarkResponse{Option: option}
+	if option.CurrentPrice != nil && option.Closed == nil {
+		iv, err := s.ivService.Record(option, time.Now())
+		if err != nil {
+			log.Printf("[IV API] Option %d marked at %.2f without implied volatility: %v", optionID, *option.CurrentPrice, err)
+			response.ImpliedVolatilityError = err.Error()
+		} else {
+			log.Printf("[IV API] Option %d marked at %.2f: %.1f%% implied against %.1f%%", optionID, iv.Mark, iv.Volatility, iv.ReferenceVolatility)
+			response.ImpliedVolatility = iv
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[IV API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// optionImpliedVolatilityHandler handles GET /api/options/{id}/iv, the
+// option's daily implied volatility
+func (s *Server) optionImpliedVolatilityHandler(w http.ResponseWriter, r *http.Request, optionID int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := s.optionService.GetByID(optionID); err != nil {
+		http.Error(w, "Option not found", http.StatusNotFound)
+		return
+	}
+
+	history, err := s.ivService.GetByOption(optionID)
+	if err != nil {
+		log.Printf("[IV API] ERROR: Failed to get implied volatility of option %d: %v", optionID, err)
+		http.Error(w, "Failed to get implied volatility", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("[IV API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// impliedVolatilitySnapshotHandler handles POST /api/iv/snapshot, recording
+// today's implied volatility of every open option with a mark
+func (s *Server) impliedVolatilitySnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[IV API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	recorded, err := s.ivService.RecordOpen(time.Now())
+	if err != nil {
+		log.Printf("[IV API] ERROR: Failed to record implied volatility: %v", err)
+		http.Error(w, "Failed to record implied volatility", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[IV API] Recorded implied volatility of %d open options", len(recorded))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recorded); err != nil {
+		log.Printf("[IV API] ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// monteCarloConfig reads a simulation's settings from the query string:
+// paths, seed, confidence and correlation, and volatility as comma-separated
+// SYMBOL:percent pairs. Without a seed, one is picked from the clock and
+// returned with the result so the run can be repeated.
+func monteCarloConfig(r *http.Request) (models.MonteCarloConfig, error) {
+	query := r.URL.Query()
+	// Keep picked seeds small enough for JavaScript to hand back exactly
+	config := models.MonteCarloConfig{Seed: time.Now().UnixNano() % 1000000000, Volatility: map[string]float64{}}
+
+	var err error
+	if value := query.Get("paths"); value != "" {
+		if config.Paths, err = strconv.Atoi(value); err != nil {
+			return config, fmt.Errorf("invalid paths: %s", value)
+		}
+	}
+	if value := query.Get("seed"); value != "" {
+		if config.Seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return config, fmt.Errorf("invalid seed: %s", value)
+		}
+	}
+	if value := query.Get("confidence"); value != "" {
+		if config.Confidence, err = strconv.ParseFloat(value, 64); err != nil {
+			return config, fmt.Errorf("invalid confidence: %s", value)
+		}
+	}
+	if value := query.Get("correlation"); value != "" {
+		if config.Correlation, err = strconv.ParseFloat(value, 64); err != nil {
+			return config, fmt.Errorf("invalid correlation: %s", value)
+		}
+	}
+	if value := query.Get("volatility"); value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			symbol, percent, ok := strings.Cut(pair, ":")
+			volatility, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+			if !ok || err != nil {
+				return config, fmt.Errorf("invalid volatility %q, expected SYMBOL:percent", pair)
+			}
+			config.Volatility[symbol] = volatility
+		}
+	}
+
+	return config, nil
+}
+
+// monteCarloAPIHandler handles GET /api/monte-carlo, simulating the selected
+// account's open options and shares to their expirations
+func (s *Server) monteCarloAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[MONTE CARLO API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	config, err := monteCarloConfig(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Simulate from the start of today so a seed repeats its result all day
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	result, err := s.pricingService.MonteCarlo(s.selectedAccountID(r), config, today)
+	if err != nil {
+		log.Printf("[MONTE CARLO API] ERROR: Failed to simulate portfolio: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to simulate portfolio: %v", err), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[MONTE CARLO API] Simulated %d paths with seed %d: mean P&L %.2f, VaR %.2f", result.Paths, result.Seed, result.MeanPnL, result.VaR)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("[MONTE CARLO API] ERROR: Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scenariosHandler serves the scenario simulator page
+func (s *Server) scenariosHandler(w http.ResponseWriter, r *http.Request) {
+	scenarios, err := s.scenarioService.GetAll()
+	if err != nil {
+		log.Printf("[SCENARIOS] ERROR: Failed to get scenarios: %v", err)
+		scenarios = []*models.Scenario{}
+	}
+
+	data := ScenariosPageData{
+		PageData: PageData{
+			Title:      "Scenarios",
+			ActivePage: "scenarios",
+			CurrentDB:  s.getCurrentDatabaseName(),
+			AllSymbols: s.getAllSymbolsList(),
+		},
+		Scenarios: scenarios,
+	}
+
+	s.renderTemplate(w, "scenarios.html", data)
+}
+
+// scenariosAPIHandler handles listing and saving scenarios
+func (s *Server) scenariosAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[SCENARIO API] %s %s", r.Method, r.URL.Path)
+
+	switch r.Method {
+	case http.MethodGet:
+		scenarios, err := s.scenarioService.GetAll()
+		if err != nil {
+			log.Printf("[SCENARIO API] ERROR: Failed to get scenarios: %v", err)
+			http.Error(w, "Failed to get scenarios", http.StatusInternalServerError)
+			return
+		}
+		s.writeScenarioJSON(w, scenarios)
+	case http.MethodPost:
+		var req models.Scenario
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		scenario, err := s.scenarioService.Create(&req)
+		if err != nil {
+			log.Printf("[SCENARIO API] ERROR: Failed to create scenario: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to create scenario: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("[SCENARIO API] Created scenario %d (%s)", scenario.ID, scenario.Name)
+		w.WriteHeader(http.StatusCreated)
+		s.writeScenarioJSON(w, scenario)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// scenarioRunHandler runs the scenario in the request body, saved or not,
+// against the selected account's open positions
+func (s *Server) scenarioRunHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[SCENARIO API] %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.Scenario
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	s.runScenario(w, r, &req)
+}
+
+// scenarioAPIHandler handles /api/scenarios/{id} and /api/scenarios/{id}/run
+func (s *Server) scenarioAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[SCENARIO API] %s %s", r.Method, r.URL.Path)
+
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/scenarios/"), "/")
+	if len(pathSegments) == 0 || pathSegments[0] == "" {
+		http.Error(w, "Scenario ID is required", http.StatusBadRequest)
+		return
+	}
+
+	scenarioID, err := strconv.Atoi(pathSegments[0])
+	if err != nil {
+		http.Error(w, "Invalid scenario ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(pathSegments) > 1 && pathSegments[1] == "run" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		scenario, err := s.scenarioService.GetByID(scenarioID)
+		if err != nil {
+			http.Error(w, "Scenario not found", http.StatusNotFound)
+			return
+		}
+		s.runScenario(w, r, scenario)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		scenario, err := s.scenarioService.GetByID(scenarioID)
+		if err != nil {
+			log.Printf("[SCENARIO API] ERROR: Failed to get scenario %d: %v", scenarioID, err)
+			http.Error(w, "Scenario not found", http.StatusNotFound)
+			return
+		}
+		s.writeScenarioJSON(w, scenario)
+	case http.MethodPut:
+		var req models.Scenario
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		scenario, err := s.scenarioService.Update(scenarioID, &req)
+		if err != nil {
+			log.Printf("[SCENARIO API] ERROR: Failed to update scenario %d: %v", scenarioID, err)
+			http.Error(w, fmt.Sprintf("Failed to update scenario: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.writeScenarioJSON(w, scenario)
+	case http.MethodDelete:
+		if err := s.scenarioService.Delete(scenarioID); err != nil {
+			log.Printf("[SCENARIO API] ERROR: Failed to delete scenario %d: %v", scenarioID, err)
+			http.Error(w, fmt.Sprintf("Failed to delete scenario: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Scenario deleted successfully"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) runScenario(w http.ResponseWriter, r *http.Request, scenario *models.Scenario) {
+	result, err := s.scenarioService.Run(scenario, s.selectedAccountID(r), time.Now())
+	if err != nil {
+		log.Printf("[SCENARIO API] ERROR: Failed to run scenario: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to run scenario: %v", err), http.StatusBadRequest)
+		return
+	}
+	s.writeScenarioJSON(w, result)
+}
+
+func (s *Server) writeScenarioJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[SCENARIO API] ERROR: Failed to encode response: %v", err)
+	}
+}
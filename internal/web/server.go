@@ -12,8 +12,10 @@ import (
 	"strconv"
 	"stonks/internal/database"
 	"stonks/internal/models"
+	"stonks/internal/notify"
 	"stonks/internal/polygon"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -28,8 +30,23 @@ type Server struct {
 	dividendService     *models.DividendService
 	settingService      *models.SettingService
 	metricService       *models.MetricService
+	campaignService     *models.CampaignService
+	accountService      *models.AccountService
+	cashService         *models.CashService
+	collateralService   *models.CollateralService
+	pricingService      *models.PricingService
+	ivService           *models.ImpliedVolatilityService
+	strategyService     *models.StrategyService
+	scenarioService     *models.ScenarioService
+	calendarService     *models.CalendarService
+	alertService        *models.AlertService
+	notificationService *models.NotificationService
+	notifier            *notify.Service
 	polygonService      *polygon.Service
 	templates           *template.Template
+	stopAlerts          chan struct{}
+	// servicesMu keeps the alert scheduler and a database switch apart
+	servicesMu sync.RWMutex
 }
 
 func NewServer() (*Server, error) {
@@ -246,22 +263,8 @@ func NewServer() (*Server, error) {
 
 	log.Printf("[SERVER] Initializing service layers")
 	
-	// Initialize core services
-	symbolService := models.NewSymbolService(dbWrapper.DB)
-	settingService := models.NewSettingService(dbWrapper.DB)
-	
-	server := &Server{
-		db:                  dbWrapper.DB,
-		optionService:       models.NewOptionService(dbWrapper.DB),
-		symbolService:       symbolService,
-		treasuryService:     models.NewTreasuryService(dbWrapper.DB),
-		longPositionService: models.NewLongPositionService(dbWrapper.DB),
-		dividendService:     models.NewDividendService(dbWrapper.DB),
-		settingService:      settingService,
-		metricService:       models.NewMetricService(dbWrapper.DB),
-		polygonService:      polygon.NewService(symbolService, settingService),
-		templates:           templates,
-	}
+	server := &Server{templates: templates}
+	server.initServices(dbWrapper.DB)
 
 	log.Printf("[SERVER] All services initialized successfully")
 	log.Printf("[SERVER] Server creation completed")
@@ -269,8 +272,42 @@ func NewServer() (*Server, error) {
 	return server, nil
 }
 
+// initServices connects every service to the database, on startup and when
+// switching to another database
+func (s *Server) initServices(db *sql.DB) {
+	symbolService := models.NewSymbolService(db)
+	settingService := models.NewSettingService(db)
+	notificationService := models.NewNotificationService(db)
+
+	s.db = db
+	s.optionService = models.NewOptionService(db)
+	s.symbolService = symbolService
+	s.treasuryService = models.NewTreasuryService(db)
+	s.longPositionService = models.NewLongPositionService(db)
+	s.dividendService = models.NewDividendService(db)
+	s.settingService = settingService
+	s.metricService = models.NewMetricService(db)
+	s.campaignService = models.NewCampaignService(db)
+	s.accountService = models.NewAccountService(db)
+	s.cashService = models.NewCashService(db)
+	s.collateralService = models.NewCollateralService(db)
+	s.pricingService = models.NewPricingService(db)
+	s.ivService = models.NewImpliedVolatilityService(db)
+	s.strategyService = models.NewStrategyService(db)
+	s.scenarioService = models.NewScenarioService(db)
+	s.calendarService = models.NewCalendarService(db)
+	s.alertService = models.NewAlertService(db)
+	s.notificationService = notificationService
+	s.notifier = notify.NewService(settingService, notificationService)
+	s.polygonService = polygon.NewService(symbolService, settingService)
+}
+
 // Close closes the database connection
 func (s *Server) Close() error {
+	if s.stopAlerts != nil {
+		close(s.stopAlerts)
+		s.stopAlerts = nil
+	}
 	if s.db != nil {
 		log.Printf("[SERVER] Closing database connection")
 		return s.db.Close()
@@ -291,6 +328,18 @@ func (s *Server) setupRoutes() {
 	http.HandleFunc("/monthly", s.monthlyHandler)
 	log.Printf("[SERVER] Route registered: /monthly -> monthlyHandler")
 
+	http.HandleFunc("/realized-gains", s.realizedGainsHandler)
+	log.Printf("[SERVER] Route registered: /realized-gains -> realizedGainsHandler")
+
+	http.HandleFunc("/realized-gains/csv", s.realizedGainsCSVHandler)
+	log.Printf("[SERVER] Route registered: /realized-gains/csv -> realizedGainsCSVHandler")
+
+	http.HandleFunc("/form-8949", s.form8949Handler)
+	log.Printf("[SERVER] Route registered: /form-8949 -> form8949Handler")
+
+	http.HandleFunc("/form-8949/csv", s.form8949CSVHandler)
+	log.Printf("[SERVER] Route registered: /form-8949/csv -> form8949CSVHandler")
+
 	http.HandleFunc("/options", s.optionsHandler)
 	log.Printf("[SERVER] Route registered: /options -> optionsHandler")
 
@@ -303,6 +352,9 @@ func (s *Server) setupRoutes() {
 	http.HandleFunc("/dividends", s.dividendsHandler)
 	log.Printf("[SERVER] Route registered: /dividends -> dividendsHandler")
 
+	http.HandleFunc("/cash", s.cashHandler)
+	log.Printf("[SERVER] Route registered: /cash -> cashHandler")
+
 	http.HandleFunc("/metrics", s.metricsHandler)
 	log.Printf("[SERVER] Route registered: /metrics -> metricsHandler")
 
@@ -327,12 +379,87 @@ func (s *Server) setupRoutes() {
 	http.HandleFunc("/api/symbols/", s.symbolAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/symbols/ -> symbolAPIHandler")
 
+	http.HandleFunc("/api/campaigns", s.campaignsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/campaigns -> campaignsAPIHandler")
+
+	http.HandleFunc("/api/campaigns/", s.campaignAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/campaigns/ -> campaignAPIHandler")
+
+	http.HandleFunc("/api/accounts", s.accountsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/accounts -> accountsAPIHandler")
+
+	http.HandleFunc("/api/accounts/", s.accountAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/accounts/ -> accountAPIHandler")
+
+	http.HandleFunc("/api/cash", s.cashAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/cash -> cashAPIHandler")
+
+	http.HandleFunc("/api/cash/", s.cashEntryAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/cash/ -> cashEntryAPIHandler")
+
+	http.HandleFunc("/api/collateral", s.collateralAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/collateral -> collateralAPIHandler")
+
+	http.HandleFunc("/api/greeks", s.greeksAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/greeks -> greeksAPIHandler")
+
+	http.HandleFunc("/api/portfolio/greeks", s.portfolioGreeksAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/portfolio/greeks -> portfolioGreeksAPIHandler")
+
+	http.HandleFunc("/api/monte-carlo", s.monteCarloAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/monte-carlo -> monteCarloAPIHandler")
+
+	http.HandleFunc("/api/iv/snapshot", s.impliedVolatilitySnapshotHandler)
+	log.Printf("[SERVER] Route registered: /api/iv/snapshot -> impliedVolatilitySnapshotHandler")
+
+	http.HandleFunc("/api/strategies", s.strategiesAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/strategies -> strategiesAPIHandler")
+
+	http.HandleFunc("/api/strategies/", s.strategyAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/strategies/ -> strategyAPIHandler")
+
+	http.HandleFunc("/api/scenarios", s.scenariosAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/scenarios -> scenariosAPIHandler")
+
+	http.HandleFunc("/api/scenarios/run", s.scenarioRunHandler)
+	log.Printf("[SERVER] Route registered: /api/scenarios/run -> scenarioRunHandler")
+
+	http.HandleFunc("/api/scenarios/", s.scenarioAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/scenarios/ -> scenarioAPIHandler")
+
+	http.HandleFunc("/api/alerts", s.alertsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/alerts -> alertsAPIHandler")
+
+	http.HandleFunc("/api/alerts/evaluate", s.alertEvaluateHandler)
+	log.Printf("[SERVER] Route registered: /api/alerts/evaluate -> alertEvaluateHandler")
+
+	http.HandleFunc("/api/alerts/read", s.alertReadAllHandler)
+	log.Printf("[SERVER] Route registered: /api/alerts/read -> alertReadAllHandler")
+
+	http.HandleFunc("/api/alerts/", s.alertAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/alerts/ -> alertAPIHandler")
+
+	http.HandleFunc("/api/alert-rules", s.alertRulesAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/alert-rules -> alertRulesAPIHandler")
+
+	http.HandleFunc("/api/alert-rules/", s.alertRuleAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/alert-rules/ -> alertRuleAPIHandler")
+
+	http.HandleFunc("/api/notifications", s.notificationsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/notifications -> notificationsAPIHandler")
+
+	http.HandleFunc("/api/notifications/test", s.notificationTestHandler)
+	log.Printf("[SERVER] Route registered: /api/notifications/test -> notificationTestHandler")
+
 	http.HandleFunc("/api/dividends", s.dividendsAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/dividends -> dividendsAPIHandler")
 
 	http.HandleFunc("/api/long-positions", s.longPositionsAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/long-positions -> longPositionsAPIHandler")
 
+	http.HandleFunc("/api/long-positions/sell", s.sellSharesHandler)
+	log.Printf("[SERVER] Route registered: /api/long-positions/sell -> sellSharesHandler")
+
 	http.HandleFunc("/api/treasuries/", s.treasuryAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/treasuries/ -> treasuryAPIHandler")
 
@@ -381,6 +508,18 @@ func (s *Server) setupRoutes() {
 	http.HandleFunc("/import", s.HandleImport)
 	log.Printf("[SERVER] Route registered: /import -> HandleImport")
 
+	http.HandleFunc("/accounts", s.accountsHandler)
+	log.Printf("[SERVER] Route registered: /accounts -> accountsHandler")
+
+	http.HandleFunc("/scenarios", s.scenariosHandler)
+	log.Printf("[SERVER] Route registered: /scenarios -> scenariosHandler")
+
+	http.HandleFunc("/alerts", s.alertsHandler)
+	log.Printf("[SERVER] Route registered: /alerts -> alertsHandler")
+
+	http.HandleFunc("/calendar.ics", s.calendarHandler)
+	log.Printf("[SERVER] Route registered: /calendar.ics -> calendarHandler")
+
 	http.HandleFunc("/backup", s.HandleBackup)
 	log.Printf("[SERVER] Route registered: /backup -> HandleBackup")
 
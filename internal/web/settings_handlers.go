@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 	"stonks/internal/models"
+	"stonks/internal/notify"
 )
 
 // SettingsData holds data for the settings template
@@ -16,6 +17,22 @@ type SettingsData struct {
 	CurrentDB  string            `json:"currentDB"`
 	ApiKey     string            `json:"apiKey"`
 	ActivePage string            `json:"activePage"`
+
+	RiskFreeRate      float64 `json:"riskFreeRate"`
+	DefaultVolatility float64 `json:"defaultVolatility"`
+	Benchmark         string  `json:"benchmark"`
+	LotMethod         string  `json:"lotMethod"`
+
+	NotifyEvents  map[string]bool `json:"notifyEvents"`
+	MaxAttempts   string          `json:"maxAttempts"`
+	WebhookURL    string          `json:"webhookUrl"`
+	WebhookSecret string          `json:"webhookSecret"`
+	SMTPHost      string          `json:"smtpHost"`
+	SMTPPort      string          `json:"smtpPort"`
+	SMTPUsername  string          `json:"smtpUsername"`
+	SMTPPassword  string          `json:"smtpPassword"`
+	SMTPFrom      string          `json:"smtpFrom"`
+	SMTPTo        string          `json:"smtpTo"`
 }
 
 // settingsHandler serves the settings management page
@@ -38,6 +55,12 @@ func (s *Server) settingsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Get API key value specifically
 	apiKey := s.settingService.GetValue("POLYGON_API_KEY")
+	rate, volatility := s.pricingService.Rates()
+
+	notifyEvents := make(map[string]bool)
+	for _, eventType := range strings.Split(s.settingService.GetValue(notify.EventsSetting), ",") {
+		notifyEvents[strings.TrimSpace(eventType)] = true
+	}
 
 	data := SettingsData{
 		Settings:   settings,
@@ -45,6 +68,22 @@ func (s *Server) settingsHandler(w http.ResponseWriter, r *http.Request) {
 		CurrentDB:  s.getCurrentDatabaseName(),
 		ApiKey:     apiKey,
 		ActivePage: "settings",
+
+		RiskFreeRate:      rate,
+		DefaultVolatility: volatility,
+		Benchmark:         s.pricingService.Benchmark(),
+		LotMethod:         s.settingService.GetValueWithDefault(models.LotMethodSetting, models.LotMethodFIFO),
+
+		NotifyEvents:  notifyEvents,
+		MaxAttempts:   s.settingService.GetValueWithDefault(notify.MaxAttemptsSetting, "3"),
+		WebhookURL:    s.settingService.GetValue(notify.WebhookURLSetting),
+		WebhookSecret: s.settingService.GetValue(notify.WebhookSecretSetting),
+		SMTPHost:      s.settingService.GetValue(notify.SMTPHostSetting),
+		SMTPPort:      s.settingService.GetValueWithDefault(notify.SMTPPortSetting, "587"),
+		SMTPUsername:  s.settingService.GetValue(notify.SMTPUsernameSetting),
+		SMTPPassword:  s.settingService.GetValue(notify.SMTPPasswordSetting),
+		SMTPFrom:      s.settingService.GetValue(notify.SMTPFromSetting),
+		SMTPTo:        s.settingService.GetValue(notify.SMTPToSetting),
 	}
 
 	s.renderTemplate(w, "settings.html", data)
@@ -24,8 +24,139 @@ document.addEventListener('DOMContentLoaded', function() {
     
     // Scroll active symbol into view
     scrollActiveSymbolIntoView();
+    
+    // Load accounts into the account selector
+    initializeAccountSelect();
+    
+    // Load the alert inbox and keep its unread count current
+    initializeAlertInbox();
 });
 
+function initializeAlertInbox() {
+    const alertInboxToggle = document.getElementById('alertInboxToggle');
+    const alertInboxList = document.getElementById('alertInboxList');
+    
+    if (!alertInboxToggle || !alertInboxList) {
+        return;
+    }
+    
+    alertInboxToggle.addEventListener('click', function(e) {
+        e.preventDefault();
+        const isExpanded = alertInboxList.classList.toggle('expanded');
+        alertInboxToggle.classList.toggle('expanded', isExpanded);
+        
+        // Opening the inbox reads everything in it; unread items stay
+        // highlighted until the inbox is next loaded
+        const badge = document.getElementById('alertBadge');
+        if (isExpanded && badge.style.display !== 'none') {
+            fetch('/api/alerts/read', { method: 'POST' })
+                .then(() => { badge.style.display = 'none'; })
+                .catch(error => console.error('Failed to mark alerts read:', error));
+        }
+    });
+    
+    loadAlertInbox();
+    setInterval(loadAlertInbox, 60000);
+}
+
+function loadAlertInbox() {
+    const items = document.getElementById('alertInboxItems');
+    const badge = document.getElementById('alertBadge');
+    
+    if (!items || !badge) {
+        return;
+    }
+    
+    fetch('/api/alerts')
+        .then(response => response.json())
+        .then(inbox => {
+            badge.textContent = inbox.unread;
+            badge.style.display = inbox.unread > 0 ? '' : 'none';
+            
+            items.innerHTML = '';
+            if (inbox.alerts.length === 0) {
+                const empty = document.createElement('div');
+                empty.className = 'alert-inbox-empty';
+                empty.textContent = 'No alerts';
+                items.appendChild(empty);
+                return;
+            }
+            
+            inbox.alerts.forEach(alert => {
+                const item = document.createElement('div');
+                item.className = 'alert-inbox-item' + (alert.read_at ? '' : ' unread');
+                
+                const text = document.createElement('div');
+                const rule = document.createElement('span');
+                rule.className = 'alert-rule';
+                rule.textContent = alert.rule_name + ' · ' + new Date(alert.fired_at).toLocaleDateString();
+                text.appendChild(document.createTextNode(alert.message));
+                text.appendChild(rule);
+                item.appendChild(text);
+                
+                const dismiss = document.createElement('button');
+                dismiss.className = 'alert-dismiss';
+                dismiss.title = 'Dismiss';
+                dismiss.innerHTML = '<i class="fas fa-times"></i>';
+                dismiss.addEventListener('click', function() {
+                    fetch('/api/alerts/' + alert.id, { method: 'DELETE' })
+                        .then(() => loadAlertInbox())
+                        .catch(error => console.error('Failed to dismiss alert:', error));
+                });
+                item.appendChild(dismiss);
+                
+                items.appendChild(item);
+            });
+        })
+        .catch(error => console.error('Failed to load alerts:', error));
+}
+
+function initializeAccountSelect() {
+    const accountSelect = document.getElementById('accountSelect');
+    
+    if (!accountSelect) {
+        return;
+    }
+    
+    const match = document.cookie.match(/(?:^|;\s*)account=([^;]*)/);
+    const selected = match ? match[1] : '';
+    
+    fetch('/api/accounts')
+        .then(response => response.json())
+        .then(accounts => {
+            accounts.forEach(account => {
+                const option = document.createElement('option');
+                option.value = account.id;
+                option.textContent = account.name;
+                accountSelect.appendChild(option);
+            });
+            
+            if (accounts.some(account => String(account.id) === selected)) {
+                accountSelect.value = selected;
+            } else if (selected !== '') {
+                // The selected account was deleted, go back to all accounts
+                setSelectedAccount('');
+            }
+            
+            // Hide the selector until there is more than the household to choose from
+            accountSelect.style.display = accounts.length > 0 ? '' : 'none';
+        })
+        .catch(error => console.error('Failed to load accounts:', error));
+    
+    accountSelect.addEventListener('change', function() {
+        setSelectedAccount(this.value);
+        window.location.reload();
+    });
+}
+
+function setSelectedAccount(accountId) {
+    if (accountId === '') {
+        document.cookie = 'account=; path=/; max-age=0';
+    } else {
+        document.cookie = 'account=' + accountId + '; path=/; max-age=31536000';
+    }
+}
+
 function initializeSymbolsToggle() {
     const symbolsToggle = document.getElementById('symbolsToggle');
     const symbolsList = document.getElementById('symbolsList');
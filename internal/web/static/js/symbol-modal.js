@@ -79,6 +79,10 @@ class SymbolModal {
             const dividendInput = document.getElementById('dividendInput');
             const exDividendDateInput = document.getElementById('exDividendDateInput');
             const peRatioInput = document.getElementById('peRatioInput');
+            const instrumentClassInput = document.getElementById('instrumentClassInput');
+            const contractMultiplierInput = document.getElementById('contractMultiplierInput');
+            const volatilityInput = document.getElementById('volatilityInput');
+            const betaInput = document.getElementById('betaInput');
             
             if (symbolInput) {
                 symbolInput.value = symbolData.symbol;
@@ -88,6 +92,10 @@ class SymbolModal {
             if (dividendInput) dividendInput.value = symbolData.dividend || '';
             if (exDividendDateInput) exDividendDateInput.value = symbolData.ex_dividend_date || '';
             if (peRatioInput) peRatioInput.value = symbolData.pe_ratio || '';
+            if (instrumentClassInput) instrumentClassInput.value = symbolData.instrument_class || 'equity';
+            if (contractMultiplierInput) contractMultiplierInput.value = symbolData.contract_multiplier || 100;
+            if (volatilityInput) volatilityInput.value = symbolData.volatility || '';
+            if (betaInput) betaInput.value = symbolData.beta || '';
         } else {
             if (this.symbolForm) {
                 this.symbolForm.reset();
@@ -121,6 +129,10 @@ class SymbolModal {
         const dividendInput = document.getElementById('dividendInput');
         const exDividendDateInput = document.getElementById('exDividendDateInput');
         const peRatioInput = document.getElementById('peRatioInput');
+        const instrumentClassInput = document.getElementById('instrumentClassInput');
+        const contractMultiplierInput = document.getElementById('contractMultiplierInput');
+        const volatilityInput = document.getElementById('volatilityInput');
+        const betaInput = document.getElementById('betaInput');
         
         if (!symbolInput) {
             console.error('Symbol input not found');
@@ -132,7 +144,11 @@ class SymbolModal {
             price: parseFloat(priceInput?.value) || 0,
             dividend: parseFloat(dividendInput?.value) || 0,
             ex_dividend_date: exDividendDateInput?.value || null,
-            pe_ratio: parseFloat(peRatioInput?.value) || null
+            pe_ratio: parseFloat(peRatioInput?.value) || null,
+            instrument_class: instrumentClassInput?.value || 'equity',
+            contract_multiplier: parseInt(contractMultiplierInput?.value) || 100,
+            volatility: parseFloat(volatilityInput?.value) || 0,
+            beta: parseFloat(betaInput?.value) || 0
         };
         
         const url = `/api/symbols/${symbolData.symbol}`;
@@ -145,7 +161,11 @@ class SymbolModal {
                 price: symbolData.price,
                 dividend: symbolData.dividend,
                 ex_dividend_date: symbolData.ex_dividend_date,
-                pe_ratio: symbolData.pe_ratio
+                pe_ratio: symbolData.pe_ratio,
+                instrument_class: symbolData.instrument_class,
+                contract_multiplier: symbolData.contract_multiplier,
+                volatility: symbolData.volatility,
+                beta: symbolData.beta
             })
         })
         .then(response => {
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strategiesAPIHandler handles listing and opening multi-leg strategies
+func (s *Server) strategiesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[STRATEGY API] %s %s", r.Method, r.URL.Path)
+
+	switch r.Method {
+	case http.MethodGet:
+		s.listStrategies(w, r)
+	case http.MethodPost:
+		s.createStrategy(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// strategyAPIHandler handles /api/strategies/{id}
+func (s *Server) strategyAPIHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[STRATEGY API] %s %s", r.Method, r.URL.Path)
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/strategies/")
+	if idStr == "" {
+		http.Error(w, "Strategy ID is required", http.StatusBadRequest)
+		return
+	}
+
+	strategyID, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid strategy ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		strategy, err := s.strategyService.GetByID(strategyID)
+		if err != nil {
+			log.Printf("[STRATEGY API] ERROR: Failed to get strategy %d: %v", strategyID, err)
+			http.Error(w, "Strategy not found", http.StatusNotFound)
+			return
+		}
+		s.writeStrategyJSON(w, buildStrategyView(strategy))
+	case http.MethodDelete:
+		if err := s.strategyService.Delete(strategyID); err != nil {
+			log.Printf("[STRATEGY API] ERROR: Failed to delete strategy %d: %v", strategyID, err)
+			http.Error(w, fmt.Sprintf("Failed to delete strategy: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Strategy deleted successfully"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// listStrategies returns all strategies, optionally filtered by ?symbol=
+func (s *Server) listStrategies(w http.ResponseWriter, r *http.Request) {
+	var strategies []*models.Strategy
+	var err error
+	if symbol := strings.ToUpper(r.URL.Query().Get("symbol")); symbol != "" {
+		strategies, err = s.strategyService.GetBySymbol(symbol)
+	} else {
+		strategies, err = s.strategyService.GetAll()
+	}
+	if err != nil {
+		log.Printf("[STRATEGY API] ERROR: Failed to get strategies: %v", err)
+		http.Error(w, "Failed to get strategies", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeStrategyJSON(w, buildStrategyViews(strategies))
+}
+
+// createStrategy handles POST requests to open a strategy and all of its legs
+func (s *Server) createStrategy(w http.ResponseWriter, r *http.Request) {
+	var req StrategyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if req.Symbol == "" || req.Type == "" {
+		http.Error(w, "Symbol and type are required", http.StatusBadRequest)
+		return
+	}
+
+	opened := time.Now().Truncate(24 * time.Hour)
+	if req.Opened != "" {
+		parsed, err := time.Parse("2006-01-02", req.Opened)
+		if err != nil {
+			http.Error(w, "Invalid opened date format", http.StatusBadRequest)
+			return
+		}
+		opened = parsed
+	}
+
+	legs := make([]models.StrategyLeg, 0, len(req.Legs))
+	for _, legReq := range req.Legs {
+		expiration, err := time.Parse("2006-01-02", legReq.Expiration)
+		if err != nil {
+			http.Error(w, "Invalid leg expiration date format", http.StatusBadRequest)
+			return
+		}
+		legs = append(legs, models.StrategyLeg{
+			Type:       legReq.Type,
+			Direction:  legReq.Direction,
+			Strike:     legReq.Strike,
+			Expiration: expiration,
+			Premium:    legReq.Premium,
+			Contracts:  legReq.Contracts,
+		})
+	}
+
+	strategy, err := s.strategyService.Create(req.Symbol, req.Type, opened, legs, req.Notes)
+	if err != nil {
+		log.Printf("[STRATEGY API] ERROR: Failed to create strategy: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to create strategy: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("[STRATEGY API] Created %s %d for %s with %d legs", strategy.Type, strategy.ID, strategy.Symbol, len(strategy.Legs))
+	w.WriteHeader(http.StatusCreated)
+	s.writeStrategyJSON(w, buildStrategyView(strategy))
+}
+
+func buildStrategyView(strategy *models.Strategy) StrategyView {
+	return StrategyView{
+		Strategy: strategy,
+		Analysis: strategy.Analyze(),
+	}
+}
+
+func buildStrategyViews(strategies []*models.Strategy) []StrategyView {
+	views := make([]StrategyView, 0, len(strategies))
+	for _, strategy := range strategies {
+		views = append(views, buildStrategyView(strategy))
+	}
+	return views
+}
+
+func (s *Server) writeStrategyJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[STRATEGY API] ERROR: Failed to encode response: %v", err)
+	}
+}
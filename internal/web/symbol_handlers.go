@@ -43,6 +43,11 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 	var dividend float64
 	var exDividendDate *time.Time
 	var peRatio *float64
+	instrumentClass := models.InstrumentEquity
+	contractMultiplier := models.DefaultContractMultiplier
+	var volatility float64
+	var beta float64
+	instruments := models.Instruments{}
 
 	var yield float64
 	var peRatioValue float64
@@ -56,6 +61,15 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 		dividend = symbolData.Dividend
 		exDividendDate = symbolData.ExDividendDate
 		peRatio = symbolData.PERatio
+		instrumentClass = symbolData.InstrumentClass
+		contractMultiplier = symbolData.Multiplier()
+		if symbolData.Volatility != nil {
+			volatility = *symbolData.Volatility
+		}
+		if symbolData.Beta != nil {
+			beta = *symbolData.Beta
+		}
+		instruments[symbol] = symbolData
 
 		// Handle P/E ratio safely
 		if symbolData.PERatio != nil {
@@ -193,7 +207,31 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 	monthlyResults := s.buildSymbolMonthlyResults(optionsList)
 	log.Printf("[SYMBOL] Built %d monthly results for %s", len(monthlyResults), symbol)
 
-	log.Printf("[SYMBOL] Step 10: Creating template data for %s", symbol)
+	// Build wheel campaigns and multi-leg strategies for this symbol
+	log.Printf("[SYMBOL] Step 10: Getting campaigns and strategies for %s", symbol)
+	campaigns, err := s.campaignService.GetBySymbol(symbol)
+	if err != nil {
+		log.Printf("[SYMBOL] ERROR: Failed to get campaigns for %s: %v", symbol, err)
+		campaigns = []*models.Campaign{}
+	} else {
+		log.Printf("[SYMBOL] Retrieved %d campaigns for %s", len(campaigns), symbol)
+	}
+
+	strategies, err := s.strategyService.GetBySymbol(symbol)
+	if err != nil {
+		log.Printf("[SYMBOL] ERROR: Failed to get strategies for %s: %v", symbol, err)
+		strategies = []*models.Strategy{}
+	} else {
+		log.Printf("[SYMBOL] Retrieved %d strategies for %s", len(strategies), symbol)
+	}
+
+	// Calculate premium-adjusted cost basis of the open holding
+	log.Printf("[SYMBOL] Step 11: Calculating adjusted cost basis for %s", symbol)
+	costBasis := models.CalculateCostBasis(symbol, longPositionsList, optionsList, dividendsList)
+	log.Printf("[SYMBOL] Cost basis for %s: %d shares, $%.2f/share, adjusted $%.2f/share, with dividends $%.2f/share",
+		symbol, costBasis.Shares, costBasis.CostPerShare, costBasis.AdjustedCostPerShare, costBasis.DividendAdjustedCostPerShare)
+
+	log.Printf("[SYMBOL] Step 12: Creating template data for %s", symbol)
 	data := SymbolData{
 		Symbol:            symbol,
 		AllSymbols:        symbols,
@@ -207,27 +245,37 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 		PERatioValue:      peRatioValue,
 		HasPERatio:        hasPERatio,
 		Yield:             yield,
+		InstrumentClass:   instrumentClass,
+		Multiplier:        contractMultiplier,
+		Volatility:        volatility,
+		Beta:              beta,
 		OptionsGains:      strconv.FormatFloat(optionsGains, 'f', 2, 64),
 		CapGains:          strconv.FormatFloat(capGains, 'f', 2, 64),
 		Dividends:         strconv.FormatFloat(dividendsTotal, 'f', 2, 64),
 		TotalProfits:      strconv.FormatFloat(totalProfits, 'f', 2, 64),
 		CashOnCash:        strconv.FormatFloat(cashOnCash, 'f', 2, 64),
+		PutExposed:        models.CalculatePutExposureBySymbol(optionsList)[symbol],
 		DividendsList:     dividendsList,
 		DividendsTotal:    dividendsTotal,
 		OptionsList:       optionsList,
 		LongPositionsList: longPositionsList,
 		MonthlyResults:    monthlyResults,
+		Campaigns:         s.buildCampaignViews(campaigns),
+		RollChains:        models.BuildRollChains(optionsList),
+		Strategies:        buildStrategyViews(strategies),
+		CostBasis:         costBasis,
+		WashSales:         models.DetectWashSales(longPositionsList, optionsList, instruments),
 		CurrentDB:         s.getCurrentDatabaseName(),
 		ActivePage:        "symbol",
 	}
 
-	log.Printf("[SYMBOL] Step 11: Template data created successfully for %s", symbol)
+	log.Printf("[SYMBOL] Step 13: Template data created successfully for %s", symbol)
 	log.Printf("[SYMBOL] Data summary: Price=%.2f, OptionsGains=%s, CapGains=%s, Dividends=%s, TotalProfits=%s, CashOnCash=%s%%",
 		data.Price, data.OptionsGains, data.CapGains, data.Dividends, data.TotalProfits, data.CashOnCash)
 	log.Printf("[SYMBOL] Data counts: %d dividends, %d options, %d long positions, %d monthly results",
 		len(data.DividendsList), len(data.OptionsList), len(data.LongPositionsList), len(data.MonthlyResults))
 
-	log.Printf("[SYMBOL] Step 12: Rendering template for %s", symbol)
+	log.Printf("[SYMBOL] Step 14: Rendering template for %s", symbol)
 	s.renderTemplate(w, "symbol.html", data)
 	log.Printf("[SYMBOL] ===== Completed symbol handler for: %s =====", symbol)
 }
@@ -369,6 +417,18 @@ func (s *Server) updateSymbolHandler(w http.ResponseWriter, r *http.Request) {
 	if updateReq.PERatio != nil {
 		peRatio = updateReq.PERatio
 	}
+	if updateReq.InstrumentClass != nil && !models.IsValidInstrumentClass(*updateReq.InstrumentClass) {
+		http.Error(w, "Instrument class must be 'equity', 'etf' or 'index'", http.StatusBadRequest)
+		return
+	}
+	if updateReq.ContractMultiplier != nil && *updateReq.ContractMultiplier <= 0 {
+		http.Error(w, "Contract multiplier must be positive", http.StatusBadRequest)
+		return
+	}
+	if updateReq.Volatility != nil && *updateReq.Volatility < 0 {
+		http.Error(w, "Volatility cannot be negative", http.StatusBadRequest)
+		return
+	}
 
 	// Update the symbol
 	updatedSymbol, err := s.symbolService.Update(symbol, price, dividend, exDividendDate, peRatio)
@@ -377,10 +437,87 @@ func (s *Server) updateSymbolHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if updateReq.InstrumentClass != nil || updateReq.ContractMultiplier != nil {
+		class := updatedSymbol.InstrumentClass
+		if updateReq.InstrumentClass != nil {
+			class = *updateReq.InstrumentClass
+		}
+		multiplier := updatedSymbol.Multiplier()
+		if updateReq.ContractMultiplier != nil {
+			multiplier = *updateReq.ContractMultiplier
+		}
+		updatedSymbol, err = s.symbolService.SetInstrument(symbol, class, multiplier)
+		if err != nil {
+			http.Error(w, "Failed to update symbol instrument", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if updateReq.Volatility != nil {
+		var volatility *float64
+		if *updateReq.Volatility > 0 {
+			volatility = updateReq.Volatility
+		}
+		updatedSymbol, err = s.symbolService.SetVolatility(symbol, volatility)
+		if err != nil {
+			http.Error(w, "Failed to update symbol volatility", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if updateReq.Beta != nil {
+		var beta *float64
+		if *updateReq.Beta != 0 {
+			beta = updateReq.Beta
+		}
+		updatedSymbol, err = s.symbolService.SetBeta(symbol, beta)
+		if err != nil {
+			http.Error(w, "Failed to update symbol beta", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedSymbol)
 }
 
+// getSymbolHandler returns a symbol with the premium-adjusted cost basis of its open holding
+func (s *Server) getSymbolHandler(w http.ResponseWriter, symbol string) {
+	symbol = strings.ToUpper(symbol)
+	symbolData, err := s.symbolService.GetBySymbol(symbol)
+	if err != nil || symbolData == nil {
+		http.Error(w, "Symbol not found", http.StatusNotFound)
+		return
+	}
+
+	positions, err := s.longPositionService.GetBySymbol(symbol)
+	if err != nil {
+		log.Printf("[SYMBOL API] ERROR: Failed to get long positions for %s: %v", symbol, err)
+		http.Error(w, "Failed to get long positions", http.StatusInternalServerError)
+		return
+	}
+	options, err := s.optionService.GetBySymbol(symbol)
+	if err != nil {
+		log.Printf("[SYMBOL API] ERROR: Failed to get options for %s: %v", symbol, err)
+		http.Error(w, "Failed to get options", http.StatusInternalServerError)
+		return
+	}
+	dividends, err := s.dividendService.GetBySymbol(symbol)
+	if err != nil {
+		log.Printf("[SYMBOL API] ERROR: Failed to get dividends for %s: %v", symbol, err)
+		http.Error(w, "Failed to get dividends", http.StatusInternalServerError)
+		return
+	}
+
+	response := SymbolResponse{
+		Symbol:    symbolData,
+		CostBasis: models.CalculateCostBasis(symbol, positions, options, dividends),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // deleteSymbolHandler deletes a symbol and all related data
 func (s *Server) deleteSymbolHandler(w http.ResponseWriter, r *http.Request, symbol string) {
 	log.Printf("[DELETE_SYMBOL] Starting deletion process for symbol: %s", symbol)
@@ -407,6 +544,20 @@ func (s *Server) deleteSymbolHandler(w http.ResponseWriter, r *http.Request, sym
 		return
 	}
 
+	log.Printf("[DELETE_SYMBOL] Deleting strategies for symbol: %s", symbol)
+	if err := s.strategyService.DeleteBySymbol(symbol); err != nil {
+		log.Printf("[DELETE_SYMBOL] ERROR: Failed to delete strategies for %s: %v", symbol, err)
+		http.Error(w, "Failed to delete symbol strategies", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[DELETE_SYMBOL] Deleting campaigns for symbol: %s", symbol)
+	if err := s.campaignService.DeleteBySymbol(symbol); err != nil {
+		log.Printf("[DELETE_SYMBOL] ERROR: Failed to delete campaigns for %s: %v", symbol, err)
+		http.Error(w, "Failed to delete symbol campaigns", http.StatusInternalServerError)
+		return
+	}
+
 	// Finally delete the symbol itself
 	log.Printf("[DELETE_SYMBOL] Deleting symbol: %s", symbol)
 	if err := s.symbolService.Delete(symbol); err != nil {
@@ -450,6 +601,8 @@ func (s *Server) symbolAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Handle different HTTP methods for symbol operations
 	switch r.Method {
+	case http.MethodGet:
+		s.getSymbolHandler(w, symbol)
 	case http.MethodPut:
 		s.updateSymbolHandler(w, r)
 	case http.MethodDelete:
@@ -494,6 +647,7 @@ func (s *Server) symbolUpdatePriceHandler(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		response["error"] = err.Error()
 		log.Printf("[SYMBOL API] Failed to update price for %s: %v", symbol, err)
+		s.notifyPriceRefreshFailed([]string{symbol + ": " + err.Error()})
 		w.WriteHeader(http.StatusBadRequest)
 	} else {
 		response["message"] = "Price updated successfully"
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"time"
+)
+
+// realizedGainsHandler serves the realized gains report for a tax year
+func (s *Server) realizedGainsHandler(w http.ResponseWriter, r *http.Request) {
+	report, years, err := s.buildRealizedGainsReport(r)
+	if err != nil {
+		log.Printf("[REALIZED GAINS] ERROR: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := RealizedGainsPageData{
+		PageData: PageData{
+			Title:      "Realized Gains",
+			ActivePage: "realized-gains",
+			CurrentDB:  s.getCurrentDatabaseName(),
+			AllSymbols: s.getAllSymbolsList(),
+		},
+		Report: report,
+		Years:  years,
+	}
+
+	s.renderTemplate(w, "realized-gains.html", data)
+}
+
+// realizedGainsCSVHandler downloads the realized gains report for a tax year as CSV
+func (s *Server) realizedGainsCSVHandler(w http.ResponseWriter, r *http.Request) {
+	report, _, err := s.buildRealizedGainsReport(r)
+	if err != nil {
+		log.Printf("[REALIZED GAINS] ERROR: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"realized-gains-%d.csv\"", report.Year))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"term", "kind", "symbol", "description", "quantity", "acquired", "sold", "proceeds", "cost_basis", "premium_adjustment", "wash_sale_disallowed", "wash_sale_basis", "gain"})
+	for _, gain := range report.All() {
+		writer.Write([]string{
+			gain.Term(),
+			gain.Kind,
+			gain.Symbol,
+			gain.Description,
+			strconv.Itoa(gain.Quantity),
+			gain.Acquired.Format("2006-01-02"),
+			gain.Sold.Format("2006-01-02"),
+			fmt.Sprintf("%.2f", gain.Proceeds),
+			fmt.Sprintf("%.2f", gain.CostBasis),
+			fmt.Sprintf("%.2f", gain.PremiumAdjustment),
+			fmt.Sprintf("%.2f", gain.WashSaleDisallowed),
+			fmt.Sprintf("%.2f", gain.WashSaleBasis),
+			fmt.Sprintf("%.2f", gain.Gain),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("[REALIZED GAINS] ERROR: Failed to write CSV: %v", err)
+	}
+}
+
+// form8949Handler serves a printable Form 8949 and Schedule D for a tax year
+func (s *Server) form8949Handler(w http.ResponseWriter, r *http.Request) {
+	report, years, err := s.buildRealizedGainsReport(r)
+	if err != nil {
+		log.Printf("[FORM 8949] ERROR: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := Form8949PageData{
+		PageData: PageData{
+			Title:      "Form 8949",
+			ActivePage: "realized-gains",
+			CurrentDB:  s.getCurrentDatabaseName(),
+			AllSymbols: s.getAllSymbolsList(),
+		},
+		Form:  models.BuildForm8949(report),
+		Years: years,
+	}
+
+	s.renderTemplate(w, "form-8949.html", data)
+}
+
+// form8949CSVHandler downloads the Form 8949 lines for a tax year as CSV
+func (s *Server) form8949CSVHandler(w http.ResponseWriter, r *http.Request) {
+	report, _, err := s.buildRealizedGainsReport(r)
+	if err != nil {
+		log.Printf("[FORM 8949] ERROR: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	form := models.BuildForm8949(report)
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"form-8949-%d.csv\"", form.Year))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"box", "description", "date_acquired", "date_sold", "proceeds", "cost_basis", "code", "adjustment", "gain"})
+	for _, box := range form.Boxes {
+		for _, line := range box.Lines {
+			writer.Write([]string{
+				box.Box,
+				line.Description,
+				line.Acquired.Format("01/02/2006"),
+				line.Sold.Format("01/02/2006"),
+				fmt.Sprintf("%.2f", line.Proceeds),
+				fmt.Sprintf("%.2f", line.CostBasis),
+				line.Code,
+				fmt.Sprintf("%.2f", line.Adjustment),
+				fmt.Sprintf("%.2f", line.Gain),
+			})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("[FORM 8949] ERROR: Failed to write CSV: %v", err)
+	}
+}
+
+// buildRealizedGainsReport builds the report for the ?year= tax year, defaulting
+// to the most recent year with realized gains, and returns the years available.
+// Trades in IRA and Roth accounts are left out, as they are not taxed when realized.
+func (s *Server) buildRealizedGainsReport(r *http.Request) (*models.RealizedGainsReport, []int, error) {
+	positions, err := s.longPositionService.GetAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load long positions: %w", err)
+	}
+	options, err := s.optionService.GetAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load options: %w", err)
+	}
+
+	taxable, err := s.accountService.TaxableFilter()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	filter := s.accountFilter(r)
+
+	instruments, err := s.symbolService.GetInstruments()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load instruments: %w", err)
+	}
+
+	marks, err := s.ivService.YearEndMarks()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load year-end marks: %w", err)
+	}
+
+	years := models.RealizedGainYears(filter.LongPositions(taxable.LongPositions(positions)), filter.Options(taxable.Options(options)), instruments)
+	year := time.Now().Year()
+	if len(years) > 0 {
+		year = years[0]
+	}
+	if param := r.URL.Query().Get("year"); param != "" {
+		year, err = strconv.Atoi(param)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid year: %s", param)
+		}
+	}
+
+	// Wash sales look across the whole household; the filters only pick what is reported
+	return models.BuildRealizedGainsReport(year, positions, options, instruments, marks, taxable, filter), years, nil
+}
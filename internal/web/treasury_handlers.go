@@ -47,6 +47,10 @@ func (s *Server) treasuriesHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[TREASURIES PAGE] Retrieved %d options from service", len(options))
 	}
 
+	filter := s.accountFilter(r)
+	treasuries = filter.Treasuries(treasuries)
+	options = filter.Options(options)
+
 	// Sort treasuries by days remaining: active positions by days ascending, then sold positions
 	sort.Slice(treasuries, func(i, j int) bool {
 		iHasExit := treasuries[i].ExitPrice != nil
@@ -72,11 +76,21 @@ func (s *Server) treasuriesHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[TREASURIES PAGE] Summary calculated: TotalAmount=%.2f, ActivePositions=%d",
 		summary.TotalAmount, summary.ActivePositions)
 
+	// Check the open puts against cash and the treasuries maturing before they expire
+	collateral, err := s.collateralService.Report(s.selectedAccountID(r))
+	if err != nil {
+		log.Printf("[TREASURIES PAGE] ERROR: Failed to build collateral report: %v", err)
+		collateral = &models.CollateralReport{Weeks: []*models.CollateralWeek{}}
+	} else if !collateral.IsCovered() {
+		log.Printf("[TREASURIES PAGE] WARNING: Open puts are short $%.2f of collateral", collateral.Shortfall)
+	}
+
 	data := TreasuriesData{
 		Symbols:    symbols,
 		AllSymbols: symbols, // For navigation compatibility
 		Treasuries: treasuries,
 		Options:    options,
+		Collateral: collateral,
 		Summary:    summary,
 		CurrentDB:  s.getCurrentDatabaseName(),
 		ActivePage: "treasuries",
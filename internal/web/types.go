@@ -18,6 +18,17 @@ type SymbolUpdateRequest struct {
 	Dividend       *float64 `json:"dividend,omitempty"`
 	ExDividendDate *string  `json:"ex_dividend_date,omitempty"`
 	PERatio        *float64 `json:"pe_ratio,omitempty"`
+
+	InstrumentClass    *string `json:"instrument_class,omitempty"`
+	ContractMultiplier *int    `json:"contract_multiplier,omitempty"`
+
+	// Volatility is the annualized volatility in percent used to price
+	// options; 0 clears it so the DEFAULT_VOLATILITY setting applies
+	Volatility *float64 `json:"volatility,omitempty"`
+
+	// Beta is the symbol's beta to the BETA_BENCHMARK symbol for weighting
+	// portfolio delta; 0 clears it so the symbol counts with a beta of 1
+	Beta *float64 `json:"beta,omitempty"`
 }
 
 type TreasuryUpdateRequest struct {
@@ -49,6 +60,8 @@ type CSVOptionRecord struct {
 	Contracts  string
 	ExitPrice  string
 	Commission string
+	Direction  string
+	Account    string
 }
 
 type CSVStockRecord struct {
@@ -58,12 +71,14 @@ type CSVStockRecord struct {
 	Shares     string
 	BuyPrice   string
 	ExitPrice  string
+	Account    string
 }
 
 type CSVDividendRecord struct {
 	Symbol       string
 	DateReceived string
 	Amount       string
+	Account      string
 }
 
 type CSVTreasuryRecord struct {
@@ -75,19 +90,24 @@ type CSVTreasuryRecord struct {
 	BuyPrice     string
 	CurrentValue string
 	ExitPrice    string
+	Account      string
 }
 
 // DashboardData holds data for the dashboard template
 type DashboardData struct {
-	Symbols         []string        `json:"symbols"`
-	AllSymbols      []string        `json:"allSymbols"`     // For navigation compatibility
-	SymbolSummaries []SymbolSummary `json:"symbolSummaries"`
-	LongByTicker    []ChartData     `json:"longByTicker"`
-	PutsByTicker    []ChartData     `json:"putsByTicker"`
-	TotalAllocation []ChartData     `json:"totalAllocation"`
-	Totals          DashboardTotals `json:"totals"`
-	CurrentDB       string          `json:"currentDB"`
-	ActivePage      string          `json:"activePage"`
+	Symbols         []string                `json:"symbols"`
+	AllSymbols      []string                `json:"allSymbols"` // For navigation compatibility
+	SymbolSummaries []SymbolSummary         `json:"symbolSummaries"`
+	LongByTicker    []ChartData             `json:"longByTicker"`
+	PutsByTicker    []ChartData             `json:"putsByTicker"`
+	TotalAllocation []ChartData             `json:"totalAllocation"`
+	Totals          DashboardTotals         `json:"totals"`
+	Greeks          *models.PortfolioGreeks `json:"greeks"`
+	CurrentDB       string                  `json:"currentDB"`
+	ActivePage      string                  `json:"activePage"`
+	// CollateralWarning is passed from the add-option form when the new put
+	// leaves its account under-collateralized
+	CollateralWarning string `json:"collateralWarning,omitempty"`
 }
 
 type SymbolSummary struct {
@@ -193,13 +213,14 @@ type SymbolPremiumData struct {
 
 // TreasuriesData holds data for the treasuries template
 type TreasuriesData struct {
-	Symbols    []string           `json:"symbols"`
-	AllSymbols []string           `json:"allSymbols"` // For navigation compatibility
-	Treasuries []*models.Treasury `json:"treasuries"`
-	Options    []*models.Option   `json:"options"`    // For put exposure chart
-	Summary    TreasuriesSummary  `json:"summary"`
-	CurrentDB  string             `json:"currentDB"`
-	ActivePage string             `json:"activePage"`
+	Symbols    []string                 `json:"symbols"`
+	AllSymbols []string                 `json:"allSymbols"` // For navigation compatibility
+	Treasuries []*models.Treasury       `json:"treasuries"`
+	Options    []*models.Option         `json:"options"` // For put exposure chart
+	Collateral *models.CollateralReport `json:"collateral"`
+	Summary    TreasuriesSummary        `json:"summary"`
+	CurrentDB  string                   `json:"currentDB"`
+	ActivePage string                   `json:"activePage"`
 }
 
 type TreasuriesSummary struct {
@@ -213,13 +234,17 @@ type TreasuriesSummary struct {
 }
 
 type OptionsData struct {
-	Symbols        []string                   `json:"symbols"`
-	AllSymbols     []string                   `json:"allSymbols"` // For navigation compatibility
-	OptionsSummary []*models.OptionSummary    `json:"options_summary"`
-	OpenPositions  []*models.OpenPositionData `json:"open_positions"`
-	SummaryTotals  *models.OptionSummary      `json:"summary_totals"`
-	CurrentDB      string                     `json:"currentDB"`
-	ActivePage     string                     `json:"activePage"`
+	Symbols           []string                          `json:"symbols"`
+	AllSymbols        []string                          `json:"allSymbols"` // For navigation compatibility
+	OptionsSummary    []*models.OptionSummary           `json:"options_summary"`
+	OpenPositions     []*models.OpenPositionData        `json:"open_positions"`
+	SummaryTotals     *models.OptionSummary             `json:"summary_totals"`
+	RollChains        []*models.RollChain               `json:"roll_chains"`
+	Greeks            map[int]*models.OptionGreeks      `json:"greeks"`
+	ImpliedVolatility map[int]*models.ImpliedVolatility `json:"implied_volatility"`
+	Odds              map[int]*models.OptionOdds        `json:"odds"`
+	CurrentDB         string                            `json:"currentDB"`
+	ActivePage        string                            `json:"activePage"`
 }
 
 // AllOptionsData holds data for the all options template
@@ -265,20 +290,59 @@ type SymbolData struct {
 	PERatioValue      float64                `json:"peRatioValue"`
 	HasPERatio        bool                   `json:"hasPERatio"`
 	Yield             float64                `json:"yield"`
+	InstrumentClass   string                 `json:"instrumentClass"`
+	Multiplier        int                    `json:"multiplier"`
+	Volatility        float64                `json:"volatility"`
+	Beta              float64                `json:"beta"`
 	OptionsGains      string                 `json:"optionsGains"`
 	CapGains          string                 `json:"capGains"`
 	Dividends         string                 `json:"dividends"`
 	TotalProfits      string                 `json:"totalProfits"`
 	CashOnCash        string                 `json:"cashOnCash"`
+	PutExposed        float64                `json:"putExposed"`
 	DividendsList     []*models.Dividend     `json:"dividendsList"`
 	DividendsTotal    float64                `json:"dividendsTotal"`
 	OptionsList       []*models.Option       `json:"optionsList"`
 	LongPositionsList []*models.LongPosition `json:"longPositionsList"`
 	MonthlyResults    []SymbolMonthlyResult  `json:"monthlyResults"`
+	Campaigns         []CampaignView         `json:"campaigns"`
+	RollChains        []*models.RollChain    `json:"rollChains"`
+	Strategies        []StrategyView         `json:"strategies"`
+	CostBasis         *models.CostBasis      `json:"costBasis"`
+	WashSales         []*models.WashSale     `json:"washSales"`
 	CurrentDB         string                 `json:"currentDB"`
 	ActivePage        string                 `json:"activePage"`
 }
 
+type StrategyRequest struct {
+	Symbol string               `json:"symbol"`
+	Type   string               `json:"type"`
+	Opened string               `json:"opened"`
+	Notes  *string              `json:"notes,omitempty"`
+	Legs   []StrategyLegRequest `json:"legs"`
+}
+
+type StrategyLegRequest struct {
+	Type       string  `json:"type"`
+	Direction  string  `json:"direction"`
+	Strike     float64 `json:"strike"`
+	Expiration string  `json:"expiration"`
+	Premium    float64 `json:"premium"`
+	Contracts  int     `json:"contracts"`
+}
+
+// StrategyView is a strategy with its risk profile
+type StrategyView struct {
+	*models.Strategy
+	Analysis models.StrategyAnalysis `json:"analysis"`
+}
+
+// SymbolResponse is the GET /api/symbols/{symbol} payload
+type SymbolResponse struct {
+	*models.Symbol
+	CostBasis *models.CostBasis `json:"cost_basis"`
+}
+
 type OptionRequest struct {
 	ID         *int     `json:"id,omitempty"`
 	Symbol     string   `json:"symbol"`
@@ -291,6 +355,67 @@ type OptionRequest struct {
 	Closed     *string  `json:"closed,omitempty"`
 	ExitPrice  *float64 `json:"exit_price,omitempty"`
 	Commission float64  `json:"commission,omitempty"`
+	Direction  string   `json:"direction,omitempty"`
+}
+
+// OptionCreateResponse is the POST /api/options payload: the new option and,
+// for a short put, a warning if the portfolio is no longer fully collateralized
+type OptionCreateResponse struct {
+	*models.Option
+	CollateralWarning string `json:"collateral_warning,omitempty"`
+}
+
+// OptionMarkRequest sets an option's current price per share; null clears it
+type OptionMarkRequest struct {
+	Mark *float64 `json:"mark"`
+}
+
+// OptionMarkResponse is the PUT /api/options/{id}/mark payload: the option and
+// the implied volatility recorded for today, or why none could be solved
+type OptionMarkResponse struct {
+	*models.Option
+	ImpliedVolatility      *models.ImpliedVolatility `json:"implied_volatility,omitempty"`
+	ImpliedVolatilityError string                    `json:"implied_volatility_error,omitempty"`
+}
+
+type AssignmentRequest struct {
+	Date string `json:"date"`
+}
+
+type AssignmentResponse struct {
+	Option       *models.Option       `json:"option"`
+	LongPosition *models.LongPosition `json:"long_position"`
+}
+
+type CalledAwayResponse struct {
+	Option        *models.Option         `json:"option"`
+	LongPositions []*models.LongPosition `json:"long_positions"`
+}
+
+type RollRequest struct {
+	Date       string  `json:"date"`
+	ExitPrice  float64 `json:"exit_price"`
+	Strike     float64 `json:"strike"`
+	Expiration string  `json:"expiration"`
+	Premium    float64 `json:"premium"`
+	Contracts  int     `json:"contracts,omitempty"`
+}
+
+type RollResponse struct {
+	Closed *models.Option    `json:"closed"`
+	Opened *models.Option    `json:"opened"`
+	Chain  *models.RollChain `json:"chain"`
+}
+
+type CloseOptionRequest struct {
+	Date      string  `json:"date"`
+	ExitPrice float64 `json:"exit_price"`
+	Contracts int     `json:"contracts,omitempty"`
+}
+
+type CloseOptionResponse struct {
+	Closed    *models.Option `json:"closed"`
+	Remaining *models.Option `json:"remaining"`
 }
 
 type DividendRequest struct {
@@ -312,6 +437,57 @@ type LongPositionRequest struct {
 	ExitPrice *float64 `json:"exit_price,omitempty"`
 }
 
+// SellSharesRequest sells shares of a symbol across its open lots. Method
+// defaults to the LOT_METHOD setting; Lots are required for specific-lot sales.
+type SellSharesRequest struct {
+	Symbol string                `json:"symbol"`
+	Date   string                `json:"date"`
+	Shares int                   `json:"shares"`
+	Price  float64               `json:"price"`
+	Method string                `json:"method,omitempty"`
+	Lots   []models.LotSelection `json:"lots,omitempty"`
+	// AccountID limits the sale to lots in one account; it defaults to the
+	// account selected in the sidebar
+	AccountID *int `json:"account_id,omitempty"`
+}
+
+type CampaignRequest struct {
+	Symbol  string  `json:"symbol"`
+	Name    string  `json:"name"`
+	Started string  `json:"started"`
+	Ended   *string `json:"ended,omitempty"`
+	Notes   *string `json:"notes,omitempty"`
+}
+
+type AccountRequest struct {
+	Name   string  `json:"name"`
+	Broker *string `json:"broker,omitempty"`
+	Type   string  `json:"type"`
+}
+
+// CashEntryRequest records a deposit, withdrawal or interest payment. A new
+// entry goes in the selected account unless AccountID is given.
+type CashEntryRequest struct {
+	Date      string  `json:"date"`
+	Type      string  `json:"type"`
+	Amount    float64 `json:"amount"`
+	Notes     *string `json:"notes,omitempty"`
+	AccountID *int    `json:"account_id,omitempty"`
+}
+
+// AccountItemsRequest lists records to move into or out of an account, or asks
+// for every unassigned record to be moved into it
+type AccountItemsRequest struct {
+	models.AccountItems
+	Unassigned bool `json:"unassigned"`
+}
+
+// CampaignView pairs a campaign with its calculated summary
+type CampaignView struct {
+	*models.Campaign
+	Summary models.CampaignSummary `json:"summary"`
+}
+
 type AllocationData struct {
 	LongByTicker        []ChartData `json:"longByTicker"`
 	PutsByTicker        []ChartData `json:"putsByTicker"`
@@ -323,6 +499,7 @@ type AllocationData struct {
 	TotalCallPremiums   float64     `json:"totalCallPremiums"`
 	TotalCallCovered    float64     `json:"totalCallCovered"`
 	TotalOptionable     float64     `json:"totalOptionable"`
+	CollateralShortfall float64     `json:"collateralShortfall"`
 }
 
 type ChartPoint struct {
@@ -469,6 +646,48 @@ type UpcomingDividendDate struct {
 }
 
 // PageData holds common data for all page templates
+// RealizedGainsPageData holds the realized gains report for one tax year
+type RealizedGainsPageData struct {
+	PageData
+	Report *models.RealizedGainsReport `json:"report"`
+	Years  []int                       `json:"years"`
+}
+
+// Form8949PageData holds Form 8949 and Schedule D for one tax year
+type Form8949PageData struct {
+	PageData
+	Form  *models.Form8949 `json:"form"`
+	Years []int            `json:"years"`
+}
+
+// AccountsPageData holds the brokerage accounts for the accounts page
+type AccountsPageData struct {
+	PageData
+	Accounts []*models.Account `json:"accounts"`
+}
+
+// CashPageData holds the cash ledger of the selected account
+type CashPageData struct {
+	PageData
+	Ledger *models.CashLedger `json:"ledger"`
+	Recent []*models.CashFlow `json:"recent"`
+}
+
+// ScenariosPageData holds the saved scenarios for the scenario simulator
+type ScenariosPageData struct {
+	PageData
+	Scenarios []*models.Scenario `json:"scenarios"`
+}
+
+// AlertsPageData holds the alert rules and every alert they have fired
+type AlertsPageData struct {
+	PageData
+	Rules     []*models.AlertRule `json:"rules"`
+	Alerts    []*models.Alert     `json:"alerts"`
+	RuleTypes []string            `json:"rule_types"`
+	Interval  int                 `json:"interval"`
+}
+
 type PageData struct {
 	Title      string   `json:"title"`
 	ActivePage string   `json:"activePage"`
@@ -21,6 +21,9 @@ func main() {
 	// Setup routes
 	server.SetupTestRoutes()
 
+	// Evaluate the alert rules in the background
+	server.StartAlertScheduler()
+
 	// Create HTTP server
 	httpServer := &http.Server{
 		Addr:    ":8080",